@@ -17,10 +17,12 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/goadesign/goa/grpc/middleware"
 	ctclient "github.com/google/certificate-transparency-go/client"
@@ -30,7 +32,9 @@ import (
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sigstore/fulcio/pkg/ca"
+	"github.com/sigstore/fulcio/pkg/ca/sshca"
 	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/ctl"
 	gw "github.com/sigstore/fulcio/pkg/generated/protobuf"
 	gw_legacy "github.com/sigstore/fulcio/pkg/generated/protobuf/legacy"
 	"github.com/sigstore/fulcio/pkg/log"
@@ -64,20 +68,85 @@ func passFulcioConfigThruContext(cfg *config.FulcioConfig) grpc.UnaryServerInter
 	}
 }
 
-func createGRPCServer(cfg *config.FulcioConfig, ctClient *ctclient.LogClient, baseca ca.CertificateAuthority) (*grpcServer, error) {
+// grpcMethodTimeouts parses the per-method timeouts, which must name methods
+// of the CA service
+func grpcMethodTimeouts() (map[string]time.Duration, error) {
+	methods := make(map[string]bool)
+	for _, m := range gw.CA_ServiceDesc.Methods {
+		methods[m.MethodName] = true
+	}
+	timeouts := make(map[string]time.Duration)
+	for method, v := range viper.GetStringMapString("grpc-method-timeouts") {
+		if !methods[method] {
+			return nil, fmt.Errorf("--grpc-method-timeouts: unknown method %q", method)
+		}
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("--grpc-method-timeouts: %s: %w", method, err)
+		}
+		timeouts[method] = timeout
+	}
+	return timeouts, nil
+}
+
+func createGRPCServer(cfg *config.FulcioConfig, ctClient *ctclient.LogClient, baseca ca.CertificateAuthority, extraOpts ...server.Option) (*grpcServer, error) {
 	logger, opts := log.SetupGRPCLogging()
 
+	methodTimeouts, err := grpcMethodTimeouts()
+	if err != nil {
+		return nil, err
+	}
+
 	myServer := grpc.NewServer(grpc.UnaryInterceptor(
 		grpcmw.ChainUnaryServer(
 			grpc_recovery.UnaryServerInterceptor(grpc_recovery.WithRecoveryHandlerContext(panicRecoveryHandler)), // recovers from per-transaction panics elegantly, so put it first
 			middleware.UnaryRequestID(middleware.UseXRequestIDMetadataOption(true), middleware.XRequestMetadataLimitOption(128)),
 			grpc_zap.UnaryServerInterceptor(logger, opts...),
 			passFulcioConfigThruContext(cfg),
+			server.UnaryTimeoutInterceptor(viper.GetDuration("grpc-timeout"), methodTimeouts),
 			grpc_prometheus.UnaryServerInterceptor,
 		)),
 		grpc.MaxRecvMsgSize(int(maxMsgSize)))
 
-	grpcCAServer := server.NewGRPCCAServer(ctClient, baseca)
+	serverOpts := []server.Option{
+		server.WithSerialCollisionRetries(viper.GetInt("serial-collision-retries")),
+		server.WithIssuerConcurrencyLimit(viper.GetInt("issuer-concurrency-limit")),
+		server.WithSigningConcurrencyLimit(viper.GetInt("signing-concurrency-limit")),
+		server.WithMaxNonces(viper.GetInt("max-outstanding-nonces")),
+		server.WithTokenExpiryWarning(viper.GetDuration("token-expiry-warning-threshold")),
+	}
+	if path := viper.GetString("ssh-ca-key"); path != "" {
+		sshCA, err := sshca.NewFromFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading SSH CA key: %w", err)
+		}
+		serverOpts = append(serverOpts, server.WithSSHCA(sshCA))
+	}
+	if viper.GetBool("csr-challenge-password-token") {
+		serverOpts = append(serverOpts, server.WithCSRChallengePasswordToken())
+	}
+	if viper.GetBool("ct-log-id-extension") {
+		if ctClient == nil || ctClient.Verifier == nil {
+			return nil, errors.New("--ct-log-id-extension requires --ct-log-url and --ct-log-public-key-path")
+		}
+		if cfg.ExtensionOIDArc == "" {
+			return nil, errors.New("--ct-log-id-extension requires ExtensionOIDArc in the configuration")
+		}
+		logID, err := ctl.LogID(ctClient.Verifier.PubKey)
+		if err != nil {
+			return nil, err
+		}
+		serverOpts = append(serverOpts, server.WithCTLogIDExtension(logID))
+	}
+	if addr := viper.GetString("statsd-addr"); addr != "" {
+		statsd, err := server.NewStatsDClient(addr, viper.GetString("statsd-prefix"))
+		if err != nil {
+			return nil, err
+		}
+		serverOpts = append(serverOpts, server.WithStatsD(statsd))
+	}
+	serverOpts = append(serverOpts, extraOpts...)
+	grpcCAServer := server.NewGRPCCAServer(ctClient, baseca, serverOpts...)
 	// Register your gRPC service implementations.
 	gw.RegisterCAServer(myServer, grpcCAServer)
 
@@ -143,6 +212,9 @@ func createLegacyGRPCServer(cfg *config.FulcioConfig, v2Server gw.CAServer) (*gr
 			middleware.UnaryRequestID(middleware.UseXRequestIDMetadataOption(true), middleware.XRequestMetadataLimitOption(128)),
 			grpc_zap.UnaryServerInterceptor(logger, opts...),
 			passFulcioConfigThruContext(cfg),
+			// Legacy calls are served by calling the v2 server directly, so
+			// only the default timeout applies
+			server.UnaryTimeoutInterceptor(viper.GetDuration("grpc-timeout"), nil),
 			grpc_prometheus.UnaryServerInterceptor,
 		)),
 		grpc.MaxRecvMsgSize(int(maxMsgSize)))
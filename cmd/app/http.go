@@ -42,12 +42,25 @@ type httpServer struct {
 	httpServerEndpoint string
 }
 
+// extractOIDCTokenFromAuthHeader passes a bearer token in the Authorization
+// header to the gRPC server as metadata. The server only uses it if the
+// request body doesn't contain a token.
 func extractOIDCTokenFromAuthHeader(ctx context.Context, req *http.Request) metadata.MD {
-	token := strings.Replace(req.Header.Get("Authorization"), "Bearer ", "", 1)
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	// The auth scheme is case-insensitive (RFC 7235)
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil
+	}
 	return metadata.Pairs(server.MetadataOIDCTokenKey, token)
 }
 
-func createHTTPServer(ctx context.Context, serverEndpoint string, grpcServer, legacyGRPCServer *grpcServer) httpServer {
+// createHTTPServer serves the REST gateway to the gRPC servers, and handlers
+// by path prefix, such as the OCSP responder.
+func createHTTPServer(ctx context.Context, serverEndpoint string, grpcServer, legacyGRPCServer *grpcServer, handlers map[string]http.Handler) httpServer {
 	mux := runtime.NewServeMux(runtime.WithMetadata(extractOIDCTokenFromAuthHeader),
 		runtime.WithForwardResponseOption(setResponseCodeModifier))
 
@@ -63,8 +76,19 @@ func createHTTPServer(ctx context.Context, serverEndpoint string, grpcServer, le
 		}
 	}
 
+	var handler http.Handler = mux
+	if len(handlers) > 0 {
+		m := http.NewServeMux()
+		m.Handle("/", mux)
+		for path, h := range handlers {
+			m.Handle(path, h)
+			m.Handle(path+"/", h)
+		}
+		handler = m
+	}
+
 	// Limit request size
-	handler := server.WithMaxBytes(mux, maxMsgSize)
+	handler = server.WithMaxBytes(handler, maxMsgSize)
 	handler = promhttp.InstrumentHandlerDuration(server.MetricLatency, handler)
 	handler = promhttp.InstrumentHandlerCounter(server.RequestsCount, handler)
 
@@ -24,11 +24,13 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/sigstore/fulcio/pkg/ca"
 	"github.com/sigstore/fulcio/pkg/identity"
+	"github.com/sigstore/fulcio/pkg/server"
 	"github.com/spf13/viper"
 
 	"google.golang.org/grpc"
@@ -60,7 +62,7 @@ func setupHTTPServer(t *testing.T) (httpServer, string) {
 	}
 
 	httpHost := httpListen.Addr().String()
-	httpServer := createHTTPServer(context.Background(), httpHost, grpcServer, nil)
+	httpServer := createHTTPServer(context.Background(), httpHost, grpcServer, nil, nil)
 	go func() {
 		_ = httpServer.Serve(httpListen)
 		grpcServer.GracefulStop()
@@ -109,6 +111,35 @@ func TestHTTPDoesntLeakGRPCHeaders(t *testing.T) {
 	}
 }
 
+func TestExtractOIDCTokenFromAuthHeader(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		want   []string
+	}{
+		"bearer token":         {header: "Bearer abc.def.ghi", want: []string{"abc.def.ghi"}},
+		"lowercase scheme":     {header: "bearer abc.def.ghi", want: []string{"abc.def.ghi"}},
+		"no header":            {header: ""},
+		"basic auth":           {header: "Basic dXNlcjpwYXNz"},
+		"missing token":        {header: "Bearer "},
+		"token without scheme": {header: "abc.def.ghi"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/api/v2/signingCert", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.header != "" {
+				req.Header.Set("Authorization", test.header)
+			}
+			md := extractOIDCTokenFromAuthHeader(context.Background(), req)
+			if got := md.Get(server.MetadataOIDCTokenKey); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("expected token %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
 // Trivial CA service that returns junk
 type TrivialCertificateAuthority struct {
 }
@@ -31,13 +31,16 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	certauth "github.com/sigstore/fulcio/pkg/ca"
 	"github.com/sigstore/fulcio/pkg/ca/ephemeralca"
+	"github.com/sigstore/fulcio/pkg/ca/fallbackca"
 	"github.com/sigstore/fulcio/pkg/ca/fileca"
 	googlecav1 "github.com/sigstore/fulcio/pkg/ca/googleca/v1"
 	"github.com/sigstore/fulcio/pkg/ca/kmsca"
 	"github.com/sigstore/fulcio/pkg/ca/pkcs11ca"
 	"github.com/sigstore/fulcio/pkg/ca/tinkca"
 	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/identity/username"
 	"github.com/sigstore/fulcio/pkg/log"
+	"github.com/sigstore/fulcio/pkg/server"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -64,6 +67,7 @@ func newServeCmd() *cobra.Command {
 	cmd.Flags().String("hsm-caroot-id", "", "HSM ID for Root CA (only used with --ca pkcs11ca)")
 	cmd.Flags().String("ct-log-url", "http://localhost:6962/test", "host and path (with log prefix at the end) to the ct log")
 	cmd.Flags().String("ct-log-public-key-path", "", "Path to a PEM-encoded public key of the CT log, used to verify SCTs")
+	cmd.Flags().Bool("ct-log-id-extension", false, "Record the ID of the CT log, the SHA-256 hash of its public key, in an extension of each certificate. Requires --ct-log-public-key-path and ExtensionOIDArc in the configuration")
 	cmd.Flags().String("config-path", "/etc/fulcio-config/config.json", "path to fulcio config json")
 	cmd.Flags().String("pkcs11-config-path", "config/crypto11.conf", "path to fulcio pkcs11 config file")
 	cmd.Flags().String("fileca-cert", "", "Path to CA certificate")
@@ -72,6 +76,12 @@ func newServeCmd() *cobra.Command {
 	cmd.Flags().Bool("fileca-watch", true, "Watch filesystem for updates")
 	cmd.Flags().String("kms-resource", "", "KMS key resource path. Must be prefixed with awskms://, azurekms://, gcpkms://, or hashivault://")
 	cmd.Flags().String("kms-cert-chain-path", "", "Path to PEM-encoded CA certificate chain for KMS-backed CA")
+	cmd.Flags().Int("kms-retries", kmsca.DefaultRetries, "The number of times a KMS signing request that fails with a transient error is retried")
+	cmd.Flags().Duration("kms-retry-backoff", kmsca.DefaultRetryBackoff, "The delay before retrying a failed KMS signing request, doubled for each further retry")
+	cmd.Flags().Int("kms-breaker-threshold", kmsca.DefaultBreakerThreshold, "The number of consecutive KMS signing requests failing with transient errors after which requests fail fast. 0 disables the circuit breaker")
+	cmd.Flags().Duration("kms-breaker-open-duration", kmsca.DefaultBreakerOpenDuration, "How long KMS signing requests fail fast before the KMS is tried again")
+	cmd.Flags().String("fallback-kms-resource", "", "KMS key resource path of a fallback CA, used when the --ca backend is unavailable. Clients must trust the chains of both. Must be prefixed with awskms://, azurekms://, gcpkms://, or hashivault://")
+	cmd.Flags().String("fallback-kms-cert-chain-path", "", "Path to PEM-encoded CA certificate chain for the fallback KMS-backed CA")
 	cmd.Flags().String("tink-kms-resource", "", "KMS key resource path for encrypted Tink keyset. Must be prefixed with gcp-kms:// or aws-kms://")
 	cmd.Flags().String("tink-cert-chain-path", "", "Path to PEM-encoded CA certificate chain for Tink-backed CA")
 	cmd.Flags().String("tink-keyset-path", "", "Path to KMS-encrypted keyset for Tink-backed CA")
@@ -79,8 +89,24 @@ func newServeCmd() *cobra.Command {
 	cmd.Flags().String("port", "8080", "The port on which to serve requests for HTTP; --http-port is alias")
 	cmd.Flags().String("grpc-host", "0.0.0.0", "The host on which to serve requests for GRPC")
 	cmd.Flags().String("grpc-port", "8081", "The port on which to serve requests for GRPC")
+	cmd.Flags().Duration("grpc-timeout", 0, "The time allowed to serve each GRPC request, unless set for the method by --grpc-method-timeouts. 0 means no timeout other than the client's deadline")
+	cmd.Flags().StringToString("grpc-method-timeouts", nil, "Timeouts for named methods of the GRPC CA service, overriding --grpc-timeout, e.g. GetTrustBundle=2s,CreateSigningCertificate=30s")
 	cmd.Flags().String("metrics-port", "2112", "The port on which to serve prometheus metrics endpoint")
 	cmd.Flags().Duration("read-header-timeout", 10*time.Second, "The time allowed to read the headers of the requests in seconds")
+	cmd.Flags().String("ssh-ca-key", "", "Path to an unencrypted private key used to sign SSH user certificates on request. If unset, SSH certificates are not issued")
+	cmd.Flags().String("admin-token", "", "Secret reference (env://NAME or file:///path) to a bearer token for admin endpoints on the metrics port. If unset, admin endpoints are disabled")
+	cmd.Flags().String("statsd-addr", "", "host:port of a StatsD server to send issuance metrics to, in addition to Prometheus. If unset, metrics are not sent to StatsD")
+	cmd.Flags().String("statsd-prefix", "fulcio", "Prefix for metric names sent to StatsD")
+	cmd.Flags().Int("issuer-concurrency-limit", 0, "The maximum number of tokens from each OIDC issuer verified concurrently, so a slow issuer can't exhaust server capacity. Requests beyond the limit fail with ResourceExhausted. 0 means no limit")
+	cmd.Flags().Duration("token-expiry-warning-threshold", 0, "Log a warning, at most once a minute for each OIDC issuer, when a token has less than this left before it expires once verified. The time left is also returned in the fulcio-token-expires-in trailer. 0 disables the warning")
+	cmd.Flags().Int("signing-concurrency-limit", 0, "The maximum number of certificates signed concurrently, so bursts don't overload the CA backend. Requests beyond the limit wait for a slot until their deadline. 0 means no limit")
+	cmd.Flags().Int("max-outstanding-nonces", server.DefaultMaxNonces, "The maximum number of nonces issued by CreateNonce that may be outstanding at once, until they are used or expire. Requests beyond the limit fail with ResourceExhausted. 0 means no limit")
+	cmd.Flags().Bool("ocsp-responder", false, "Serve an OCSP responder for issued certificates at "+server.OCSPPath+", signed by the CA key. Revoked certificates are reported as revoked. Not supported by googleca")
+	cmd.Flags().Bool("crl-endpoint", false, "Serve a CRL of revoked certificates at "+server.CRLPath+", signed by the CA key. Not supported by googleca")
+	cmd.Flags().Bool("validate-san-endpoint", false, "Serve an endpoint at "+server.ValidateSANPath+" that validates a username OtherName and returns its encoded SAN extension, without issuing a certificate")
+	cmd.Flags().String("revocation-list-path", "", "Path to a JSON file of revoked certificates, which the admin revoke endpoint adds to. Revocations in the file are served alongside Revocations in the config, and their serial numbers are never issued")
+	cmd.Flags().Bool("csr-challenge-password-token", false, "Read the OIDC token from the challengePassword attribute of a CSR if the request contains no other token, for legacy enrollment clients")
+	cmd.Flags().Int("serial-collision-retries", server.DefaultSerialCollisionRetries, "The number of times to retry issuance if the CA reports a serial number collision")
 
 	// convert "http-host" flag to "host" and "http-port" flag to be "port"
 	cmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
@@ -210,7 +236,9 @@ func runServeCmd(cmd *cobra.Command, args []string) {
 	case "ephemeralca":
 		baseca, err = ephemeralca.NewEphemeralCA()
 	case "kmsca":
-		baseca, err = kmsca.NewKMSCA(cmd.Context(), viper.GetString("kms-resource"), viper.GetString("kms-cert-chain-path"))
+		baseca, err = kmsca.NewKMSCA(cmd.Context(), viper.GetString("kms-resource"), viper.GetString("kms-cert-chain-path"),
+			kmsca.WithRetries(viper.GetInt("kms-retries"), viper.GetDuration("kms-retry-backoff")),
+			kmsca.WithCircuitBreaker(viper.GetInt("kms-breaker-threshold"), viper.GetDuration("kms-breaker-open-duration")))
 	case "tinkca":
 		baseca, err = tinkca.NewTinkCA(cmd.Context(),
 			viper.GetString("tink-kms-resource"), viper.GetString("tink-keyset-path"), viper.GetString("tink-cert-chain-path"))
@@ -220,6 +248,21 @@ func runServeCmd(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Logger.Fatal(err)
 	}
+	// The OCSP responder and CRL are signed by the primary CA
+	signerCA := baseca
+
+	if resource := viper.GetString("fallback-kms-resource"); resource != "" {
+		if !viper.IsSet("fallback-kms-cert-chain-path") {
+			log.Logger.Fatal("fallback-kms-cert-chain-path must be set when using fallback-kms-resource")
+		}
+		fallback, err := kmsca.NewKMSCA(cmd.Context(), resource, viper.GetString("fallback-kms-cert-chain-path"),
+			kmsca.WithRetries(viper.GetInt("kms-retries"), viper.GetDuration("kms-retry-backoff")),
+			kmsca.WithCircuitBreaker(viper.GetInt("kms-breaker-threshold"), viper.GetDuration("kms-breaker-open-duration")))
+		if err != nil {
+			log.Logger.Fatalf("error loading fallback CA: %v", err)
+		}
+		baseca = fallbackca.NewFallbackCA(baseca, fallback)
+	}
 
 	var ctClient *ctclient.LogClient
 	if logURL := viper.GetString("ct-log-url"); logURL != "" {
@@ -243,8 +286,19 @@ func runServeCmd(cmd *cobra.Command, args []string) {
 	httpServerEndpoint := fmt.Sprintf("%v:%v", viper.GetString("http-host"), viper.GetString("http-port"))
 
 	reg := prometheus.NewRegistry()
+	username.SetObserver(server.SANFailureObserver{})
 
-	grpcServer, err := createGRPCServer(cfg, ctClient, baseca)
+	revocations := []server.RevocationSource{cfg}
+	var revocationList *server.RevocationList
+	if path := viper.GetString("revocation-list-path"); path != "" {
+		revocationList, err = server.LoadRevocationList(path)
+		if err != nil {
+			log.Logger.Fatalf("error loading --revocation-list-path=%s: %v", path, err)
+		}
+		revocations = append(revocations, revocationList)
+	}
+
+	grpcServer, err := createGRPCServer(cfg, ctClient, baseca, server.WithRevocations(revocations...))
 	if err != nil {
 		log.Logger.Fatal(err)
 	}
@@ -257,18 +311,56 @@ func runServeCmd(cmd *cobra.Command, args []string) {
 	}
 	legacyGRPCServer.startUnixListener()
 
-	httpServer := createHTTPServer(context.Background(), httpServerEndpoint, grpcServer, legacyGRPCServer)
+	handlers := make(map[string]http.Handler)
+	for flag, path := range map[string]string{"ocsp-responder": server.OCSPPath, "crl-endpoint": server.CRLPath} {
+		if !viper.GetBool(flag) {
+			continue
+		}
+		signer, ok := signerCA.(certauth.SignerWithChain)
+		if !ok {
+			log.Logger.Fatalf("--%s is not supported by --ca=%s", flag, viper.GetString("ca"))
+		}
+		if path == server.OCSPPath {
+			handlers[path] = server.NewOCSPHandler(signer, revocations...)
+		} else {
+			handlers[path] = server.NewCRLHandler(signer, revocations...)
+		}
+	}
+
+	if viper.GetBool("validate-san-endpoint") {
+		handlers[server.ValidateSANPath] = server.NewValidateSANHandler(cfg)
+	}
+
+	httpServer := createHTTPServer(context.Background(), httpServerEndpoint, grpcServer, legacyGRPCServer, handlers)
 	httpServer.startListener()
 
+	metricsHandler := promhttp.Handler()
+	if ref := viper.GetString("admin-token"); ref != "" {
+		token, err := config.DefaultSecretProvider.GetSecret(ref)
+		if err != nil {
+			log.Logger.Fatalf("error loading --admin-token: %v", err)
+		}
+		metricsHandler = withAdminHandler(metricsHandler, cfg, revocationList, token)
+	}
+
 	readHeaderTimeout := viper.GetDuration("read-header-timeout")
 	prom := http.Server{
 		Addr:              fmt.Sprintf(":%v", viper.GetString("metrics-port")),
-		Handler:           promhttp.Handler(),
+		Handler:           metricsHandler,
 		ReadHeaderTimeout: readHeaderTimeout,
 	}
 	log.Logger.Error(prom.ListenAndServe())
 }
 
+// withAdminHandler serves the admin endpoints alongside metrics, which are
+// not usually exposed publicly.
+func withAdminHandler(metrics http.Handler, cfg *config.FulcioConfig, revocations *server.RevocationList, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", metrics)
+	mux.Handle("/admin/", server.NewAdminHandler(cfg, revocations, token))
+	return mux
+}
+
 func checkServeCmdConfigFile() error {
 	if serveCmdConfigFilePath != "" {
 		if _, err := os.Stat(serveCmdConfigFilePath); err != nil {
@@ -0,0 +1,78 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/server"
+)
+
+func TestAdminHandlerRoutes(t *testing.T) {
+	revocations, err := server.LoadRevocationList(filepath.Join(t.TempDir(), "revocations.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := withAdminHandler(metrics, &config.FulcioConfig{}, revocations, "secret")
+
+	tests := map[string]struct {
+		Path       string
+		WantStatus int
+	}{
+		"metrics are served": {
+			Path:       "/metrics",
+			WantStatus: http.StatusTeapot,
+		},
+		"revoke is routed to the admin handler": {
+			Path:       server.AdminRevokePath + "?serial=3a0f",
+			WantStatus: http.StatusNoContent,
+		},
+		"flush is routed to the admin handler": {
+			Path:       server.AdminFlushCachesPath + "?cache=unknown",
+			WantStatus: http.StatusBadRequest,
+		},
+		"unknown admin paths aren't served as metrics": {
+			Path:       "/admin/unknown",
+			WantStatus: http.StatusNotFound,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, test.Path, nil)
+			req.Header.Set("Authorization", "Bearer secret")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != test.WantStatus {
+				t.Fatalf("got status %d, expected %d", rec.Code, test.WantStatus)
+			}
+		})
+	}
+
+	revoked, err := revocations.RevokedCertificates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revoked) != 1 || revoked[0].SerialNumber.Cmp(big.NewInt(0x3a0f)) != 0 {
+		t.Fatalf("expected serial 3a0f to be revoked, got %v", revoked)
+	}
+}
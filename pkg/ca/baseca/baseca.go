@@ -28,6 +28,7 @@ import (
 	ctx509 "github.com/google/certificate-transparency-go/x509"
 	"github.com/sigstore/fulcio/pkg/ca"
 	"github.com/sigstore/fulcio/pkg/identity"
+	"github.com/sigstore/fulcio/pkg/identity/username"
 )
 
 var (
@@ -50,6 +51,16 @@ func (bca *BaseCA) CreatePrecertificate(ctx context.Context, principal identity.
 
 	certChain, privateKey := bca.GetSignerWithChain()
 
+	// Don't sign a username OtherName the issuing CA's name constraints forbid
+	if err := username.CheckCertificateOtherNameConstraints(cert, certChain[0]); err != nil {
+		return nil, ca.ValidationError(err)
+	}
+
+	cert.SignatureAlgorithm, err = ca.SignatureAlgorithm(ctx, privateKey.Public(), publicKey)
+	if err != nil {
+		return nil, err
+	}
+
 	// Append poison extension
 	cert.ExtraExtensions = append(cert.ExtraExtensions, pkix.Extension{
 		Id:       OIDExtensionCTPoison,
@@ -131,6 +142,16 @@ func (bca *BaseCA) CreateCertificate(ctx context.Context, principal identity.Pri
 
 	certChain, privateKey := bca.GetSignerWithChain()
 
+	// Don't sign a username OtherName the issuing CA's name constraints forbid
+	if err := username.CheckCertificateOtherNameConstraints(cert, certChain[0]); err != nil {
+		return nil, ca.ValidationError(err)
+	}
+
+	cert.SignatureAlgorithm, err = ca.SignatureAlgorithm(ctx, privateKey.Public(), publicKey)
+	if err != nil {
+		return nil, err
+	}
+
 	finalCertBytes, err := x509.CreateCertificate(rand.Reader, cert, certChain[0], publicKey, privateKey)
 	if err != nil {
 		return nil, err
@@ -23,11 +23,14 @@ import (
 	"crypto/x509"
 	"encoding/asn1"
 	"reflect"
+	"strings"
 	"testing"
 
 	ct "github.com/google/certificate-transparency-go"
 	"github.com/sigstore/fulcio/pkg/ca"
 	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/identity/username"
 	"github.com/sigstore/fulcio/pkg/test"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
@@ -151,3 +154,87 @@ func TestCreatePrecertificateAndIssueFinalCertificate(t *testing.T) {
 		t.Fatal("expected SCT extension to be in certificate")
 	}
 }
+
+func TestCreateCertificateSignatureAlgorithm(t *testing.T) {
+	rootCert, rootKey, _ := test.GenerateRootCA()
+	subCert, subKey, _ := test.GenerateSubordinateCA(rootCert, rootKey)
+	certChain := []*x509.Certificate{subCert, rootCert}
+
+	bca := BaseCA{
+		SignerWithChain: &ca.SignerCerts{Certs: certChain, Signer: subKey},
+	}
+
+	// The default matches the P-256 CA key, whatever the leaf key
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384()} {
+		priv, _ := ecdsa.GenerateKey(curve, rand.Reader)
+		csc, err := bca.CreateCertificate(context.TODO(), testPrincipal{}, priv.Public())
+		if err != nil {
+			t.Fatalf("error generating certificate: %v", err)
+		}
+		if csc.FinalCertificate.SignatureAlgorithm != x509.ECDSAWithSHA256 {
+			t.Fatalf("expected ECDSA-SHA256 for %s leaf key, got %v", curve.Params().Name, csc.FinalCertificate.SignatureAlgorithm)
+		}
+	}
+
+	// A configured algorithm overrides the default
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ctx := config.With(context.TODO(), &config.FulcioConfig{
+		SignatureAlgorithms: map[string]string{config.KeyTypeECDSAP256: "ECDSA-SHA512"},
+	})
+	csc, err := bca.CreateCertificate(ctx, testPrincipal{}, priv.Public())
+	if err != nil {
+		t.Fatalf("error generating certificate: %v", err)
+	}
+	if csc.FinalCertificate.SignatureAlgorithm != x509.ECDSAWithSHA512 {
+		t.Fatalf("expected configured ECDSA-SHA512, got %v", csc.FinalCertificate.SignatureAlgorithm)
+	}
+}
+
+type usernamePrincipal string
+
+func (p usernamePrincipal) Name(context.Context) string {
+	return string(p)
+}
+
+func (p usernamePrincipal) Embed(ctx context.Context, cert *x509.Certificate) error {
+	san, err := username.MarshalSANS(string(p), true)
+	if err != nil {
+		return err
+	}
+	cert.ExtraExtensions = append(cert.ExtraExtensions, *san)
+	return nil
+}
+
+func TestCreateCertificateNameConstraints(t *testing.T) {
+	rootCert, rootKey, _ := test.GenerateRootCA()
+	subCert, subKey, err := test.GenerateConstrainedSubordinateCA(rootCert, rootKey, []string{"corp.com"}, []string{"secret.corp.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bca := BaseCA{
+		SignerWithChain: &ca.SignerCerts{Certs: []*x509.Certificate{subCert, rootCert}, Signer: subKey},
+	}
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	for _, name := range []string{"alice!build.corp.com", "alice!*.pool.corp.com"} {
+		if _, err := bca.CreateCertificate(context.TODO(), usernamePrincipal(name), priv.Public()); err != nil {
+			t.Errorf("CreateCertificate() for %q = %v", name, err)
+		}
+		if _, err := bca.CreatePrecertificate(context.TODO(), usernamePrincipal(name), priv.Public()); err != nil {
+			t.Errorf("CreatePrecertificate() for %q = %v", name, err)
+		}
+	}
+
+	for name, wantErr := range map[string]string{
+		"user!host.evil.com":          "not permitted",
+		"alice!*.secret.corp.com":     "excluded",
+		"alice!vault.secret.corp.com": "excluded",
+	} {
+		if _, err := bca.CreateCertificate(context.TODO(), usernamePrincipal(name), priv.Public()); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("expected CreateCertificate() for %q to fail with %q, got %v", name, wantErr, err)
+		}
+		if _, err := bca.CreatePrecertificate(context.TODO(), usernamePrincipal(name), priv.Public()); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("expected CreatePrecertificate() for %q to fail with %q, got %v", name, wantErr, err)
+		}
+	}
+}
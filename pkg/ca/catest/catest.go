@@ -0,0 +1,118 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package catest checks that a CA backend issues certificates which
+// verify against its trust bundle. It's shared by the tests of each backend.
+package catest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/sigstore/fulcio/pkg/ca"
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/identity/username"
+)
+
+// OtherName is the username identity embedded in certificates issued by
+// CheckIssuance.
+const OtherName = "alice!example.com"
+
+var oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+type principal struct{}
+
+func (principal) Name(context.Context) string {
+	return "alice"
+}
+
+func (principal) Embed(ctx context.Context, cert *x509.Certificate) error {
+	san, err := username.MarshalSANS(OtherName, true)
+	if err != nil {
+		return err
+	}
+	exts, err := certificate.Extensions{
+		Issuer: "https://issuer.example.com",
+	}.Render()
+	if err != nil {
+		return err
+	}
+	cert.ExtraExtensions = append([]pkix.Extension{*san}, exts...)
+	return nil
+}
+
+// CheckIssuance issues a code signing certificate from authority with an
+// OtherName SAN produced by username.MarshalSANS, and checks that it chains
+// to the authority's trust bundle with the OtherName intact. It returns the
+// issued certificate for further checks.
+func CheckIssuance(t testing.TB, authority ca.CertificateAuthority) *x509.Certificate {
+	t.Helper()
+	ctx := context.Background()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csc, err := authority.CreateCertificate(ctx, principal{}, priv.Public())
+	if err != nil {
+		t.Fatalf("CreateCertificate() = %v", err)
+	}
+	cert := csc.FinalCertificate
+
+	if got, err := username.UnmarshalSANS(cert.Extensions); err != nil || got != OtherName {
+		t.Fatalf("UnmarshalSANS() = %q, %v, want %q", got, err, OtherName)
+	}
+
+	bundle, err := authority.TrustBundle(ctx)
+	if err != nil {
+		t.Fatalf("TrustBundle() = %v", err)
+	}
+	roots := x509.NewCertPool()
+	intermediates := x509.NewCertPool()
+	for _, chain := range bundle {
+		if len(chain) == 0 {
+			t.Fatal("empty chain in trust bundle")
+		}
+		for _, c := range chain[:len(chain)-1] {
+			intermediates.AddCert(c)
+		}
+		roots.AddCert(chain[len(chain)-1])
+	}
+
+	// crypto/x509 doesn't parse OtherNames, so a critical SAN holding only
+	// an OtherName is left unhandled. It was checked above, as verifiers do.
+	unhandled := cert.UnhandledCriticalExtensions[:0]
+	for _, oid := range cert.UnhandledCriticalExtensions {
+		if !oid.Equal(oidSubjectAltName) {
+			unhandled = append(unhandled, oid)
+		}
+	}
+	cert.UnhandledCriticalExtensions = unhandled
+
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}); err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+	return cert
+}
@@ -19,22 +19,41 @@ import (
 	"context"
 	"crypto"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/config"
 	"github.com/sigstore/fulcio/pkg/identity"
+	"github.com/sigstore/fulcio/pkg/identity/username"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"gopkg.in/square/go-jose.v2"
 )
 
 func MakeX509(ctx context.Context, principal identity.Principal, publicKey crypto.PublicKey) (*x509.Certificate, error) {
+	if err := ValidatePublicKey(publicKey); err != nil {
+		return nil, ValidationError(err)
+	}
+
 	serialNumber, err := cryptoutils.GenerateSerialNumber()
 	if err != nil {
 		return nil, err
 	}
-
-	skid, err := cryptoutils.SKID(publicKey)
-	if err != nil {
+	if revoked, err := serialRevoked(ctx, serialNumber); err != nil {
 		return nil, err
+	} else if revoked {
+		return nil, fmt.Errorf("serial number %s is revoked: %w", serialNumber.Text(16), ErrSerialCollision)
+	}
+
+	skid := subjectKeyIDFromContext(ctx)
+	if len(skid) == 0 {
+		if skid, err = cryptoutils.SKID(publicKey); err != nil {
+			return nil, err
+		}
 	}
 
 	cert := &x509.Certificate{
@@ -51,9 +70,157 @@ func MakeX509(ctx context.Context, principal identity.Principal, publicKey crypt
 		return nil, ValidationError(err)
 	}
 
+	cfg := config.FromContext(ctx)
+	arc, err := cfg.ExtensionArc()
+	if err != nil {
+		return nil, err
+	}
+
+	if logIDs := ctLogIDsFromContext(ctx); len(logIDs) > 0 {
+		ext, err := certificate.RenderCTLogIDs(arc, logIDs)
+		if err != nil {
+			return nil, err
+		}
+		cert.ExtraExtensions = append(cert.ExtraExtensions, ext)
+	}
+
+	if profile := config.ProfileFromContext(ctx); profile != nil {
+		if err := applyProfile(cert, profile); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg != nil {
+		ekus, err := cfg.AdditionalExtKeyUsageOIDs()
+		if err != nil {
+			return nil, err
+		}
+		cert.UnknownExtKeyUsage = append(cert.UnknownExtKeyUsage, ekus...)
+	}
+
+	if cfg != nil && cfg.Environment != "" && len(arc) > 0 {
+		cert.ExtraExtensions = append(cert.ExtraExtensions, pkix.Extension{
+			Id:    arc.OID(certificate.ArcEnvironment),
+			Value: []byte(cfg.Environment),
+		})
+	}
+
+	if class := identityClassFromContext(ctx); class != "" && len(arc) > 0 {
+		cert.ExtraExtensions = append(cert.ExtraExtensions, pkix.Extension{
+			Id:    arc.OID(certificate.ArcIdentityClass),
+			Value: []byte(class),
+		})
+	}
+
+	if oid, ok, err := cfg.ScopeExtensionObjectIdentifier(); err != nil {
+		return nil, err
+	} else if scopes := scopesFromContext(ctx); ok && len(scopes) > 0 {
+		ext, err := certificate.RenderScopes(oid, scopes)
+		if err != nil {
+			return nil, err
+		}
+		cert.ExtraExtensions = append(cert.ExtraExtensions, ext)
+	}
+
+	if cfg != nil && cfg.JWKThumbprintExtension && len(arc) > 0 {
+		ext, err := jwkThumbprintExtension(arc, publicKey)
+		if err != nil {
+			return nil, ValidationError(err)
+		}
+		cert.ExtraExtensions = append(cert.ExtraExtensions, ext)
+	}
+
+	if err := username.PackSANS(cert, cfg != nil && cfg.SANPacking == config.SANPackingSplit); err != nil {
+		return nil, err
+	}
+
+	if kind := identityKindFromContext(ctx); kind != "" {
+		if err := username.SetSANCriticality(cert, cfg.CriticalityPolicy().ShouldBeCritical(kind)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkExtKeyUsage(cert); err != nil {
+		return nil, err
+	}
+
 	return cert, nil
 }
 
+// jwkThumbprintExtension records the RFC 7638 JWK thumbprint of publicKey
+// under arc, using SHA-256 and base64url encoded as in the DPoP jkt claim.
+func jwkThumbprintExtension(arc certificate.ExtensionArc, publicKey crypto.PublicKey) (pkix.Extension, error) {
+	thumbprint, err := (&jose.JSONWebKey{Key: publicKey}).Thumbprint(crypto.SHA256)
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("computing JWK thumbprint: %w", err)
+	}
+	return pkix.Extension{
+		Id:    arc.OID(certificate.ArcJWKThumbprint),
+		Value: []byte(base64.RawURLEncoding.EncodeToString(thumbprint)),
+	}, nil
+}
+
+// applyProfile overrides the default code signing policy of the certificate
+// with the policy of the requested profile.
+func applyProfile(cert *x509.Certificate, profile *config.Profile) error {
+	ekus, err := profile.ExtKeyUsageOIDs()
+	if err != nil {
+		return err
+	}
+	if len(ekus) > 0 {
+		cert.ExtKeyUsage = nil
+		cert.UnknownExtKeyUsage = ekus
+	}
+
+	validity, err := profile.ValidityDuration()
+	if err != nil {
+		return err
+	}
+	if validity > 0 {
+		cert.NotAfter = cert.NotBefore.Add(validity)
+	}
+
+	excluded, err := profile.ExcludeExtensionOIDs()
+	if err != nil {
+		return err
+	}
+	if len(excluded) > 0 {
+		var exts []pkix.Extension
+		for _, ext := range cert.ExtraExtensions {
+			keep := true
+			for _, oid := range excluded {
+				if ext.Id.Equal(oid) {
+					keep = false
+					break
+				}
+			}
+			if keep {
+				exts = append(exts, ext)
+			}
+		}
+		cert.ExtraExtensions = exts
+	}
+	return nil
+}
+
+var oidAnyExtendedKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37, 0}
+
+// checkExtKeyUsage guards against issuing a certificate with
+// anyExtendedKeyUsage, however it got into the template.
+func checkExtKeyUsage(cert *x509.Certificate) error {
+	for _, eku := range cert.ExtKeyUsage {
+		if eku == x509.ExtKeyUsageAny {
+			return errors.New("certificate must not have anyExtendedKeyUsage")
+		}
+	}
+	for _, eku := range cert.UnknownExtKeyUsage {
+		if eku.Equal(oidAnyExtendedKeyUsage) {
+			return errors.New("certificate must not have anyExtendedKeyUsage")
+		}
+	}
+	return nil
+}
+
 func VerifyCertChain(certs []*x509.Certificate, signer crypto.Signer) error {
 	if len(certs) == 0 {
 		return errors.New("certificate chain must contain at least one certificate")
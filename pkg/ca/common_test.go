@@ -19,11 +19,24 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/identity"
+	"github.com/sigstore/fulcio/pkg/identity/username"
 	"github.com/sigstore/fulcio/pkg/test"
 	"github.com/sigstore/sigstore/pkg/signature"
 )
@@ -70,6 +83,339 @@ func TestMakeX509(t *testing.T) {
 	}
 }
 
+func TestMakeX509RevokedSerial(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+
+	var checked *big.Int
+	ctx := WithRevokedSerials(context.TODO(), func(serial *big.Int) (bool, error) {
+		checked = serial
+		return true, nil
+	})
+	if _, err := MakeX509(ctx, &testPrincipal{}, key.Public()); !errors.Is(err, ErrSerialCollision) {
+		t.Fatalf("expected serial collision for a revoked serial, got %v", err)
+	}
+	if checked == nil {
+		t.Fatal("expected the serial to be checked")
+	}
+
+	ctx = WithRevokedSerials(context.TODO(), func(serial *big.Int) (bool, error) {
+		return false, nil
+	})
+	if _, err := MakeX509(ctx, &testPrincipal{}, key.Public()); err != nil {
+		t.Fatalf("unexpected error calling MakeX509: %v", err)
+	}
+
+	ctx = WithRevokedSerials(context.TODO(), func(serial *big.Int) (bool, error) {
+		return false, fmt.Errorf("revocations unavailable")
+	})
+	if _, err := MakeX509(ctx, &testPrincipal{}, key.Public()); err == nil || errors.Is(err, ErrSerialCollision) {
+		t.Fatalf("expected the revocation check error, got %v", err)
+	}
+}
+
+// anyEKUPrincipal tries to add anyExtendedKeyUsage to the certificate
+type anyEKUPrincipal struct {
+	unknown bool
+}
+
+func (a *anyEKUPrincipal) Name(_ context.Context) string {
+	return "test"
+}
+func (a *anyEKUPrincipal) Embed(_ context.Context, cert *x509.Certificate) error {
+	if a.unknown {
+		cert.UnknownExtKeyUsage = append(cert.UnknownExtKeyUsage, asn1.ObjectIdentifier{2, 5, 29, 37, 0})
+	} else {
+		cert.ExtKeyUsage = append(cert.ExtKeyUsage, x509.ExtKeyUsageAny)
+	}
+	return nil
+}
+
+func TestMakeX509ExtKeyUsage(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+
+	for _, p := range []*anyEKUPrincipal{{unknown: false}, {unknown: true}} {
+		if _, err := MakeX509(context.TODO(), p, key.Public()); err == nil || !strings.Contains(err.Error(), "anyExtendedKeyUsage") {
+			t.Fatalf("expected anyExtendedKeyUsage to be rejected, got %v", err)
+		}
+	}
+
+	ctx := config.With(context.TODO(), &config.FulcioConfig{
+		AdditionalExtKeyUsages: []string{"1.3.6.1.5.5.7.3.8"},
+	})
+	cert, err := MakeX509(ctx, &testPrincipal{}, key.Public())
+	if err != nil {
+		t.Fatalf("unexpected error calling MakeX509: %v", err)
+	}
+	if len(cert.UnknownExtKeyUsage) != 1 || !cert.UnknownExtKeyUsage[0].Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 8}) {
+		t.Fatalf("expected additional extended key usage, got %v", cert.UnknownExtKeyUsage)
+	}
+
+	// Configuration that bypassed validation is still rejected
+	ctx = config.With(context.TODO(), &config.FulcioConfig{
+		AdditionalExtKeyUsages: []string{"2.5.29.37.0"},
+	})
+	if _, err := MakeX509(ctx, &testPrincipal{}, key.Public()); err == nil {
+		t.Fatal("expected anyExtendedKeyUsage to be rejected")
+	}
+}
+
+func TestMakeX509WithProfile(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+	ctx := config.WithProfile(context.TODO(), &config.Profile{
+		ExtKeyUsages:      []string{"1.3.6.1.5.5.7.3.4"},
+		Validity:          "1h",
+		ExcludeExtensions: []string{"1.3.6.1.4.1.57264.1.2"},
+	})
+	cert, err := MakeX509(ctx, &extensionPrincipal{}, key.Public())
+	if err != nil {
+		t.Fatalf("unexpected error calling MakeX509: %v", err)
+	}
+	if len(cert.ExtKeyUsage) != 0 || len(cert.UnknownExtKeyUsage) != 1 || !cert.UnknownExtKeyUsage[0].Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 4}) {
+		t.Fatalf("expected only the profile extended key usage, got %v %v", cert.ExtKeyUsage, cert.UnknownExtKeyUsage)
+	}
+	if cert.NotAfter.Sub(cert.NotBefore) != time.Hour {
+		t.Fatalf("expected 1 hour lifetime, got %v", cert.NotAfter.Sub(cert.NotBefore))
+	}
+	if len(cert.ExtraExtensions) != 1 || !cert.ExtraExtensions[0].Id.Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}) {
+		t.Fatalf("expected only the issuer extension, got %v", cert.ExtraExtensions)
+	}
+}
+
+// extensionPrincipal embeds the issuer and workflow trigger extensions
+type extensionPrincipal struct{}
+
+func (e *extensionPrincipal) Name(_ context.Context) string {
+	return "test"
+}
+func (e *extensionPrincipal) Embed(_ context.Context, cert *x509.Certificate) error {
+	cert.EmailAddresses = []string{"test@example.com"}
+	cert.ExtraExtensions = []pkix.Extension{
+		{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}, Value: []byte("https://example.com")},
+		{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 2}, Value: []byte("push")},
+	}
+	return nil
+}
+
+func TestMakeX509SANPacking(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+	for packing, wantExts := range map[config.SANPacking]int{
+		"":                      1,
+		config.SANPackingSingle: 1,
+		config.SANPackingSplit:  2,
+	} {
+		ctx := config.With(context.TODO(), &config.FulcioConfig{SANPacking: packing})
+		cert, err := MakeX509(ctx, &otherNamePrincipal{}, key.Public())
+		if err != nil {
+			t.Fatalf("unexpected error calling MakeX509: %v", err)
+		}
+		var sans int
+		for _, ext := range cert.ExtraExtensions {
+			if ext.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 17}) {
+				sans++
+			}
+		}
+		if sans != wantExts || len(cert.URIs) != 0 {
+			t.Fatalf("SANPacking %q: expected %d SAN extensions, got %d", packing, wantExts, sans)
+		}
+		// Split SANs repeat the extension, so need the lenient decoding
+		name, err := username.UnmarshalSANSLenient(cert.ExtraExtensions)
+		if err != nil || name != "foo!example.com" {
+			t.Fatalf("SANPacking %q: UnmarshalSANSLenient() = %q, %v", packing, name, err)
+		}
+	}
+}
+
+func TestMakeX509SANCriticality(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+	cfg := &config.FulcioConfig{
+		SANCriticality: config.CriticalityPolicy{
+			config.IdentityKindUsername: false,
+			config.IdentityKindEmail:    false,
+			config.IdentityKindURI:      true,
+		},
+	}
+	tests := map[string]struct {
+		principal    identity.Principal
+		kind         config.IdentityKind
+		wantCritical bool
+	}{
+		"username":     {&otherNamePrincipal{}, config.IdentityKindUsername, false},
+		"email":        {&testPrincipal{}, config.IdentityKindEmail, false},
+		"uri":          {&otherNamePrincipal{}, config.IdentityKindURI, true},
+		"unconfigured": {&testPrincipal{}, config.IdentityKindGitHub, true},
+		"unknown":      {&testPrincipal{}, "dns", true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := WithIdentityKind(config.With(context.TODO(), cfg), test.kind)
+			template, err := MakeX509(ctx, test.principal, key.Public())
+			if err != nil {
+				t.Fatalf("unexpected error calling MakeX509: %v", err)
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sans int
+			for _, ext := range cert.Extensions {
+				if ext.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 17}) {
+					sans++
+					if ext.Critical != test.wantCritical {
+						t.Fatalf("expected SAN critical %v, got %v", test.wantCritical, ext.Critical)
+					}
+				}
+			}
+			if sans != 1 {
+				t.Fatalf("expected 1 SAN extension, got %d", sans)
+			}
+			if len(cert.EmailAddresses)+len(cert.URIs) != 1 {
+				t.Fatalf("expected SAN to be kept, got %v %v", cert.EmailAddresses, cert.URIs)
+			}
+		})
+	}
+}
+
+func TestMakeX509Environment(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+	findEnvironment := func(cert *x509.Certificate) []string {
+		var envs []string
+		for _, ext := range cert.ExtraExtensions {
+			if ext.Id.Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcEnvironment}) {
+				envs = append(envs, string(ext.Value))
+			}
+		}
+		return envs
+	}
+
+	ctx := config.With(context.TODO(), &config.FulcioConfig{ExtensionOIDArc: "1.3.6.1.4.1.32473.1", Environment: "staging"})
+	cert, err := MakeX509(ctx, &testPrincipal{}, key.Public())
+	if err != nil {
+		t.Fatalf("unexpected error calling MakeX509: %v", err)
+	}
+	if envs := findEnvironment(cert); len(envs) != 1 || envs[0] != "staging" {
+		t.Fatalf("expected environment extension staging, got %v", envs)
+	}
+
+	cert, err = MakeX509(config.With(context.TODO(), &config.FulcioConfig{}), &testPrincipal{}, key.Public())
+	if err != nil {
+		t.Fatalf("unexpected error calling MakeX509: %v", err)
+	}
+	if envs := findEnvironment(cert); len(envs) != 0 {
+		t.Fatalf("expected no environment extension, got %v", envs)
+	}
+}
+
+func TestMakeX509JWKThumbprint(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+
+	// RFC 7638 thumbprint input for an EC key: the required members in
+	// lexicographic order, without whitespace
+	coord := func(n *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(n.FillBytes(make([]byte, 32)))
+	}
+	input := fmt.Sprintf(`{"crv":"P-256","kty":"EC","x":%q,"y":%q}`, coord(key.X), coord(key.Y))
+	sum := sha256.Sum256([]byte(input))
+	want := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	ctx := config.With(context.TODO(), &config.FulcioConfig{ExtensionOIDArc: "1.3.6.1.4.1.32473.1", JWKThumbprintExtension: true})
+	cert, err := MakeX509(ctx, &testPrincipal{}, key.Public())
+	if err != nil {
+		t.Fatalf("unexpected error calling MakeX509: %v", err)
+	}
+	var got []string
+	for _, ext := range cert.ExtraExtensions {
+		if ext.Id.Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcJWKThumbprint}) {
+			got = append(got, string(ext.Value))
+		}
+	}
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("expected JWK thumbprint %s, got %v", want, got)
+	}
+}
+
+func TestMakeX509Scopes(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+	oid := asn1.ObjectIdentifier{1, 2, 3, 4}
+	cfg := &config.FulcioConfig{ScopeExtensionOID: "1.2.3.4"}
+	scopeExtensions := func(cert *x509.Certificate) [][]string {
+		var got [][]string
+		for _, ext := range cert.ExtraExtensions {
+			if ext.Id.Equal(oid) {
+				scopes, err := certificate.ParseScopes(ext.Value)
+				if err != nil {
+					t.Fatalf("unexpected error parsing scopes: %v", err)
+				}
+				got = append(got, scopes)
+			}
+		}
+		return got
+	}
+
+	// Each scope is a separate value
+	ctx := WithScopes(config.With(context.TODO(), cfg), []string{"openid", "email", "repo:read"})
+	cert, err := MakeX509(ctx, &testPrincipal{}, key.Public())
+	if err != nil {
+		t.Fatalf("unexpected error calling MakeX509: %v", err)
+	}
+	want := [][]string{{"openid", "email", "repo:read"}}
+	if got := scopeExtensions(cert); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected scopes %v, got %v", want, got)
+	}
+
+	// No scopes, no extension
+	cert, err = MakeX509(config.With(context.TODO(), cfg), &testPrincipal{}, key.Public())
+	if err != nil {
+		t.Fatalf("unexpected error calling MakeX509: %v", err)
+	}
+	if got := scopeExtensions(cert); len(got) != 0 {
+		t.Fatalf("expected no scope extension, got %v", got)
+	}
+}
+
+// otherNamePrincipal embeds an OtherName SAN extension and a URI SAN
+type otherNamePrincipal struct{}
+
+func (o *otherNamePrincipal) Name(_ context.Context) string {
+	return "foo"
+}
+func (o *otherNamePrincipal) Embed(_ context.Context, cert *x509.Certificate) error {
+	ext, err := username.MarshalSANS("foo!example.com", true)
+	if err != nil {
+		return err
+	}
+	cert.ExtraExtensions = []pkix.Extension{*ext}
+	cert.URIs = []*url.URL{{Scheme: "https", Host: "example.com", Path: "/foo"}}
+	return nil
+}
+
 func TestVerifyCertChain(t *testing.T) {
 	rootCert, rootKey, _ := test.GenerateRootCA()
 	subCert, subKey, _ := test.GenerateSubordinateCA(rootCert, rootKey)
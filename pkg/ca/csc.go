@@ -16,6 +16,8 @@ package ca
 
 import (
 	"crypto/x509"
+	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
@@ -96,3 +98,30 @@ func (c *CodeSigningCertificate) ChainPEM() ([]string, error) {
 	}
 	return c.finalChainPEM, nil
 }
+
+// VerifyChain checks that the certificate is signed by the first certificate
+// of its chain, and that each certificate of the chain is signed by the one
+// after it. A chain that ends in a self-signed root must verify against it.
+// Only signatures and CA constraints are checked, not validity periods or
+// extensions.
+func (c *CodeSigningCertificate) VerifyChain() error {
+	if len(c.FinalChain) == 0 {
+		return errors.New("certificate has no issuing certificate")
+	}
+	certs := append([]*x509.Certificate{c.FinalCertificate}, c.FinalChain...)
+	for i := 0; i < len(certs)-1; i++ {
+		if err := certs[i].CheckSignatureFrom(certs[i+1]); err != nil {
+			return fmt.Errorf("certificate %d of chain does not verify against its issuer: %w", i, err)
+		}
+	}
+	if last := certs[len(certs)-1]; len(certs) > 1 && isSelfSigned(last) {
+		if err := last.CheckSignatureFrom(last); err != nil {
+			return fmt.Errorf("root certificate does not verify: %w", err)
+		}
+	}
+	return nil
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return string(cert.RawIssuer) == string(cert.RawSubject)
+}
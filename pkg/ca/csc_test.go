@@ -15,11 +15,13 @@
 package ca
 
 import (
+	"crypto/x509"
 	"encoding/pem"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/sigstore/fulcio/pkg/test"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 )
 
@@ -218,3 +220,70 @@ BOO!
 		})
 	}
 }
+
+func TestVerifyChain(t *testing.T) {
+	rootCert, rootKey, err := test.GenerateRootCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	subCert, subKey, err := test.GenerateSubordinateCA(rootCert, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafCert, _, err := test.GenerateLeafCert("subject", "oidc-issuer", subCert, subKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRoot, _, err := test.GenerateRootCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	corrupt := append([]byte{}, leafCert.Raw...)
+	corrupt[len(corrupt)-1] ^= 0xff
+
+	tests := map[string]struct {
+		Cert    []byte
+		Chain   []*x509.Certificate
+		WantErr bool
+	}{
+		"full chain": {
+			Cert:  leafCert.Raw,
+			Chain: []*x509.Certificate{subCert, rootCert},
+		},
+		"chain without root": {
+			Cert:  leafCert.Raw,
+			Chain: []*x509.Certificate{subCert},
+		},
+		"corrupt signature": {
+			Cert:    corrupt,
+			Chain:   []*x509.Certificate{subCert, rootCert},
+			WantErr: true,
+		},
+		"wrong issuer": {
+			Cert:    leafCert.Raw,
+			Chain:   []*x509.Certificate{rootCert},
+			WantErr: true,
+		},
+		"wrong root": {
+			Cert:    leafCert.Raw,
+			Chain:   []*x509.Certificate{subCert, otherRoot},
+			WantErr: true,
+		},
+		"no chain": {
+			Cert:    leafCert.Raw,
+			WantErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			csc, err := CreateCSCFromDER(test.Cert, test.Chain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := csc.VerifyChain(); (err != nil) != test.WantErr {
+				t.Errorf("VerifyChain() = %v, want error %v", err, test.WantErr)
+			}
+		})
+	}
+}
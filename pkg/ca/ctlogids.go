@@ -0,0 +1,33 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ca
+
+import "context"
+
+type ctLogIDsKey struct{}
+
+// WithCTLogIDs attaches the IDs of the CT logs a certificate will be
+// submitted to, which MakeX509 records in the CT log IDs extension. The
+// extension is part of the precertificate, so the IDs must be known before
+// submission rather than taken from the returned SCTs.
+func WithCTLogIDs(ctx context.Context, logIDs [][32]byte) context.Context {
+	return context.WithValue(ctx, ctLogIDsKey{}, logIDs)
+}
+
+func ctLogIDsFromContext(ctx context.Context) [][32]byte {
+	logIDs, _ := ctx.Value(ctLogIDsKey{}).([][32]byte)
+	return logIDs
+}
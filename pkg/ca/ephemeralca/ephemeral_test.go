@@ -19,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sigstore/fulcio/pkg/ca/catest"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 )
 
@@ -48,3 +49,11 @@ func TestNewEphemeralCA(t *testing.T) {
 		t.Fatalf("expected verification key and certificate key to match")
 	}
 }
+
+func TestEphemeralCAIssuance(t *testing.T) {
+	ca, err := NewEphemeralCA()
+	if err != nil {
+		t.Fatalf("unexpected error generating ephemeral CA: %v", err)
+	}
+	catest.CheckIssuance(t, ca)
+}
@@ -15,6 +15,16 @@
 
 package ca
 
+import "errors"
+
+// ErrSerialCollision indicates that the serial number assigned to a certificate
+// is already in use. Certificate issuance may be retried with a new serial number.
+var ErrSerialCollision = errors.New("certificate serial number collision")
+
+// ErrUnavailable indicates that the CA backend is temporarily unable to sign.
+// The request may succeed if retried later.
+var ErrUnavailable = errors.New("CA backend is unavailable")
+
 // ValidationError indicates that there is an issue with the content in the HTTP Request that
 // should result in an HTTP 400 Bad Request error being returned to the client
 type ValidationError error
@@ -0,0 +1,142 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package fallbackca fails over from a primary CA backend to a secondary one
+// when the primary is unavailable.
+package fallbackca
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/sigstore/fulcio/pkg/ca"
+	"github.com/sigstore/fulcio/pkg/identity"
+	"github.com/sigstore/fulcio/pkg/log"
+)
+
+type fallbackCA struct {
+	primary   ca.CertificateAuthority
+	secondary ca.CertificateAuthority
+}
+
+// embeddedFallbackCA also issues certificates with embedded SCTs, when both
+// backends do.
+type embeddedFallbackCA struct {
+	fallbackCA
+}
+
+// NewFallbackCA returns a CA that signs with primary, and with secondary when
+// primary fails with ca.ErrUnavailable. Clients must trust the chains of
+// both, which are served together in the trust bundle.
+func NewFallbackCA(primary, secondary ca.CertificateAuthority) ca.CertificateAuthority {
+	f := fallbackCA{primary: primary, secondary: secondary}
+	_, primaryEmbedded := primary.(ca.EmbeddedSCTCA)
+	_, secondaryEmbedded := secondary.(ca.EmbeddedSCTCA)
+	if primaryEmbedded && secondaryEmbedded {
+		return &embeddedFallbackCA{f}
+	}
+	return &f
+}
+
+func (f *fallbackCA) CreateCertificate(ctx context.Context, principal identity.Principal, publicKey crypto.PublicKey) (*ca.CodeSigningCertificate, error) {
+	csc, err := f.primary.CreateCertificate(ctx, principal, publicKey)
+	if !errors.Is(err, ca.ErrUnavailable) {
+		return csc, err
+	}
+	logFailover(ctx, err)
+	return f.secondary.CreateCertificate(ctx, principal, publicKey)
+}
+
+// TrustBundle returns the chains of the primary, followed by the chains of
+// the secondary that the primary doesn't share.
+func (f *fallbackCA) TrustBundle(ctx context.Context) ([][]*x509.Certificate, error) {
+	bundle, err := f.primary.TrustBundle(ctx)
+	if err != nil {
+		return nil, err
+	}
+	secondary, err := f.secondary.TrustBundle(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, chain := range secondary {
+		if !containsChain(bundle, chain) {
+			bundle = append(bundle, chain)
+		}
+	}
+	return bundle, nil
+}
+
+func (f *embeddedFallbackCA) CreatePrecertificate(ctx context.Context, principal identity.Principal, publicKey crypto.PublicKey) (*ca.CodeSigningPreCertificate, error) {
+	precert, err := f.primary.(ca.EmbeddedSCTCA).CreatePrecertificate(ctx, principal, publicKey)
+	if !errors.Is(err, ca.ErrUnavailable) {
+		return precert, err
+	}
+	logFailover(ctx, err)
+	return f.secondary.(ca.EmbeddedSCTCA).CreatePrecertificate(ctx, principal, publicKey)
+}
+
+// IssueFinalCertificate issues the final certificate with the backend that
+// created the precertificate, identified by the certificate that issued it.
+func (f *embeddedFallbackCA) IssueFinalCertificate(ctx context.Context, precert *ca.CodeSigningPreCertificate, sct *ct.SignedCertificateTimestamp) (*ca.CodeSigningCertificate, error) {
+	backend := f.primary
+	if !issuedBy(ctx, f.primary, precert) && issuedBy(ctx, f.secondary, precert) {
+		backend = f.secondary
+	}
+	return backend.(ca.EmbeddedSCTCA).IssueFinalCertificate(ctx, precert, sct)
+}
+
+// issuedBy returns whether the precertificate was issued by a chain in the
+// trust bundle of backend.
+func issuedBy(ctx context.Context, backend ca.CertificateAuthority, precert *ca.CodeSigningPreCertificate) bool {
+	if len(precert.CertChain) == 0 {
+		return false
+	}
+	bundle, err := backend.TrustBundle(ctx)
+	if err != nil {
+		return false
+	}
+	for _, chain := range bundle {
+		if len(chain) > 0 && chain[0].Equal(precert.CertChain[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+func logFailover(ctx context.Context, err error) {
+	log.ContextLogger(ctx).Warnw("Primary CA is unavailable, failing over to the fallback CA", "error", err)
+}
+
+func containsChain(bundle [][]*x509.Certificate, chain []*x509.Certificate) bool {
+	for _, c := range bundle {
+		if len(c) != len(chain) {
+			continue
+		}
+		equal := true
+		for i := range c {
+			if !c[i].Equal(chain[i]) {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			return true
+		}
+	}
+	return false
+}
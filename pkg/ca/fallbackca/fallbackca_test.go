@@ -0,0 +1,157 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package fallbackca
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/sigstore/fulcio/pkg/ca"
+	"github.com/sigstore/fulcio/pkg/ca/ephemeralca"
+	"github.com/sigstore/fulcio/pkg/identity"
+)
+
+type testPrincipal struct{}
+
+func (testPrincipal) Name(_ context.Context) string {
+	return "test"
+}
+
+func (testPrincipal) Embed(_ context.Context, cert *x509.Certificate) error {
+	cert.EmailAddresses = []string{"test@example.com"}
+	return nil
+}
+
+// failingCA fails to sign with err
+type failingCA struct {
+	*ephemeralca.EphemeralCA
+	err error
+}
+
+func (f *failingCA) CreateCertificate(context.Context, identity.Principal, crypto.PublicKey) (*ca.CodeSigningCertificate, error) {
+	return nil, f.err
+}
+
+func (f *failingCA) CreatePrecertificate(context.Context, identity.Principal, crypto.PublicKey) (*ca.CodeSigningPreCertificate, error) {
+	return nil, f.err
+}
+
+func newEphemeralCA(t *testing.T) *ephemeralca.EphemeralCA {
+	t.Helper()
+	eca, err := ephemeralca.NewEphemeralCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return eca
+}
+
+func root(eca *ephemeralca.EphemeralCA) *x509.Certificate {
+	chain, _ := eca.GetSignerWithChain()
+	return chain[0]
+}
+
+func TestFallbackCA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary, secondary := newEphemeralCA(t), newEphemeralCA(t)
+	unavailable := fmt.Errorf("%w: KMS region is down", ca.ErrUnavailable)
+
+	for name, tc := range map[string]struct {
+		primary    ca.CertificateAuthority
+		wantIssuer *x509.Certificate
+		wantErr    bool
+	}{
+		"primary signs": {
+			primary:    primary,
+			wantIssuer: root(primary),
+		},
+		"secondary signs when primary is unavailable": {
+			primary:    &failingCA{EphemeralCA: primary, err: unavailable},
+			wantIssuer: root(secondary),
+		},
+		"other errors don't fail over": {
+			primary: &failingCA{EphemeralCA: primary, err: errors.New("invalid request")},
+			wantErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := NewFallbackCA(tc.primary, secondary)
+			ctx := context.Background()
+
+			csc, err := f.CreateCertificate(ctx, testPrincipal{}, key.Public())
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("CreateCertificate() = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err == nil {
+				if err := csc.FinalCertificate.CheckSignatureFrom(tc.wantIssuer); err != nil {
+					t.Fatalf("expected certificate issued by %v: %v", tc.wantIssuer.Subject, err)
+				}
+			}
+
+			// Precertificates fail over too, and the final certificate is
+			// issued by the same backend as the precertificate
+			embedded, ok := f.(ca.EmbeddedSCTCA)
+			if !ok {
+				t.Fatal("expected fallback of embedded SCT CAs to issue embedded SCTs")
+			}
+			precert, err := embedded.CreatePrecertificate(ctx, testPrincipal{}, key.Public())
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("CreatePrecertificate() = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			csc, err = embedded.IssueFinalCertificate(ctx, precert, &ct.SignedCertificateTimestamp{})
+			if err != nil {
+				t.Fatalf("IssueFinalCertificate() = %v", err)
+			}
+			if err := csc.FinalCertificate.CheckSignatureFrom(tc.wantIssuer); err != nil {
+				t.Fatalf("expected final certificate issued by %v: %v", tc.wantIssuer.Subject, err)
+			}
+		})
+	}
+}
+
+func TestFallbackCATrustBundle(t *testing.T) {
+	primary, secondary := newEphemeralCA(t), newEphemeralCA(t)
+
+	bundle, err := NewFallbackCA(primary, secondary).TrustBundle(context.Background())
+	if err != nil {
+		t.Fatalf("TrustBundle() = %v", err)
+	}
+	if len(bundle) != 2 || !bundle[0][0].Equal(root(primary)) || !bundle[1][0].Equal(root(secondary)) {
+		t.Fatalf("expected primary and secondary chains, got %v", bundle)
+	}
+
+	// Shared chains are served once
+	bundle, err = NewFallbackCA(primary, primary).TrustBundle(context.Background())
+	if err != nil {
+		t.Fatalf("TrustBundle() = %v", err)
+	}
+	if len(bundle) != 1 {
+		t.Fatalf("expected one chain, got %d", len(bundle))
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ca
+
+import "context"
+
+type identityClassKey struct{}
+
+// WithIdentityClass attaches the class of the identity a certificate is
+// issued for, one of the certificate.IdentityClass constants, which MakeX509
+// records in an extension.
+func WithIdentityClass(ctx context.Context, class string) context.Context {
+	return context.WithValue(ctx, identityClassKey{}, class)
+}
+
+func identityClassFromContext(ctx context.Context) string {
+	class, _ := ctx.Value(identityClassKey{}).(string)
+	return class
+}
@@ -0,0 +1,36 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ca
+
+import (
+	"context"
+
+	"github.com/sigstore/fulcio/pkg/config"
+)
+
+type identityKindKey struct{}
+
+// WithIdentityKind attaches the kind of identity a certificate is issued
+// for, which MakeX509 looks up in the configured SANCriticality to mark the
+// subject alternative name extension critical or not.
+func WithIdentityKind(ctx context.Context, kind config.IdentityKind) context.Context {
+	return context.WithValue(ctx, identityKindKey{}, kind)
+}
+
+func identityKindFromContext(ctx context.Context) config.IdentityKind {
+	kind, _ := ctx.Value(identityKindKey{}).(config.IdentityKind)
+	return kind
+}
@@ -0,0 +1,204 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kmsca
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sigstore/fulcio/pkg/ca"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultRetries is the default number of times a failed KMS signing
+	// request is retried.
+	DefaultRetries = 2
+	// DefaultRetryBackoff is the default delay before the first retry, which
+	// doubles with each further retry.
+	DefaultRetryBackoff = 100 * time.Millisecond
+	// DefaultBreakerThreshold is the default number of consecutive failed
+	// signing requests, after retries, that open the circuit breaker.
+	DefaultBreakerThreshold = 5
+	// DefaultBreakerOpenDuration is the default time the circuit breaker
+	// stays open before a signing request is attempted again.
+	DefaultBreakerOpenDuration = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned without contacting the KMS while the circuit
+// breaker is open after sustained failures.
+var ErrCircuitOpen = fmt.Errorf("%w: KMS circuit breaker is open after repeated signing failures", ca.ErrUnavailable)
+
+var metricCircuitOpen = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "fulcio_kms_circuit_open",
+	Help: "1 if the KMS signer's circuit breaker is open, failing signing requests fast, otherwise 0",
+})
+
+// Option configures how the KMS CA retries signing requests.
+type Option func(*breakerSigner)
+
+// WithRetries sets how many times a signing request that fails with a
+// transient error is retried, and the backoff before the first retry.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(s *breakerSigner) {
+		s.retries = retries
+		s.backoff = backoff
+	}
+}
+
+// WithCircuitBreaker sets how many consecutive signing requests failing with
+// transient errors open the circuit breaker, and how long it stays open. A
+// threshold of 0 disables the breaker.
+func WithCircuitBreaker(threshold int, openDuration time.Duration) Option {
+	return func(s *breakerSigner) {
+		s.threshold = threshold
+		s.openDuration = openDuration
+	}
+}
+
+// breakerSigner wraps a KMS signer, retrying transient errors with
+// exponential backoff and failing fast while the KMS is persistently
+// failing. After the breaker has been open for its duration, the next
+// request is attempted; success closes the breaker and failure reopens it.
+type breakerSigner struct {
+	crypto.Signer
+
+	retries      int
+	backoff      time.Duration
+	threshold    int
+	openDuration time.Duration
+
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func newBreakerSigner(signer crypto.Signer, opts ...Option) *breakerSigner {
+	s := &breakerSigner{
+		Signer:       signer,
+		retries:      DefaultRetries,
+		backoff:      DefaultRetryBackoff,
+		threshold:    DefaultBreakerThreshold,
+		openDuration: DefaultBreakerOpenDuration,
+		now:          time.Now,
+		sleep:        time.Sleep,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *breakerSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if s.open() {
+		return nil, ErrCircuitOpen
+	}
+
+	backoff := s.backoff
+	var sig []byte
+	var err error
+	for attempt := 0; ; attempt++ {
+		sig, err = s.Signer.Sign(rand, digest, opts)
+		if err == nil || attempt >= s.retries || !isTransient(err) {
+			break
+		}
+		s.sleep(backoff)
+		backoff *= 2
+	}
+	// Permanent errors are a problem with the request or the key rather than
+	// the KMS being unavailable, so they don't count towards the breaker
+	transient := err != nil && isTransient(err)
+	if err == nil || transient {
+		s.record(err)
+	}
+	if transient {
+		err = unavailableError{err}
+	}
+	return sig, err
+}
+
+// unavailableError marks a transient error that persisted through every
+// retry as ca.ErrUnavailable, so that callers can fail over or retry
+// later, while keeping the original error.
+type unavailableError struct {
+	err error
+}
+
+func (e unavailableError) Error() string {
+	return e.err.Error()
+}
+
+func (e unavailableError) Unwrap() error {
+	return e.err
+}
+
+func (e unavailableError) Is(target error) bool {
+	return target == ca.ErrUnavailable
+}
+
+// open reports whether requests should currently fail fast.
+func (s *breakerSigner) open() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now().Before(s.openUntil)
+}
+
+func (s *breakerSigner) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		s.failures = 0
+		s.openUntil = time.Time{}
+		metricCircuitOpen.Set(0)
+		return
+	}
+	s.failures++
+	if s.threshold > 0 && s.failures >= s.threshold {
+		s.openUntil = s.now().Add(s.openDuration)
+		metricCircuitOpen.Set(1)
+	}
+}
+
+// isTransient reports whether a signing error may succeed if retried. KMS
+// providers don't share error types, so errors are assumed to be transient
+// unless they are gRPC errors that indicate a problem with the request or
+// the key, or the request was cancelled. gRPC errors are found even when
+// wrapped, which status.FromError doesn't do.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		switch grpcErr.GRPCStatus().Code() {
+		case codes.InvalidArgument, codes.NotFound, codes.PermissionDenied,
+			codes.Unauthenticated, codes.FailedPrecondition, codes.Unimplemented:
+			return false
+		}
+	}
+	return true
+}
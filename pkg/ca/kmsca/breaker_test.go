@@ -0,0 +1,169 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kmsca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sigstore/fulcio/pkg/ca"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// flakySigner fails with err for the first failures calls
+type flakySigner struct {
+	crypto.Signer
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakySigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	return f.Signer.Sign(rand, digest, opts)
+}
+
+func newFlakySigner(t *testing.T, failures int, err error) *flakySigner {
+	t.Helper()
+	key, err2 := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err2 != nil {
+		t.Fatal(err2)
+	}
+	return &flakySigner{Signer: key, failures: failures, err: err}
+}
+
+func TestBreakerSignerTransientFailures(t *testing.T) {
+	flaky := newFlakySigner(t, 2, status.Error(codes.Unavailable, "try again"))
+	var sleeps []time.Duration
+	s := newBreakerSigner(flaky, WithRetries(2, 10*time.Millisecond))
+	s.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	digest := sha256.Sum256([]byte("tbs"))
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+		t.Fatalf("expected signing to recover after retries, got %v", err)
+	}
+	if flaky.calls != 3 {
+		t.Fatalf("expected 3 signing attempts, got %d", flaky.calls)
+	}
+	if len(sleeps) != 2 || sleeps[0] != 10*time.Millisecond || sleeps[1] != 20*time.Millisecond {
+		t.Fatalf("expected exponential backoff [10ms 20ms], got %v", sleeps)
+	}
+}
+
+func TestBreakerSignerPermanentFailure(t *testing.T) {
+	flaky := newFlakySigner(t, 1, status.Error(codes.PermissionDenied, "no"))
+	s := newBreakerSigner(flaky)
+	s.sleep = func(time.Duration) { t.Fatal("permanent errors should not be retried") }
+
+	digest := sha256.Sum256([]byte("tbs"))
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA256); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied, got %v", err)
+	}
+	if flaky.calls != 1 {
+		t.Fatalf("expected 1 signing attempt, got %d", flaky.calls)
+	}
+}
+
+func TestBreakerSignerWrappedPermanentFailure(t *testing.T) {
+	flaky := newFlakySigner(t, 1000, fmt.Errorf("signing with KMS: %w", status.Error(codes.PermissionDenied, "no")))
+	s := newBreakerSigner(flaky, WithCircuitBreaker(1, time.Minute))
+	s.sleep = func(time.Duration) { t.Fatal("wrapped permanent errors should not be retried") }
+
+	digest := sha256.Sum256([]byte("tbs"))
+	for i := 0; i < 2; i++ {
+		_, err := s.Sign(rand.Reader, digest[:], crypto.SHA256)
+		if err == nil || errors.Is(err, ErrCircuitOpen) || errors.Is(err, ca.ErrUnavailable) {
+			t.Fatalf("request %d: expected the permanent KMS error, got %v", i, err)
+		}
+	}
+	// Each request reached the KMS once, so the breaker never opened
+	if flaky.calls != 2 {
+		t.Fatalf("expected 2 signing attempts, got %d", flaky.calls)
+	}
+	if s.open() {
+		t.Fatal("expected permanent errors not to open the breaker")
+	}
+}
+
+func TestBreakerSignerSustainedFailures(t *testing.T) {
+	flaky := newFlakySigner(t, 1000, errors.New("kms unavailable"))
+	now := time.Now()
+	s := newBreakerSigner(flaky, WithRetries(1, time.Millisecond), WithCircuitBreaker(3, time.Minute))
+	s.sleep = func(time.Duration) {}
+	s.now = func() time.Time { return now }
+	digest := sha256.Sum256([]byte("tbs"))
+
+	// Each request is retried until the breaker opens
+	for i := 0; i < 3; i++ {
+		_, err := s.Sign(rand.Reader, digest[:], crypto.SHA256)
+		if err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("request %d: expected KMS error, got %v", i, err)
+		}
+		// Transient errors that outlast the retries are unavailability
+		if !errors.Is(err, ca.ErrUnavailable) {
+			t.Fatalf("request %d: expected unavailable error, got %v", i, err)
+		}
+	}
+	if flaky.calls != 6 {
+		t.Fatalf("expected 6 signing attempts, got %d", flaky.calls)
+	}
+	if got := testutil.ToFloat64(metricCircuitOpen); got != 1 {
+		t.Fatalf("expected circuit open gauge to be 1, got %v", got)
+	}
+
+	// While open, requests fail fast without contacting the KMS
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA256); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if flaky.calls != 6 {
+		t.Fatalf("expected no further signing attempts while open, got %d", flaky.calls)
+	}
+
+	// After the open duration, a failing request reopens the breaker
+	now = now.Add(time.Minute)
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA256); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected KMS error after the breaker's open duration, got %v", err)
+	}
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA256); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen after reopening, got %v", err)
+	}
+
+	// Once the KMS recovers, a successful request closes the breaker
+	flaky.failures = 0
+	now = now.Add(time.Minute)
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+		t.Fatalf("expected signing to succeed after recovery, got %v", err)
+	}
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+		t.Fatalf("expected breaker to be closed, got %v", err)
+	}
+	if got := testutil.ToFloat64(metricCircuitOpen); got != 0 {
+		t.Fatalf("expected circuit open gauge to be 0, got %v", got)
+	}
+}
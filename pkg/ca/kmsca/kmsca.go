@@ -16,15 +16,12 @@
 package kmsca
 
 import (
-	"bytes"
 	"context"
 	"crypto"
-	"os"
-	"path/filepath"
+	"crypto/x509"
 
 	"github.com/sigstore/fulcio/pkg/ca"
 	"github.com/sigstore/fulcio/pkg/ca/baseca"
-	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature/kms"
 
 	// Register the provider-specific plugins
@@ -36,11 +33,18 @@ import (
 
 type kmsCA struct {
 	baseca.BaseCA
+	chains *rotatingChain
 }
 
-func NewKMSCA(ctx context.Context, kmsKey, certPath string) (ca.CertificateAuthority, error) {
-	var ica kmsCA
-
+// NewKMSCA returns a CA that signs with a KMS key. Signing requests are
+// retried and guarded by a circuit breaker, configured by opts.
+//
+// The key's primary version may be rotated without restarting Fulcio. The
+// public key is resolved again every DefaultKeyVersionTTL, or when a
+// signature doesn't verify, and certPath is read again if no chain loaded
+// so far is for it, so the chain for a new version should be written there
+// when the key is rotated.
+func NewKMSCA(ctx context.Context, kmsKey, certPath string, opts ...Option) (ca.CertificateAuthority, error) {
 	kmsSigner, err := kms.Get(ctx, kmsKey, crypto.SHA256)
 	if err != nil {
 		return nil, err
@@ -50,22 +54,16 @@ func NewKMSCA(ctx context.Context, kmsKey, certPath string) (ca.CertificateAutho
 		return nil, err
 	}
 
-	sc := ca.SignerCerts{}
-	ica.SignerWithChain = &sc
-
-	sc.Signer = signer
-
-	data, err := os.ReadFile(filepath.Clean(certPath))
-	if err != nil {
-		return nil, err
-	}
-	sc.Certs, err = cryptoutils.LoadCertificatesFromPEM(bytes.NewReader(data))
+	chains, err := newRotatingChain(newBreakerSigner(signer, opts...), certPath)
 	if err != nil {
 		return nil, err
 	}
-	if err := ca.VerifyCertChain(sc.Certs, sc.Signer); err != nil {
-		return nil, err
-	}
+	ica := &kmsCA{chains: chains}
+	ica.SignerWithChain = chains
+	return ica, nil
+}
 
-	return &ica, nil
+// TrustBundle returns the chains of every key version used so far.
+func (kca *kmsCA) TrustBundle(ctx context.Context) ([][]*x509.Certificate, error) {
+	return kca.chains.TrustBundle(), nil
 }
@@ -0,0 +1,194 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kmsca
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/ca"
+	"github.com/sigstore/fulcio/pkg/log"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// DefaultKeyVersionTTL is how long the public key of the KMS key's current
+// version is used before it's resolved again, to pick up key rotation.
+const DefaultKeyVersionTTL = time.Minute
+
+// ErrKeyRotated is returned when a signature doesn't verify with the key
+// version its certificate chain was picked for, because the KMS key was
+// rotated in the meantime. The next request uses the new version.
+var ErrKeyRotated = fmt.Errorf("%w: KMS key version changed while signing", ca.ErrUnavailable)
+
+// rotatingChain implements ca.SignerWithChain for a KMS key whose primary
+// version may be rotated while Fulcio is running. The KMS signs with
+// whichever version is primary, so the public key is resolved again every
+// ttl, or as soon as a signature doesn't verify, and the certificate chain
+// for that key is returned with it. The chain of a new version is loaded by
+// reading certPath again, so an operator can replace the chain there before
+// or after rotating the key. Chains already loaded are kept, for versions
+// still in use.
+type rotatingChain struct {
+	signer   crypto.Signer
+	certPath string
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu         sync.Mutex
+	chains     [][]*x509.Certificate
+	current    []*x509.Certificate
+	public     crypto.PublicKey
+	resolvedAt time.Time
+}
+
+func newRotatingChain(signer crypto.Signer, certPath string) (*rotatingChain, error) {
+	r := &rotatingChain{
+		signer:   signer,
+		certPath: certPath,
+		ttl:      DefaultKeyVersionTTL,
+		now:      time.Now,
+	}
+	if err := r.resolve(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingChain) GetSignerWithChain() ([]*x509.Certificate, crypto.Signer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.now().Sub(r.resolvedAt) >= r.ttl {
+		if err := r.resolveLocked(); err != nil {
+			// Keep the last version, so signing fails with ErrKeyRotated
+			// if it has really changed
+			log.Logger.Warnw("resolving KMS key version", "error", err)
+			r.resolvedAt = r.now()
+		}
+	}
+	return r.current, versionSigner{chain: r, public: r.public}
+}
+
+// TrustBundle returns the chains of every key version loaded, newest first,
+// so verifiers trust certificates signed before and after rotation.
+func (r *rotatingChain) TrustBundle() [][]*x509.Certificate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]*x509.Certificate(nil), r.chains...)
+}
+
+func (r *rotatingChain) resolve() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.resolveLocked()
+}
+
+// resolveLocked picks the chain for the current public key of the KMS key,
+// reading certPath if no chain loaded so far matches it.
+func (r *rotatingChain) resolveLocked() error {
+	public := r.signer.Public()
+	if public == nil {
+		return errors.New("could not get the public key of the KMS key")
+	}
+	chain := r.chainFor(public)
+	if chain == nil {
+		data, err := os.ReadFile(filepath.Clean(r.certPath))
+		if err != nil {
+			return err
+		}
+		certs, err := cryptoutils.LoadCertificatesFromPEM(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		// Check the chain against the key resolved, rather than the
+		// signer, whose version may change again
+		if err := ca.VerifyCertChain(certs, versionSigner{chain: r, public: public}); err != nil {
+			return fmt.Errorf("certificate chain in %s is not for the current KMS key version: %w", r.certPath, err)
+		}
+		chain = certs
+		r.chains = append([][]*x509.Certificate{chain}, r.chains...)
+	}
+	r.current = chain
+	r.public = public
+	r.resolvedAt = r.now()
+	return nil
+}
+
+func (r *rotatingChain) chainFor(public crypto.PublicKey) []*x509.Certificate {
+	for _, chain := range r.chains {
+		if cryptoutils.EqualKeys(chain[0].PublicKey, public) == nil {
+			return chain
+		}
+	}
+	return nil
+}
+
+// invalidate makes the next request resolve the key version again.
+func (r *rotatingChain) invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolvedAt = time.Time{}
+}
+
+// versionSigner signs with the KMS key, checking the signature verifies with
+// the public key of the version its chain was picked for.
+type versionSigner struct {
+	chain  *rotatingChain
+	public crypto.PublicKey
+}
+
+func (s versionSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+func (s versionSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	sig, err := s.chain.signer.Sign(rand, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !verifySignature(s.public, digest, sig, opts) {
+		s.chain.invalidate()
+		return nil, ErrKeyRotated
+	}
+	return sig, nil
+}
+
+// verifySignature reports whether sig is a signature of digest, as
+// produced by crypto.Signer.Sign with opts, by the private key of public.
+func verifySignature(public crypto.PublicKey, digest, sig []byte, opts crypto.SignerOpts) bool {
+	switch public := public.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(public, digest, sig)
+	case *rsa.PublicKey:
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+			return rsa.VerifyPSS(public, pss.Hash, digest, sig, pss) == nil
+		}
+		return rsa.VerifyPKCS1v15(public, opts.HashFunc(), digest, sig) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(public, digest, sig)
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kmsca
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/ca"
+	"github.com/sigstore/fulcio/pkg/ca/catest"
+	"github.com/sigstore/fulcio/pkg/test"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// rotatingSigner is a fake KMS key, which like a KMS signs with whichever
+// version is primary
+type rotatingSigner struct {
+	mu  sync.Mutex
+	key *ecdsa.PrivateKey
+}
+
+func (s *rotatingSigner) rotate(key *ecdsa.PrivateKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = key
+}
+
+func (s *rotatingSigner) Public() crypto.PublicKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.key.Public()
+}
+
+func (s *rotatingSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.key.Sign(rand, digest, opts)
+}
+
+func TestRotatingChain(t *testing.T) {
+	rootCert, rootKey, err := test.GenerateRootCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath := filepath.Join(t.TempDir(), "chain.pem")
+	// newVersion creates a key version with a chain, and writes the chain
+	// to certPath
+	newVersion := func() (*x509.Certificate, *ecdsa.PrivateKey) {
+		t.Helper()
+		subCert, subKey, err := test.GenerateSubordinateCA(rootCert, rootKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pemChain, err := cryptoutils.MarshalCertificatesToPEM([]*x509.Certificate{subCert, rootCert})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(certPath, pemChain, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return subCert, subKey
+	}
+
+	cert1, key1 := newVersion()
+	signer := &rotatingSigner{key: key1}
+	chains, err := newRotatingChain(signer, certPath)
+	if err != nil {
+		t.Fatalf("newRotatingChain() = %v", err)
+	}
+	now := time.Now()
+	chains.now = func() time.Time { return now }
+	chains.resolvedAt = now
+	kca := &kmsCA{chains: chains}
+	kca.SignerWithChain = chains
+
+	issuedBy := func(cert, issuer *x509.Certificate) bool {
+		return cert.CheckSignatureFrom(issuer) == nil
+	}
+	if cert := catest.CheckIssuance(t, kca); !issuedBy(cert, cert1) {
+		t.Fatal("expected certificate to be issued by the first version")
+	}
+
+	// The key is rotated mid-TTL, so the signature that notices fails,
+	// and the next request uses the new version and its chain
+	cert2, key2 := newVersion()
+	signer.rotate(key2)
+	certs, stale := chains.GetSignerWithChain()
+	if !certs[0].Equal(cert1) {
+		t.Fatal("expected the first version's chain before the rotation is noticed")
+	}
+	digest := sha256.Sum256([]byte("tbs"))
+	if _, err := stale.Sign(rand.Reader, digest[:], crypto.SHA256); !errors.Is(err, ErrKeyRotated) || !errors.Is(err, ca.ErrUnavailable) {
+		t.Fatalf("expected ErrKeyRotated, got %v", err)
+	}
+	if cert := catest.CheckIssuance(t, kca); !issuedBy(cert, cert2) {
+		t.Fatal("expected certificate to be issued by the second version")
+	}
+
+	// Both chains are trusted, for certificates issued before the rotation
+	bundle, err := kca.TrustBundle(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle) != 2 || !bundle[0][0].Equal(cert2) || !bundle[1][0].Equal(cert1) {
+		t.Fatalf("expected trust bundle with both versions' chains, got %d chains", len(bundle))
+	}
+
+	// Once the TTL passes, a rotation is picked up without any failure
+	cert3, key3 := newVersion()
+	signer.rotate(key3)
+	now = now.Add(DefaultKeyVersionTTL)
+	if cert := catest.CheckIssuance(t, kca); !issuedBy(cert, cert3) {
+		t.Fatal("expected certificate to be issued by the third version")
+	}
+
+	// Rotating back to a version whose chain was replaced in certPath
+	// uses the chain loaded earlier
+	signer.rotate(key1)
+	now = now.Add(DefaultKeyVersionTTL)
+	if cert := catest.CheckIssuance(t, kca); !issuedBy(cert, cert1) {
+		t.Fatal("expected certificate to be issued by the first version again")
+	}
+}
+
+func TestRotatingChainMissingChain(t *testing.T) {
+	rootCert, rootKey, err := test.GenerateRootCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	subCert, subKey, err := test.GenerateSubordinateCA(rootCert, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath := filepath.Join(t.TempDir(), "chain.pem")
+	pemChain, err := cryptoutils.MarshalCertificatesToPEM([]*x509.Certificate{subCert, rootCert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certPath, pemChain, 0600); err != nil {
+		t.Fatal(err)
+	}
+	signer := &rotatingSigner{key: subKey}
+	chains, err := newRotatingChain(signer, certPath)
+	if err != nil {
+		t.Fatalf("newRotatingChain() = %v", err)
+	}
+
+	// Without a chain for the new version, the old chain is kept, and
+	// signing fails rather than issuing certificates that don't chain
+	_, otherKey, err := test.GenerateSubordinateCA(rootCert, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.rotate(otherKey)
+	chains.resolvedAt = time.Time{}
+	certs, s := chains.GetSignerWithChain()
+	if !certs[0].Equal(subCert) {
+		t.Fatal("expected the old chain to be kept")
+	}
+	digest := sha256.Sum256([]byte("tbs"))
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA256); !errors.Is(err, ErrKeyRotated) {
+		t.Fatalf("expected ErrKeyRotated, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+//go:build cgo && softhsm
+// +build cgo,softhsm
+
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs11ca
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ThalesIgnite/crypto11"
+	"github.com/sigstore/fulcio/pkg/ca/catest"
+)
+
+// These tests need SoftHSM, and only run with the softhsm build tag:
+//
+//	apt-get install softhsm2
+//	go test -tags softhsm ./pkg/ca/pkcs11ca/
+//
+// SOFTHSM2_LIB overrides the path of the SoftHSM PKCS#11 library.
+
+const (
+	tokenLabel = "fulcio"
+	tokenPin   = "2324"
+	rootID     = "99"
+)
+
+// setupSoftHSM initializes a SoftHSM token in a temporary directory, and
+// returns the path of a crypto11 config for it.
+func setupSoftHSM(t *testing.T) string {
+	t.Helper()
+	lib := os.Getenv("SOFTHSM2_LIB")
+	if lib == "" {
+		lib = "/usr/lib/softhsm/libsofthsm2.so"
+	}
+	if _, err := os.Stat(lib); err != nil {
+		t.Skipf("SoftHSM library not found: %v", err)
+	}
+	util, err := exec.LookPath("softhsm2-util")
+	if err != nil {
+		t.Skipf("softhsm2-util not found: %v", err)
+	}
+
+	dir := t.TempDir()
+	tokens := filepath.Join(dir, "tokens")
+	if err := os.Mkdir(tokens, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	softhsmConf := filepath.Join(dir, "softhsm2.conf")
+	if err := os.WriteFile(softhsmConf, []byte(fmt.Sprintf("directories.tokendir = %s\nobjectstore.backend = file\n", tokens)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOFTHSM2_CONF", softhsmConf)
+
+	cmd := exec.Command(util, "--init-token", "--free", "--label", tokenLabel, "--pin", tokenPin, "--so-pin", tokenPin)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("initializing token: %v: %s", err, out)
+	}
+
+	config, err := json.Marshal(crypto11.Config{Path: lib, TokenLabel: tokenLabel, Pin: tokenPin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "crypto11.conf")
+	if err := os.WriteFile(configPath, config, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return configPath
+}
+
+// createRootCA generates the CA key in the HSM, where NewPKCS11CA expects
+// it, and imports a self-signed root certificate for it, which is returned.
+func createRootCA(t *testing.T, configPath string) *x509.Certificate {
+	t.Helper()
+	p11Ctx, err := crypto11.ConfigureFromFile(configPath)
+	if err != nil {
+		t.Fatalf("configuring crypto11: %v", err)
+	}
+	defer p11Ctx.Close()
+
+	signer, err := p11Ctx.GenerateECDSAKeyPairWithLabel([]byte("1"), []byte("PKCS11CA"), elliptic.P384())
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sigstore", Organization: []string{"sigstore.dev"}},
+		NotBefore:             time.Now().Add(-5 * time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLen:            1,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	if err != nil {
+		t.Fatalf("creating root certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p11Ctx.ImportCertificateWithLabel([]byte(rootID), []byte("FulcioCA"), cert); err != nil {
+		t.Fatalf("importing root certificate: %v", err)
+	}
+	return cert
+}
+
+// SoftHSM reads its config when the library is first loaded, and the CA
+// keeps the library loaded, so all cases share one token.
+func TestPKCS11CAIssuance(t *testing.T) {
+	configPath := setupSoftHSM(t)
+	root := createRootCA(t, configPath)
+
+	caPath := filepath.Join(t.TempDir(), "root.pem")
+	if err := os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]Params{
+		"root from HSM":  {ConfigPath: configPath, RootID: rootID},
+		"root from file": {ConfigPath: configPath, RootID: rootID, CAPath: &caPath},
+	}
+	for name, params := range tests {
+		t.Run(name, func(t *testing.T) {
+			ca, err := NewPKCS11CA(params)
+			if err != nil {
+				t.Fatalf("NewPKCS11CA() = %v", err)
+			}
+			cert := catest.CheckIssuance(t, ca)
+			if err := cert.CheckSignatureFrom(root); err != nil {
+				t.Fatalf("certificate not signed by HSM key: %v", err)
+			}
+		})
+	}
+}
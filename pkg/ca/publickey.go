@@ -0,0 +1,41 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ca
+
+import (
+	"crypto"
+	"fmt"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// ValidatePublicKey checks that a certificate can be issued for pub. The
+// supported keys are:
+//   - RSA, from 2048 to 4096 bits
+//   - ECDSA, on the NIST P-256, P-384 or P-521 curves
+//   - Ed25519
+//
+// Keys are also checked for weak parameters, e.g. an RSA key with a small
+// public exponent.
+func ValidatePublicKey(pub crypto.PublicKey) error {
+	if _, err := KeyType(pub); err != nil {
+		return err
+	}
+	if err := cryptoutils.ValidatePubKey(pub); err != nil {
+		return fmt.Errorf("insecure %T public key: %w", pub, err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ca
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/sigstore/fulcio/pkg/identity/username"
+)
+
+func TestValidatePublicKey(t *testing.T) {
+	rsaKey := func(bits int) crypto.PublicKey {
+		k, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k.Public()
+	}
+	ecdsaKey := func(curve elliptic.Curve) crypto.PublicKey {
+		k, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k.Public()
+	}
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weakExponent := rsaKey(2048).(*rsa.PublicKey)
+	weakExponent.E = 3
+
+	tests := map[string]struct {
+		key    crypto.PublicKey
+		wantOK bool
+	}{
+		"RSA 1024":            {rsaKey(1024), false},
+		"RSA 2048":            {rsaKey(2048), true},
+		"RSA 3072":            {rsaKey(3072), true},
+		"RSA 4096":            {rsaKey(4096), true},
+		"RSA with exponent 3": {weakExponent, false},
+		"ECDSA P-224":         {ecdsaKey(elliptic.P224()), false},
+		"ECDSA P-256":         {ecdsaKey(elliptic.P256()), true},
+		"ECDSA P-384":         {ecdsaKey(elliptic.P384()), true},
+		"ECDSA P-521":         {ecdsaKey(elliptic.P521()), true},
+		"Ed25519":             {edKey, true},
+		"Ed25519 private key": {ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)), false},
+		"nil":                 {nil, false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidatePublicKey(test.key)
+			if test.wantOK && err != nil {
+				t.Fatalf("expected key to be accepted, got %v", err)
+			}
+			if !test.wantOK && err == nil {
+				t.Fatal("expected key to be rejected")
+			}
+
+			// Certificates with an OtherName SAN are only built for
+			// accepted keys
+			cert, err := MakeX509(context.TODO(), &otherNamePrincipal{}, test.key)
+			if !test.wantOK {
+				if err == nil {
+					t.Fatal("expected MakeX509 to reject key")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MakeX509() = %v", err)
+			}
+			if name, err := username.UnmarshalSANS(cert.ExtraExtensions); err != nil || name != "foo!example.com" {
+				t.Fatalf("UnmarshalSANS() = %q, %v", name, err)
+			}
+		})
+	}
+}
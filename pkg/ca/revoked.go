@@ -0,0 +1,38 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"math/big"
+)
+
+type revokedKey struct{}
+
+// WithRevokedSerials attaches a check for revoked serial numbers, which
+// MakeX509 consults before a certificate is signed. A certificate is never
+// issued with a revoked serial number; MakeX509 reports ErrSerialCollision
+// instead, so that issuance can be retried with a new one.
+func WithRevokedSerials(ctx context.Context, revoked func(serial *big.Int) (bool, error)) context.Context {
+	return context.WithValue(ctx, revokedKey{}, revoked)
+}
+
+func serialRevoked(ctx context.Context, serial *big.Int) (bool, error) {
+	revoked, _ := ctx.Value(revokedKey{}).(func(*big.Int) (bool, error))
+	if revoked == nil {
+		return false, nil
+	}
+	return revoked(serial)
+}
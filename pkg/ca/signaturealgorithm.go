@@ -0,0 +1,106 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ca
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/sigstore/fulcio/pkg/config"
+)
+
+// KeyType returns the config.KeyType constant for a certificate public key.
+func KeyType(pub crypto.PublicKey) (string, error) {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return config.KeyTypeECDSAP256, nil
+		case elliptic.P384():
+			return config.KeyTypeECDSAP384, nil
+		case elliptic.P521():
+			return config.KeyTypeECDSAP521, nil
+		}
+		return "", fmt.Errorf("unsupported ECDSA curve %v", k.Curve.Params().Name)
+	case *rsa.PublicKey:
+		return config.KeyTypeRSA, nil
+	case ed25519.PublicKey:
+		return config.KeyTypeEd25519, nil
+	}
+	return "", fmt.Errorf("unsupported public key type %T", pub)
+}
+
+// defaultSignatureAlgorithms match the hash strength to the security
+// strength of the CA key, as crypto/x509 does when no algorithm is given.
+var defaultSignatureAlgorithms = map[string]x509.SignatureAlgorithm{
+	config.KeyTypeECDSAP256: x509.ECDSAWithSHA256,
+	config.KeyTypeECDSAP384: x509.ECDSAWithSHA384,
+	config.KeyTypeECDSAP521: x509.ECDSAWithSHA512,
+	config.KeyTypeRSA:       x509.SHA256WithRSA,
+	config.KeyTypeEd25519:   x509.PureEd25519,
+}
+
+// SignatureAlgorithm selects the algorithm that a CA key signs a certificate
+// for leafKey with. The algorithm configured for the leaf key type is used
+// if there is one, and must be compatible with the CA key. Otherwise, the
+// default depends on the CA key type, e.g. ECDSA-SHA256 for a P-256 CA key.
+func SignatureAlgorithm(ctx context.Context, caKey, leafKey crypto.PublicKey) (x509.SignatureAlgorithm, error) {
+	keyType, err := KeyType(leafKey)
+	if err != nil {
+		return x509.UnknownSignatureAlgorithm, err
+	}
+
+	if cfg := config.FromContext(ctx); cfg != nil {
+		alg, ok, err := cfg.GetSignatureAlgorithm(keyType)
+		if err != nil {
+			return x509.UnknownSignatureAlgorithm, err
+		}
+		if ok {
+			if !signatureAlgorithmCompatible(alg, caKey) {
+				return x509.UnknownSignatureAlgorithm, fmt.Errorf("signature algorithm %v configured for key type %s is not compatible with CA key type %T", alg, keyType, caKey)
+			}
+			return alg, nil
+		}
+	}
+
+	caKeyType, err := KeyType(caKey)
+	if err != nil {
+		return x509.UnknownSignatureAlgorithm, fmt.Errorf("CA key: %w", err)
+	}
+	return defaultSignatureAlgorithms[caKeyType], nil
+}
+
+func signatureAlgorithmCompatible(alg x509.SignatureAlgorithm, caKey crypto.PublicKey) bool {
+	switch caKey.(type) {
+	case *ecdsa.PublicKey:
+		return alg == x509.ECDSAWithSHA256 || alg == x509.ECDSAWithSHA384 || alg == x509.ECDSAWithSHA512
+	case *rsa.PublicKey:
+		switch alg {
+		case x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA,
+			x509.SHA256WithRSAPSS, x509.SHA384WithRSAPSS, x509.SHA512WithRSAPSS:
+			return true
+		}
+	case ed25519.PublicKey:
+		return alg == x509.PureEd25519
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ca
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+
+	"github.com/sigstore/fulcio/pkg/config"
+)
+
+func TestSignatureAlgorithmDefaults(t *testing.T) {
+	ecdsaKey := func(curve elliptic.Curve) crypto.PublicKey {
+		k, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k.Public()
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519Key, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafKeys := map[string]crypto.PublicKey{
+		config.KeyTypeECDSAP256: ecdsaKey(elliptic.P256()),
+		config.KeyTypeECDSAP384: ecdsaKey(elliptic.P384()),
+		config.KeyTypeECDSAP521: ecdsaKey(elliptic.P521()),
+		config.KeyTypeRSA:       rsaKey.Public(),
+		config.KeyTypeEd25519:   ed25519Key,
+	}
+	// The default depends on the CA key, whatever the leaf key
+	caKeys := []struct {
+		key  crypto.PublicKey
+		want x509.SignatureAlgorithm
+	}{
+		{ecdsaKey(elliptic.P256()), x509.ECDSAWithSHA256},
+		{ecdsaKey(elliptic.P384()), x509.ECDSAWithSHA384},
+		{ecdsaKey(elliptic.P521()), x509.ECDSAWithSHA512},
+		{rsaKey.Public(), x509.SHA256WithRSA},
+		{ed25519Key, x509.PureEd25519},
+	}
+
+	for _, ca := range caKeys {
+		caKey, want := ca.key, ca.want
+		for keyType, leafKey := range leafKeys {
+			gotType, err := KeyType(leafKey)
+			if err != nil || gotType != keyType {
+				t.Fatalf("KeyType() = %q, %v, want %q", gotType, err, keyType)
+			}
+			alg, err := SignatureAlgorithm(context.TODO(), caKey, leafKey)
+			if err != nil {
+				t.Fatalf("%T CA, %s leaf: unexpected error: %v", caKey, keyType, err)
+			}
+			if alg != want {
+				t.Errorf("%T CA, %s leaf: expected %v, got %v", caKey, keyType, want, alg)
+			}
+		}
+	}
+}
+
+func TestSignatureAlgorithmConfigured(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := config.With(context.TODO(), &config.FulcioConfig{
+		SignatureAlgorithms: map[string]string{config.KeyTypeECDSAP256: "ECDSA-SHA384"},
+	})
+	alg, err := SignatureAlgorithm(ctx, caKey.Public(), leafKey.Public())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if alg != x509.ECDSAWithSHA384 {
+		t.Fatalf("expected configured ECDSA-SHA384, got %v", alg)
+	}
+
+	// The configured algorithm must be compatible with the CA key
+	ctx = config.With(context.TODO(), &config.FulcioConfig{
+		SignatureAlgorithms: map[string]string{config.KeyTypeECDSAP256: "SHA256-RSA"},
+	})
+	if _, err := SignatureAlgorithm(ctx, caKey.Public(), leafKey.Public()); err == nil {
+		t.Fatal("expected error for RSA algorithm with ECDSA CA key")
+	}
+
+	// Unsupported leaf keys have no algorithm
+	p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SignatureAlgorithm(context.TODO(), caKey.Public(), p224.Public()); err == nil {
+		t.Fatal("expected error for P-224 leaf key")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package sshca issues OpenSSH user certificates for authenticated
+// identities, for use alongside the X.509 code signing certificates.
+package sshca
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/identity"
+	"golang.org/x/crypto/ssh"
+)
+
+// defaultExtensions match the permissions granted by `ssh-keygen -s` for a
+// user certificate.
+var defaultExtensions = map[string]string{
+	"permit-X11-forwarding":   "",
+	"permit-agent-forwarding": "",
+	"permit-port-forwarding":  "",
+	"permit-pty":              "",
+	"permit-user-rc":          "",
+}
+
+// SSHCA signs OpenSSH user certificates with a CA key.
+type SSHCA struct {
+	signer ssh.Signer
+}
+
+// New returns an SSHCA that signs certificates with signer.
+func New(signer ssh.Signer) *SSHCA {
+	return &SSHCA{signer: signer}
+}
+
+// NewFromFile returns an SSHCA that signs certificates with the unencrypted
+// private key at path, in any format accepted by ssh-keygen.
+func NewFromFile(path string) (*SSHCA, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("parse SSH CA key: %w", err)
+	}
+	return New(signer), nil
+}
+
+// PublicKey returns the CA public key, which hosts trust through
+// TrustedUserCAKeys.
+func (s *SSHCA) PublicKey() ssh.PublicKey {
+	return s.signer.PublicKey()
+}
+
+// CreateCertificate issues a user certificate for publicKey, valid between
+// notBefore and notAfter. The certificate's only principal, and its key ID,
+// is the name of the principal.
+func (s *SSHCA) CreateCertificate(ctx context.Context, principal identity.Principal, publicKey crypto.PublicKey, notBefore, notAfter time.Time) (*ssh.Certificate, error) {
+	name := principal.Name(ctx)
+	if name == "" {
+		return nil, errors.New("principal has no name")
+	}
+	key, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported SSH public key: %w", err)
+	}
+
+	var serial [8]byte
+	if _, err := rand.Read(serial[:]); err != nil {
+		return nil, err
+	}
+
+	extensions := make(map[string]string, len(defaultExtensions))
+	for k, v := range defaultExtensions {
+		extensions[k] = v
+	}
+	cert := &ssh.Certificate{
+		Key:             key,
+		Serial:          binary.BigEndian.Uint64(serial[:]),
+		CertType:        ssh.UserCert,
+		KeyId:           name,
+		ValidPrincipals: []string{name},
+		ValidAfter:      uint64(notBefore.Unix()),
+		ValidBefore:     uint64(notAfter.Unix()),
+		Permissions: ssh.Permissions{
+			Extensions: extensions,
+		},
+	}
+	if err := cert.SignCert(rand.Reader, s.signer); err != nil {
+		return nil, fmt.Errorf("sign SSH certificate: %w", err)
+	}
+	return cert, nil
+}
@@ -0,0 +1,31 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ca
+
+import "context"
+
+type subjectKeyIDKey struct{}
+
+// WithSubjectKeyID attaches a subject key identifier supplied by the client,
+// which MakeX509 uses instead of computing one from the public key.
+func WithSubjectKeyID(ctx context.Context, skid []byte) context.Context {
+	return context.WithValue(ctx, subjectKeyIDKey{}, skid)
+}
+
+func subjectKeyIDFromContext(ctx context.Context) []byte {
+	skid, _ := ctx.Value(subjectKeyIDKey{}).([]byte)
+	return skid
+}
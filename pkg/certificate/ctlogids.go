@@ -0,0 +1,68 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certificate
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// RenderCTLogIDs returns an extension under arc listing the IDs of the CT
+// logs that a certificate is submitted to. A log ID is the SHA-256 hash of
+// the log's DER encoded public key. The value is a SEQUENCE OF OCTET STRING.
+func RenderCTLogIDs(arc ExtensionArc, logIDs [][32]byte) (pkix.Extension, error) {
+	if len(arc) == 0 {
+		return pkix.Extension{}, errors.New("an extension arc is required")
+	}
+	if len(logIDs) == 0 {
+		return pkix.Extension{}, errors.New("at least one CT log ID is required")
+	}
+	values := make([][]byte, 0, len(logIDs))
+	for i := range logIDs {
+		values = append(values, logIDs[i][:])
+	}
+	value, err := asn1.Marshal(values)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{
+		Id:    arc.OID(ArcCTLogIDs),
+		Value: value,
+	}, nil
+}
+
+// ParseCTLogIDs parses the value of a CT log ID extension.
+func ParseCTLogIDs(value []byte) ([][32]byte, error) {
+	var values [][]byte
+	rest, err := asn1.Unmarshal(value, &values)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after CT log IDs")
+	}
+	logIDs := make([][32]byte, 0, len(values))
+	for _, v := range values {
+		if len(v) != 32 {
+			return nil, fmt.Errorf("CT log ID must be 32 bytes, got %d", len(v))
+		}
+		var logID [32]byte
+		copy(logID[:], v)
+		logIDs = append(logIDs, logID)
+	}
+	return logIDs, nil
+}
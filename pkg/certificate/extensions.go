@@ -30,11 +30,61 @@ var (
 	OIDOtherName                = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 7}
 )
 
+// ExtensionArc is an OID arc chosen by the operator, such as one under their
+// own IANA Private Enterprise Number, for the extensions of this Fulcio that
+// Sigstore hasn't allocated OIDs for. They can't be numbered under Sigstore's
+// arc, since upstream Fulcio allocates those OIDs itself.
+type ExtensionArc asn1.ObjectIdentifier
+
+// OID returns the OID of extension n under the arc, or nil if the arc is
+// empty.
+func (a ExtensionArc) OID(n int) asn1.ObjectIdentifier {
+	if len(a) == 0 {
+		return nil
+	}
+	oid := make(asn1.ObjectIdentifier, len(a), len(a)+1)
+	copy(oid, a)
+	return append(oid, n)
+}
+
+// Numbers of the extensions under an ExtensionArc. They're documented at
+// docs/oid-info.md, and must not be reused.
+const (
+	ArcCTLogIDs                  = 1
+	ArcEnvironment               = 2
+	ArcJWKThumbprint             = 3
+	ArcGitHubWorkflowEnvironment = 4
+	ArcIdentityClass             = 5
+	ArcGitLabProjectPath         = 6
+	ArcGitLabCIConfigRefURI      = 7
+	ArcGitLabPipelineID          = 8
+	ArcGitLabRunnerID            = 9
+	ArcKubernetesNamespace       = 10
+	ArcKubernetesServiceAccount  = 11
+	ArcBitbucketRepositoryUUID   = 12
+	ArcBitbucketPipelineUUID     = 13
+	ArcBitbucketStepUUID         = 14
+	ArcBitbucketWorkspaceUUID    = 15
+	ArcAWSRoleARN                = 16
+	ArcAWSAccountID              = 17
+)
+
+// Identity classes recorded under ArcIdentityClass, so that verifiers can
+// apply different policies to people and workloads.
+const (
+	IdentityClassHuman   = "human"
+	IdentityClassMachine = "machine"
+)
+
 // Extensions contains all custom x509 extensions defined by Fulcio
 type Extensions struct {
 	// NB: New extensions must be added here and documented
 	// at docs/oidc-info.md
 
+	// The arc of the extensions that Sigstore hasn't allocated OIDs for.
+	// Those extensions are only rendered if it's set.
+	Arc ExtensionArc
+
 	// The OIDC issuer. Should match `iss` claim of ID token or, in the case of
 	// a federated login like Dex it should match the issuer URL of the
 	// upstream issuer. The issuer is not set the extensions are invalid and
@@ -60,6 +110,57 @@ type Extensions struct {
 	// Git Ref of the Github Actions Workflow. Matches the `ref` claim of the ID tokens
 	// from Github Actions
 	GithubWorkflowRef string // 1.3.6.1.4.1.57264.1.6
+
+	// Deployment environment of the Github Actions job. Matches the
+	// `environment` claim of the ID tokens from Github Actions
+	GithubWorkflowEnvironment string // ArcGitHubWorkflowEnvironment under Arc
+
+	// Path of the GitLab project the pipeline ran for, e.g. group/project.
+	// Matches the `project_path` claim of ID tokens from GitLab CI
+	GitLabProjectPath string // ArcGitLabProjectPath under Arc
+
+	// Reference to the CI config of the GitLab pipeline. Matches the
+	// `ci_config_ref_uri` claim of ID tokens from GitLab CI
+	GitLabCIConfigRefURI string // ArcGitLabCIConfigRefURI under Arc
+
+	// ID of the GitLab pipeline. Matches the `pipeline_id` claim of ID tokens
+	// from GitLab CI
+	GitLabPipelineID string // ArcGitLabPipelineID under Arc
+
+	// ID of the runner that ran the GitLab job. Matches the `runner_id` claim
+	// of ID tokens from GitLab CI
+	GitLabRunnerID string // ArcGitLabRunnerID under Arc
+
+	// Namespace of the Kubernetes service account. Matches the namespace
+	// claim of Kubernetes service account tokens
+	KubernetesNamespace string // ArcKubernetesNamespace under Arc
+
+	// Name of the Kubernetes service account. Matches the service account
+	// name claim of Kubernetes service account tokens
+	KubernetesServiceAccount string // ArcKubernetesServiceAccount under Arc
+
+	// UUID of the Bitbucket repository the pipeline ran for. Matches the
+	// `repositoryUuid` claim of ID tokens from Bitbucket Pipelines
+	BitbucketRepositoryUUID string // ArcBitbucketRepositoryUUID under Arc
+
+	// UUID of the Bitbucket pipeline. Matches the `pipelineUuid` claim of ID
+	// tokens from Bitbucket Pipelines
+	BitbucketPipelineUUID string // ArcBitbucketPipelineUUID under Arc
+
+	// UUID of the step of the Bitbucket pipeline. Matches the `stepUuid`
+	// claim of ID tokens from Bitbucket Pipelines
+	BitbucketStepUUID string // ArcBitbucketStepUUID under Arc
+
+	// UUID of the Bitbucket workspace of the repository. Matches the
+	// `workspaceUuid` claim of ID tokens from Bitbucket Pipelines
+	BitbucketWorkspaceUUID string // ArcBitbucketWorkspaceUUID under Arc
+
+	// ARN of the AWS IAM role that an EKS service account assumes with its
+	// token, e.g. arn:aws:iam::123456789012:role/deploy
+	AWSRoleARN string // ArcAWSRoleARN under Arc
+
+	// ID of the AWS account of the IAM role, e.g. 123456789012
+	AWSAccountID string // ArcAWSAccountID under Arc
 }
 
 func (e Extensions) Render() ([]pkix.Extension, error) {
@@ -103,11 +204,97 @@ func (e Extensions) Render() ([]pkix.Extension, error) {
 			Value: []byte(e.GithubWorkflowRef),
 		})
 	}
+	if e.GithubWorkflowEnvironment != "" && len(e.Arc) > 0 {
+		exts = append(exts, pkix.Extension{
+			Id:    e.Arc.OID(ArcGitHubWorkflowEnvironment),
+			Value: []byte(e.GithubWorkflowEnvironment),
+		})
+	}
+	if e.GitLabProjectPath != "" && len(e.Arc) > 0 {
+		exts = append(exts, pkix.Extension{
+			Id:    e.Arc.OID(ArcGitLabProjectPath),
+			Value: []byte(e.GitLabProjectPath),
+		})
+	}
+	if e.GitLabCIConfigRefURI != "" && len(e.Arc) > 0 {
+		exts = append(exts, pkix.Extension{
+			Id:    e.Arc.OID(ArcGitLabCIConfigRefURI),
+			Value: []byte(e.GitLabCIConfigRefURI),
+		})
+	}
+	if e.GitLabPipelineID != "" && len(e.Arc) > 0 {
+		exts = append(exts, pkix.Extension{
+			Id:    e.Arc.OID(ArcGitLabPipelineID),
+			Value: []byte(e.GitLabPipelineID),
+		})
+	}
+	if e.GitLabRunnerID != "" && len(e.Arc) > 0 {
+		exts = append(exts, pkix.Extension{
+			Id:    e.Arc.OID(ArcGitLabRunnerID),
+			Value: []byte(e.GitLabRunnerID),
+		})
+	}
+	if e.KubernetesNamespace != "" && len(e.Arc) > 0 {
+		exts = append(exts, pkix.Extension{
+			Id:    e.Arc.OID(ArcKubernetesNamespace),
+			Value: []byte(e.KubernetesNamespace),
+		})
+	}
+	if e.KubernetesServiceAccount != "" && len(e.Arc) > 0 {
+		exts = append(exts, pkix.Extension{
+			Id:    e.Arc.OID(ArcKubernetesServiceAccount),
+			Value: []byte(e.KubernetesServiceAccount),
+		})
+	}
+	if e.BitbucketRepositoryUUID != "" && len(e.Arc) > 0 {
+		exts = append(exts, pkix.Extension{
+			Id:    e.Arc.OID(ArcBitbucketRepositoryUUID),
+			Value: []byte(e.BitbucketRepositoryUUID),
+		})
+	}
+	if e.BitbucketPipelineUUID != "" && len(e.Arc) > 0 {
+		exts = append(exts, pkix.Extension{
+			Id:    e.Arc.OID(ArcBitbucketPipelineUUID),
+			Value: []byte(e.BitbucketPipelineUUID),
+		})
+	}
+	if e.BitbucketStepUUID != "" && len(e.Arc) > 0 {
+		exts = append(exts, pkix.Extension{
+			Id:    e.Arc.OID(ArcBitbucketStepUUID),
+			Value: []byte(e.BitbucketStepUUID),
+		})
+	}
+	if e.BitbucketWorkspaceUUID != "" && len(e.Arc) > 0 {
+		exts = append(exts, pkix.Extension{
+			Id:    e.Arc.OID(ArcBitbucketWorkspaceUUID),
+			Value: []byte(e.BitbucketWorkspaceUUID),
+		})
+	}
+	if e.AWSRoleARN != "" && len(e.Arc) > 0 {
+		exts = append(exts, pkix.Extension{
+			Id:    e.Arc.OID(ArcAWSRoleARN),
+			Value: []byte(e.AWSRoleARN),
+		})
+	}
+	if e.AWSAccountID != "" && len(e.Arc) > 0 {
+		exts = append(exts, pkix.Extension{
+			Id:    e.Arc.OID(ArcAWSAccountID),
+			Value: []byte(e.AWSAccountID),
+		})
+	}
 	return exts, nil
 }
 
+// ParseExtensions parses the extensions of a certificate that have Sigstore
+// OIDs.
 func ParseExtensions(ext []pkix.Extension) (Extensions, error) {
-	out := Extensions{}
+	return ParseExtensionsWithArc(ext, nil)
+}
+
+// ParseExtensionsWithArc is like ParseExtensions, but also parses the
+// extensions under arc.
+func ParseExtensionsWithArc(ext []pkix.Extension, arc ExtensionArc) (Extensions, error) {
+	out := Extensions{Arc: arc}
 
 	for _, e := range ext {
 		switch {
@@ -123,6 +310,32 @@ func ParseExtensions(ext []pkix.Extension) (Extensions, error) {
 			out.GithubWorkflowRepository = string(e.Value)
 		case e.Id.Equal(OIDGitHubWorkflowRef):
 			out.GithubWorkflowRef = string(e.Value)
+		case e.Id.Equal(arc.OID(ArcGitHubWorkflowEnvironment)):
+			out.GithubWorkflowEnvironment = string(e.Value)
+		case e.Id.Equal(arc.OID(ArcGitLabProjectPath)):
+			out.GitLabProjectPath = string(e.Value)
+		case e.Id.Equal(arc.OID(ArcGitLabCIConfigRefURI)):
+			out.GitLabCIConfigRefURI = string(e.Value)
+		case e.Id.Equal(arc.OID(ArcGitLabPipelineID)):
+			out.GitLabPipelineID = string(e.Value)
+		case e.Id.Equal(arc.OID(ArcGitLabRunnerID)):
+			out.GitLabRunnerID = string(e.Value)
+		case e.Id.Equal(arc.OID(ArcKubernetesNamespace)):
+			out.KubernetesNamespace = string(e.Value)
+		case e.Id.Equal(arc.OID(ArcKubernetesServiceAccount)):
+			out.KubernetesServiceAccount = string(e.Value)
+		case e.Id.Equal(arc.OID(ArcBitbucketRepositoryUUID)):
+			out.BitbucketRepositoryUUID = string(e.Value)
+		case e.Id.Equal(arc.OID(ArcBitbucketPipelineUUID)):
+			out.BitbucketPipelineUUID = string(e.Value)
+		case e.Id.Equal(arc.OID(ArcBitbucketStepUUID)):
+			out.BitbucketStepUUID = string(e.Value)
+		case e.Id.Equal(arc.OID(ArcBitbucketWorkspaceUUID)):
+			out.BitbucketWorkspaceUUID = string(e.Value)
+		case e.Id.Equal(arc.OID(ArcAWSRoleARN)):
+			out.AWSRoleARN = string(e.Value)
+		case e.Id.Equal(arc.OID(ArcAWSAccountID)):
+			out.AWSAccountID = string(e.Value)
 		}
 	}
 
@@ -22,11 +22,16 @@ import (
 	"github.com/google/go-cmp/cmp"
 )
 
+// testArc is under 32473, the Private Enterprise Number for documentation
+var testArc = ExtensionArc{1, 3, 6, 1, 4, 1, 32473, 1}
+
 func TestExtensions(t *testing.T) {
 	tests := map[string]struct {
 		Extensions Extensions
 		Expect     []pkix.Extension
-		WantErr    bool
+		// Parsed is the expected result of parsing, if not Extensions
+		Parsed  *Extensions
+		WantErr bool
 	}{
 		`Missing issuer extension leads to render error`: {
 			Extensions: Extensions{
@@ -34,14 +39,43 @@ func TestExtensions(t *testing.T) {
 			},
 			WantErr: true,
 		},
+		`extensions under an arc are not rendered without one`: {
+			Extensions: Extensions{
+				Issuer:            `1`,
+				GitLabProjectPath: `13`,
+			},
+			Expect: []pkix.Extension{
+				{
+					Id:    OIDIssuer,
+					Value: []byte(`1`),
+				},
+			},
+			Parsed: &Extensions{
+				Issuer: `1`,
+			},
+		},
 		`complete extensions list should create all extensions with correct OIDs`: {
 			Extensions: Extensions{
-				Issuer:                   `1`, // OID 1.3.6.1.4.1.57264.1.1
-				GithubWorkflowTrigger:    `2`, // OID 1.3.6.1.4.1.57264.1.2
-				GithubWorkflowSHA:        `3`, // OID 1.3.6.1.4.1.57264.1.3
-				GithubWorkflowName:       `4`, // OID 1.3.6.1.4.1.57264.1.4
-				GithubWorkflowRepository: `5`, // OID 1.3.6.1.4.1.57264.1.5
-				GithubWorkflowRef:        `6`, // 1.3.6.1.4.1.57264.1.6
+				Issuer:                    `1`, // OID 1.3.6.1.4.1.57264.1.1
+				GithubWorkflowTrigger:     `2`, // OID 1.3.6.1.4.1.57264.1.2
+				GithubWorkflowSHA:         `3`, // OID 1.3.6.1.4.1.57264.1.3
+				GithubWorkflowName:        `4`, // OID 1.3.6.1.4.1.57264.1.4
+				GithubWorkflowRepository:  `5`, // OID 1.3.6.1.4.1.57264.1.5
+				GithubWorkflowRef:         `6`, // 1.3.6.1.4.1.57264.1.6
+				Arc:                       testArc,
+				GithubWorkflowEnvironment: `a4`,  // Arc GitHubWorkflowEnvironment
+				GitLabProjectPath:         `a6`,  // Arc GitLabProjectPath
+				GitLabCIConfigRefURI:      `a7`,  // Arc GitLabCIConfigRefURI
+				GitLabPipelineID:          `a8`,  // Arc GitLabPipelineID
+				GitLabRunnerID:            `a9`,  // Arc GitLabRunnerID
+				KubernetesNamespace:       `a10`, // Arc KubernetesNamespace
+				KubernetesServiceAccount:  `a11`, // Arc KubernetesServiceAccount
+				BitbucketRepositoryUUID:   `a12`, // Arc BitbucketRepositoryUUID
+				BitbucketPipelineUUID:     `a13`, // Arc BitbucketPipelineUUID
+				BitbucketStepUUID:         `a14`, // Arc BitbucketStepUUID
+				BitbucketWorkspaceUUID:    `a15`, // Arc BitbucketWorkspaceUUID
+				AWSRoleARN:                `a16`, // Arc AWSRoleARN
+				AWSAccountID:              `a17`, // Arc AWSAccountID
 			},
 			Expect: []pkix.Extension{
 				{
@@ -68,6 +102,58 @@ func TestExtensions(t *testing.T) {
 					Id:    OIDGitHubWorkflowRef,
 					Value: []byte(`6`),
 				},
+				{
+					Id:    testArc.OID(ArcGitHubWorkflowEnvironment),
+					Value: []byte(`a4`),
+				},
+				{
+					Id:    testArc.OID(ArcGitLabProjectPath),
+					Value: []byte(`a6`),
+				},
+				{
+					Id:    testArc.OID(ArcGitLabCIConfigRefURI),
+					Value: []byte(`a7`),
+				},
+				{
+					Id:    testArc.OID(ArcGitLabPipelineID),
+					Value: []byte(`a8`),
+				},
+				{
+					Id:    testArc.OID(ArcGitLabRunnerID),
+					Value: []byte(`a9`),
+				},
+				{
+					Id:    testArc.OID(ArcKubernetesNamespace),
+					Value: []byte(`a10`),
+				},
+				{
+					Id:    testArc.OID(ArcKubernetesServiceAccount),
+					Value: []byte(`a11`),
+				},
+				{
+					Id:    testArc.OID(ArcBitbucketRepositoryUUID),
+					Value: []byte(`a12`),
+				},
+				{
+					Id:    testArc.OID(ArcBitbucketPipelineUUID),
+					Value: []byte(`a13`),
+				},
+				{
+					Id:    testArc.OID(ArcBitbucketStepUUID),
+					Value: []byte(`a14`),
+				},
+				{
+					Id:    testArc.OID(ArcBitbucketWorkspaceUUID),
+					Value: []byte(`a15`),
+				},
+				{
+					Id:    testArc.OID(ArcAWSRoleARN),
+					Value: []byte(`a16`),
+				},
+				{
+					Id:    testArc.OID(ArcAWSAccountID),
+					Value: []byte(`a17`),
+				},
 			},
 			WantErr: false,
 		},
@@ -87,11 +173,15 @@ func TestExtensions(t *testing.T) {
 				t.Errorf("Render: %s", diff)
 			}
 
-			parse, err := ParseExtensions(render)
+			parse, err := ParseExtensionsWithArc(render, test.Extensions.Arc)
 			if err != nil {
 				t.Fatalf("ParseExtensions: err = %v", err)
 			}
-			if diff := cmp.Diff(test.Extensions, parse); diff != "" {
+			want := test.Extensions
+			if test.Parsed != nil {
+				want = *test.Parsed
+			}
+			if diff := cmp.Diff(want, parse); diff != "" {
 				t.Errorf("ParseExtensions: %s", diff)
 			}
 		})
@@ -0,0 +1,119 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certificate
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+)
+
+// The v2 extensions allocated by Sigstore. Unlike the extensions .1 to .6,
+// their values are DER-encoded UTF8Strings.
+var (
+	OIDIssuerV2                            = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+	OIDBuildSignerURI                      = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 9}
+	OIDBuildSignerDigest                   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 10}
+	OIDRunnerEnvironment                   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 11}
+	OIDSourceRepositoryURI                 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 12}
+	OIDSourceRepositoryDigest              = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 13}
+	OIDSourceRepositoryRef                 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 14}
+	OIDSourceRepositoryIdentifier          = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 15}
+	OIDSourceRepositoryOwnerURI            = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 16}
+	OIDSourceRepositoryOwnerIdentifier     = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 17}
+	OIDBuildConfigURI                      = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 18}
+	OIDBuildConfigDigest                   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 19}
+	OIDBuildTrigger                        = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 20}
+	OIDRunInvocationURI                    = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 21}
+	OIDSourceRepositoryVisibilityAtSigning = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 22}
+)
+
+// githubURL is the base of the URIs built from GitHub Actions claims.
+const githubURL = "https://github.com/"
+
+// GitHubWorkflowClaims are the claims of a GitHub Actions OIDC token that are
+// recorded in the v2 extensions.
+type GitHubWorkflowClaims struct {
+	Issuer               string `json:"iss"`
+	JobWorkflowRef       string `json:"job_workflow_ref"`
+	JobWorkflowSHA       string `json:"job_workflow_sha"`
+	RunnerEnvironment    string `json:"runner_environment"`
+	Repository           string `json:"repository"`
+	SHA                  string `json:"sha"`
+	Ref                  string `json:"ref"`
+	RepositoryID         string `json:"repository_id"`
+	RepositoryOwner      string `json:"repository_owner"`
+	RepositoryOwnerID    string `json:"repository_owner_id"`
+	WorkflowRef          string `json:"workflow_ref"`
+	WorkflowSHA          string `json:"workflow_sha"`
+	EventName            string `json:"event_name"`
+	RunID                string `json:"run_id"`
+	RunAttempt           string `json:"run_attempt"`
+	RepositoryVisibility string `json:"repository_visibility"`
+}
+
+// Render returns the v2 extensions for the claims. Extensions whose claims
+// are absent are left out rather than issued empty.
+func (c GitHubWorkflowClaims) Render() ([]pkix.Extension, error) {
+	values := []struct {
+		oid   asn1.ObjectIdentifier
+		value string
+	}{
+		{OIDIssuerV2, c.Issuer},
+		{OIDBuildSignerURI, githubURI(c.JobWorkflowRef)},
+		{OIDBuildSignerDigest, c.JobWorkflowSHA},
+		{OIDRunnerEnvironment, c.RunnerEnvironment},
+		{OIDSourceRepositoryURI, githubURI(c.Repository)},
+		{OIDSourceRepositoryDigest, c.SHA},
+		{OIDSourceRepositoryRef, c.Ref},
+		{OIDSourceRepositoryIdentifier, c.RepositoryID},
+		{OIDSourceRepositoryOwnerURI, githubURI(c.RepositoryOwner)},
+		{OIDSourceRepositoryOwnerIdentifier, c.RepositoryOwnerID},
+		{OIDBuildConfigURI, githubURI(c.WorkflowRef)},
+		{OIDBuildConfigDigest, c.WorkflowSHA},
+		{OIDBuildTrigger, c.EventName},
+		{OIDRunInvocationURI, c.runInvocationURI()},
+		{OIDSourceRepositoryVisibilityAtSigning, c.RepositoryVisibility},
+	}
+
+	var exts []pkix.Extension
+	for _, v := range values {
+		if v.value == "" {
+			continue
+		}
+		value, err := asn1.MarshalWithParams(v.value, "utf8")
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, pkix.Extension{
+			Id:    v.oid,
+			Value: value,
+		})
+	}
+	return exts, nil
+}
+
+func (c GitHubWorkflowClaims) runInvocationURI() string {
+	if c.Repository == "" || c.RunID == "" || c.RunAttempt == "" {
+		return ""
+	}
+	return githubURL + c.Repository + "/actions/runs/" + c.RunID + "/attempts/" + c.RunAttempt
+}
+
+func githubURI(path string) string {
+	if path == "" {
+		return ""
+	}
+	return githubURL + path
+}
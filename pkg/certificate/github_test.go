@@ -0,0 +1,119 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certificate
+
+import (
+	"encoding/asn1"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGitHubWorkflowClaims(t *testing.T) {
+	tests := map[string]struct {
+		Claims string
+		// Expect maps the OID of each extension to its decoded value
+		Expect map[string]string
+	}{
+		`all claims are recorded with their v2 OIDs`: {
+			Claims: `{
+				"iss": "https://token.actions.githubusercontent.com",
+				"job_workflow_ref": "sigstore/fulcio/.github/workflows/release.yml@refs/tags/v1.0.0",
+				"job_workflow_sha": "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+				"runner_environment": "github-hosted",
+				"repository": "sigstore/fulcio",
+				"sha": "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+				"ref": "refs/tags/v1.0.0",
+				"repository_id": "385959535",
+				"repository_owner": "sigstore",
+				"repository_owner_id": "71096353",
+				"workflow_ref": "sigstore/fulcio/.github/workflows/build.yml@refs/tags/v1.0.0",
+				"workflow_sha": "cccccccccccccccccccccccccccccccccccccccc",
+				"event_name": "push",
+				"run_id": "4735384265",
+				"run_attempt": "1",
+				"repository_visibility": "public"
+			}`,
+			Expect: map[string]string{
+				`1.3.6.1.4.1.57264.1.8`:  `https://token.actions.githubusercontent.com`,
+				`1.3.6.1.4.1.57264.1.9`:  `https://github.com/sigstore/fulcio/.github/workflows/release.yml@refs/tags/v1.0.0`,
+				`1.3.6.1.4.1.57264.1.10`: `aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa`,
+				`1.3.6.1.4.1.57264.1.11`: `github-hosted`,
+				`1.3.6.1.4.1.57264.1.12`: `https://github.com/sigstore/fulcio`,
+				`1.3.6.1.4.1.57264.1.13`: `bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb`,
+				`1.3.6.1.4.1.57264.1.14`: `refs/tags/v1.0.0`,
+				`1.3.6.1.4.1.57264.1.15`: `385959535`,
+				`1.3.6.1.4.1.57264.1.16`: `https://github.com/sigstore`,
+				`1.3.6.1.4.1.57264.1.17`: `71096353`,
+				`1.3.6.1.4.1.57264.1.18`: `https://github.com/sigstore/fulcio/.github/workflows/build.yml@refs/tags/v1.0.0`,
+				`1.3.6.1.4.1.57264.1.19`: `cccccccccccccccccccccccccccccccccccccccc`,
+				`1.3.6.1.4.1.57264.1.20`: `push`,
+				`1.3.6.1.4.1.57264.1.21`: `https://github.com/sigstore/fulcio/actions/runs/4735384265/attempts/1`,
+				`1.3.6.1.4.1.57264.1.22`: `public`,
+			},
+		},
+		`absent claims are left out`: {
+			Claims: `{
+				"iss": "https://token.actions.githubusercontent.com",
+				"repository": "sigstore/fulcio",
+				"run_id": "4735384265"
+			}`,
+			Expect: map[string]string{
+				`1.3.6.1.4.1.57264.1.8`:  `https://token.actions.githubusercontent.com`,
+				`1.3.6.1.4.1.57264.1.12`: `https://github.com/sigstore/fulcio`,
+			},
+		},
+		`no claims give no extensions`: {
+			Claims: `{}`,
+			Expect: map[string]string{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var claims GitHubWorkflowClaims
+			if err := json.Unmarshal([]byte(test.Claims), &claims); err != nil {
+				t.Fatal(err)
+			}
+			exts, err := claims.Render()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, ext := range exts {
+				if ext.Critical {
+					t.Errorf("extension %v should not be critical", ext.Id)
+				}
+				var raw asn1.RawValue
+				if rest, err := asn1.Unmarshal(ext.Value, &raw); err != nil {
+					t.Fatalf("extension %v: %v", ext.Id, err)
+				} else if len(rest) != 0 {
+					t.Errorf("extension %v has trailing data", ext.Id)
+				}
+				if raw.Class != asn1.ClassUniversal || raw.Tag != asn1.TagUTF8String {
+					t.Errorf("extension %v is not a UTF8String", ext.Id)
+				}
+				if _, ok := got[ext.Id.String()]; ok {
+					t.Errorf("extension %v is repeated", ext.Id)
+				}
+				got[ext.Id.String()] = string(raw.Bytes)
+			}
+			if diff := cmp.Diff(test.Expect, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certificate
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+)
+
+// RenderScopes returns an extension with the given OID listing the OAuth
+// scopes granted to a token. The value is a SEQUENCE OF UTF8String.
+func RenderScopes(oid asn1.ObjectIdentifier, scopes []string) (pkix.Extension, error) {
+	if len(scopes) == 0 {
+		return pkix.Extension{}, errors.New("at least one scope is required")
+	}
+	values := make([]asn1.RawValue, 0, len(scopes))
+	for _, scope := range scopes {
+		values = append(values, asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte(scope)})
+	}
+	value, err := asn1.Marshal(values)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{
+		Id:    oid,
+		Value: value,
+	}, nil
+}
+
+// ParseScopes parses the value of a scope extension.
+func ParseScopes(value []byte) ([]string, error) {
+	var values []asn1.RawValue
+	rest, err := asn1.Unmarshal(value, &values)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after scopes")
+	}
+	scopes := make([]string, 0, len(values))
+	for _, v := range values {
+		if v.Class != asn1.ClassUniversal || v.Tag != asn1.TagUTF8String {
+			return nil, errors.New("scope must be a UTF8String")
+		}
+		scopes = append(scopes, string(v.Bytes))
+	}
+	return scopes, nil
+}
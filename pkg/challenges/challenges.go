@@ -23,11 +23,16 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/sigstore/fulcio/pkg/config"
 	"github.com/sigstore/fulcio/pkg/identity"
+	"github.com/sigstore/fulcio/pkg/identity/aws"
+	"github.com/sigstore/fulcio/pkg/identity/bitbucket"
 	"github.com/sigstore/fulcio/pkg/identity/email"
 	"github.com/sigstore/fulcio/pkg/identity/github"
+	"github.com/sigstore/fulcio/pkg/identity/gitlab"
 	"github.com/sigstore/fulcio/pkg/identity/kubernetes"
 	"github.com/sigstore/fulcio/pkg/identity/spiffe"
 	"github.com/sigstore/fulcio/pkg/identity/uri"
@@ -36,17 +41,53 @@ import (
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
+	"golang.org/x/text/unicode/norm"
 )
 
-// CheckSignature verifies a challenge, a signature over the subject or email
-// of an OIDC token
+// ErrNonCanonicalChallenge is returned when the challenge value has no
+// unambiguous byte serialization.
+var ErrNonCanonicalChallenge = errors.New("challenge is not in canonical form")
+
+// CanonicalChallenge returns the bytes that must be signed to prove
+// possession of a private key for subject. The canonical serialization is
+// the UTF-8 encoding of subject, unchanged, which must be valid UTF-8 in
+// Unicode Normalization Form C, without leading or trailing whitespace and
+// without control characters. Subjects that don't meet these rules are
+// rejected rather than normalized, so that every client signs exactly the
+// bytes that are verified.
+func CanonicalChallenge(subject string) ([]byte, error) {
+	if subject == "" {
+		return nil, fmt.Errorf("%w: empty", ErrNonCanonicalChallenge)
+	}
+	if !utf8.ValidString(subject) {
+		return nil, fmt.Errorf("%w: invalid UTF-8", ErrNonCanonicalChallenge)
+	}
+	if strings.TrimSpace(subject) != subject {
+		return nil, fmt.Errorf("%w: leading or trailing whitespace", ErrNonCanonicalChallenge)
+	}
+	if strings.IndexFunc(subject, unicode.IsControl) != -1 {
+		return nil, fmt.Errorf("%w: control character", ErrNonCanonicalChallenge)
+	}
+	if !norm.NFC.IsNormalString(subject) {
+		return nil, fmt.Errorf("%w: not in Unicode Normalization Form C", ErrNonCanonicalChallenge)
+	}
+	return []byte(subject), nil
+}
+
+// CheckSignature verifies a challenge, a signature over the canonical
+// serialization of the subject or email of an OIDC token
 func CheckSignature(pub crypto.PublicKey, proof []byte, subject string) error {
+	challenge, err := CanonicalChallenge(subject)
+	if err != nil {
+		return err
+	}
+
 	verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
 	if err != nil {
 		return err
 	}
 
-	return verifier.VerifySignature(bytes.NewReader(proof), strings.NewReader(subject))
+	return verifier.VerifySignature(bytes.NewReader(proof), bytes.NewReader(challenge))
 }
 
 func PrincipalFromIDToken(ctx context.Context, tok *oidc.IDToken) (identity.Principal, error) {
@@ -63,6 +104,12 @@ func PrincipalFromIDToken(ctx context.Context, tok *oidc.IDToken) (identity.Prin
 		principal, err = spiffe.PrincipalFromIDToken(ctx, tok)
 	case config.IssuerTypeGithubWorkflow:
 		principal, err = github.WorkflowPrincipalFromIDToken(ctx, tok)
+	case config.IssuerTypeGitLabPipeline:
+		principal, err = gitlab.PipelinePrincipalFromIDToken(ctx, tok)
+	case config.IssuerTypeBitbucketPipeline:
+		principal, err = bitbucket.PipelinePrincipalFromIDToken(ctx, tok)
+	case config.IssuerTypeAWSIRSA:
+		principal, err = aws.RolePrincipalFromIDToken(ctx, tok)
 	case config.IssuerTypeKubernetes:
 		principal, err = kubernetes.PrincipalFromIDToken(ctx, tok)
 	case config.IssuerTypeURI:
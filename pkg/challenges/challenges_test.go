@@ -16,12 +16,14 @@
 package challenges
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"errors"
 	"testing"
 
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
@@ -85,6 +87,75 @@ func TestCheckSignatureRSA(t *testing.T) {
 	}
 }
 
+func TestCanonicalChallenge(t *testing.T) {
+	tests := map[string]struct {
+		subject string
+		wantErr bool
+	}{
+		"email":                   {subject: "test@gmail.com"},
+		"NFC":                     {subject: "jos\u00e9@example.com"},
+		"NFD":                     {subject: "jose\u0301@example.com", wantErr: true},
+		"trailing newline":        {subject: "test@gmail.com\n", wantErr: true},
+		"trailing space":          {subject: "test@gmail.com ", wantErr: true},
+		"leading tab":             {subject: "\ttest@gmail.com", wantErr: true},
+		"trailing no-break space": {subject: "test@gmail.com\u00a0", wantErr: true},
+		"control character":       {subject: "test\x00@gmail.com", wantErr: true},
+		"invalid UTF-8":           {subject: "test\xff@gmail.com", wantErr: true},
+		"empty":                   {subject: "", wantErr: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := CanonicalChallenge(test.subject)
+			if test.wantErr {
+				if !errors.Is(err, ErrNonCanonicalChallenge) {
+					t.Fatalf("CanonicalChallenge(%q) error = %v, want ErrNonCanonicalChallenge", test.subject, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CanonicalChallenge(%q) = %v", test.subject, err)
+			}
+			if !bytes.Equal(got, []byte(test.subject)) {
+				t.Fatalf("CanonicalChallenge(%q) = %q, want the UTF-8 bytes of the subject", test.subject, got)
+			}
+		})
+	}
+}
+
+func TestCheckSignatureNonCanonical(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	failErr(t, err)
+
+	sign := func(msg string) []byte {
+		h := sha256.Sum256([]byte(msg))
+		signature, err := priv.Sign(rand.Reader, h[:], crypto.SHA256)
+		failErr(t, err)
+		return signature
+	}
+
+	// "josé" composed (NFC) and decomposed (NFD) are equivalent, but only the
+	// composed form is accepted, whichever form was signed
+	nfc, nfd := "jos\u00e9@example.com", "jose\u0301@example.com"
+	if err := CheckSignature(&priv.PublicKey, sign(nfc), nfc); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckSignature(&priv.PublicKey, sign(nfd), nfc); err == nil {
+		t.Fatal("signature over decomposed subject should not verify")
+	}
+	if err := CheckSignature(&priv.PublicKey, sign(nfd), nfd); !errors.Is(err, ErrNonCanonicalChallenge) {
+		t.Fatalf("expected ErrNonCanonicalChallenge, got %v", err)
+	}
+
+	// A signature over the subject with a trailing newline never verifies
+	email := "test@gmail.com"
+	if err := CheckSignature(&priv.PublicKey, sign(email+"\n"), email); err == nil {
+		t.Fatal("signature over subject with trailing newline should not verify")
+	}
+	if err := CheckSignature(&priv.PublicKey, sign(email+"\n"), email+"\n"); !errors.Is(err, ErrNonCanonicalChallenge) {
+		t.Fatalf("expected ErrNonCanonicalChallenge, got %v", err)
+	}
+}
+
 func TestParsePublicKey(t *testing.T) {
 	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	failErr(t, err)
@@ -0,0 +1,224 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/sigstore/fulcio/pkg/log"
+)
+
+const (
+	// maxCachedResponses bounds the responses cached for meta issuers,
+	// whose URLs come from tokens.
+	maxCachedResponses = 1000
+	// maxCacheAge bounds the max-age of cached responses.
+	maxCacheAge = 24 * time.Hour
+)
+
+// OIDCCacheMinTTLDuration returns the parsed OIDCCacheMinTTL, or zero if
+// unset.
+func (fc *FulcioConfig) OIDCCacheMinTTLDuration() (time.Duration, error) {
+	return parseCacheDuration("OIDCCacheMinTTL", fc.OIDCCacheMinTTL)
+}
+
+// OIDCCacheMaxStaleDuration returns the parsed OIDCCacheMaxStale, or zero if
+// unset.
+func (fc *FulcioConfig) OIDCCacheMaxStaleDuration() (time.Duration, error) {
+	return parseCacheDuration("OIDCCacheMaxStale", fc.OIDCCacheMaxStale)
+}
+
+func parseCacheDuration(field, s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", field, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s must not be negative", field)
+	}
+	return d, nil
+}
+
+// newResponseCache returns the cache shared by every issuer's discovery and
+// JWKS requests.
+func (fc *FulcioConfig) newResponseCache() (*responseCache, error) {
+	minTTL, err := fc.OIDCCacheMinTTLDuration()
+	if err != nil {
+		return nil, err
+	}
+	maxStale, err := fc.OIDCCacheMaxStaleDuration()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := lru.New(maxCachedResponses)
+	if err != nil {
+		return nil, fmt.Errorf("lru: %w", err)
+	}
+	return &responseCache{minTTL: minTTL, maxStale: maxStale, entries: entries, now: time.Now}, nil
+}
+
+// responseCache caches successful GET responses by URL, so that discovery
+// documents and JWKS aren't fetched again while they're fresh. If fetching
+// an expired response fails, the cached one is served until it's maxStale
+// past its expiry, and then the failure is returned.
+type responseCache struct {
+	minTTL   time.Duration
+	maxStale time.Duration
+	entries  *lru.Cache
+	now      func() time.Time
+}
+
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// purge discards every cached response.
+func (c *responseCache) purge() {
+	if c != nil {
+		c.entries.Purge()
+	}
+}
+
+// transport returns a RoundTripper that serves responses from the cache,
+// and sends other requests to base.
+func (c *responseCache) transport(base http.RoundTripper) http.RoundTripper {
+	return &cacheTransport{cache: c, base: base}
+}
+
+type cacheTransport struct {
+	cache *responseCache
+	base  http.RoundTripper
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Method != http.MethodGet {
+		return base.RoundTrip(req)
+	}
+	c := t.cache
+	key := req.URL.String()
+
+	var cached *cachedResponse
+	if v, ok := c.entries.Get(key); ok {
+		cached = v.(*cachedResponse)
+		if c.now().Before(cached.expires) {
+			return cached.response(req), nil
+		}
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err == nil && resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusTooManyRequests {
+		if resp.StatusCode != http.StatusOK {
+			return resp, nil
+		}
+		return c.store(key, req, resp)
+	}
+	// The issuer is unavailable, so serve the last good response if it's
+	// recent enough, or fail closed
+	if cached != nil && c.now().Before(cached.expires.Add(c.maxStale)) {
+		if err == nil {
+			resp.Body.Close()
+		}
+		log.Logger.Warnf("Serving stale response for %s, which expired at %v", key, cached.expires)
+		return cached.response(req), nil
+	}
+	return resp, err
+}
+
+// store reads a successful response and caches it, unless it's marked
+// no-store, for its max-age or minTTL, whichever is longer.
+func (c *responseCache) store(key string, req *http.Request, resp *http.Response) (*http.Response, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	cached := &cachedResponse{status: resp.StatusCode, header: resp.Header.Clone(), body: body}
+	if ttl, ok := cacheTTL(resp.Header); ok {
+		if ttl < c.minTTL {
+			ttl = c.minTTL
+		}
+		cached.expires = c.now().Add(ttl)
+		c.entries.Add(key, cached)
+	} else {
+		c.entries.Remove(key)
+	}
+	return cached.response(req), nil
+}
+
+// response returns a new response with the cached status, headers and body.
+func (r *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.status, http.StatusText(r.status)),
+		StatusCode:    r.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}
+}
+
+// cacheTTL returns how long a response may be cached for from its
+// Cache-Control max-age, less its Age, up to maxCacheAge. It returns false
+// if the response must not be stored. Responses without a max-age, or marked
+// no-cache, have a TTL of zero.
+func cacheTTL(header http.Header) (time.Duration, bool) {
+	var maxAge time.Duration
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			return 0, false
+		case "no-cache":
+			return 0, true
+		case "max-age":
+			seconds, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+			if err != nil || seconds < 0 {
+				return 0, true
+			}
+			maxAge = maxCacheAge
+			if seconds < int64(maxCacheAge/time.Second) {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	if age, err := strconv.ParseInt(header.Get("Age"), 10, 64); err == nil && age > 0 {
+		maxAge -= time.Duration(age) * time.Second
+	}
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	return maxAge, true
+}
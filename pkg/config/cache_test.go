@@ -0,0 +1,299 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// fakeProvider serves a document with the configured Cache-Control header,
+// or fails with the configured status, counting requests.
+type fakeProvider struct {
+	server       *httptest.Server
+	cacheControl atomic.Value
+	status       int32
+	requests     int32
+}
+
+func newFakeProvider(t *testing.T, cacheControl string) *fakeProvider {
+	p := &fakeProvider{status: http.StatusOK}
+	p.cacheControl.Store(cacheControl)
+	p.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&p.requests, 1)
+		if status := atomic.LoadInt32(&p.status); status != http.StatusOK {
+			http.Error(w, "unavailable", int(status))
+			return
+		}
+		if cc := p.cacheControl.Load().(string); cc != "" {
+			w.Header().Set("Cache-Control", cc)
+		}
+		fmt.Fprintf(w, "response %d", n)
+	}))
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// fakeClock is a clock for responseCache that only moves when advanced.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestResponseCache(t *testing.T, minTTL, maxStale time.Duration) (*responseCache, *fakeClock) {
+	t.Helper()
+	entries, err := lru.New(maxCachedResponses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	return &responseCache{minTTL: minTTL, maxStale: maxStale, entries: entries, now: clock.now}, clock
+}
+
+// get fetches url through the cache, returning the body, or the status if
+// it isn't 200.
+func get(t *testing.T, c *responseCache, url string) (string, error) {
+	t.Helper()
+	client := &http.Client{Transport: c.transport(nil)}
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return string(body), nil
+}
+
+func TestResponseCacheHonorsMaxAge(t *testing.T) {
+	p := newFakeProvider(t, "public, max-age=60")
+	c, clock := newTestResponseCache(t, 0, 0)
+
+	for i := 0; i < 3; i++ {
+		if got, err := get(t, c, p.server.URL); err != nil || got != "response 1" {
+			t.Fatalf("get() = %q, %v, want the first response", got, err)
+		}
+		clock.advance(15 * time.Second)
+	}
+	if n := atomic.LoadInt32(&p.requests); n != 1 {
+		t.Fatalf("expected 1 request within max-age, got %d", n)
+	}
+
+	// Expired responses are fetched again
+	clock.advance(16 * time.Second)
+	if got, err := get(t, c, p.server.URL); err != nil || got != "response 2" {
+		t.Fatalf("get() after expiry = %q, %v, want the second response", got, err)
+	}
+	if got, err := get(t, c, p.server.URL); err != nil || got != "response 2" {
+		t.Fatalf("get() = %q, %v, want the refreshed response", got, err)
+	}
+
+	// Purging discards cached responses
+	c.purge()
+	if got, err := get(t, c, p.server.URL); err != nil || got != "response 3" {
+		t.Fatalf("get() after purge = %q, %v, want the third response", got, err)
+	}
+}
+
+func TestResponseCacheMinTTL(t *testing.T) {
+	tests := map[string]struct {
+		cacheControl string
+		minTTL       time.Duration
+		wantTTL      time.Duration
+	}{
+		"without Cache-Control":       {"", 0, 0},
+		"minimum applies without one": {"", 5 * time.Minute, 5 * time.Minute},
+		"minimum extends max-age":     {"max-age=60", 5 * time.Minute, 5 * time.Minute},
+		"longer max-age is kept":      {"max-age=600", 5 * time.Minute, 10 * time.Minute},
+		"minimum extends no-cache":    {"no-cache", time.Minute, time.Minute},
+		"no-store is never cached":    {"no-store, max-age=600", 5 * time.Minute, 0},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := newFakeProvider(t, test.cacheControl)
+			c, clock := newTestResponseCache(t, test.minTTL, 0)
+
+			if _, err := get(t, c, p.server.URL); err != nil {
+				t.Fatal(err)
+			}
+			if test.wantTTL > 0 {
+				clock.advance(test.wantTTL - time.Second)
+				if got, err := get(t, c, p.server.URL); err != nil || got != "response 1" {
+					t.Fatalf("get() before expiry = %q, %v, want the cached response", got, err)
+				}
+			}
+			clock.advance(time.Second)
+			if got, err := get(t, c, p.server.URL); err != nil || got != "response 2" {
+				t.Fatalf("get() at expiry = %q, %v, want a new response", got, err)
+			}
+		})
+	}
+}
+
+func TestResponseCacheServesStale(t *testing.T) {
+	p := newFakeProvider(t, "max-age=60")
+	c, clock := newTestResponseCache(t, 0, time.Hour)
+
+	if _, err := get(t, c, p.server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	// While the provider fails, the last good response is served until
+	// it's an hour past its expiry
+	for _, status := range []int32{http.StatusServiceUnavailable, http.StatusTooManyRequests} {
+		atomic.StoreInt32(&p.status, status)
+		clock.advance(30 * time.Minute)
+		if got, err := get(t, c, p.server.URL); err != nil || got != "response 1" {
+			t.Fatalf("get() with status %d = %q, %v, want the stale response", status, got, err)
+		}
+	}
+	// and then requests fail closed
+	clock.advance(2 * time.Minute)
+	if got, err := get(t, c, p.server.URL); err == nil {
+		t.Fatalf("get() past the staleness bound = %q, expected error", got)
+	}
+
+	// Once the provider recovers, its responses are cached again
+	atomic.StoreInt32(&p.status, http.StatusOK)
+	if got, err := get(t, c, p.server.URL); err != nil || got != "response 5" {
+		t.Fatalf("get() after recovery = %q, %v, want a new response", got, err)
+	}
+
+	// Transport errors are treated the same
+	failing := &http.Client{Transport: c.transport(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}))}
+	clock.advance(time.Minute + 30*time.Minute)
+	resp, err := failing.Get(p.server.URL)
+	if err != nil {
+		t.Fatalf("expected stale response on transport error, got %v", err)
+	}
+	resp.Body.Close()
+	clock.advance(31 * time.Minute)
+	if _, err := failing.Get(p.server.URL); err == nil {
+		t.Fatal("expected transport error past the staleness bound")
+	}
+}
+
+func TestResponseCacheDoesNotCacheFailures(t *testing.T) {
+	p := newFakeProvider(t, "max-age=60")
+	c, _ := newTestResponseCache(t, time.Minute, time.Hour)
+
+	atomic.StoreInt32(&p.status, http.StatusNotFound)
+	if _, err := get(t, c, p.server.URL); err == nil {
+		t.Fatal("expected error for 404")
+	}
+	atomic.StoreInt32(&p.status, http.StatusOK)
+	if got, err := get(t, c, p.server.URL); err != nil || got != "response 2" {
+		t.Fatalf("get() = %q, %v, want a new response", got, err)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	tests := []struct {
+		header   http.Header
+		want     time.Duration
+		wantKeep bool
+	}{
+		{http.Header{}, 0, true},
+		{http.Header{"Cache-Control": {"max-age=300"}}, 5 * time.Minute, true},
+		{http.Header{"Cache-Control": {`public, MAX-AGE="300", must-revalidate`}}, 5 * time.Minute, true},
+		{http.Header{"Cache-Control": {"max-age=300"}, "Age": {"100"}}, 200 * time.Second, true},
+		{http.Header{"Cache-Control": {"max-age=300"}, "Age": {"400"}}, 0, true},
+		{http.Header{"Cache-Control": {"max-age=forever"}}, 0, true},
+		{http.Header{"Cache-Control": {"max-age=99999999999999999"}}, maxCacheAge, true},
+		{http.Header{"Cache-Control": {"no-cache"}}, 0, true},
+		{http.Header{"Cache-Control": {"private, no-store"}}, 0, false},
+	}
+	for _, test := range tests {
+		got, keep := cacheTTL(test.header)
+		if got != test.want || keep != test.wantKeep {
+			t.Errorf("cacheTTL(%v) = %v, %v, want %v, %v", test.header, got, keep, test.want, test.wantKeep)
+		}
+	}
+}
+
+func TestReadCachesDiscovery(t *testing.T) {
+	var issuer string
+	var discoveries int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&discoveries, 1)
+		w.Header().Set("Cache-Control", "max-age=3600")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   issuer,
+			"jwks_uri": issuer + "/keys",
+		})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	issuer = server.URL
+
+	cfg, err := Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		},
+		"OIDCCacheMinTTL": "1m",
+		"OIDCCacheMaxStale": "1h"
+	}`, issuer, issuer)))
+	if err != nil {
+		t.Fatalf("Read() = %v", err)
+	}
+	if cfg.responses.minTTL != time.Minute || cfg.responses.maxStale != time.Hour {
+		t.Fatalf("expected configured cache durations, got %v and %v", cfg.responses.minTTL, cfg.responses.maxStale)
+	}
+	if n := atomic.LoadInt32(&discoveries); n != 1 {
+		t.Fatalf("expected 1 discovery request, got %d", n)
+	}
+
+	// Discovering again is served from the cache
+	if _, err := cfg.discover(context.Background()); err != nil {
+		t.Fatalf("discover() = %v", err)
+	}
+	if n := atomic.LoadInt32(&discoveries); n != 1 {
+		t.Fatalf("expected cached discovery, got %d requests", n)
+	}
+
+	// Flushing discovery bypasses the cache
+	if err := cfg.FlushDiscovery(context.Background()); err != nil {
+		t.Fatalf("FlushDiscovery() = %v", err)
+	}
+	if n := atomic.LoadInt32(&discoveries); n != 2 {
+		t.Fatalf("expected discovery to be repeated after flushing, got %d requests", n)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
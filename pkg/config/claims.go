@@ -0,0 +1,73 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"errors"
+	"sort"
+)
+
+// standardClaims are the registered JWT and OIDC ID token claims, which are
+// always allowed in strict claims mode.
+var standardClaims = map[string]bool{
+	"iss":       true,
+	"sub":       true,
+	"aud":       true,
+	"exp":       true,
+	"nbf":       true,
+	"iat":       true,
+	"jti":       true,
+	"auth_time": true,
+	"nonce":     true,
+	"acr":       true,
+	"amr":       true,
+	"azp":       true,
+	"at_hash":   true,
+	"c_hash":    true,
+}
+
+// UnexpectedClaims returns the names of claims that are not allowed for
+// this issuer, sorted. It always returns nil unless StrictClaims is set.
+func (iss OIDCIssuer) UnexpectedClaims(claims map[string]interface{}) []string {
+	if !iss.StrictClaims {
+		return nil
+	}
+	allowed := make(map[string]bool, len(iss.AllowedClaims))
+	for _, claim := range iss.AllowedClaims {
+		allowed[claim] = true
+	}
+
+	var unexpected []string
+	for claim := range claims {
+		if !standardClaims[claim] && !allowed[claim] {
+			unexpected = append(unexpected, claim)
+		}
+	}
+	sort.Strings(unexpected)
+	return unexpected
+}
+
+func validateIssuerClaims(iss OIDCIssuer) error {
+	if len(iss.AllowedClaims) > 0 && !iss.StrictClaims {
+		return errors.New("AllowedClaims requires StrictClaims")
+	}
+	for _, claim := range iss.AllowedClaims {
+		if claim == "" {
+			return errors.New("AllowedClaims must not contain an empty claim name")
+		}
+	}
+	return nil
+}
@@ -18,6 +18,7 @@ package config
 import (
 	"context"
 	"crypto/x509"
+	"encoding/asn1"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,12 +26,16 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/sigstore/fulcio/pkg/certificate"
 	fulciogrpc "github.com/sigstore/fulcio/pkg/generated/protobuf"
+	"github.com/sigstore/fulcio/pkg/identity/santemplate"
 	"github.com/sigstore/fulcio/pkg/log"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 )
@@ -41,6 +46,11 @@ const defaultOIDCDiscoveryTimeout = 10 * time.Second
 // top-level and second-level domain
 const minimumHostnameLength = 2
 
+// oidAnyExtendedKeyUsage is the anyExtendedKeyUsage OID, defined in
+// RFC5280 4.2.1.12. It permits a certificate to be used for any purpose,
+// so it must never be added to issued certificates.
+var oidAnyExtendedKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37, 0}
+
 type FulcioConfig struct {
 	OIDCIssuers map[string]OIDCIssuer `json:"OIDCIssuers,omitempty"`
 
@@ -53,10 +63,106 @@ type FulcioConfig struct {
 	// * https://container.googleapis.com/v1/projects/mattmoor-credit/locations/us-west1-b/clusters/tenant-cluster
 	MetaIssuers map[string]OIDCIssuer `json:"MetaIssuers,omitempty"`
 
-	// verifiers is a fixed mapping from our OIDCIssuers to their OIDC verifiers.
-	verifiers map[string]*oidc.IDTokenVerifier
+	// Optional, extended key usages added to issued certificates in
+	// addition to code signing, as dotted OIDs. anyExtendedKeyUsage
+	// (2.5.29.37.0) is rejected.
+	AdditionalExtKeyUsages []string `json:"AdditionalExtKeyUsages,omitempty"`
+
+	// Optional, named certificate profiles that issuers may allow clients to
+	// request instead of the default code signing certificate.
+	Profiles map[string]Profile `json:"Profiles,omitempty"`
+
+	// Optional, how subject alternative names are laid out when a
+	// certificate has an OtherName SAN as well as other SANs, such as a URI.
+	// "single" (the default) packs them into one extension. "split" puts the
+	// other SANs in a second extension, which some verifiers expect.
+	SANPacking SANPacking `json:"SANPacking,omitempty"`
+
+	// Optional, whether the subject alternative name extension is critical,
+	// by identity kind: "username", "email", "uri" or "github", e.g.
+	// {"email": false}. Kinds that aren't listed are critical.
+	SANCriticality CriticalityPolicy `json:"SANCriticality,omitempty"`
+
+	// Optional, the signature algorithm used to sign certificates, by the
+	// key type of the certificate's public key, e.g.
+	// {"ecdsa-p384": "ECDSA-SHA384"}. The algorithm must be compatible with
+	// the CA key. Key types without an algorithm use a default matching the
+	// strength of the CA key, e.g. ECDSA-SHA384 for a P-384 CA key.
+	SignatureAlgorithms map[string]string `json:"SignatureAlgorithms,omitempty"`
+
+	// Optional, a dotted OID arc, such as one under the operator's IANA
+	// Private Enterprise Number, under which the extensions that Sigstore
+	// hasn't allocated OIDs for are issued, numbered as in
+	// docs/oid-info.md. Without it those extensions aren't issued.
+	ExtensionOIDArc string `json:"ExtensionOIDArc,omitempty"`
+
+	// Optional, the environment this instance issues certificates for, e.g.
+	// "staging", which is recorded in an extension of each certificate so
+	// that verifiers can reject certificates from other environments.
+	// Requires ExtensionOIDArc.
+	Environment string `json:"Environment,omitempty"`
+
+	// Optional, if true the RFC 7638 JWK thumbprint of the certificate's
+	// public key is recorded in an extension, so that systems binding tokens
+	// to keys, like DPoP, can correlate the certificate to the key.
+	// Requires ExtensionOIDArc.
+	JWKThumbprintExtension bool `json:"JWKThumbprintExtension,omitempty"`
+
+	// Optional, the message returned to clients that present a token from an
+	// issuer that isn't configured, as a text/template with the token's iss
+	// claim as .Issuer, e.g.
+	// "issuer {{.Issuer}} is not configured, see https://example.com/issuers".
+	// By default the message doesn't mention the issuer.
+	UnknownIssuerMessage string `json:"UnknownIssuerMessage,omitempty"`
+
+	// Optional, a dotted OID under which the OAuth scopes granted to the
+	// token, from its space-delimited scope claim, are recorded in issued
+	// certificates. Tokens without scopes get no extension.
+	ScopeExtensionOID string `json:"ScopeExtensionOID,omitempty"`
+
+	// Optional, built-in policies evaluated in order before a certificate is
+	// issued. The first policy that denies a request rejects it.
+	Policies []Policy `json:"Policies,omitempty"`
+
+	// Optional, certificates revoked by the operator, which the OCSP
+	// responder and CRL report as revoked
+	Revocations []Revocation `json:"Revocations,omitempty"`
+
+	// Optional, if true GetTrustBundle returns only the intermediate
+	// certificates of each chain, for clients that pin an intermediate and
+	// reject bundles containing the root. Chains without intermediates are
+	// returned unchanged.
+	TrustBundleExcludeRoot bool `json:"TrustBundleExcludeRoot,omitempty"`
+
+	// Optional, if true a subject key identifier extension in a CSR is
+	// copied to the issued certificate, instead of computing the identifier
+	// from the public key, for systems that track keys by a client-chosen
+	// identifier. The identifier must be 20 bytes, like a computed one.
+	HonorCSRSubjectKeyID bool `json:"HonorCSRSubjectKeyID,omitempty"`
+
+	// Optional, the minimum time that discovery documents and JWKS fetched
+	// from issuers are cached for, as a duration, e.g. "5m". Responses are
+	// cached for their Cache-Control max-age, or this if it's longer, unless
+	// they're marked no-store. A longer minimum delays noticing that an
+	// issuer has rotated its keys. By default only max-age is honored.
+	OIDCCacheMinTTL string `json:"OIDCCacheMinTTL,omitempty"`
+
+	// Optional, how long after a cached discovery document or JWKS expires
+	// it's still served while the issuer is unreachable or returning errors,
+	// e.g. "1h". Once it's older, requests fail. By default expired
+	// responses aren't served.
+	OIDCCacheMaxStale string `json:"OIDCCacheMaxStale,omitempty"`
+
+	// discovered holds the *discovery of our OIDCIssuers, which is replaced
+	// when caches are flushed.
+	discovered atomic.Value
 	// lru is an LRU cache of recently used verifiers for our meta issuers.
 	lru *lru.TwoQueueCache
+	// responses caches the discovery and JWKS responses of every issuer.
+	responses *responseCache
+	// sanTemplates holds the parsed SANTemplates of our issuers, by their
+	// text, so that they aren't parsed for every request.
+	sanTemplates map[string]*santemplate.SANTemplate
 }
 
 type OIDCIssuer struct {
@@ -72,10 +178,94 @@ type OIDCIssuer struct {
 	// The domain that must be present in the subject for 'uri' issuer types
 	// Also used to create an email for 'username' issuer types
 	SubjectDomain string `json:"SubjectDomain,omitempty"`
+	// Optional, for 'username' issuer types, a template over the token's
+	// claims that renders the OtherName instead of sub!SubjectDomain, e.g.
+	// "{{.preferred_username}}!example.com". The rendered hostname must be
+	// SubjectDomain. See the santemplate package for the template syntax.
+	SANTemplate string `json:"SANTemplate,omitempty"`
 	// SPIFFETrustDomain specifies the trust domain that 'spiffe' issuer types
 	// issue ID tokens for. Tokens with a different trust domain will be
 	// rejected.
 	SPIFFETrustDomain string `json:"SPIFFETrustDomain,omitempty"`
+	// Optional, for 'spiffe' issuer types, more trust domains that SPIFFE IDs
+	// may be in besides SPIFFETrustDomain. A trust domain may start with a
+	// "*." label to allow any single label in its place. IDs in any other
+	// trust domain are rejected.
+	SPIFFETrustDomains []string `json:"SPIFFETrustDomains,omitempty"`
+	// Optional, for 'spiffe' issuer types, a claim holding a SPIFFE ID or
+	// list of SPIFFE IDs that the workload also holds. Each is embedded as a
+	// URI SAN after the subject, and must be in an allowed trust domain.
+	SPIFFEIDsClaim string `json:"SPIFFEIDsClaim,omitempty"`
+	// Optional, for 'github-workflow' issuer types, the deployment
+	// environments that jobs must run in, from the environment claim, e.g.
+	// ["production"]. Tokens for jobs without an environment are rejected.
+	// By default any environment, or none, is accepted.
+	GitHubEnvironments []string `json:"GitHubEnvironments,omitempty"`
+	// For 'aws-irsa' issuer types, the ARN of the IAM role that each service
+	// account assumes, by the sub claim of its tokens, e.g.
+	// {"system:serviceaccount:default:deploy": "arn:aws:iam::123456789012:role/deploy"},
+	// matching the trust policies of the roles. Tokens for service accounts
+	// that aren't listed are rejected.
+	AWSRoleARNs map[string]string `json:"AWSRoleARNs,omitempty"`
+	// Optional, static headers added to discovery and JWKS requests sent to
+	// the issuer. Values are secret references resolved by the
+	// DefaultSecretProvider, e.g. "env://IDP_API_KEY".
+	HTTPHeaders map[string]string `json:"HTTPHeaders,omitempty"`
+	// Optional, the RFC 7638 JWK thumbprints, SHA-256 and base64url
+	// encoded, of the only keys allowed to sign tokens from this issuer.
+	// Keys served by the JWKS endpoint that aren't pinned are ignored, so a
+	// compromised endpoint can't introduce a key.
+	PinnedJWKThumbprints []string `json:"PinnedJWKThumbprints,omitempty"`
+	// Optional, a short human-friendly name for the issuer, used in metrics
+	// and logs instead of the issuer URL
+	Name string `json:"Name,omitempty"`
+	// Optional, other `iss` values accepted for this issuer, e.g. while an
+	// IdP migrates hostnames. Each is discovered separately, but is otherwise
+	// treated identically to IssuerURL.
+	AdditionalIssuerURLs []string `json:"AdditionalIssuerURLs,omitempty"`
+	// Optional, the names of the certificate profiles that may be requested
+	// with tokens from this issuer
+	Profiles []string `json:"Profiles,omitempty"`
+	// Optional, if true the nonce claim of tokens from this issuer must be a
+	// nonce issued by Fulcio's CreateNonce endpoint, which binds the token
+	// to an interactive flow started for this Fulcio instance
+	RequireNonce bool `json:"RequireNonce,omitempty"`
+	// Optional, if true tokens from this issuer are rejected if they contain
+	// claims other than the registered JWT and OIDC claims and AllowedClaims.
+	// Used to catch IdP misconfiguration.
+	StrictClaims bool `json:"StrictClaims,omitempty"`
+	// The top level claims allowed in tokens from this issuer in strict
+	// claims mode, in addition to the registered claims. This must include
+	// the claims the issuer type reads, e.g. email and email_verified.
+	AllowedClaims []string `json:"AllowedClaims,omitempty"`
+	// Optional, the maximum age of tokens from this issuer as a duration,
+	// e.g. "5m", measured from their iat claim. Older tokens are rejected
+	// even if they haven't expired.
+	MaxTokenAge string `json:"MaxTokenAge,omitempty"`
+}
+
+// MaxTokenAgeDuration returns the parsed MaxTokenAge, or zero if unset.
+func (iss OIDCIssuer) MaxTokenAgeDuration() (time.Duration, error) {
+	if iss.MaxTokenAge == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(iss.MaxTokenAge)
+	if err != nil {
+		return 0, fmt.Errorf("MaxTokenAge: %w", err)
+	}
+	if d <= 0 {
+		return 0, errors.New("MaxTokenAge must be positive")
+	}
+	return d, nil
+}
+
+// DisplayName returns the name used for the issuer in metrics and logs,
+// falling back to the issuer URL if no name is configured.
+func (iss OIDCIssuer) DisplayName() string {
+	if iss.Name != "" {
+		return iss.Name
+	}
+	return iss.IssuerURL
 }
 
 func metaRegex(issuer string) (*regexp.Regexp, error) {
@@ -102,20 +292,44 @@ func (fc *FulcioConfig) GetIssuer(issuerURL string) (OIDCIssuer, bool) {
 		return iss, ok
 	}
 
+	for _, iss := range fc.OIDCIssuers {
+		for _, additional := range iss.AdditionalIssuerURLs {
+			if additional == issuerURL {
+				return iss.withIssuerURL(issuerURL), true
+			}
+		}
+	}
+
 	for meta, iss := range fc.MetaIssuers {
 		re, err := metaRegex(meta)
 		if err != nil {
 			continue // Shouldn't happen, we check parsing the config
 		}
 		if re.MatchString(issuerURL) {
+			// Unnamed meta issuers are identified by their template, not
+			// the concrete URL, to bound metric cardinality.
+			name := iss.Name
+			if name == "" {
+				name = meta
+			}
 			// If it matches, then return a concrete OIDCIssuer
 			// configuration for this issuer URL.
 			return OIDCIssuer{
-				IssuerURL:     issuerURL,
-				ClientID:      iss.ClientID,
-				Type:          iss.Type,
-				IssuerClaim:   iss.IssuerClaim,
-				SubjectDomain: iss.SubjectDomain,
+				IssuerURL:            issuerURL,
+				ClientID:             iss.ClientID,
+				Type:                 iss.Type,
+				IssuerClaim:          iss.IssuerClaim,
+				SubjectDomain:        iss.SubjectDomain,
+				SANTemplate:          iss.SANTemplate,
+				HTTPHeaders:          iss.HTTPHeaders,
+				PinnedJWKThumbprints: iss.PinnedJWKThumbprints,
+				Name:                 name,
+				Profiles:             iss.Profiles,
+				RequireNonce:         iss.RequireNonce,
+				StrictClaims:         iss.StrictClaims,
+				AllowedClaims:        iss.AllowedClaims,
+				MaxTokenAge:          iss.MaxTokenAge,
+				GitHubEnvironments:   iss.GitHubEnvironments,
 			}, true
 		}
 	}
@@ -123,12 +337,35 @@ func (fc *FulcioConfig) GetIssuer(issuerURL string) (OIDCIssuer, bool) {
 	return OIDCIssuer{}, false
 }
 
+// withIssuerURL returns a copy of the issuer configuration for one of its
+// AdditionalIssuerURLs. Unless a name is configured, it keeps the name of
+// the original issuer URL so that both are reported together.
+func (iss OIDCIssuer) withIssuerURL(issuerURL string) OIDCIssuer {
+	iss.Name = iss.DisplayName()
+	iss.IssuerURL = issuerURL
+	iss.AdditionalIssuerURLs = nil
+	return iss
+}
+
+// concreteIssuers returns the configured OIDC issuers, along with a copy
+// for each of their AdditionalIssuerURLs.
+func (fc *FulcioConfig) concreteIssuers() []OIDCIssuer {
+	var issuers []OIDCIssuer
+	for _, iss := range fc.OIDCIssuers {
+		issuers = append(issuers, iss)
+		for _, additional := range iss.AdditionalIssuerURLs {
+			issuers = append(issuers, iss.withIssuerURL(additional))
+		}
+	}
+	return issuers
+}
+
 // GetVerifier fetches a token verifier for the given `issuerURL`
 // coming from an incoming OIDC token.  If no matching configuration
 // is found, then it returns `false`.
 func (fc *FulcioConfig) GetVerifier(issuerURL string) (*oidc.IDTokenVerifier, bool) {
 	// Look up our fixed issuer verifiers
-	v, ok := fc.verifiers[issuerURL]
+	v, ok := fc.discovery().verifiers[issuerURL]
 	if ok {
 		return v, true
 	}
@@ -148,26 +385,118 @@ func (fc *FulcioConfig) GetVerifier(issuerURL string) (*oidc.IDTokenVerifier, bo
 
 	ctx, cancel := context.WithTimeout(context.Background(), defaultOIDCDiscoveryTimeout)
 	defer cancel()
+	ctx, err := iss.clientContext(ctx, fc.responses)
+	if err != nil {
+		log.Logger.Warnf("Failed to configure HTTP client for issuer URL %q: %v", issuerURL, err)
+		return nil, false
+	}
 	provider, err := oidc.NewProvider(ctx, issuerURL)
 	if err != nil {
 		log.Logger.Warnf("Failed to create provider for issuer URL %q: %v", issuerURL, err)
 		return nil, false
 	}
-	verifier := provider.Verifier(&oidc.Config{ClientID: iss.ClientID})
+	verifier, err := fc.newVerifier(iss, provider)
+	if err != nil {
+		log.Logger.Warnf("Failed to create verifier for issuer URL %q: %v", issuerURL, err)
+		return nil, false
+	}
 	fc.lru.Add(issuerURL, verifier)
 	return verifier, true
 }
 
+// AdditionalExtKeyUsageOIDs returns the parsed AdditionalExtKeyUsages.
+func (fc *FulcioConfig) AdditionalExtKeyUsageOIDs() ([]asn1.ObjectIdentifier, error) {
+	oids := make([]asn1.ObjectIdentifier, 0, len(fc.AdditionalExtKeyUsages))
+	for _, eku := range fc.AdditionalExtKeyUsages {
+		oid, err := parseOID(eku)
+		if err != nil {
+			return nil, fmt.Errorf("extended key usage %q: %w", eku, err)
+		}
+		if oid.Equal(oidAnyExtendedKeyUsage) {
+			return nil, errors.New("anyExtendedKeyUsage must not be added to issued certificates")
+		}
+		oids = append(oids, oid)
+	}
+	return oids, nil
+}
+
+// ExtensionArc returns the parsed ExtensionOIDArc, which is empty if it
+// isn't set.
+func (fc *FulcioConfig) ExtensionArc() (certificate.ExtensionArc, error) {
+	if fc == nil || fc.ExtensionOIDArc == "" {
+		return nil, nil
+	}
+	oid, err := parseOID(fc.ExtensionOIDArc)
+	if err != nil {
+		return nil, fmt.Errorf("ExtensionOIDArc %q: %w", fc.ExtensionOIDArc, err)
+	}
+	if sigstore := (asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264}); len(oid) >= len(sigstore) && oid[:len(sigstore)].Equal(sigstore) {
+		return nil, fmt.Errorf("ExtensionOIDArc %q is under Sigstore's arc, whose OIDs Sigstore allocates", fc.ExtensionOIDArc)
+	}
+	return certificate.ExtensionArc(oid), nil
+}
+
+// ScopeExtensionObjectIdentifier returns the parsed ScopeExtensionOID. It
+// returns false if scopes aren't recorded.
+func (fc *FulcioConfig) ScopeExtensionObjectIdentifier() (asn1.ObjectIdentifier, bool, error) {
+	if fc == nil || fc.ScopeExtensionOID == "" {
+		return nil, false, nil
+	}
+	oid, err := parseOID(fc.ScopeExtensionOID)
+	if err != nil {
+		return nil, false, fmt.Errorf("ScopeExtensionOID %q: %w", fc.ScopeExtensionOID, err)
+	}
+	return oid, true, nil
+}
+
+// SANTemplate returns the parsed SANTemplate of iss, or nil if it has none.
+// Templates are parsed once when the configuration is read, and only parsed
+// here for configurations that weren't.
+func (fc *FulcioConfig) SANTemplate(iss OIDCIssuer) (*santemplate.SANTemplate, error) {
+	if iss.SANTemplate == "" {
+		return nil, nil
+	}
+	if fc != nil {
+		if tmpl, ok := fc.sanTemplates[iss.SANTemplate]; ok {
+			return tmpl, nil
+		}
+	}
+	return santemplate.Parse(iss.SANTemplate)
+}
+
+// parseSANTemplate checks the SANTemplate of iss, if any, and caches it.
+func (fc *FulcioConfig) parseSANTemplate(iss OIDCIssuer) error {
+	if iss.SANTemplate == "" {
+		return nil
+	}
+	if iss.Type != IssuerTypeUsername {
+		return errors.New("only username issuers can use SANTemplate")
+	}
+	tmpl, err := santemplate.Parse(iss.SANTemplate)
+	if err != nil {
+		return fmt.Errorf("invalid SANTemplate: %w", err)
+	}
+	if fc.sanTemplates == nil {
+		fc.sanTemplates = make(map[string]*santemplate.SANTemplate)
+	}
+	fc.sanTemplates[iss.SANTemplate] = tmpl
+	return nil
+}
+
 // ToIssuers returns a proto representation of the OIDC issuer configuration.
 func (fc *FulcioConfig) ToIssuers() []*fulciogrpc.OIDCIssuer {
 	var issuers []*fulciogrpc.OIDCIssuer
 
-	for _, cfgIss := range fc.OIDCIssuers {
+	for _, cfgIss := range fc.concreteIssuers() {
 		issuer := &fulciogrpc.OIDCIssuer{
 			Issuer:            &fulciogrpc.OIDCIssuer_IssuerUrl{IssuerUrl: cfgIss.IssuerURL},
 			Audience:          cfgIss.ClientID,
 			SpiffeTrustDomain: cfgIss.SPIFFETrustDomain,
 			ChallengeClaim:    issuerToChallengeClaim(cfgIss.Type),
+			ChallengeType:     issuerToChallengeType(cfgIss.Type),
+			RequiredFields:    issuerToRequiredFields(cfgIss.Type),
+			Profiles:          cfgIss.Profiles,
+			NonceRequired:     cfgIss.RequireNonce,
 		}
 		issuers = append(issuers, issuer)
 	}
@@ -178,6 +507,10 @@ func (fc *FulcioConfig) ToIssuers() []*fulciogrpc.OIDCIssuer {
 			Audience:          cfgIss.ClientID,
 			SpiffeTrustDomain: cfgIss.SPIFFETrustDomain,
 			ChallengeClaim:    issuerToChallengeClaim(cfgIss.Type),
+			ChallengeType:     issuerToChallengeType(cfgIss.Type),
+			RequiredFields:    issuerToRequiredFields(cfgIss.Type),
+			Profiles:          cfgIss.Profiles,
+			NonceRequired:     cfgIss.RequireNonce,
 		}
 		issuers = append(issuers, issuer)
 	}
@@ -186,16 +519,17 @@ func (fc *FulcioConfig) ToIssuers() []*fulciogrpc.OIDCIssuer {
 }
 
 func (fc *FulcioConfig) prepare() error {
-	fc.verifiers = make(map[string]*oidc.IDTokenVerifier, len(fc.OIDCIssuers))
-	for _, iss := range fc.OIDCIssuers {
-		ctx, cancel := context.WithTimeout(context.Background(), defaultOIDCDiscoveryTimeout)
-		defer cancel()
-		provider, err := oidc.NewProvider(ctx, iss.IssuerURL)
-		if err != nil {
-			return fmt.Errorf("provider %s: %w", iss.IssuerURL, err)
-		}
-		fc.verifiers[iss.IssuerURL] = provider.Verifier(&oidc.Config{ClientID: iss.ClientID})
+	responses, err := fc.newResponseCache()
+	if err != nil {
+		return err
+	}
+	fc.responses = responses
+
+	d, err := fc.discover(context.Background())
+	if err != nil {
+		return err
 	}
+	fc.discovered.Store(d)
 
 	cache, err := lru.New2Q(100 /* size */)
 	if err != nil {
@@ -205,15 +539,116 @@ func (fc *FulcioConfig) prepare() error {
 	return nil
 }
 
+// discovery is the result of OIDC discovery for our OIDCIssuers.
+type discovery struct {
+	// providers is a fixed mapping from our OIDCIssuers to their OIDC providers.
+	providers map[string]*oidc.Provider
+	// verifiers is a fixed mapping from our OIDCIssuers to their OIDC verifiers.
+	verifiers map[string]*oidc.IDTokenVerifier
+}
+
+func (fc *FulcioConfig) discovery() *discovery {
+	d, _ := fc.discovered.Load().(*discovery)
+	if d == nil {
+		return &discovery{}
+	}
+	return d
+}
+
+// discover runs OIDC discovery for each of our OIDCIssuers.
+func (fc *FulcioConfig) discover(ctx context.Context) (*discovery, error) {
+	issuers := fc.concreteIssuers()
+	d := &discovery{
+		providers: make(map[string]*oidc.Provider, len(issuers)),
+		verifiers: make(map[string]*oidc.IDTokenVerifier, len(issuers)),
+	}
+	for _, iss := range issuers {
+		ctx, cancel := context.WithTimeout(ctx, defaultOIDCDiscoveryTimeout)
+		defer cancel()
+		ctx, err := iss.clientContext(ctx, fc.responses)
+		if err != nil {
+			return nil, fmt.Errorf("provider %s: %w", iss.IssuerURL, err)
+		}
+		provider, err := oidc.NewProvider(ctx, iss.IssuerURL)
+		if err != nil {
+			return nil, fmt.Errorf("provider %s: %w", iss.IssuerURL, err)
+		}
+		d.providers[iss.IssuerURL] = provider
+		if d.verifiers[iss.IssuerURL], err = fc.newVerifier(iss, provider); err != nil {
+			return nil, fmt.Errorf("provider %s: %w", iss.IssuerURL, err)
+		}
+	}
+	return d, nil
+}
+
+// signingAlgorithms are the ID token signing algorithms that go-oidc
+// supports. Discovery drops any others that a provider advertises.
+var signingAlgorithms = map[string]bool{
+	oidc.RS256: true,
+	oidc.RS384: true,
+	oidc.RS512: true,
+	oidc.ES256: true,
+	oidc.ES384: true,
+	oidc.ES512: true,
+	oidc.PS256: true,
+	oidc.PS384: true,
+	oidc.PS512: true,
+}
+
+// newVerifier returns the verifier for ID tokens from iss, which was
+// discovered as provider. The verifier has a key set of its own for the
+// provider's JWKS endpoint, so no signing keys are cached until it first
+// verifies a token.
+func (fc *FulcioConfig) newVerifier(iss OIDCIssuer, provider *oidc.Provider) (*oidc.IDTokenVerifier, error) {
+	var claims struct {
+		JWKSURL    string   `json:"jwks_uri"`
+		Algorithms []string `json:"id_token_signing_alg_values_supported"`
+	}
+	if err := provider.Claims(&claims); err != nil {
+		return nil, err
+	}
+	ctx, err := iss.clientContext(context.Background(), fc.responses)
+	if err != nil {
+		return nil, err
+	}
+	pc := &oidc.ProviderConfig{IssuerURL: iss.IssuerURL, JWKSURL: claims.JWKSURL}
+	for _, alg := range claims.Algorithms {
+		if signingAlgorithms[alg] {
+			pc.Algorithms = append(pc.Algorithms, alg)
+		}
+	}
+	return pc.NewProvider(ctx).Verifier(&oidc.Config{ClientID: iss.ClientID}), nil
+}
+
+var environmentRegex = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+var (
+	// awsServiceAccountRegexp matches the sub claim of Kubernetes service
+	// account tokens
+	awsServiceAccountRegexp = regexp.MustCompile(`^system:serviceaccount:[a-z0-9]([-a-z0-9]*[a-z0-9])?:[a-z0-9]([-.a-z0-9]*[a-z0-9])?$`)
+	// awsRoleARNRegexp matches the ARN of an IAM role in any partition
+	awsRoleARNRegexp = regexp.MustCompile(`^arn:aws(-cn|-us-gov)?:iam::[0-9]{12}:role/[A-Za-z0-9+=,.@_/-]+$`)
+)
+
+type SANPacking string
+
+const (
+	SANPackingSingle SANPacking = "single"
+	SANPackingSplit  SANPacking = "split"
+)
+
 type IssuerType string
 
 const (
-	IssuerTypeEmail          = "email"
-	IssuerTypeGithubWorkflow = "github-workflow"
-	IssuerTypeKubernetes     = "kubernetes"
-	IssuerTypeSpiffe         = "spiffe"
-	IssuerTypeURI            = "uri"
-	IssuerTypeUsername       = "username"
+	IssuerTypeAWSIRSA           = "aws-irsa"
+	IssuerTypeBitbucketPipeline = "bitbucket-pipeline"
+	IssuerTypeEmail             = "email"
+	IssuerTypeGithubWorkflow    = "github-workflow"
+	IssuerTypeGitLabPipeline    = "gitlab-pipeline"
+	IssuerTypeKubernetes        = "kubernetes"
+	IssuerTypeSpiffe            = "spiffe"
+	IssuerTypeURI               = "uri"
+	IssuerTypeUsername          = "username"
 )
 
 func parseConfig(b []byte) (cfg *FulcioConfig, err error) {
@@ -230,10 +665,44 @@ func validateConfig(conf *FulcioConfig) error {
 		return errors.New("nil config")
 	}
 
-	for _, issuer := range conf.OIDCIssuers {
+	issuerURLs := make(map[string]bool)
+	for _, issuer := range conf.concreteIssuers() {
+		if issuerURLs[issuer.IssuerURL] {
+			return fmt.Errorf("issuer URL %s is configured more than once", issuer.IssuerURL)
+		}
+		issuerURLs[issuer.IssuerURL] = true
+
 		if issuer.IssuerClaim != "" && issuer.Type != IssuerTypeEmail {
 			return errors.New("only email issuers can use issuer claim mapping")
 		}
+		if issuer.SPIFFEIDsClaim != "" && issuer.Type != IssuerTypeSpiffe {
+			return errors.New("only spiffe issuers can use SPIFFEIDsClaim")
+		}
+		if len(issuer.SPIFFETrustDomains) > 0 && issuer.Type != IssuerTypeSpiffe {
+			return errors.New("only spiffe issuers can use SPIFFETrustDomains")
+		}
+		if len(issuer.GitHubEnvironments) > 0 && issuer.Type != IssuerTypeGithubWorkflow {
+			return errors.New("only github-workflow issuers can use GitHubEnvironments")
+		}
+		if len(issuer.AWSRoleARNs) > 0 && issuer.Type != IssuerTypeAWSIRSA {
+			return errors.New("only aws-irsa issuers can use AWSRoleARNs")
+		}
+		if issuer.Type == IssuerTypeAWSIRSA {
+			if len(issuer.AWSRoleARNs) == 0 {
+				return errors.New("aws-irsa issuer must have AWSRoleARNs set")
+			}
+			for sub, arn := range issuer.AWSRoleARNs {
+				if !awsServiceAccountRegexp.MatchString(sub) {
+					return fmt.Errorf("AWSRoleARNs: %q is not a service account subject like system:serviceaccount:<namespace>:<name>", sub)
+				}
+				if !awsRoleARNRegexp.MatchString(arn) {
+					return fmt.Errorf("AWSRoleARNs: %q is not an IAM role ARN", arn)
+				}
+			}
+		}
+		if err := conf.parseSANTemplate(issuer); err != nil {
+			return err
+		}
 		if issuer.Type == IssuerTypeSpiffe {
 			if issuer.SPIFFETrustDomain == "" {
 				return errors.New("spiffe issuer must have SPIFFETrustDomain set")
@@ -242,6 +711,12 @@ func validateConfig(conf *FulcioConfig) error {
 			if _, err := spiffeid.TrustDomainFromString(issuer.SPIFFETrustDomain); err != nil {
 				return errors.New("spiffe trust domain is invalid")
 			}
+			for _, td := range issuer.SPIFFETrustDomains {
+				// Allowed trust domains may be wildcards, like *.internal
+				if _, err := spiffeid.TrustDomainFromString(strings.TrimPrefix(td, "*.")); err != nil {
+					return fmt.Errorf("spiffe trust domain %q is invalid", td)
+				}
+			}
 		}
 		if issuer.Type == IssuerTypeURI {
 			if issuer.SubjectDomain == "" {
@@ -307,12 +782,111 @@ func validateConfig(conf *FulcioConfig) error {
 		}
 	}
 
+	if _, err := conf.AdditionalExtKeyUsageOIDs(); err != nil {
+		return err
+	}
+
+	if _, _, err := conf.ScopeExtensionObjectIdentifier(); err != nil {
+		return err
+	}
+
+	if arc, err := conf.ExtensionArc(); err != nil {
+		return err
+	} else if len(arc) == 0 && conf.Environment != "" {
+		return errors.New("an ExtensionOIDArc is required to record the Environment")
+	} else if len(arc) == 0 && conf.JWKThumbprintExtension {
+		return errors.New("an ExtensionOIDArc is required to record JWK thumbprints")
+	}
+
+	if err := conf.validateSignatureAlgorithms(); err != nil {
+		return err
+	}
+
+	if conf.Environment != "" && !environmentRegex.MatchString(conf.Environment) {
+		return fmt.Errorf("invalid Environment %q, must only contain letters, digits, '.', '_' and '-'", conf.Environment)
+	}
+
+	if err := conf.validateUnknownIssuerMessage(); err != nil {
+		return err
+	}
+
+	switch conf.SANPacking {
+	case "", SANPackingSingle, SANPackingSplit:
+	default:
+		return fmt.Errorf("unknown SANPacking %q, must be %s or %s", conf.SANPacking, SANPackingSingle, SANPackingSplit)
+	}
+
+	if _, err := conf.OIDCCacheMinTTLDuration(); err != nil {
+		return err
+	}
+	if _, err := conf.OIDCCacheMaxStaleDuration(); err != nil {
+		return err
+	}
+	if err := conf.SANCriticality.validate(); err != nil {
+		return err
+	}
+
+	if _, err := conf.RevokedCertificates(); err != nil {
+		return err
+	}
+
+	for i, policy := range conf.Policies {
+		if err := policy.validate(); err != nil {
+			return fmt.Errorf("policy %d: %w", i, err)
+		}
+	}
+
+	for name, profile := range conf.Profiles {
+		if err := profile.validate(); err != nil {
+			return fmt.Errorf("profile %s: %w", name, err)
+		}
+	}
+	for _, issuer := range conf.concreteIssuers() {
+		if err := conf.validateIssuerProfiles(issuer); err != nil {
+			return err
+		}
+		if err := validateIssuerClaims(issuer); err != nil {
+			return fmt.Errorf("issuer %s: %w", issuer.IssuerURL, err)
+		}
+		if _, err := issuer.MaxTokenAgeDuration(); err != nil {
+			return fmt.Errorf("issuer %s: %w", issuer.IssuerURL, err)
+		}
+		if _, err := issuer.pinnedJWKThumbprints(); err != nil {
+			return fmt.Errorf("issuer %s: %w", issuer.IssuerURL, err)
+		}
+	}
+	for meta, issuer := range conf.MetaIssuers {
+		if err := conf.validateIssuerProfiles(issuer); err != nil {
+			return err
+		}
+		if err := validateIssuerClaims(issuer); err != nil {
+			return fmt.Errorf("meta issuer %s: %w", meta, err)
+		}
+		if _, err := issuer.MaxTokenAgeDuration(); err != nil {
+			return fmt.Errorf("meta issuer %s: %w", meta, err)
+		}
+		if _, err := issuer.pinnedJWKThumbprints(); err != nil {
+			return fmt.Errorf("meta issuer %s: %w", meta, err)
+		}
+		if err := conf.parseSANTemplate(issuer); err != nil {
+			return fmt.Errorf("meta issuer %s: %w", meta, err)
+		}
+	}
+
 	for _, metaIssuer := range conf.MetaIssuers {
 		if metaIssuer.Type == IssuerTypeSpiffe {
 			// This would establish a many to one relationship for OIDC issuers
 			// to trust domains so we fail early and reject this configuration.
 			return errors.New("SPIFFE meta issuers not supported")
 		}
+		if metaIssuer.Type == IssuerTypeAWSIRSA {
+			// Anyone can create an EKS cluster, and so an issuer matching a
+			// meta issuer, with a service account mapped to a role
+			return errors.New("aws-irsa meta issuers not supported")
+		}
+		if len(metaIssuer.AWSRoleARNs) > 0 {
+			return errors.New("only aws-irsa issuers can use AWSRoleARNs")
+		}
 
 		if issuerToChallengeClaim(metaIssuer.Type) == "" {
 			return errors.New("issuer missing challenge claim")
@@ -322,6 +896,15 @@ func validateConfig(conf *FulcioConfig) error {
 	return nil
 }
 
+func (fc *FulcioConfig) validateIssuerProfiles(issuer OIDCIssuer) error {
+	for _, name := range issuer.Profiles {
+		if _, ok := fc.Profiles[name]; !ok {
+			return fmt.Errorf("issuer %s allows unknown profile %s", issuer.IssuerURL, name)
+		}
+	}
+	return nil
+}
+
 var DefaultConfig = &FulcioConfig{
 	OIDCIssuers: map[string]OIDCIssuer{
 		"https://oauth2.sigstore.dev/auth": {
@@ -457,12 +1040,35 @@ func validateAllowedDomain(subjectHostname, issuerHostname string) error {
 	return fmt.Errorf("hostname top-level and second-level domains do not match: %s, %s", subjectHostname, issuerHostname)
 }
 
+// parseOID parses a dotted OID string, e.g. "1.3.6.1.5.5.7.3.3"
+func parseOID(s string) (asn1.ObjectIdentifier, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 {
+		return nil, errors.New("OID must have at least two arcs")
+	}
+	oid := make(asn1.ObjectIdentifier, 0, len(parts))
+	for _, p := range parts {
+		arc, err := strconv.Atoi(p)
+		if err != nil || arc < 0 {
+			return nil, fmt.Errorf("invalid OID arc %q", p)
+		}
+		oid = append(oid, arc)
+	}
+	return oid, nil
+}
+
 func issuerToChallengeClaim(issType IssuerType) string {
 	switch issType {
 	case IssuerTypeEmail:
 		return "email"
 	case IssuerTypeGithubWorkflow:
 		return "sub"
+	case IssuerTypeGitLabPipeline:
+		return "sub"
+	case IssuerTypeBitbucketPipeline:
+		return "sub"
+	case IssuerTypeAWSIRSA:
+		return "sub"
 	case IssuerTypeKubernetes:
 		return "sub"
 	case IssuerTypeSpiffe:
@@ -475,3 +1081,39 @@ func issuerToChallengeClaim(issType IssuerType) string {
 		return ""
 	}
 }
+
+// IssuerToIdentityClass returns whether identities from an issuer type are
+// people or workloads, as one of the certificate.IdentityClass constants.
+func IssuerToIdentityClass(issType IssuerType) string {
+	switch issType {
+	case IssuerTypeEmail, IssuerTypeUsername:
+		return certificate.IdentityClassHuman
+	case IssuerTypeAWSIRSA, IssuerTypeBitbucketPipeline, IssuerTypeGithubWorkflow, IssuerTypeGitLabPipeline, IssuerTypeKubernetes, IssuerTypeSpiffe, IssuerTypeURI:
+		return certificate.IdentityClassMachine
+	default:
+		return ""
+	}
+}
+
+func issuerToChallengeType(issType IssuerType) fulciogrpc.ChallengeType {
+	if issuerToChallengeClaim(issType) == "" {
+		return fulciogrpc.ChallengeType_CHALLENGE_TYPE_UNSPECIFIED
+	}
+	// All issuer types currently require proof of possession of the private key
+	return fulciogrpc.ChallengeType_PROOF_OF_POSSESSION
+}
+
+// issuerToRequiredFields returns the paths of the CreateSigningCertificateRequest
+// fields that must be set to request a certificate for the issuer type.
+func issuerToRequiredFields(issType IssuerType) []string {
+	switch issuerToChallengeType(issType) {
+	case fulciogrpc.ChallengeType_PROOF_OF_POSSESSION:
+		return []string{
+			"credentials.oidc_identity_token",
+			"public_key_request.public_key.content",
+			"public_key_request.proof_of_possession",
+		}
+	default:
+		return nil
+	}
+}
@@ -19,9 +19,12 @@ import (
 	"fmt"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/sigstore/fulcio/pkg/certificate"
 	"github.com/sigstore/fulcio/pkg/generated/protobuf"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 var validCfg = `
@@ -112,6 +115,555 @@ func TestValidateConfig(t *testing.T) {
 			},
 			WantError: false,
 		},
+		"additional extended key usage": {
+			Config: &FulcioConfig{
+				AdditionalExtKeyUsages: []string{"1.3.6.1.5.5.7.3.8"},
+			},
+			WantError: false,
+		},
+		"anyExtendedKeyUsage cannot be added": {
+			Config: &FulcioConfig{
+				AdditionalExtKeyUsages: []string{"2.5.29.37.0"},
+			},
+			WantError: true,
+		},
+		"invalid extended key usage OID": {
+			Config: &FulcioConfig{
+				AdditionalExtKeyUsages: []string{"1.3.6.foo"},
+			},
+			WantError: true,
+		},
+		"additional issuer URLs": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://new.example.com": {
+						IssuerURL:            "https://new.example.com",
+						AdditionalIssuerURLs: []string{"https://old.example.com"},
+						ClientID:             "foo",
+						Type:                 IssuerTypeEmail,
+					},
+				},
+			},
+			WantError: false,
+		},
+		"additional issuer URL cannot duplicate another issuer": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://new.example.com": {
+						IssuerURL:            "https://new.example.com",
+						AdditionalIssuerURLs: []string{"https://old.example.com"},
+						ClientID:             "foo",
+						Type:                 IssuerTypeEmail,
+					},
+					"https://old.example.com": {
+						IssuerURL: "https://old.example.com",
+						ClientID:  "foo",
+						Type:      IssuerTypeEmail,
+					},
+				},
+			},
+			WantError: true,
+		},
+		"additional issuer URL must match uri subject domain": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://accounts.example.com": {
+						IssuerURL:            "https://accounts.example.com",
+						AdditionalIssuerURLs: []string{"https://accounts.other.com"},
+						ClientID:             "foo",
+						Type:                 IssuerTypeURI,
+						SubjectDomain:        "https://example.com",
+					},
+				},
+			},
+			WantError: true,
+		},
+		"issuer with profile": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://issuer.example.com": {
+						IssuerURL: "https://issuer.example.com",
+						ClientID:  "foo",
+						Type:      IssuerTypeEmail,
+						Profiles:  []string{"document-signing"},
+					},
+				},
+				Profiles: map[string]Profile{
+					"document-signing": {
+						ExtKeyUsages:      []string{"1.3.6.1.5.5.7.3.4"},
+						Validity:          "1h",
+						ExcludeExtensions: []string{"1.3.6.1.4.1.57264.1.2"},
+					},
+				},
+			},
+			WantError: false,
+		},
+		"issuer cannot allow unknown profile": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://issuer.example.com": {
+						IssuerURL: "https://issuer.example.com",
+						ClientID:  "foo",
+						Type:      IssuerTypeEmail,
+						Profiles:  []string{"document-signing"},
+					},
+				},
+			},
+			WantError: true,
+		},
+		"signature algorithm": {
+			Config: &FulcioConfig{
+				SignatureAlgorithms: map[string]string{
+					KeyTypeECDSAP256: "ECDSA-SHA384",
+					KeyTypeRSA:       "SHA256-RSAPSS",
+				},
+			},
+			WantError: false,
+		},
+		"signature algorithm for unknown key type": {
+			Config: &FulcioConfig{
+				SignatureAlgorithms: map[string]string{"ecdsa-p224": "ECDSA-SHA256"},
+			},
+			WantError: true,
+		},
+		"unsupported signature algorithm": {
+			Config: &FulcioConfig{
+				SignatureAlgorithms: map[string]string{KeyTypeRSA: "SHA1-RSA"},
+			},
+			WantError: true,
+		},
+		"split SAN packing": {
+			Config: &FulcioConfig{
+				SANPacking: SANPackingSplit,
+			},
+			WantError: false,
+		},
+		"SAN criticality": {
+			Config: &FulcioConfig{
+				SANCriticality: CriticalityPolicy{IdentityKindUsername: true, IdentityKindEmail: false},
+			},
+			WantError: false,
+		},
+		"strict claims with allowed claims": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://issuer.example.com": {
+						IssuerURL:     "https://issuer.example.com",
+						ClientID:      "foo",
+						Type:          IssuerTypeEmail,
+						StrictClaims:  true,
+						AllowedClaims: []string{"email", "email_verified"},
+					},
+				},
+			},
+			WantError: false,
+		},
+		"allowed claims require strict claims": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://issuer.example.com": {
+						IssuerURL:     "https://issuer.example.com",
+						ClientID:      "foo",
+						Type:          IssuerTypeEmail,
+						AllowedClaims: []string{"email", "email_verified"},
+					},
+				},
+			},
+			WantError: true,
+		},
+		"max token age": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://issuer.example.com": {
+						IssuerURL:   "https://issuer.example.com",
+						ClientID:    "foo",
+						Type:        IssuerTypeEmail,
+						MaxTokenAge: "5m",
+					},
+				},
+			},
+			WantError: false,
+		},
+		"max token age must be a positive duration": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://issuer.example.com": {
+						IssuerURL:   "https://issuer.example.com",
+						ClientID:    "foo",
+						Type:        IssuerTypeEmail,
+						MaxTokenAge: "forever",
+					},
+				},
+			},
+			WantError: true,
+		},
+		"environment": {
+			Config: &FulcioConfig{
+				ExtensionOIDArc: "1.3.6.1.4.1.32473.1",
+				Environment:     "staging",
+			},
+			WantError: false,
+		},
+		"environment must be a simple name": {
+			Config: &FulcioConfig{
+				ExtensionOIDArc: "1.3.6.1.4.1.32473.1",
+				Environment:     "staging env",
+			},
+			WantError: true,
+		},
+		"environment requires an extension arc": {
+			Config: &FulcioConfig{
+				Environment: "staging",
+			},
+			WantError: true,
+		},
+		"JWK thumbprint requires an extension arc": {
+			Config: &FulcioConfig{
+				JWKThumbprintExtension: true,
+			},
+			WantError: true,
+		},
+		"extension arc must be an OID": {
+			Config: &FulcioConfig{
+				ExtensionOIDArc: "not an OID",
+			},
+			WantError: true,
+		},
+		"extension arc must not be under Sigstore's arc": {
+			Config: &FulcioConfig{
+				ExtensionOIDArc: "1.3.6.1.4.1.57264.1",
+			},
+			WantError: true,
+		},
+		"spiffe IDs claim": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://issuer.example.com": {
+						IssuerURL:         "https://issuer.example.com",
+						ClientID:          "foo",
+						Type:              IssuerTypeSpiffe,
+						SPIFFETrustDomain: "example.com",
+						SPIFFEIDsClaim:    "spiffe_ids",
+					},
+				},
+			},
+			WantError: false,
+		},
+		"spiffe IDs claim requires a spiffe issuer": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://issuer.example.com": {
+						IssuerURL:      "https://issuer.example.com",
+						ClientID:       "foo",
+						Type:           IssuerTypeEmail,
+						SPIFFEIDsClaim: "spiffe_ids",
+					},
+				},
+			},
+			WantError: true,
+		},
+		"unknown issuer message": {
+			Config: &FulcioConfig{
+				UnknownIssuerMessage: "issuer {{.Issuer}} is not configured",
+			},
+			WantError: false,
+		},
+		"unknown issuer message must be a valid template": {
+			Config: &FulcioConfig{
+				UnknownIssuerMessage: "issuer {{.Issuer is not configured",
+			},
+			WantError: true,
+		},
+		"unknown issuer message may only use the issuer": {
+			Config: &FulcioConfig{
+				UnknownIssuerMessage: "issuer {{.Subject}} is not configured",
+			},
+			WantError: true,
+		},
+		"scope extension OID": {
+			Config: &FulcioConfig{
+				ScopeExtensionOID: "1.3.6.1.4.1.99999.1",
+			},
+			WantError: false,
+		},
+		"scope extension OID must be dotted": {
+			Config: &FulcioConfig{
+				ScopeExtensionOID: "scope",
+			},
+			WantError: true,
+		},
+		"OIDC cache durations": {
+			Config: &FulcioConfig{
+				OIDCCacheMinTTL:   "5m",
+				OIDCCacheMaxStale: "1h",
+			},
+			WantError: false,
+		},
+		"OIDC cache minimum TTL must be a duration": {
+			Config: &FulcioConfig{
+				OIDCCacheMinTTL: "forever",
+			},
+			WantError: true,
+		},
+		"OIDC cache staleness bound must not be negative": {
+			Config: &FulcioConfig{
+				OIDCCacheMaxStale: "-1h",
+			},
+			WantError: true,
+		},
+		"policies": {
+			Config: &FulcioConfig{
+				Policies: []Policy{
+					{Type: PolicyTypeAllowlist, Claim: "email", Values: []string{"foo@example.com"}},
+					{Type: PolicyTypeDenylist, Values: []string{"bar@example.com"}},
+				},
+			},
+			WantError: false,
+		},
+		"unknown policy type": {
+			Config: &FulcioConfig{
+				Policies: []Policy{{Type: "cel", Values: []string{"true"}}},
+			},
+			WantError: true,
+		},
+		"policy without values": {
+			Config: &FulcioConfig{
+				Policies: []Policy{{Type: PolicyTypeDenylist}},
+			},
+			WantError: true,
+		},
+		"aws-irsa issuer": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://oidc.eks.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06": {
+						IssuerURL:   "https://oidc.eks.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06",
+						ClientID:    "sts.amazonaws.com",
+						Type:        IssuerTypeAWSIRSA,
+						AWSRoleARNs: map[string]string{"system:serviceaccount:default:deploy": "arn:aws:iam::123456789012:role/deploy"},
+					},
+				},
+			},
+			WantError: false,
+		},
+		"aws-irsa issuer requires role ARNs": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://oidc.eks.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06": {
+						IssuerURL: "https://oidc.eks.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06",
+						ClientID:  "sts.amazonaws.com",
+						Type:      IssuerTypeAWSIRSA,
+					},
+				},
+			},
+			WantError: true,
+		},
+		"aws-irsa role ARNs must be keyed by service account": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://oidc.eks.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06": {
+						IssuerURL:   "https://oidc.eks.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06",
+						ClientID:    "sts.amazonaws.com",
+						Type:        IssuerTypeAWSIRSA,
+						AWSRoleARNs: map[string]string{"deploy": "arn:aws:iam::123456789012:role/deploy"},
+					},
+				},
+			},
+			WantError: true,
+		},
+		"aws-irsa role ARNs must be IAM role ARNs": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://oidc.eks.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06": {
+						IssuerURL:   "https://oidc.eks.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06",
+						ClientID:    "sts.amazonaws.com",
+						Type:        IssuerTypeAWSIRSA,
+						AWSRoleARNs: map[string]string{"system:serviceaccount:default:deploy": "arn:aws:iam::123456789012:user/deploy"},
+					},
+				},
+			},
+			WantError: true,
+		},
+		"role ARNs require an aws-irsa issuer": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://oidc.eks.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06": {
+						IssuerURL:   "https://oidc.eks.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06",
+						ClientID:    "sts.amazonaws.com",
+						Type:        IssuerTypeKubernetes,
+						AWSRoleARNs: map[string]string{"system:serviceaccount:default:deploy": "arn:aws:iam::123456789012:role/deploy"},
+					},
+				},
+			},
+			WantError: true,
+		},
+		"aws-irsa meta issuers are not supported": {
+			Config: &FulcioConfig{
+				MetaIssuers: map[string]OIDCIssuer{
+					"https://oidc.eks.*.amazonaws.com/id/*": {
+						IssuerURL:   "https://oidc.eks.*.amazonaws.com/id/*",
+						ClientID:    "sts.amazonaws.com",
+						Type:        IssuerTypeAWSIRSA,
+						AWSRoleARNs: map[string]string{"system:serviceaccount:default:deploy": "arn:aws:iam::123456789012:role/deploy"},
+					},
+				},
+			},
+			WantError: true,
+		},
+		"github environments": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://token.actions.githubusercontent.com": {
+						IssuerURL:          "https://token.actions.githubusercontent.com",
+						ClientID:           "sigstore",
+						Type:               IssuerTypeGithubWorkflow,
+						GitHubEnvironments: []string{"production"},
+					},
+				},
+			},
+			WantError: false,
+		},
+		"github environments require a github-workflow issuer": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://issuer.example.com": {
+						IssuerURL:          "https://issuer.example.com",
+						ClientID:           "foo",
+						Type:               IssuerTypeEmail,
+						GitHubEnvironments: []string{"production"},
+					},
+				},
+			},
+			WantError: true,
+		},
+		"SAN template": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://accounts.example.com": {
+						IssuerURL:     "https://accounts.example.com",
+						ClientID:      "sigstore",
+						Type:          IssuerTypeUsername,
+						SubjectDomain: "example.com",
+						SANTemplate:   "{{.preferred_username}}!example.com",
+					},
+				},
+			},
+			WantError: false,
+		},
+		"SAN template must be valid": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://accounts.example.com": {
+						IssuerURL:     "https://accounts.example.com",
+						ClientID:      "sigstore",
+						Type:          IssuerTypeUsername,
+						SubjectDomain: "example.com",
+						SANTemplate:   `{{printf "%s" .sub}}!example.com`,
+					},
+				},
+			},
+			WantError: true,
+		},
+		"SAN template requires a username issuer": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://issuer.example.com": {
+						IssuerURL:   "https://issuer.example.com",
+						ClientID:    "foo",
+						Type:        IssuerTypeEmail,
+						SANTemplate: "{{.sub}}!example.com",
+					},
+				},
+			},
+			WantError: true,
+		},
+		"revocations": {
+			Config: &FulcioConfig{
+				Revocations: []Revocation{
+					{SerialNumber: "2a", RevokedAt: "2022-10-01T12:00:00Z"},
+					{SerialNumber: "01:ff", RevokedAt: "2022-10-01T12:00:00Z", Reason: 1},
+				},
+			},
+			WantError: false,
+		},
+		"revocation serial number must be hex": {
+			Config: &FulcioConfig{
+				Revocations: []Revocation{{SerialNumber: "xyz", RevokedAt: "2022-10-01T12:00:00Z"}},
+			},
+			WantError: true,
+		},
+		"revocation time must be RFC 3339": {
+			Config: &FulcioConfig{
+				Revocations: []Revocation{{SerialNumber: "2a", RevokedAt: "yesterday"}},
+			},
+			WantError: true,
+		},
+		"revocation reason must be a CRLReason": {
+			Config: &FulcioConfig{
+				Revocations: []Revocation{{SerialNumber: "2a", RevokedAt: "2022-10-01T12:00:00Z", Reason: 7}},
+			},
+			WantError: true,
+		},
+		"pinned JWK thumbprints": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"https://issuer.example.com": {
+						IssuerURL:            "https://issuer.example.com",
+						ClientID:             "foo",
+						Type:                 IssuerTypeEmail,
+						PinnedJWKThumbprints: []string{"NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"},
+					},
+				},
+			},
+			WantError: false,
+		},
+		"pinned JWK thumbprints must be SHA-256 thumbprints": {
+			Config: &FulcioConfig{
+				MetaIssuers: map[string]OIDCIssuer{
+					"https://oidc.eks.*.amazonaws.com/id/*": {
+						ClientID:             "foo",
+						Type:                 IssuerTypeKubernetes,
+						PinnedJWKThumbprints: []string{"not a thumbprint"},
+					},
+				},
+			},
+			WantError: true,
+		},
+		"unknown SAN packing": {
+			Config: &FulcioConfig{
+				SANPacking: "ordered",
+			},
+			WantError: true,
+		},
+		"unknown identity kind in SAN criticality": {
+			Config: &FulcioConfig{
+				SANCriticality: CriticalityPolicy{"dns": false},
+			},
+			WantError: true,
+		},
+		"profile cannot add anyExtendedKeyUsage": {
+			Config: &FulcioConfig{
+				Profiles: map[string]Profile{
+					"any": {ExtKeyUsages: []string{"2.5.29.37.0"}},
+				},
+			},
+			WantError: true,
+		},
+		"profile validity must be a positive duration": {
+			Config: &FulcioConfig{
+				Profiles: map[string]Profile{
+					"negative": {Validity: "-10m"},
+				},
+			},
+			WantError: true,
+		},
+		"profile cannot exclude issuer extension": {
+			Config: &FulcioConfig{
+				Profiles: map[string]Profile{
+					"anonymous": {ExcludeExtensions: []string{"1.3.6.1.4.1.57264.1.1"}},
+				},
+			},
+			WantError: true,
+		},
 		"spiffe issuer requires a trust domain": {
 			Config: &FulcioConfig{
 				OIDCIssuers: map[string]OIDCIssuer{
@@ -149,6 +701,75 @@ func TestValidateConfig(t *testing.T) {
 			},
 			WantError: true,
 		},
+		"good spiffe trust domains": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"issuer.example.com": {
+						IssuerURL:          "issuer.example.com",
+						ClientID:           "foo",
+						Type:               IssuerTypeSpiffe,
+						SPIFFETrustDomain:  "example.com",
+						SPIFFETrustDomains: []string{"other.example.com"},
+					},
+				},
+			},
+			WantError: false,
+		},
+		"wildcard spiffe trust domains": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"issuer.example.com": {
+						IssuerURL:          "issuer.example.com",
+						ClientID:           "foo",
+						Type:               IssuerTypeSpiffe,
+						SPIFFETrustDomain:  "example.com",
+						SPIFFETrustDomains: []string{"*.internal"},
+					},
+				},
+			},
+			WantError: false,
+		},
+		"wildcard spiffe trust domain that isn't a label": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"issuer.example.com": {
+						IssuerURL:          "issuer.example.com",
+						ClientID:           "foo",
+						Type:               IssuerTypeSpiffe,
+						SPIFFETrustDomain:  "example.com",
+						SPIFFETrustDomains: []string{"*internal"},
+					},
+				},
+			},
+			WantError: true,
+		},
+		"invalid spiffe trust domains": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"issuer.example.com": {
+						IssuerURL:          "issuer.example.com",
+						ClientID:           "foo",
+						Type:               IssuerTypeSpiffe,
+						SPIFFETrustDomain:  "example.com",
+						SPIFFETrustDomains: []string{"invalid#domain"},
+					},
+				},
+			},
+			WantError: true,
+		},
+		"spiffe trust domains for non-spiffe issuer": {
+			Config: &FulcioConfig{
+				OIDCIssuers: map[string]OIDCIssuer{
+					"issuer.example.com": {
+						IssuerURL:          "issuer.example.com",
+						ClientID:           "foo",
+						Type:               IssuerTypeEmail,
+						SPIFFETrustDomains: []string{"example.com"},
+					},
+				},
+			},
+			WantError: true,
+		},
 		"good uri config": {
 			Config: &FulcioConfig{
 				OIDCIssuers: map[string]OIDCIssuer{
@@ -335,6 +956,124 @@ func TestValidateConfig(t *testing.T) {
 	}
 }
 
+func TestIssuerDisplayName(t *testing.T) {
+	cfg := &FulcioConfig{
+		OIDCIssuers: map[string]OIDCIssuer{
+			"https://named.example.com": {
+				IssuerURL: "https://named.example.com",
+				Name:      "named",
+			},
+			"https://unnamed.example.com": {
+				IssuerURL: "https://unnamed.example.com",
+			},
+		},
+		MetaIssuers: map[string]OIDCIssuer{
+			"https://oidc.eks.*.amazonaws.com/id/*": {
+				ClientID: "sigstore",
+				Type:     IssuerTypeKubernetes,
+			},
+		},
+	}
+
+	tests := map[string]string{
+		"https://named.example.com":   "named",
+		"https://unnamed.example.com": "https://unnamed.example.com",
+		// Unnamed meta issuers use the template rather than the concrete URL
+		"https://oidc.eks.us-west-2.amazonaws.com/id/B02C93B6A2D30341AD01E1B6D48164CB": "https://oidc.eks.*.amazonaws.com/id/*",
+	}
+	for issuerURL, want := range tests {
+		iss, ok := cfg.GetIssuer(issuerURL)
+		if !ok {
+			t.Fatalf("expected issuer %s", issuerURL)
+		}
+		if got := iss.DisplayName(); got != want {
+			t.Errorf("DisplayName() for %s = %s, want %s", issuerURL, got, want)
+		}
+	}
+}
+
+func TestGetIssuerAdditionalIssuerURLs(t *testing.T) {
+	cfg := &FulcioConfig{
+		OIDCIssuers: map[string]OIDCIssuer{
+			"https://new.example.com": {
+				IssuerURL:            "https://new.example.com",
+				AdditionalIssuerURLs: []string{"https://old.example.com"},
+				ClientID:             "sigstore",
+				Type:                 IssuerTypeEmail,
+			},
+		},
+	}
+
+	iss, ok := cfg.GetIssuer("https://old.example.com")
+	if !ok {
+		t.Fatal("expected additional issuer URL to be found")
+	}
+	if iss.IssuerURL != "https://old.example.com" {
+		t.Errorf("expected issuer URL to be the token issuer, got %s", iss.IssuerURL)
+	}
+	if iss.ClientID != "sigstore" || iss.Type != IssuerTypeEmail {
+		t.Errorf("expected configuration of the original issuer, got %+v", iss)
+	}
+	if iss.DisplayName() != "https://new.example.com" {
+		t.Errorf("expected additional issuer URL to be reported as the original issuer, got %s", iss.DisplayName())
+	}
+	if _, ok := cfg.GetIssuer("https://other.example.com"); ok {
+		t.Error("expected unknown issuer URL not to be found")
+	}
+}
+
+func TestSANTemplateIsParsedOnce(t *testing.T) {
+	iss := OIDCIssuer{
+		IssuerURL:     "https://accounts.example.com",
+		ClientID:      "sigstore",
+		Type:          IssuerTypeUsername,
+		SubjectDomain: "example.com",
+		SANTemplate:   "{{.preferred_username}}!example.com",
+	}
+	meta := iss
+	meta.IssuerURL = "https://*.example.com"
+	meta.SANTemplate = "{{.name}}!example.com"
+	cfg := &FulcioConfig{
+		OIDCIssuers: map[string]OIDCIssuer{iss.IssuerURL: iss},
+		MetaIssuers: map[string]OIDCIssuer{meta.IssuerURL: meta},
+	}
+
+	// Before validation, templates are parsed on demand
+	tmpl, err := cfg.SANTemplate(iss)
+	if err != nil || tmpl == nil {
+		t.Fatalf("SANTemplate() = %v, %v", tmpl, err)
+	}
+	if again, _ := cfg.SANTemplate(iss); again == tmpl {
+		t.Fatal("expected an unvalidated template to be parsed again")
+	}
+
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("validateConfig() = %v", err)
+	}
+	for _, iss := range []OIDCIssuer{iss, meta} {
+		tmpl, err := cfg.SANTemplate(iss)
+		if err != nil || tmpl == nil || tmpl.String() != iss.SANTemplate {
+			t.Fatalf("SANTemplate() = %v, %v", tmpl, err)
+		}
+		if again, _ := cfg.SANTemplate(iss); again != tmpl {
+			t.Fatalf("expected template %q to be cached when the config is validated", iss.SANTemplate)
+		}
+	}
+
+	if tmpl, err := cfg.SANTemplate(OIDCIssuer{}); tmpl != nil || err != nil {
+		t.Fatalf("expected no template for an issuer without one, got %v, %v", tmpl, err)
+	}
+}
+
+func TestLoadRejectsAnyExtKeyUsage(t *testing.T) {
+	_, err := Read([]byte(`{
+		"AdditionalExtKeyUsages": ["1.3.6.1.5.5.7.3.8", "2.5.29.37.0"]
+	}`))
+	if err == nil || !strings.Contains(err.Error(), "anyExtendedKeyUsage") {
+		t.Fatalf("expected anyExtendedKeyUsage to be rejected, got %v", err)
+	}
+}
+
 func Test_isURISubjectAllowed(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -485,6 +1224,15 @@ func Test_issuerToChallengeClaim(t *testing.T) {
 	if claim := issuerToChallengeClaim(IssuerTypeGithubWorkflow); claim != "sub" {
 		t.Fatalf("expected sub subject claim for GitHub issuer, got %s", claim)
 	}
+	if claim := issuerToChallengeClaim(IssuerTypeGitLabPipeline); claim != "sub" {
+		t.Fatalf("expected sub subject claim for GitLab issuer, got %s", claim)
+	}
+	if claim := issuerToChallengeClaim(IssuerTypeBitbucketPipeline); claim != "sub" {
+		t.Fatalf("expected sub subject claim for Bitbucket issuer, got %s", claim)
+	}
+	if claim := issuerToChallengeClaim(IssuerTypeAWSIRSA); claim != "sub" {
+		t.Fatalf("expected sub subject claim for AWS IRSA issuer, got %s", claim)
+	}
 	if claim := issuerToChallengeClaim(IssuerTypeKubernetes); claim != "sub" {
 		t.Fatalf("expected sub subject claim for K8S issuer, got %s", claim)
 	}
@@ -494,6 +1242,58 @@ func Test_issuerToChallengeClaim(t *testing.T) {
 	}
 }
 
+func TestIssuerToIdentityClass(t *testing.T) {
+	for issType, want := range map[IssuerType]string{
+		IssuerTypeEmail:             certificate.IdentityClassHuman,
+		IssuerTypeUsername:          certificate.IdentityClassHuman,
+		IssuerTypeGithubWorkflow:    certificate.IdentityClassMachine,
+		IssuerTypeGitLabPipeline:    certificate.IdentityClassMachine,
+		IssuerTypeBitbucketPipeline: certificate.IdentityClassMachine,
+		IssuerTypeAWSIRSA:           certificate.IdentityClassMachine,
+		IssuerTypeKubernetes:        certificate.IdentityClassMachine,
+		IssuerTypeSpiffe:            certificate.IdentityClassMachine,
+		IssuerTypeURI:               certificate.IdentityClassMachine,
+		"invalid":                   "",
+	} {
+		if got := IssuerToIdentityClass(issType); got != want {
+			t.Errorf("expected identity class %q for %s issuer, got %q", want, issType, got)
+		}
+	}
+}
+
+func Test_issuerToRequiredFields(t *testing.T) {
+	for _, issType := range []IssuerType{IssuerTypeAWSIRSA, IssuerTypeBitbucketPipeline, IssuerTypeEmail, IssuerTypeGithubWorkflow, IssuerTypeGitLabPipeline, IssuerTypeKubernetes, IssuerTypeSpiffe, IssuerTypeURI, IssuerTypeUsername} {
+		if challengeType := issuerToChallengeType(issType); challengeType != protobuf.ChallengeType_PROOF_OF_POSSESSION {
+			t.Fatalf("expected proof of possession challenge for %s issuer, got %v", issType, challengeType)
+		}
+		fields := issuerToRequiredFields(issType)
+		if len(fields) == 0 {
+			t.Fatalf("expected required fields for %s issuer", issType)
+		}
+		// Every path must resolve to a field of the request
+		for _, path := range fields {
+			desc := (&protobuf.CreateSigningCertificateRequest{}).ProtoReflect().Descriptor()
+			parts := strings.Split(path, ".")
+			for i, name := range parts {
+				field := desc.Fields().ByName(protoreflect.Name(name))
+				if field == nil {
+					t.Fatalf("required field %s for %s issuer does not exist", path, issType)
+				}
+				if i < len(parts)-1 {
+					desc = field.Message()
+				}
+			}
+		}
+	}
+	// unexpected issuer has no challenge
+	if challengeType := issuerToChallengeType("invalid"); challengeType != protobuf.ChallengeType_CHALLENGE_TYPE_UNSPECIFIED {
+		t.Fatalf("expected no challenge for invalid issuer, got %v", challengeType)
+	}
+	if fields := issuerToRequiredFields("invalid"); fields != nil {
+		t.Fatalf("expected no required fields for invalid issuer, got %v", fields)
+	}
+}
+
 func TestToIssuers(t *testing.T) {
 	config := &FulcioConfig{
 		OIDCIssuers: map[string]OIDCIssuer{
@@ -516,9 +1316,17 @@ func TestToIssuers(t *testing.T) {
 		t.Fatalf("unexpected number of issues, expected 2, got %v", len(issuers))
 	}
 
+	requiredFields := []string{
+		"credentials.oidc_identity_token",
+		"public_key_request.public_key.content",
+		"public_key_request.proof_of_possession",
+	}
+
 	iss := &protobuf.OIDCIssuer{
 		Audience:       "sigstore",
 		ChallengeClaim: "email",
+		ChallengeType:  protobuf.ChallengeType_PROOF_OF_POSSESSION,
+		RequiredFields: requiredFields,
 		Issuer: &protobuf.OIDCIssuer_IssuerUrl{
 			IssuerUrl: "example.com",
 		},
@@ -529,6 +1337,8 @@ func TestToIssuers(t *testing.T) {
 	iss = &protobuf.OIDCIssuer{
 		Audience:       "sigstore",
 		ChallengeClaim: "sub",
+		ChallengeType:  protobuf.ChallengeType_PROOF_OF_POSSESSION,
+		RequiredFields: requiredFields,
 		Issuer: &protobuf.OIDCIssuer_WildcardIssuerUrl{
 			WildcardIssuerUrl: "wildcard.*.example.com",
 		},
@@ -0,0 +1,81 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import "fmt"
+
+// IdentityKind is the kind of name in the subject alternative name of a
+// certificate.
+type IdentityKind string
+
+const (
+	IdentityKindUsername IdentityKind = "username"
+	IdentityKindEmail    IdentityKind = "email"
+	IdentityKindURI      IdentityKind = "uri"
+	IdentityKindGitHub   IdentityKind = "github"
+)
+
+// CriticalityPolicy is whether the subject alternative name extension of
+// certificates is marked critical, by the kind of identity they're issued
+// for, e.g. {"username": true, "email": false}.
+type CriticalityPolicy map[IdentityKind]bool
+
+// ShouldBeCritical returns whether the subject alternative name extension
+// for an identity of kind is critical. Kinds missing from the policy default
+// to critical, as RFC 5280 requires for certificates with an empty subject.
+func (p CriticalityPolicy) ShouldBeCritical(kind IdentityKind) bool {
+	if critical, ok := p[kind]; ok {
+		return critical
+	}
+	return true
+}
+
+func (p CriticalityPolicy) validate() error {
+	for kind := range p {
+		switch kind {
+		case IdentityKindUsername, IdentityKindEmail, IdentityKindURI, IdentityKindGitHub:
+		default:
+			return fmt.Errorf("unknown identity kind %q in SANCriticality", kind)
+		}
+	}
+	return nil
+}
+
+// IssuerToIdentityKind returns the kind of identity issued for tokens from
+// an issuer type. Workload identities other than GitHub workflows are URIs.
+func IssuerToIdentityKind(issType IssuerType) IdentityKind {
+	switch issType {
+	case IssuerTypeUsername:
+		return IdentityKindUsername
+	case IssuerTypeEmail:
+		return IdentityKindEmail
+	case IssuerTypeGithubWorkflow:
+		return IdentityKindGitHub
+	case IssuerTypeAWSIRSA, IssuerTypeBitbucketPipeline, IssuerTypeGitLabPipeline, IssuerTypeKubernetes, IssuerTypeSpiffe, IssuerTypeURI:
+		return IdentityKindURI
+	default:
+		return ""
+	}
+}
+
+// CriticalityPolicy returns the SANCriticality of the config, which may be
+// nil, in which case every subject alternative name extension is critical.
+func (fc *FulcioConfig) CriticalityPolicy() CriticalityPolicy {
+	if fc == nil {
+		return nil
+	}
+	return fc.SANCriticality
+}
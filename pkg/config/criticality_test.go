@@ -0,0 +1,70 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import "testing"
+
+func TestCriticalityPolicy(t *testing.T) {
+	policy := CriticalityPolicy{
+		IdentityKindUsername: true,
+		IdentityKindEmail:    false,
+		IdentityKindURI:      false,
+		IdentityKindGitHub:   true,
+	}
+	for kind, want := range map[IdentityKind]bool{
+		IdentityKindUsername: true,
+		IdentityKindEmail:    false,
+		IdentityKindURI:      false,
+		IdentityKindGitHub:   true,
+		// Unknown kinds are critical
+		"dns": true,
+		"":    true,
+	} {
+		if got := policy.ShouldBeCritical(kind); got != want {
+			t.Errorf("ShouldBeCritical(%q) = %v, want %v", kind, got, want)
+		}
+	}
+
+	// Kinds missing from the policy are critical
+	for _, kind := range []IdentityKind{IdentityKindUsername, IdentityKindEmail, IdentityKindURI, IdentityKindGitHub} {
+		if !(CriticalityPolicy{}).ShouldBeCritical(kind) {
+			t.Errorf("ShouldBeCritical(%q) of empty policy = false, want true", kind)
+		}
+		var fc *FulcioConfig
+		if !fc.CriticalityPolicy().ShouldBeCritical(kind) {
+			t.Errorf("ShouldBeCritical(%q) of nil config = false, want true", kind)
+		}
+	}
+}
+
+func TestIssuerToIdentityKind(t *testing.T) {
+	for issType, want := range map[IssuerType]IdentityKind{
+		IssuerTypeUsername:          IdentityKindUsername,
+		IssuerTypeEmail:             IdentityKindEmail,
+		IssuerTypeGithubWorkflow:    IdentityKindGitHub,
+		IssuerTypeGitLabPipeline:    IdentityKindURI,
+		IssuerTypeBitbucketPipeline: IdentityKindURI,
+		IssuerTypeAWSIRSA:           IdentityKindURI,
+		IssuerTypeKubernetes:        IdentityKindURI,
+		IssuerTypeSpiffe:            IdentityKindURI,
+		IssuerTypeURI:               IdentityKindURI,
+		"unknown":                   "",
+	} {
+		if got := IssuerToIdentityKind(issType); got != want {
+			t.Errorf("IssuerToIdentityKind(%q) = %q, want %q", issType, got, want)
+		}
+	}
+}
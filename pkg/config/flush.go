@@ -0,0 +1,68 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// FlushJWKS discards the cached signing keys of every issuer, so that keys
+// are fetched again from the issuer's JWKS endpoint on the next request.
+// Verifiers for meta issuers are discarded entirely, which also repeats
+// their discovery. Cached discovery and JWKS responses are discarded too.
+// If an issuer hasn't been discovered, or its verifier can't be rebuilt,
+// an error is returned and the existing verifiers are kept.
+func (fc *FulcioConfig) FlushJWKS() error {
+	fc.responses.purge()
+	providers := fc.discovery().providers
+	d := &discovery{
+		providers: providers,
+		verifiers: make(map[string]*oidc.IDTokenVerifier, len(providers)),
+	}
+	for _, iss := range fc.concreteIssuers() {
+		provider, ok := providers[iss.IssuerURL]
+		if !ok {
+			return fmt.Errorf("provider %s: not discovered", iss.IssuerURL)
+		}
+		verifier, err := fc.newVerifier(iss, provider)
+		if err != nil {
+			return fmt.Errorf("provider %s: %w", iss.IssuerURL, err)
+		}
+		d.verifiers[iss.IssuerURL] = verifier
+	}
+
+	fc.discovered.Store(d)
+	fc.lru.Purge()
+	return nil
+}
+
+// FlushDiscovery repeats OIDC discovery for every issuer, which also
+// discards their cached signing keys. If discovery fails for any issuer,
+// the existing verifiers are kept.
+func (fc *FulcioConfig) FlushDiscovery(ctx context.Context) error {
+	fc.responses.purge()
+	d, err := fc.discover(ctx)
+	if err != nil {
+		return err
+	}
+
+	fc.discovered.Store(d)
+	fc.lru.Purge()
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestFlushJWKS(t *testing.T) {
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk := jose.JSONWebKey{Algorithm: string(jose.ES256), Key: pk, KeyID: "key"}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: jwk}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var issuer string
+	fetches := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                issuer,
+			"jwks_uri":                              issuer + "/keys",
+			"id_token_signing_alg_values_supported": []string{"ES256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{jwk.Public()}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	issuer = server.URL
+
+	cfg, err := Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, issuer, issuer)))
+	if err != nil {
+		t.Fatalf("Read() = %v", err)
+	}
+	tok, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Issuer:   issuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  "foo@example.com",
+		Audience: jwt.Audience{"sigstore"},
+	}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		verifier, ok := cfg.GetVerifier(issuer)
+		if !ok {
+			t.Fatal("expected verifier for issuer")
+		}
+		// The verifier still accepts the algorithms the issuer advertised
+		if _, err := verifier.Verify(context.Background(), tok); err != nil {
+			t.Fatalf("Verify() = %v", err)
+		}
+		if fetches != i {
+			t.Fatalf("expected %d JWKS fetches, got %d", i, fetches)
+		}
+		if err := cfg.FlushJWKS(); err != nil {
+			t.Fatalf("FlushJWKS() = %v", err)
+		}
+	}
+}
+
+func TestFlushJWKSUndiscoveredIssuer(t *testing.T) {
+	cfg := &FulcioConfig{
+		OIDCIssuers: map[string]OIDCIssuer{
+			"https://issuer.example.com": {
+				IssuerURL: "https://issuer.example.com",
+				ClientID:  "sigstore",
+				Type:      IssuerTypeEmail,
+			},
+		},
+	}
+	err := cfg.FlushJWKS()
+	if err == nil || !strings.Contains(err.Error(), "https://issuer.example.com: not discovered") {
+		t.Fatalf("expected error for undiscovered issuer, got %v", err)
+	}
+}
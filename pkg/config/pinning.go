@@ -0,0 +1,112 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sigstore/fulcio/pkg/log"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// pinnedJWKThumbprints returns the parsed PinnedJWKThumbprints as a set.
+func (iss OIDCIssuer) pinnedJWKThumbprints() (map[string]bool, error) {
+	pinned := make(map[string]bool, len(iss.PinnedJWKThumbprints))
+	for _, thumbprint := range iss.PinnedJWKThumbprints {
+		b, err := base64.RawURLEncoding.DecodeString(thumbprint)
+		if err != nil || len(b) != crypto.SHA256.Size() {
+			return nil, fmt.Errorf("PinnedJWKThumbprints: %q is not a base64url encoded SHA-256 JWK thumbprint", thumbprint)
+		}
+		pinned[thumbprint] = true
+	}
+	return pinned, nil
+}
+
+// pinnedKeysTransport removes keys that aren't pinned from JWKS responses,
+// so that go-oidc never verifies a token with them, even if the issuer's
+// JWKS endpoint serves them. Discovery documents are passed through; any
+// other response must be a JWKS with at least one pinned key, or the request
+// fails.
+type pinnedKeysTransport struct {
+	issuerURL string
+	pinned    map[string]bool
+	base      http.RoundTripper
+}
+
+func (t *pinnedKeysTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || strings.HasSuffix(req.URL.Path, discoveryPath) {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if body, err = t.filterKeys(body); err != nil {
+		return nil, fmt.Errorf("JWKS from issuer %s: %w", t.issuerURL, err)
+	}
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	resp.ContentLength = int64(len(body))
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// discoveryPath is the path of OpenID Connect discovery documents, relative
+// to the issuer URL.
+const discoveryPath = "/.well-known/openid-configuration"
+
+// filterKeys returns a JWKS of only the pinned keys in body. It decodes the
+// JWKS as go-oidc does, so that keys can't be smuggled past the filter under
+// another spelling of the "keys" member, and re-encodes the pinned keys
+// rather than editing body.
+func (t *pinnedKeysTransport) filterKeys(body []byte) ([]byte, error) {
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal(body, &jwks); err != nil {
+		return nil, fmt.Errorf("parsing JWKS: %w", err)
+	}
+
+	var pinned []jose.JSONWebKey
+	for _, key := range jwks.Keys {
+		thumbprint, err := key.Thumbprint(crypto.SHA256)
+		if err != nil {
+			log.Logger.Warnf("Ignoring key %q served by issuer %s: %v", key.KeyID, t.issuerURL, err)
+			continue
+		}
+		if t.pinned[base64.RawURLEncoding.EncodeToString(thumbprint)] {
+			pinned = append(pinned, key)
+		} else {
+			log.Logger.Warnf("Ignoring unpinned key %q served by issuer %s", key.KeyID, t.issuerURL)
+		}
+	}
+	if len(pinned) == 0 {
+		return nil, errors.New("no pinned keys")
+	}
+	return json.Marshal(jose.JSONWebKeySet{Keys: pinned})
+}
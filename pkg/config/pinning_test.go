@@ -0,0 +1,205 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestIssuerPinnedJWKThumbprints(t *testing.T) {
+	newKey := func(kid string) (jose.JSONWebKey, jose.Signer) {
+		t.Helper()
+		pk, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jwk := jose.JSONWebKey{Algorithm: string(jose.RS256), Key: pk, KeyID: kid}
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jwk}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return jwk.Public(), signer
+	}
+	pinnedKey, pinnedSigner := newKey("pinned")
+	rogueKey, rogueSigner := newKey("rogue")
+	thumbprint, err := pinnedKey.Thumbprint(crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The JWKS endpoint serves a rogue key alongside the pinned one
+	var issuer string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   issuer,
+			"jwks_uri": issuer + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{pinnedKey, rogueKey}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	issuer = server.URL
+
+	cfg, err := Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email",
+				"PinnedJWKThumbprints": [%q]
+			}
+		}
+	}`, issuer, issuer, base64.RawURLEncoding.EncodeToString(thumbprint))))
+	if err != nil {
+		t.Fatalf("Read() = %v", err)
+	}
+
+	sign := func(signer jose.Signer) string {
+		t.Helper()
+		tok, err := jwt.Signed(signer).Claims(jwt.Claims{
+			Issuer:   issuer,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+			Subject:  "foo@example.com",
+			Audience: jwt.Audience{"sigstore"},
+		}).CompactSerialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+
+	verifier, ok := cfg.GetVerifier(issuer)
+	if !ok {
+		t.Fatal("expected verifier for issuer")
+	}
+	if _, err := verifier.Verify(context.Background(), sign(pinnedSigner)); err != nil {
+		t.Fatalf("expected token signed by pinned key to verify, got %v", err)
+	}
+	if _, err := verifier.Verify(context.Background(), sign(rogueSigner)); err == nil {
+		t.Fatal("expected token signed by unpinned key to be rejected")
+	}
+
+	// Pinning also applies after the JWKS cache is flushed
+	if err := cfg.FlushJWKS(); err != nil {
+		t.Fatalf("FlushJWKS() = %v", err)
+	}
+	verifier, _ = cfg.GetVerifier(issuer)
+	if _, err := verifier.Verify(context.Background(), sign(rogueSigner)); err == nil {
+		t.Fatal("expected token signed by unpinned key to be rejected after flushing")
+	}
+}
+
+func TestPinnedKeysTransportFilterKeys(t *testing.T) {
+	newKey := func(kid string) string {
+		t.Helper()
+		pk, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(jose.JSONWebKey{Algorithm: string(jose.RS256), Key: pk.Public(), KeyID: kid})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	pinnedKey, rogueKey := newKey("pinned"), newKey("rogue")
+	var key jose.JSONWebKey
+	if err := json.Unmarshal([]byte(pinnedKey), &key); err != nil {
+		t.Fatal(err)
+	}
+	thumbprint, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &pinnedKeysTransport{
+		issuerURL: "https://issuer.example.com",
+		pinned:    map[string]bool{base64.RawURLEncoding.EncodeToString(thumbprint): true},
+	}
+
+	tests := map[string]struct {
+		Body    string
+		WantErr bool
+	}{
+		"unpinned keys are removed": {
+			Body: `{"keys":[` + pinnedKey + `,` + rogueKey + `]}`,
+		},
+		"mixed-case keys member is filtered": {
+			Body: `{"Keys":[` + pinnedKey + `,` + rogueKey + `]}`,
+		},
+		"mixed-case keys member with only unpinned keys fails": {
+			Body:    `{"Keys":[` + rogueKey + `]}`,
+			WantErr: true,
+		},
+		"duplicate keys member replacing the pinned keys fails": {
+			Body:    `{"keys":[` + pinnedKey + `],"KEYS":[` + rogueKey + `]}`,
+			WantErr: true,
+		},
+		"duplicate keys member is filtered": {
+			Body: `{"keys":[` + rogueKey + `],"KEYS":[` + pinnedKey + `,` + rogueKey + `]}`,
+		},
+		"missing keys member fails": {
+			Body:    `{"issuer":"https://issuer.example.com"}`,
+			WantErr: true,
+		},
+		"keys member that isn't an array fails": {
+			Body:    `{"keys":` + rogueKey + `}`,
+			WantErr: true,
+		},
+		"body that isn't JSON fails": {
+			Body:    `not json`,
+			WantErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			filtered, err := transport.filterKeys([]byte(test.Body))
+			if test.WantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s", filtered)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("filterKeys() = %v", err)
+			}
+			// Decode as go-oidc does
+			var jwks jose.JSONWebKeySet
+			if err := json.Unmarshal(filtered, &jwks); err != nil {
+				t.Fatal(err)
+			}
+			if len(jwks.Keys) != 1 || jwks.Keys[0].KeyID != "pinned" {
+				t.Fatalf("expected only the pinned key, got %s", filtered)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PolicyType is the kind of a pre-issuance Policy.
+type PolicyType string
+
+const (
+	// PolicyTypeAllowlist only allows requests whose value is listed.
+	PolicyTypeAllowlist PolicyType = "allowlist"
+	// PolicyTypeDenylist rejects requests whose value is listed.
+	PolicyTypeDenylist PolicyType = "denylist"
+)
+
+// Policy configures a built-in pre-issuance policy, which decides whether a
+// certificate may be issued after the token has been authenticated.
+type Policy struct {
+	// The kind of policy, "allowlist" or "denylist"
+	Type PolicyType `json:"Type"`
+	// Optional, the top level string claim of the token that is matched
+	// against Values. Defaults to the identity of the certificate, as signed
+	// in the proof of possession challenge.
+	Claim string `json:"Claim,omitempty"`
+	// The values that are allowed or denied, matched exactly
+	Values []string `json:"Values,omitempty"`
+}
+
+func (p Policy) validate() error {
+	switch p.Type {
+	case PolicyTypeAllowlist, PolicyTypeDenylist:
+	default:
+		return fmt.Errorf("unknown type %q, must be %s or %s", p.Type, PolicyTypeAllowlist, PolicyTypeDenylist)
+	}
+	if len(p.Values) == 0 {
+		return errors.New("at least one value is required")
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"context"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/certificate"
+)
+
+var oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+// Profile bundles the policy for a kind of certificate, such as code signing
+// or document signing. Issuers list the profiles they may request.
+type Profile struct {
+	// Extended key usages of issued certificates, as dotted OIDs. Defaults to
+	// code signing if empty.
+	ExtKeyUsages []string `json:"ExtKeyUsages,omitempty"`
+	// Validity of issued certificates as a duration, e.g. "10m". Defaults to
+	// 10 minutes if empty.
+	Validity string `json:"Validity,omitempty"`
+	// Extensions, as dotted OIDs, that are omitted from issued certificates.
+	// The issuer and subject alternative name extensions cannot be excluded.
+	ExcludeExtensions []string `json:"ExcludeExtensions,omitempty"`
+}
+
+// ExtKeyUsageOIDs returns the parsed ExtKeyUsages.
+func (p Profile) ExtKeyUsageOIDs() ([]asn1.ObjectIdentifier, error) {
+	oids, err := parseOIDs(p.ExtKeyUsages)
+	if err != nil {
+		return nil, fmt.Errorf("extended key usage %w", err)
+	}
+	for _, oid := range oids {
+		if oid.Equal(oidAnyExtendedKeyUsage) {
+			return nil, errors.New("anyExtendedKeyUsage must not be added to issued certificates")
+		}
+	}
+	return oids, nil
+}
+
+// ValidityDuration returns the parsed Validity, or zero if unset.
+func (p Profile) ValidityDuration() (time.Duration, error) {
+	if p.Validity == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(p.Validity)
+	if err != nil {
+		return 0, fmt.Errorf("validity: %w", err)
+	}
+	if d <= 0 {
+		return 0, errors.New("validity must be positive")
+	}
+	return d, nil
+}
+
+// ExcludeExtensionOIDs returns the parsed ExcludeExtensions.
+func (p Profile) ExcludeExtensionOIDs() ([]asn1.ObjectIdentifier, error) {
+	oids, err := parseOIDs(p.ExcludeExtensions)
+	if err != nil {
+		return nil, fmt.Errorf("excluded extension %w", err)
+	}
+	for _, oid := range oids {
+		if oid.Equal(certificate.OIDIssuer) || oid.Equal(oidSubjectAltName) {
+			return nil, fmt.Errorf("extension %v cannot be excluded", oid)
+		}
+	}
+	return oids, nil
+}
+
+func (p Profile) validate() error {
+	if _, err := p.ExtKeyUsageOIDs(); err != nil {
+		return err
+	}
+	if _, err := p.ValidityDuration(); err != nil {
+		return err
+	}
+	if _, err := p.ExcludeExtensionOIDs(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetProfile looks up the profile `name` requested for a token from
+// `issuerURL`. An empty name selects the default code signing profile and
+// returns nil. It is an error to request a profile that does not exist or
+// is not allowed for the issuer.
+func (fc *FulcioConfig) GetProfile(issuerURL, name string) (*Profile, error) {
+	if name == "" {
+		return nil, nil
+	}
+	iss, ok := fc.GetIssuer(issuerURL)
+	if !ok {
+		return nil, fmt.Errorf("unknown issuer %s", issuerURL)
+	}
+	allowed := false
+	for _, p := range iss.Profiles {
+		if p == name {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("profile %s is not allowed for issuer %s", name, issuerURL)
+	}
+	profile, ok := fc.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %s", name)
+	}
+	return &profile, nil
+}
+
+type profileKey struct{}
+
+// WithProfile attaches the profile selected for a request to the context.
+func WithProfile(ctx context.Context, profile *Profile) context.Context {
+	return context.WithValue(ctx, profileKey{}, profile)
+}
+
+// ProfileFromContext returns the profile selected for a request, or nil if
+// the default profile should be used.
+func ProfileFromContext(ctx context.Context) *Profile {
+	untyped := ctx.Value(profileKey{})
+	if untyped == nil {
+		return nil
+	}
+	return untyped.(*Profile)
+}
+
+func parseOIDs(oids []string) ([]asn1.ObjectIdentifier, error) {
+	parsed := make([]asn1.ObjectIdentifier, 0, len(oids))
+	for _, s := range oids {
+		oid, err := parseOID(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", s, err)
+		}
+		parsed = append(parsed, oid)
+	}
+	return parsed, nil
+}
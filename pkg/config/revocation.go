@@ -0,0 +1,100 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Revocation revokes a certificate issued by this instance, e.g. after its
+// key was compromised. Revocations are reported by the OCSP responder and CRL.
+type Revocation struct {
+	// The serial number of the certificate, in hex, e.g. as printed by
+	// openssl. Colons are allowed between bytes.
+	SerialNumber string `json:"SerialNumber"`
+	// When the certificate was revoked, in RFC 3339 format
+	RevokedAt string `json:"RevokedAt"`
+	// Optional, the RFC 5280 CRLReason code, e.g. 1 for keyCompromise.
+	// Defaults to 0, unspecified.
+	Reason int `json:"Reason,omitempty"`
+}
+
+// RevokedCertificate is a parsed Revocation.
+type RevokedCertificate struct {
+	SerialNumber *big.Int
+	RevokedAt    time.Time
+	Reason       int
+}
+
+// RevokedCertificates returns the parsed Revocations.
+func (fc *FulcioConfig) RevokedCertificates() ([]RevokedCertificate, error) {
+	if fc == nil {
+		return nil, nil
+	}
+	revoked := make([]RevokedCertificate, 0, len(fc.Revocations))
+	for _, r := range fc.Revocations {
+		rc, err := r.parse()
+		if err != nil {
+			return nil, fmt.Errorf("revocation of %s: %w", r.SerialNumber, err)
+		}
+		revoked = append(revoked, rc)
+	}
+	return revoked, nil
+}
+
+// Revoked returns the revocation of the certificate with the given serial
+// number, if it is revoked.
+func (fc *FulcioConfig) Revoked(serial *big.Int) (RevokedCertificate, bool, error) {
+	revoked, err := fc.RevokedCertificates()
+	if err != nil {
+		return RevokedCertificate{}, false, err
+	}
+	for _, rc := range revoked {
+		if rc.SerialNumber.Cmp(serial) == 0 {
+			return rc, true, nil
+		}
+	}
+	return RevokedCertificate{}, false, nil
+}
+
+func (r Revocation) parse() (RevokedCertificate, error) {
+	serial, ok := new(big.Int).SetString(strings.ReplaceAll(r.SerialNumber, ":", ""), 16)
+	if !ok {
+		return RevokedCertificate{}, errors.New("SerialNumber must be hex")
+	}
+	revokedAt, err := time.Parse(time.RFC3339, r.RevokedAt)
+	if err != nil {
+		return RevokedCertificate{}, fmt.Errorf("RevokedAt: %w", err)
+	}
+	if !ValidCRLReason(r.Reason) {
+		return RevokedCertificate{}, fmt.Errorf("invalid Reason %d", r.Reason)
+	}
+	return RevokedCertificate{
+		SerialNumber: serial,
+		RevokedAt:    revokedAt,
+		Reason:       r.Reason,
+	}, nil
+}
+
+// ValidCRLReason reports whether reason is an RFC 5280 CRLReason code.
+func ValidCRLReason(reason int) bool {
+	// 7 is not used, and 10 (aACompromise) is the highest reason code
+	return reason >= 0 && reason != 7 && reason <= 10
+}
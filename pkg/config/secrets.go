@@ -0,0 +1,120 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+const (
+	envSecretPrefix  = "env://"
+	fileSecretPrefix = "file://"
+)
+
+// SecretProvider resolves a secret reference from the configuration into
+// its value.
+type SecretProvider interface {
+	GetSecret(ref string) (string, error)
+}
+
+// DefaultSecretProvider is used to resolve secret references in the
+// configuration, such as issuer HTTP header values.
+var DefaultSecretProvider SecretProvider = referenceSecretProvider{}
+
+// referenceSecretProvider resolves references of the form
+// `env://NAME`, read from the environment variable NAME, and
+// `file:///path/to/secret`, read from a file with surrounding whitespace
+// trimmed. The latter works with secrets mounted into a pod.
+type referenceSecretProvider struct{}
+
+func (referenceSecretProvider) GetSecret(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, envSecretPrefix):
+		name := strings.TrimPrefix(ref, envSecretPrefix)
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return val, nil
+	case strings.HasPrefix(ref, fileSecretPrefix):
+		b, err := os.ReadFile(strings.TrimPrefix(ref, fileSecretPrefix))
+		if err != nil {
+			return "", fmt.Errorf("read secret: %w", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	default:
+		return "", fmt.Errorf("unsupported secret reference %q, must start with %s or %s", ref, envSecretPrefix, fileSecretPrefix)
+	}
+}
+
+// headerTransport adds static headers to every outgoing request.
+type headerTransport struct {
+	headers http.Header
+	base    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, vals := range t.headers {
+		req.Header[k] = vals
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// clientContext returns a context that go-oidc will use for discovery and
+// JWKS requests to the issuer. If the issuer has HTTPHeaders configured,
+// their values are resolved with DefaultSecretProvider and attached to
+// every request. If it has PinnedJWKThumbprints, keys that aren't pinned
+// are removed from JWKS responses. Responses are cached in responses, if set.
+func (iss OIDCIssuer) clientContext(ctx context.Context, responses *responseCache) (context.Context, error) {
+	if len(iss.HTTPHeaders) == 0 && len(iss.PinnedJWKThumbprints) == 0 && responses == nil {
+		return ctx, nil
+	}
+	var transport http.RoundTripper
+	if len(iss.HTTPHeaders) > 0 {
+		headers := make(http.Header, len(iss.HTTPHeaders))
+		for name, ref := range iss.HTTPHeaders {
+			val, err := DefaultSecretProvider.GetSecret(ref)
+			if err != nil {
+				return nil, fmt.Errorf("header %s: %w", name, err)
+			}
+			headers.Set(name, val)
+		}
+		transport = &headerTransport{headers: headers}
+	}
+	if responses != nil {
+		transport = responses.transport(transport)
+	}
+	if len(iss.PinnedJWKThumbprints) > 0 {
+		pinned, err := iss.pinnedJWKThumbprints()
+		if err != nil {
+			return nil, err
+		}
+		transport = &pinnedKeysTransport{issuerURL: iss.IssuerURL, pinned: pinned, base: transport}
+	}
+	client := &http.Client{Transport: transport}
+	return oidc.ClientContext(ctx, client), nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestReferenceSecretProvider(t *testing.T) {
+	t.Setenv("FULCIO_TEST_SECRET", "from-env")
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		"env":           {ref: "env://FULCIO_TEST_SECRET", want: "from-env"},
+		"file":          {ref: "file://" + path, want: "from-file"},
+		"unset env":     {ref: "env://FULCIO_TEST_SECRET_UNSET", wantErr: true},
+		"missing file":  {ref: "file://" + path + ".missing", wantErr: true},
+		"literal value": {ref: "hunter2", wantErr: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := referenceSecretProvider{}.GetSecret(test.ref)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("GetSecret(%q) error = %v, wantErr %v", test.ref, err, test.wantErr)
+			}
+			if got != test.want {
+				t.Fatalf("GetSecret(%q) = %q, want %q", test.ref, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIssuerHTTPHeaders(t *testing.T) {
+	const apiKey = "s3cr3t"
+	t.Setenv("FULCIO_TEST_IDP_API_KEY", apiKey)
+
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk := jose.JSONWebKey{Algorithm: string(jose.RS256), Key: pk}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: pk}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var issuer string
+	var jwksRequests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != apiKey {
+			http.Error(w, "missing API key", http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   issuer,
+			"jwks_uri": issuer + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != apiKey {
+			http.Error(w, "missing API key", http.StatusUnauthorized)
+			return
+		}
+		atomic.AddInt32(&jwksRequests, 1)
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{jwk.Public()}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	issuer = server.URL
+
+	cfgTmpl := `{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"%s
+			}
+		}
+	}`
+
+	// Without the header, discovery is rejected by the issuer
+	if _, err := Read([]byte(fmt.Sprintf(cfgTmpl, issuer, issuer, ""))); err == nil {
+		t.Fatal("expected discovery to fail without the API key header")
+	}
+
+	// A reference to an unset secret fails to load
+	unset := `,
+				"HTTPHeaders": {"X-Api-Key": "env://FULCIO_TEST_IDP_API_KEY_UNSET"}`
+	if _, err := Read([]byte(fmt.Sprintf(cfgTmpl, issuer, issuer, unset))); err == nil || !strings.Contains(err.Error(), "X-Api-Key") {
+		t.Fatalf("expected error resolving header secret, got %v", err)
+	}
+
+	headers := `,
+				"HTTPHeaders": {"X-Api-Key": "env://FULCIO_TEST_IDP_API_KEY"}`
+	cfg, err := Read([]byte(fmt.Sprintf(cfgTmpl, issuer, issuer, headers)))
+	if err != nil {
+		t.Fatalf("Read() = %v", err)
+	}
+
+	tok, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Issuer:   issuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  "foo@example.com",
+		Audience: jwt.Audience{"sigstore"},
+	}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, ok := cfg.GetVerifier(issuer)
+	if !ok {
+		t.Fatal("expected verifier for issuer")
+	}
+	if _, err := verifier.Verify(context.Background(), tok); err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+	if atomic.LoadInt32(&jwksRequests) == 0 {
+		t.Fatal("expected JWKS to be fetched")
+	}
+}
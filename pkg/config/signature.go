@@ -0,0 +1,87 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"crypto/x509"
+	"fmt"
+)
+
+// Key types of certificate public keys, used to select the signature
+// algorithm of issued certificates.
+const (
+	KeyTypeECDSAP256 = "ecdsa-p256"
+	KeyTypeECDSAP384 = "ecdsa-p384"
+	KeyTypeECDSAP521 = "ecdsa-p521"
+	KeyTypeRSA       = "rsa"
+	KeyTypeEd25519   = "ed25519"
+)
+
+var keyTypes = map[string]bool{
+	KeyTypeECDSAP256: true,
+	KeyTypeECDSAP384: true,
+	KeyTypeECDSAP521: true,
+	KeyTypeRSA:       true,
+	KeyTypeEd25519:   true,
+}
+
+// signatureAlgorithms are the algorithms that may be configured, by the
+// names used by crypto/x509.
+var signatureAlgorithms = func() map[string]x509.SignatureAlgorithm {
+	algs := make(map[string]x509.SignatureAlgorithm)
+	for _, alg := range []x509.SignatureAlgorithm{
+		x509.ECDSAWithSHA256,
+		x509.ECDSAWithSHA384,
+		x509.ECDSAWithSHA512,
+		x509.SHA256WithRSA,
+		x509.SHA384WithRSA,
+		x509.SHA512WithRSA,
+		x509.SHA256WithRSAPSS,
+		x509.SHA384WithRSAPSS,
+		x509.SHA512WithRSAPSS,
+		x509.PureEd25519,
+	} {
+		algs[alg.String()] = alg
+	}
+	return algs
+}()
+
+// GetSignatureAlgorithm returns the signature algorithm configured for
+// certificates with a public key of keyType, one of the KeyType constants.
+// If none is configured, it returns false.
+func (fc *FulcioConfig) GetSignatureAlgorithm(keyType string) (x509.SignatureAlgorithm, bool, error) {
+	name, ok := fc.SignatureAlgorithms[keyType]
+	if !ok {
+		return x509.UnknownSignatureAlgorithm, false, nil
+	}
+	alg, ok := signatureAlgorithms[name]
+	if !ok {
+		return x509.UnknownSignatureAlgorithm, false, fmt.Errorf("unsupported signature algorithm %q for key type %s", name, keyType)
+	}
+	return alg, true, nil
+}
+
+func (fc *FulcioConfig) validateSignatureAlgorithms() error {
+	for keyType := range fc.SignatureAlgorithms {
+		if !keyTypes[keyType] {
+			return fmt.Errorf("unknown key type %q for signature algorithm", keyType)
+		}
+		if _, _, err := fc.GetSignatureAlgorithm(keyType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
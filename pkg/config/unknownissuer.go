@@ -0,0 +1,65 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// maxUnknownIssuerLength bounds how much of an unknown iss claim is
+// reflected back to the client.
+const maxUnknownIssuerLength = 256
+
+// RenderUnknownIssuerMessage renders the configured UnknownIssuerMessage for
+// a token from issuer. It returns false if no message is configured, or the
+// template fails to render, so callers should fall back to their default.
+func (fc *FulcioConfig) RenderUnknownIssuerMessage(issuer string) (string, bool) {
+	if fc == nil || fc.UnknownIssuerMessage == "" {
+		return "", false
+	}
+	if len(issuer) > maxUnknownIssuerLength {
+		issuer = issuer[:maxUnknownIssuerLength] + "..."
+	}
+	msg, err := renderUnknownIssuerMessage(fc.UnknownIssuerMessage, issuer)
+	if err != nil {
+		return "", false
+	}
+	return msg, true
+}
+
+func renderUnknownIssuerMessage(text, issuer string) (string, error) {
+	tmpl, err := template.New("UnknownIssuerMessage").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, struct{ Issuer string }{issuer}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (fc *FulcioConfig) validateUnknownIssuerMessage() error {
+	if fc.UnknownIssuerMessage == "" {
+		return nil
+	}
+	if _, err := renderUnknownIssuerMessage(fc.UnknownIssuerMessage, "https://issuer.example.com"); err != nil {
+		return fmt.Errorf("invalid UnknownIssuerMessage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ctl
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	ct "github.com/google/certificate-transparency-go"
+	ctclient "github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/tls"
+)
+
+// AddChain submits a certificate chain to the log. If the log reports that
+// the chain is a duplicate of an existing entry and returns that entry's SCT,
+// the SCT is verified and returned as if the submission had succeeded, which
+// makes retrying a submission safe.
+func AddChain(ctx context.Context, c *ctclient.LogClient, chain []ct.ASN1Cert) (*ct.SignedCertificateTimestamp, error) {
+	sct, err := c.AddChain(ctx, chain)
+	if err != nil {
+		return duplicateSCT(c, err, ct.X509LogEntryType, chain)
+	}
+	return sct, nil
+}
+
+// AddPreChain submits a precertificate chain to the log, handling duplicate
+// entry responses like AddChain.
+func AddPreChain(ctx context.Context, c *ctclient.LogClient, chain []ct.ASN1Cert) (*ct.SignedCertificateTimestamp, error) {
+	sct, err := c.AddPreChain(ctx, chain)
+	if err != nil {
+		return duplicateSCT(c, err, ct.PrecertLogEntryType, chain)
+	}
+	return sct, nil
+}
+
+// duplicateSCT returns the SCT in a duplicate entry response, a 409 Conflict
+// with an add-chain response body. Any other error is returned unchanged.
+func duplicateSCT(c *ctclient.LogClient, err error, ctype ct.LogEntryType, chain []ct.ASN1Cert) (*ct.SignedCertificateTimestamp, error) {
+	var rspErr ctclient.RspError
+	if !errors.As(err, &rspErr) || rspErr.StatusCode != http.StatusConflict {
+		return nil, err
+	}
+	var resp ct.AddChainResponse
+	if jsonErr := json.Unmarshal(rspErr.Body, &resp); jsonErr != nil || len(resp.Signature) == 0 {
+		return nil, err
+	}
+	sct, parseErr := fromAddChainResponse(&resp)
+	if parseErr != nil {
+		return nil, fmt.Errorf("parsing SCT in duplicate entry response: %w", parseErr)
+	}
+	if verifyErr := c.VerifySCTSignature(*sct, ctype, chain); verifyErr != nil {
+		return nil, fmt.Errorf("verifying SCT in duplicate entry response: %w", verifyErr)
+	}
+	return sct, nil
+}
+
+// fromAddChainResponse is the inverse of ToAddChainResponse.
+func fromAddChainResponse(resp *ct.AddChainResponse) (*ct.SignedCertificateTimestamp, error) {
+	var ds ct.DigitallySigned
+	if rest, err := tls.Unmarshal(resp.Signature, &ds); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, fmt.Errorf("trailing data (%d bytes) after DigitallySigned", len(rest))
+	}
+	exts, err := base64.StdEncoding.DecodeString(resp.Extensions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 data in Extensions: %w", err)
+	}
+	var logID ct.LogID
+	copy(logID.KeyID[:], resp.ID)
+	return &ct.SignedCertificateTimestamp{
+		SCTVersion: resp.SCTVersion,
+		LogID:      logID,
+		Timestamp:  resp.Timestamp,
+		Extensions: ct.CTExtensions(exts),
+		Signature:  ds,
+	}, nil
+}
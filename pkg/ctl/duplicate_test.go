@@ -0,0 +1,144 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ctl
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	ctclient "github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/tls"
+)
+
+func TestAddChainDuplicate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(10 * time.Minute),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := []ct.ASN1Cert{{Data: certDER}}
+
+	// Sign an SCT for the chain, as the log did when the chain was first
+	// submitted
+	sct := ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		LogID:      ct.LogID{KeyID: sha256.Sum256(pubDER)},
+		Timestamp:  uint64(time.Now().UnixMilli()),
+		Extensions: ct.CTExtensions{},
+	}
+	leaf, err := ct.MerkleTreeLeafFromRawChain(chain, ct.X509LogEntryType, sct.Timestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, err := ct.SerializeSCTSignatureInput(sct, ct.LogEntry{Leaf: *leaf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := tls.CreateSignature(*key, tls.SHA256, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sct.Signature = ct.DigitallySigned(sig)
+	sctResp, err := ToAddChainResponse(&sct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	badSCT := sct
+	badSCT.Timestamp++
+	badSCTResp, err := ToAddChainResponse(&badSCT)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		status  int
+		body    interface{}
+		wantErr bool
+	}{
+		"duplicate with SCT": {
+			status: http.StatusConflict,
+			body:   sctResp,
+		},
+		"duplicate with invalid SCT": {
+			status:  http.StatusConflict,
+			body:    badSCTResp,
+			wantErr: true,
+		},
+		"duplicate without SCT": {
+			status:  http.StatusConflict,
+			body:    map[string]string{"error": "duplicate entry"},
+			wantErr: true,
+		},
+		"other error with SCT": {
+			status:  http.StatusBadRequest,
+			body:    sctResp,
+			wantErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+				_ = json.NewEncoder(w).Encode(test.body)
+			}))
+			defer server.Close()
+
+			client, err := ctclient.New(server.URL, server.Client(), jsonclient.Options{PublicKeyDER: pubDER})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := AddChain(context.Background(), client, chain)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddChain() = %v", err)
+			}
+			if !reflect.DeepEqual(*got, sct) {
+				t.Fatalf("AddChain() = %+v, want %+v", *got, sct)
+			}
+		})
+	}
+}
@@ -15,6 +15,8 @@
 package ctl
 
 import (
+	"crypto"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"fmt"
@@ -49,3 +51,13 @@ func ToAddChainResponse(sct *ct.SignedCertificateTimestamp) (*ct.AddChainRespons
 
 	return addChainResp, nil
 }
+
+// LogID returns the ID of the CT log with the given public key, the SHA-256
+// hash of its DER encoding.
+func LogID(pub crypto.PublicKey) ([32]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("failed to marshal CT log public key: %w", err)
+	}
+	return sha256.Sum256(der), nil
+}
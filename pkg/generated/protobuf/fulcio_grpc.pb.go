@@ -31,6 +31,14 @@ type CAClient interface {
 	// *
 	// Returns the configuration of supported OIDC issuers, including the required challenge for each issuer.
 	GetConfiguration(ctx context.Context, in *GetConfigurationRequest, opts ...grpc.CallOption) (*Configuration, error)
+	// *
+	// Verifies that a certificate chains to the trust bundle of this Fulcio instance and returns the
+	// Sigstore extensions it contains. No credentials are required.
+	VerifySigningCertificate(ctx context.Context, in *VerifySigningCertificateRequest, opts ...grpc.CallOption) (*SigningCertificateVerification, error)
+	// *
+	// Returns a single-use nonce to send to the OIDC issuer in an interactive flow. Issuers that
+	// require a nonce only accept tokens whose nonce claim was issued by this Fulcio instance.
+	CreateNonce(ctx context.Context, in *CreateNonceRequest, opts ...grpc.CallOption) (*Nonce, error)
 }
 
 type cAClient struct {
@@ -68,6 +76,24 @@ func (c *cAClient) GetConfiguration(ctx context.Context, in *GetConfigurationReq
 	return out, nil
 }
 
+func (c *cAClient) VerifySigningCertificate(ctx context.Context, in *VerifySigningCertificateRequest, opts ...grpc.CallOption) (*SigningCertificateVerification, error) {
+	out := new(SigningCertificateVerification)
+	err := c.cc.Invoke(ctx, "/dev.sigstore.fulcio.v2.CA/VerifySigningCertificate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cAClient) CreateNonce(ctx context.Context, in *CreateNonceRequest, opts ...grpc.CallOption) (*Nonce, error) {
+	out := new(Nonce)
+	err := c.cc.Invoke(ctx, "/dev.sigstore.fulcio.v2.CA/CreateNonce", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CAServer is the server API for CA service.
 // All implementations must embed UnimplementedCAServer
 // for forward compatibility
@@ -81,6 +107,14 @@ type CAServer interface {
 	// *
 	// Returns the configuration of supported OIDC issuers, including the required challenge for each issuer.
 	GetConfiguration(context.Context, *GetConfigurationRequest) (*Configuration, error)
+	// *
+	// Verifies that a certificate chains to the trust bundle of this Fulcio instance and returns the
+	// Sigstore extensions it contains. No credentials are required.
+	VerifySigningCertificate(context.Context, *VerifySigningCertificateRequest) (*SigningCertificateVerification, error)
+	// *
+	// Returns a single-use nonce to send to the OIDC issuer in an interactive flow. Issuers that
+	// require a nonce only accept tokens whose nonce claim was issued by this Fulcio instance.
+	CreateNonce(context.Context, *CreateNonceRequest) (*Nonce, error)
 	mustEmbedUnimplementedCAServer()
 }
 
@@ -97,6 +131,12 @@ func (UnimplementedCAServer) GetTrustBundle(context.Context, *GetTrustBundleRequ
 func (UnimplementedCAServer) GetConfiguration(context.Context, *GetConfigurationRequest) (*Configuration, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetConfiguration not implemented")
 }
+func (UnimplementedCAServer) VerifySigningCertificate(context.Context, *VerifySigningCertificateRequest) (*SigningCertificateVerification, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifySigningCertificate not implemented")
+}
+func (UnimplementedCAServer) CreateNonce(context.Context, *CreateNonceRequest) (*Nonce, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateNonce not implemented")
+}
 func (UnimplementedCAServer) mustEmbedUnimplementedCAServer() {}
 
 // UnsafeCAServer may be embedded to opt out of forward compatibility for this service.
@@ -164,6 +204,42 @@ func _CA_GetConfiguration_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CA_VerifySigningCertificate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifySigningCertificateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CAServer).VerifySigningCertificate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dev.sigstore.fulcio.v2.CA/VerifySigningCertificate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CAServer).VerifySigningCertificate(ctx, req.(*VerifySigningCertificateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CA_CreateNonce_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateNonceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CAServer).CreateNonce(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dev.sigstore.fulcio.v2.CA/CreateNonce",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CAServer).CreateNonce(ctx, req.(*CreateNonceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CA_ServiceDesc is the grpc.ServiceDesc for CA service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -183,6 +259,14 @@ var CA_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetConfiguration",
 			Handler:    _CA_GetConfiguration_Handler,
 		},
+		{
+			MethodName: "VerifySigningCertificate",
+			Handler:    _CA_VerifySigningCertificate_Handler,
+		},
+		{
+			MethodName: "CreateNonce",
+			Handler:    _CA_CreateNonce_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "fulcio.proto",
@@ -0,0 +1,159 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/identity"
+)
+
+var (
+	// EKS clusters each have an OIDC issuer in their region, named by a
+	// 32 character hex ID, e.g.
+	// https://oidc.eks.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06.
+	// Clusters in China are under amazonaws.com.cn.
+	issuerRegexp = regexp.MustCompile(`^https://oidc\.eks\.[a-z]{2}(-gov)?-[a-z]+-[0-9]+\.amazonaws\.com(\.cn)?/id/[0-9A-F]{32}$`)
+
+	// The sub claim of service account tokens is
+	// system:serviceaccount:<namespace>:<name>
+	subjectRegexp = regexp.MustCompile(`^system:serviceaccount:([a-z0-9]([-a-z0-9]*[a-z0-9])?):([a-z0-9]([-.a-z0-9]*[a-z0-9])?)$`)
+
+	// accountIDRegexp matches the 12 digit ID of an AWS account
+	accountIDRegexp = regexp.MustCompile(`^[0-9]{12}$`)
+)
+
+type rolePrincipal struct {
+	// Subject matches the 'sub' claim from the OIDC ID token, e.g.
+	// system:serviceaccount:default:deploy. This is what is signed as proof
+	// of possession for AWS IRSA identities
+	subject string
+
+	// OIDC Issuer URL. Matches 'iss' claim from ID token, e.g.
+	// https://oidc.eks.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06
+	issuer string
+
+	// ARN of the IAM role the service account assumes, which will be set as
+	// the SubjectAlternativeName URI in the final certificate, e.g.
+	// arn:aws:iam::123456789012:role/deploy
+	roleARN string
+
+	// ID of the AWS account of the role
+	accountID string
+
+	// Namespace of the service account
+	namespace string
+
+	// Name of the service account
+	serviceAccount string
+}
+
+// RolePrincipalFromIDToken returns the principal of an EKS service account
+// token, projected for IAM Roles for Service Accounts (IRSA). The token
+// doesn't name the role the pod assumes, which comes from an annotation on
+// the service account, so the role ARN is looked up by the token's subject
+// in the AWSRoleARNs of the issuer's configuration.
+func RolePrincipalFromIDToken(ctx context.Context, token *oidc.IDToken) (identity.Principal, error) {
+	if !issuerRegexp.MatchString(token.Issuer) {
+		return nil, fmt.Errorf("token issuer %s is not an EKS OIDC issuer", token.Issuer)
+	}
+	cfg := config.FromContext(ctx)
+	if cfg == nil {
+		return nil, errors.New("configuration is required to map service accounts to IAM roles")
+	}
+	iss, ok := cfg.GetIssuer(token.Issuer)
+	if !ok {
+		return nil, fmt.Errorf("configuration can not be loaded for issuer %v", token.Issuer)
+	}
+	if !audienceAllowed(token.Audience, iss.ClientID) {
+		return nil, fmt.Errorf("token audience %v does not include expected audience %s", token.Audience, iss.ClientID)
+	}
+
+	if token.Subject == "" {
+		return nil, errors.New("missing sub claim in ID token")
+	}
+	match := subjectRegexp.FindStringSubmatch(token.Subject)
+	if match == nil {
+		return nil, fmt.Errorf("sub claim %q in ID token is not a Kubernetes service account", token.Subject)
+	}
+	roleARN, ok := iss.AWSRoleARNs[token.Subject]
+	if !ok {
+		return nil, fmt.Errorf("service account %s is not mapped to an IAM role", token.Subject)
+	}
+	// arn:<partition>:iam::<account>:role/<name>
+	fields := strings.SplitN(roleARN, ":", 6)
+	if len(fields) != 6 || !accountIDRegexp.MatchString(fields[4]) || !strings.HasPrefix(fields[5], "role/") {
+		return nil, fmt.Errorf("invalid IAM role ARN %q for service account %s", roleARN, token.Subject)
+	}
+
+	return &rolePrincipal{
+		subject:        token.Subject,
+		issuer:         token.Issuer,
+		roleARN:        roleARN,
+		accountID:      fields[4],
+		namespace:      match[1],
+		serviceAccount: match[3],
+	}, nil
+}
+
+func audienceAllowed(audience []string, expected string) bool {
+	for _, aud := range audience {
+		if aud == expected {
+			return true
+		}
+	}
+	return false
+}
+
+func (p rolePrincipal) Name(ctx context.Context) string {
+	return p.subject
+}
+
+func (p rolePrincipal) Embed(ctx context.Context, cert *x509.Certificate) error {
+	// Set the role ARN to SubjectAlternativeName on certificate
+	parsed, err := url.Parse(p.roleARN)
+	if err != nil {
+		return err
+	}
+	cert.URIs = []*url.URL{parsed}
+
+	// Embed additional information into custom extensions
+	arc, err := config.FromContext(ctx).ExtensionArc()
+	if err != nil {
+		return err
+	}
+	cert.ExtraExtensions, err = certificate.Extensions{
+		Arc:                      arc,
+		Issuer:                   p.issuer,
+		AWSRoleARN:               p.roleARN,
+		AWSAccountID:             p.accountID,
+		KubernetesNamespace:      p.namespace,
+		KubernetesServiceAccount: p.serviceAccount,
+	}.Render()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,391 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/identity"
+)
+
+const (
+	sampleIssuer   = "https://oidc.eks.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06"
+	sampleAudience = "sts.amazonaws.com"
+	sampleSubject  = "system:serviceaccount:default:deploy"
+	sampleRoleARN  = "arn:aws:iam::123456789012:role/deploy"
+	sampleAccount  = "123456789012"
+)
+
+// sampleClaims are the claims of a service account token projected into a
+// pod for IAM Roles for Service Accounts
+func sampleClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"aud": []interface{}{sampleAudience},
+		"exp": 1700086400,
+		"iat": 1700000000,
+		"iss": sampleIssuer,
+		"kubernetes.io": map[string]interface{}{
+			"namespace": "default",
+			"pod": map[string]interface{}{
+				"name": "deploy-7d9c6b5f4-x2k8q",
+				"uid":  "49ad3572-b3dd-43a6-8d77-5858d3660275",
+			},
+			"serviceaccount": map[string]interface{}{
+				"name": "deploy",
+				"uid":  "f5720c1d-e152-4356-a897-11b07aff165d",
+			},
+		},
+		"nbf": 1700000000,
+		"sub": sampleSubject,
+	}
+}
+
+func sampleConfig() *config.FulcioConfig {
+	return &config.FulcioConfig{
+		OIDCIssuers: map[string]config.OIDCIssuer{
+			sampleIssuer: {
+				IssuerURL: sampleIssuer,
+				ClientID:  sampleAudience,
+				Type:      config.IssuerTypeAWSIRSA,
+				AWSRoleARNs: map[string]string{
+					sampleSubject:                      sampleRoleARN,
+					"system:serviceaccount:ci:builder": "arn:aws-cn:iam::210987654321:role/ci/builder",
+					"system:serviceaccount:ci:broken":  "arn:aws:iam::1234:role/broken",
+				},
+			},
+			"https://oidc.eks.cn-north-1.amazonaws.com.cn/id/0123456789ABCDEF0123456789ABCDEF": {
+				IssuerURL: "https://oidc.eks.cn-north-1.amazonaws.com.cn/id/0123456789ABCDEF0123456789ABCDEF",
+				ClientID:  sampleAudience,
+				Type:      config.IssuerTypeAWSIRSA,
+				AWSRoleARNs: map[string]string{
+					sampleSubject: "arn:aws-cn:iam::210987654321:role/deploy",
+				},
+			},
+		},
+	}
+}
+
+func TestRolePrincipalFromIDToken(t *testing.T) {
+	with := func(claim string, value interface{}) map[string]interface{} {
+		claims := sampleClaims()
+		claims[claim] = value
+		return claims
+	}
+	tests := map[string]struct {
+		Claims          map[string]interface{}
+		Config          *config.FulcioConfig
+		ExpectPrincipal rolePrincipal
+		WantErr         bool
+		ErrContains     string
+	}{
+		`Valid token authenticates with correct claims`: {
+			Claims: sampleClaims(),
+			Config: sampleConfig(),
+			ExpectPrincipal: rolePrincipal{
+				subject:        sampleSubject,
+				issuer:         sampleIssuer,
+				roleARN:        sampleRoleARN,
+				accountID:      sampleAccount,
+				namespace:      "default",
+				serviceAccount: "deploy",
+			},
+			WantErr: false,
+		},
+		`Role ARNs may have a path and another partition`: {
+			Claims: with("sub", "system:serviceaccount:ci:builder"),
+			Config: sampleConfig(),
+			ExpectPrincipal: rolePrincipal{
+				subject:        "system:serviceaccount:ci:builder",
+				issuer:         sampleIssuer,
+				roleARN:        "arn:aws-cn:iam::210987654321:role/ci/builder",
+				accountID:      "210987654321",
+				namespace:      "ci",
+				serviceAccount: "builder",
+			},
+			WantErr: false,
+		},
+		`Clusters in China are EKS issuers`: {
+			Claims: with("iss", "https://oidc.eks.cn-north-1.amazonaws.com.cn/id/0123456789ABCDEF0123456789ABCDEF"),
+			Config: sampleConfig(),
+			ExpectPrincipal: rolePrincipal{
+				subject:        sampleSubject,
+				issuer:         "https://oidc.eks.cn-north-1.amazonaws.com.cn/id/0123456789ABCDEF0123456789ABCDEF",
+				roleARN:        "arn:aws-cn:iam::210987654321:role/deploy",
+				accountID:      "210987654321",
+				namespace:      "default",
+				serviceAccount: "deploy",
+			},
+			WantErr: false,
+		},
+		`Token from an issuer that isn't EKS should be rejected`: {
+			Claims:      with("iss", "https://oidc.example.com/id/B71C2D539D4633E53DE1B71E5A4F8C06"),
+			Config:      sampleConfig(),
+			WantErr:     true,
+			ErrContains: "not an EKS OIDC issuer",
+		},
+		`Token from an issuer on another host under amazonaws.com should be rejected`: {
+			Claims:      with("iss", "https://evil.s3.us-west-2.amazonaws.com/id/B71C2D539D4633E53DE1B71E5A4F8C06"),
+			Config:      sampleConfig(),
+			WantErr:     true,
+			ErrContains: "not an EKS OIDC issuer",
+		},
+		`Token from an EKS issuer with a bad cluster ID should be rejected`: {
+			Claims:      with("iss", "https://oidc.eks.us-west-2.amazonaws.com/id/EXAMPLE/../evil"),
+			Config:      sampleConfig(),
+			WantErr:     true,
+			ErrContains: "not an EKS OIDC issuer",
+		},
+		`Token from an unconfigured EKS cluster should be rejected`: {
+			Claims:      with("iss", "https://oidc.eks.eu-west-1.amazonaws.com/id/0123456789ABCDEF0123456789ABCDEF"),
+			Config:      sampleConfig(),
+			WantErr:     true,
+			ErrContains: "configuration can not be loaded",
+		},
+		`Token without configuration should be rejected`: {
+			Claims:      sampleClaims(),
+			WantErr:     true,
+			ErrContains: "configuration is required",
+		},
+		`Token for another audience should be rejected`: {
+			Claims:      with("aud", []interface{}{"sigstore"}),
+			Config:      sampleConfig(),
+			WantErr:     true,
+			ErrContains: "audience",
+		},
+		`Token for a subject that isn't a service account should be rejected`: {
+			Claims:      with("sub", "system:node:ip-10-0-0-1"),
+			Config:      sampleConfig(),
+			WantErr:     true,
+			ErrContains: "not a Kubernetes service account",
+		},
+		`Token without a subject should be rejected`: {
+			Claims:      with("sub", ""),
+			Config:      sampleConfig(),
+			WantErr:     true,
+			ErrContains: "missing sub claim",
+		},
+		`Token for a service account without a role should be rejected`: {
+			Claims:      with("sub", "system:serviceaccount:default:other"),
+			Config:      sampleConfig(),
+			WantErr:     true,
+			ErrContains: "is not mapped to an IAM role",
+		},
+		`Token for a service account with an invalid role ARN should be rejected`: {
+			Claims:      with("sub", "system:serviceaccount:ci:broken"),
+			Config:      sampleConfig(),
+			WantErr:     true,
+			ErrContains: "invalid IAM role ARN",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var audience []string
+			for _, aud := range test.Claims["aud"].([]interface{}) {
+				audience = append(audience, aud.(string))
+			}
+			token := &oidc.IDToken{
+				Issuer:   test.Claims["iss"].(string),
+				Subject:  test.Claims["sub"].(string),
+				Audience: audience,
+			}
+			claims, err := json.Marshal(test.Claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			withClaims(token, claims)
+
+			ctx := context.TODO()
+			if test.Config != nil {
+				ctx = config.With(ctx, test.Config)
+			}
+			untyped, err := RolePrincipalFromIDToken(ctx, token)
+			if err != nil {
+				if !test.WantErr {
+					t.Fatal("didn't expect error", err)
+				}
+				if !strings.Contains(err.Error(), test.ErrContains) {
+					t.Fatalf("expected error %s to contain %s", err, test.ErrContains)
+				}
+				return
+			}
+			if err == nil && test.WantErr {
+				t.Fatal("expected error but got none")
+			}
+
+			principal, ok := untyped.(*rolePrincipal)
+			if !ok {
+				t.Errorf("Got wrong principal type %v", untyped)
+			}
+			if *principal != test.ExpectPrincipal {
+				t.Errorf("got %v principal and expected %v", *principal, test.ExpectPrincipal)
+			}
+		})
+	}
+}
+
+func withClaims(token *oidc.IDToken, data []byte) {
+	val := reflect.Indirect(reflect.ValueOf(token))
+	member := val.FieldByName("claims")
+	pointer := unsafe.Pointer(member.UnsafeAddr())
+	realPointer := (*[]byte)(pointer)
+	*realPointer = data
+}
+
+func TestName(t *testing.T) {
+	claims := sampleClaims()
+	token := &oidc.IDToken{
+		Issuer:   claims["iss"].(string),
+		Subject:  claims["sub"].(string),
+		Audience: []string{sampleAudience},
+	}
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withClaims(token, raw)
+
+	principal, err := RolePrincipalFromIDToken(config.With(context.TODO(), sampleConfig()), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Clients sign their subject, not the role ARN
+	if gotName := principal.Name(context.TODO()); gotName != sampleSubject {
+		t.Errorf("got %s and expected %s", gotName, sampleSubject)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	tests := map[string]struct {
+		Principal identity.Principal
+		WantErr   bool
+		WantFacts map[string]func(x509.Certificate) error
+	}{
+		`AWS role should have all AWS extensions and issuer set`: {
+			Principal: &rolePrincipal{
+				subject:        "doesntmatter",
+				issuer:         sampleIssuer,
+				roleARN:        sampleRoleARN,
+				accountID:      sampleAccount,
+				namespace:      "default",
+				serviceAccount: "deploy",
+			},
+			WantErr: false,
+			WantFacts: map[string]func(x509.Certificate) error{
+				`Certificate should have correct issuer`:            factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}, sampleIssuer),
+				`Certificate has correct role ARN extension`:        factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcAWSRoleARN}, sampleRoleARN),
+				`Certificate has correct account ID extension`:      factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcAWSAccountID}, sampleAccount),
+				`Certificate has correct namespace extension`:       factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcKubernetesNamespace}, "default"),
+				`Certificate has correct service account extension`: factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcKubernetesServiceAccount}, "deploy"),
+				`Certificate has the role ARN as the SAN`:           factSANIs(sampleRoleARN),
+				`Certificate role ARN SAN survives a round trip`:    factSANRoundTrips(sampleRoleARN),
+			},
+		},
+		`AWS role with bad ARN fails`: {
+			Principal: &rolePrincipal{
+				subject: "doesntmatter",
+				issuer:  sampleIssuer,
+				roleARN: "\nbadarn",
+			},
+			WantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var cert x509.Certificate
+			// Extensions without Sigstore OIDs need an arc
+			ctx := config.With(context.TODO(), &config.FulcioConfig{ExtensionOIDArc: "1.3.6.1.4.1.32473.1"})
+			err := test.Principal.Embed(ctx, &cert)
+			if err != nil {
+				if !test.WantErr {
+					t.Error(err)
+				}
+				return
+			} else if test.WantErr {
+				t.Error("expected error")
+			}
+			for factName, fact := range test.WantFacts {
+				t.Run(factName, func(t *testing.T) {
+					if err := fact(cert); err != nil {
+						t.Error(err)
+					}
+				})
+			}
+		})
+	}
+}
+
+func factSANIs(uri string) func(x509.Certificate) error {
+	return func(cert x509.Certificate) error {
+		if len(cert.URIs) != 1 || cert.URIs[0].String() != uri {
+			return fmt.Errorf("expected URI SAN %s, got %v", uri, cert.URIs)
+		}
+		return nil
+	}
+}
+
+// factSANRoundTrips checks the URI SAN is encoded and parsed intact, as
+// ARNs are opaque URIs
+func factSANRoundTrips(uri string) func(x509.Certificate) error {
+	return func(cert x509.Certificate) error {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return err
+		}
+		template := &x509.Certificate{SerialNumber: big.NewInt(1), URIs: cert.URIs}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		if err != nil {
+			return err
+		}
+		parsed, err := x509.ParseCertificate(der)
+		if err != nil {
+			return err
+		}
+		return factSANIs(uri)(*parsed)
+	}
+}
+
+func factExtensionIs(oid asn1.ObjectIdentifier, value string) func(x509.Certificate) error {
+	return func(cert x509.Certificate) error {
+		for _, ext := range cert.ExtraExtensions {
+			if ext.Id.Equal(oid) {
+				if !bytes.Equal(ext.Value, []byte(value)) {
+					return fmt.Errorf("expected oid %v to be %s, but got %s", oid, value, ext.Value)
+				}
+				return nil
+			}
+		}
+		return errors.New("extension not set")
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bitbucket
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/identity"
+)
+
+var (
+	// Bitbucket Pipelines has an OIDC issuer per workspace, named by the
+	// workspace's ID, which may only contain lowercase letters, digits, - and _
+	issuerRegexp = regexp.MustCompile(`^https://api\.bitbucket\.org/2\.0/workspaces/([a-z0-9_-]+)/pipelines-config/identity/oidc$`)
+
+	// Bitbucket UUIDs are in braces, e.g. {4a2b1e1c-7b3e-4e8a-9a3f-2c1d0e9f8b7a}
+	uuidRegexp = regexp.MustCompile(`^\{[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\}$`)
+)
+
+type pipelinePrincipal struct {
+	// Subject matches the 'sub' claim from the OIDC ID token, e.g.
+	// {repositoryUuid}:{stepUuid}. This is what is signed as proof of
+	// possession for Bitbucket pipeline identities
+	subject string
+
+	// OIDC Issuer URL. Matches 'iss' claim from ID token, e.g.
+	// https://api.bitbucket.org/2.0/workspaces/my-workspace/pipelines-config/identity/oidc
+	issuer string
+
+	// The URL of the repository the pipeline ran for. This will be set as
+	// the SubjectAlternativeName URI in the final certificate.
+	url string
+
+	// UUID of the repository
+	repositoryUUID string
+
+	// UUID of the pipeline
+	pipelineUUID string
+
+	// UUID of the step of the pipeline
+	stepUUID string
+
+	// UUID of the workspace of the repository
+	workspaceUUID string
+}
+
+func PipelinePrincipalFromIDToken(ctx context.Context, token *oidc.IDToken) (identity.Principal, error) {
+	match := issuerRegexp.FindStringSubmatch(token.Issuer)
+	if match == nil {
+		return nil, fmt.Errorf("token issuer %s is not a Bitbucket Pipelines issuer", token.Issuer)
+	}
+	workspace := match[1]
+
+	if cfg := config.FromContext(ctx); cfg != nil {
+		if iss, ok := cfg.GetIssuer(token.Issuer); ok && !audienceAllowed(token.Audience, iss.ClientID) {
+			return nil, fmt.Errorf("token audience %v does not include expected audience %s", token.Audience, iss.ClientID)
+		}
+	}
+
+	var claims struct {
+		RepositoryUUID string `json:"repositoryUuid"`
+		PipelineUUID   string `json:"pipelineUuid"`
+		StepUUID       string `json:"stepUuid"`
+		WorkspaceUUID  string `json:"workspaceUuid"`
+	}
+	if err := token.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	for _, claim := range []struct{ name, value string }{
+		{"repositoryUuid", claims.RepositoryUUID},
+		{"pipelineUuid", claims.PipelineUUID},
+		{"stepUuid", claims.StepUUID},
+		{"workspaceUuid", claims.WorkspaceUUID},
+	} {
+		if claim.value == "" {
+			return nil, fmt.Errorf("missing %s claim in ID token", claim.name)
+		}
+		if !uuidRegexp.MatchString(claim.value) {
+			return nil, fmt.Errorf("%s claim %q in ID token is not a Bitbucket UUID", claim.name, claim.value)
+		}
+	}
+	if token.Subject == "" {
+		return nil, errors.New("missing sub claim in ID token")
+	}
+
+	return &pipelinePrincipal{
+		subject:        token.Subject,
+		issuer:         token.Issuer,
+		url:            "https://bitbucket.org/" + workspace + "/" + url.PathEscape(claims.RepositoryUUID),
+		repositoryUUID: claims.RepositoryUUID,
+		pipelineUUID:   claims.PipelineUUID,
+		stepUUID:       claims.StepUUID,
+		workspaceUUID:  claims.WorkspaceUUID,
+	}, nil
+}
+
+func audienceAllowed(audience []string, expected string) bool {
+	for _, aud := range audience {
+		if aud == expected {
+			return true
+		}
+	}
+	return false
+}
+
+func (p pipelinePrincipal) Name(ctx context.Context) string {
+	return p.subject
+}
+
+func (p pipelinePrincipal) Embed(ctx context.Context, cert *x509.Certificate) error {
+	// Set the repository URL to SubjectAlternativeName on certificate
+	parsed, err := url.Parse(p.url)
+	if err != nil {
+		return err
+	}
+	cert.URIs = []*url.URL{parsed}
+
+	// Embed additional information into custom extensions
+	arc, err := config.FromContext(ctx).ExtensionArc()
+	if err != nil {
+		return err
+	}
+	cert.ExtraExtensions, err = certificate.Extensions{
+		Arc:                     arc,
+		Issuer:                  p.issuer,
+		BitbucketRepositoryUUID: p.repositoryUUID,
+		BitbucketPipelineUUID:   p.pipelineUUID,
+		BitbucketStepUUID:       p.stepUUID,
+		BitbucketWorkspaceUUID:  p.workspaceUUID,
+	}.Render()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
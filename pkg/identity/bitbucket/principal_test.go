@@ -0,0 +1,302 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package bitbucket
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/identity"
+)
+
+const (
+	sampleIssuer        = "https://api.bitbucket.org/2.0/workspaces/sigstore/pipelines-config/identity/oidc"
+	sampleAudience      = "ari:cloud:bitbucket::workspace/{5e1d9c3a-8f2b-4a6e-b7c4-1d2e3f4a5b6c}"
+	sampleRepository    = "{0b2c4d6e-8f1a-4b3c-9d5e-7f6a8b9c0d1e}"
+	samplePipeline      = "{a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c5d}"
+	sampleStep          = "{f0e1d2c3-b4a5-4968-8776-5a4b3c2d1e0f}"
+	sampleWorkspace     = "{5e1d9c3a-8f2b-4a6e-b7c4-1d2e3f4a5b6c}"
+	sampleRepositoryURL = "https://bitbucket.org/sigstore/%7B0b2c4d6e-8f1a-4b3c-9d5e-7f6a8b9c0d1e%7D"
+)
+
+// sampleClaims are the claims of an ID token from Bitbucket Pipelines
+func sampleClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"aud":                       sampleAudience,
+		"exp":                       0,
+		"iat":                       0,
+		"iss":                       sampleIssuer,
+		"sub":                       sampleRepository + ":" + sampleStep,
+		"accountUuid":               "{9c8b7a6f-5e4d-4c3b-a2a1-0f9e8d7c6b5a}",
+		"branchName":                "main",
+		"deploymentEnvironmentUuid": "{1a2b3c4d-5e6f-4a8b-9c0d-1e2f3a4b5c6d}",
+		"pipelineUuid":              samplePipeline,
+		"repositoryUuid":            sampleRepository,
+		"stepUuid":                  sampleStep,
+		"workspaceUuid":             sampleWorkspace,
+	}
+}
+
+func TestPipelinePrincipalFromIDToken(t *testing.T) {
+	with := func(claim string, value interface{}) map[string]interface{} {
+		claims := sampleClaims()
+		claims[claim] = value
+		return claims
+	}
+	without := func(claim string) map[string]interface{} {
+		claims := sampleClaims()
+		delete(claims, claim)
+		return claims
+	}
+	cfg := &config.FulcioConfig{
+		OIDCIssuers: map[string]config.OIDCIssuer{
+			sampleIssuer: {
+				IssuerURL: sampleIssuer,
+				ClientID:  sampleAudience,
+				Type:      config.IssuerTypeBitbucketPipeline,
+			},
+		},
+	}
+	tests := map[string]struct {
+		Claims          map[string]interface{}
+		Config          *config.FulcioConfig
+		ExpectPrincipal pipelinePrincipal
+		WantErr         bool
+		ErrContains     string
+	}{
+		`Valid token authenticates with correct claims`: {
+			Claims: sampleClaims(),
+			Config: cfg,
+			ExpectPrincipal: pipelinePrincipal{
+				subject:        sampleRepository + ":" + sampleStep,
+				issuer:         sampleIssuer,
+				url:            sampleRepositoryURL,
+				repositoryUUID: sampleRepository,
+				pipelineUUID:   samplePipeline,
+				stepUUID:       sampleStep,
+				workspaceUUID:  sampleWorkspace,
+			},
+			WantErr: false,
+		},
+		`Token from another issuer should be rejected`: {
+			Claims:      with("iss", "https://api.bitbucket.example.com/2.0/workspaces/sigstore/pipelines-config/identity/oidc"),
+			WantErr:     true,
+			ErrContains: "not a Bitbucket Pipelines issuer",
+		},
+		`Token from a workspace that isn't a path segment should be rejected`: {
+			Claims:      with("iss", "https://api.bitbucket.org/2.0/workspaces/sigstore/evil/pipelines-config/identity/oidc"),
+			WantErr:     true,
+			ErrContains: "not a Bitbucket Pipelines issuer",
+		},
+		`Token for another audience should be rejected`: {
+			Claims:      with("aud", "ari:cloud:bitbucket::workspace/{00000000-0000-4000-8000-000000000000}"),
+			Config:      cfg,
+			WantErr:     true,
+			ErrContains: "audience",
+		},
+		`Token missing repositoryUuid claim should be rejected`: {
+			Claims:      without("repositoryUuid"),
+			WantErr:     true,
+			ErrContains: "repositoryUuid",
+		},
+		`Token missing pipelineUuid claim should be rejected`: {
+			Claims:      without("pipelineUuid"),
+			WantErr:     true,
+			ErrContains: "pipelineUuid",
+		},
+		`Token missing stepUuid claim should be rejected`: {
+			Claims:      without("stepUuid"),
+			WantErr:     true,
+			ErrContains: "stepUuid",
+		},
+		`Token missing workspaceUuid claim should be rejected`: {
+			Claims:      without("workspaceUuid"),
+			WantErr:     true,
+			ErrContains: "workspaceUuid",
+		},
+		`Token with a repositoryUuid that isn't a UUID should be rejected`: {
+			Claims:      with("repositoryUuid", "../../evil"),
+			WantErr:     true,
+			ErrContains: "not a Bitbucket UUID",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			token := &oidc.IDToken{
+				Issuer:   test.Claims["iss"].(string),
+				Subject:  test.Claims["sub"].(string),
+				Audience: []string{test.Claims["aud"].(string)},
+			}
+			claims, err := json.Marshal(test.Claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			withClaims(token, claims)
+
+			ctx := context.TODO()
+			if test.Config != nil {
+				ctx = config.With(ctx, test.Config)
+			}
+			untyped, err := PipelinePrincipalFromIDToken(ctx, token)
+			if err != nil {
+				if !test.WantErr {
+					t.Fatal("didn't expect error", err)
+				}
+				if !strings.Contains(err.Error(), test.ErrContains) {
+					t.Fatalf("expected error %s to contain %s", err, test.ErrContains)
+				}
+				return
+			}
+			if err == nil && test.WantErr {
+				t.Fatal("expected error but got none")
+			}
+
+			principal, ok := untyped.(*pipelinePrincipal)
+			if !ok {
+				t.Errorf("Got wrong principal type %v", untyped)
+			}
+			if *principal != test.ExpectPrincipal {
+				t.Errorf("got %v principal and expected %v", *principal, test.ExpectPrincipal)
+			}
+		})
+	}
+}
+
+func withClaims(token *oidc.IDToken, data []byte) {
+	val := reflect.Indirect(reflect.ValueOf(token))
+	member := val.FieldByName("claims")
+	pointer := unsafe.Pointer(member.UnsafeAddr())
+	realPointer := (*[]byte)(pointer)
+	*realPointer = data
+}
+
+func TestName(t *testing.T) {
+	claims := sampleClaims()
+	token := &oidc.IDToken{
+		Issuer:  claims["iss"].(string),
+		Subject: claims["sub"].(string),
+	}
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withClaims(token, raw)
+
+	principal, err := PipelinePrincipalFromIDToken(context.TODO(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotName := principal.Name(context.TODO()); gotName != claims["sub"] {
+		t.Errorf("got %s and expected %s", gotName, claims["sub"])
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	tests := map[string]struct {
+		Principal identity.Principal
+		WantErr   bool
+		WantFacts map[string]func(x509.Certificate) error
+	}{
+		`Bitbucket pipeline challenge should have all Bitbucket extensions and issuer set`: {
+			Principal: &pipelinePrincipal{
+				subject:        "doesntmatter",
+				issuer:         sampleIssuer,
+				url:            sampleRepositoryURL,
+				repositoryUUID: sampleRepository,
+				pipelineUUID:   samplePipeline,
+				stepUUID:       sampleStep,
+				workspaceUUID:  sampleWorkspace,
+			},
+			WantErr: false,
+			WantFacts: map[string]func(x509.Certificate) error{
+				`Certificate should have correct issuer`:            factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}, sampleIssuer),
+				`Certificate has correct repository UUID extension`: factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcBitbucketRepositoryUUID}, sampleRepository),
+				`Certificate has correct pipeline UUID extension`:   factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcBitbucketPipelineUUID}, samplePipeline),
+				`Certificate has correct step UUID extension`:       factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcBitbucketStepUUID}, sampleStep),
+				`Certificate has correct workspace UUID extension`:  factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcBitbucketWorkspaceUUID}, sampleWorkspace),
+				`Certificate has the repository URL as the SAN`:     factSANIs(sampleRepositoryURL),
+			},
+		},
+		`Bitbucket pipeline value with bad URL fails`: {
+			Principal: &pipelinePrincipal{
+				subject: "doesntmatter",
+				issuer:  sampleIssuer,
+				url:     "\nbadurl",
+			},
+			WantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var cert x509.Certificate
+			// Extensions without Sigstore OIDs need an arc
+			ctx := config.With(context.TODO(), &config.FulcioConfig{ExtensionOIDArc: "1.3.6.1.4.1.32473.1"})
+			err := test.Principal.Embed(ctx, &cert)
+			if err != nil {
+				if !test.WantErr {
+					t.Error(err)
+				}
+				return
+			} else if test.WantErr {
+				t.Error("expected error")
+			}
+			for factName, fact := range test.WantFacts {
+				t.Run(factName, func(t *testing.T) {
+					if err := fact(cert); err != nil {
+						t.Error(err)
+					}
+				})
+			}
+		})
+	}
+}
+
+func factSANIs(uri string) func(x509.Certificate) error {
+	return func(cert x509.Certificate) error {
+		if len(cert.URIs) != 1 || cert.URIs[0].String() != uri {
+			return fmt.Errorf("expected URI SAN %s, got %v", uri, cert.URIs)
+		}
+		return nil
+	}
+}
+
+func factExtensionIs(oid asn1.ObjectIdentifier, value string) func(x509.Certificate) error {
+	return func(cert x509.Certificate) error {
+		for _, ext := range cert.ExtraExtensions {
+			if ext.Id.Equal(oid) {
+				if !bytes.Equal(ext.Value, []byte(value)) {
+					return fmt.Errorf("expected oid %v to be %s, but got %s", oid, value, ext.Value)
+				}
+				return nil
+			}
+		}
+		return errors.New("extension not set")
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/coreos/go-oidc/v3/oidc"
@@ -26,11 +27,17 @@ import (
 	"github.com/sigstore/fulcio/pkg/config"
 	"github.com/sigstore/fulcio/pkg/identity"
 	"github.com/sigstore/fulcio/pkg/oauthflow"
+	"golang.org/x/net/idna"
 )
 
 type principal struct {
+	// address is the email address as it appears in the token, which
+	// clients sign as their challenge
 	address string
-	issuer  string
+	// rfc822Name is address with an internationalized domain encoded in
+	// A-label form, as embedded in the certificate
+	rfc822Name string
+	issuer     string
 }
 
 func PrincipalFromIDToken(ctx context.Context, token *oidc.IDToken) (identity.Principal, error) {
@@ -42,8 +49,13 @@ func PrincipalFromIDToken(ctx context.Context, token *oidc.IDToken) (identity.Pr
 		return nil, errors.New("email_verified claim was false")
 	}
 
-	if !govalidator.IsEmail(emailAddress) {
-		return nil, fmt.Errorf("email address is not valid")
+	normalized, err := NormalizeEmail(emailAddress)
+	if err != nil {
+		return nil, err
+	}
+	rfc822Name, err := toRFC822Name(normalized)
+	if err != nil {
+		return nil, err
 	}
 
 	cfg, ok := config.FromContext(ctx).GetIssuer(token.Issuer)
@@ -57,17 +69,56 @@ func PrincipalFromIDToken(ctx context.Context, token *oidc.IDToken) (identity.Pr
 	}
 
 	return principal{
-		issuer:  issuer,
-		address: emailAddress,
+		issuer:     issuer,
+		address:    emailAddress,
+		rfc822Name: rfc822Name,
 	}, nil
 }
 
+// NormalizeEmail checks that addr is a valid email address and lowercases its
+// domain. Domains are case-insensitive, so Foo@Example.com and
+// Foo@example.com are the same mailbox, but RFC 5321 leaves the local part
+// case-sensitive, so it is returned unchanged.
+func NormalizeEmail(addr string) (string, error) {
+	if strings.Count(addr, "@") != 1 {
+		return "", errors.New("email address must contain exactly one @")
+	}
+	if !govalidator.IsEmail(addr) {
+		return "", errors.New("email address is not valid")
+	}
+	at := strings.IndexByte(addr, '@')
+	return addr[:at] + "@" + strings.ToLower(addr[at+1:]), nil
+}
+
+// toRFC822Name converts an email address to the form that can be encoded in
+// an rfc822Name SAN, an IA5String. An internationalized domain is converted
+// to A-labels, e.g. alice@bücher.example becomes alice@xn--bcher-kva.example.
+// The local part can't be converted and must already be ASCII.
+func toRFC822Name(address string) (string, error) {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return "", errors.New("email address is not valid")
+	}
+	local, domain := address[:at], address[at+1:]
+	for _, r := range local {
+		if r > 0x7f {
+			return "", errors.New("email address local part must be ASCII")
+		}
+	}
+	domain, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return "", fmt.Errorf("email address domain is not valid: %w", err)
+	}
+	return local + "@" + domain, nil
+}
+
 func (p principal) Name(context.Context) string {
 	return p.address
 }
 
 func (p principal) Embed(ctx context.Context, cert *x509.Certificate) error {
-	cert.EmailAddresses = []string{p.address}
+	// crypto/x509 encodes EmailAddresses as rfc822Name GeneralNames
+	cert.EmailAddresses = []string{p.rfc822Name}
 
 	var err error
 	cert.ExtraExtensions, err = certificate.Extensions{
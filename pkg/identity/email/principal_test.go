@@ -17,11 +17,15 @@ package email
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
 	"encoding/asn1"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"reflect"
 	"testing"
 	"unsafe"
@@ -55,8 +59,9 @@ func TestPrincipalFromIDToken(t *testing.T) {
 				},
 			},
 			ExpectedPrincipal: principal{
-				issuer:  "https://iss.example.com",
-				address: "alice@example.com",
+				issuer:     "https://iss.example.com",
+				address:    "alice@example.com",
+				rfc822Name: "alice@example.com",
 			},
 			WantErr: false,
 		},
@@ -82,8 +87,9 @@ func TestPrincipalFromIDToken(t *testing.T) {
 				},
 			},
 			ExpectedPrincipal: principal{
-				issuer:  "https://example.com",
-				address: "alice@example.com",
+				issuer:     "https://example.com",
+				address:    "alice@example.com",
+				rfc822Name: "alice@example.com",
 			},
 			WantErr: false,
 		},
@@ -107,6 +113,73 @@ func TestPrincipalFromIDToken(t *testing.T) {
 			},
 			WantErr: true,
 		},
+		`Internationalized domain is encoded as A-labels`: {
+			Claims: map[string]interface{}{
+				"aud":            "sigstore",
+				"iss":            "https://iss.example.com",
+				"sub":            "doesntmatter",
+				"email":          "alice@bücher.example",
+				"email_verified": true,
+			},
+			Config: config.FulcioConfig{
+				OIDCIssuers: map[string]config.OIDCIssuer{
+					"https://iss.example.com": {
+						IssuerURL: "https://iss.example.com",
+						Type:      config.IssuerTypeEmail,
+						ClientID:  "sigstore",
+					},
+				},
+			},
+			ExpectedPrincipal: principal{
+				issuer:     "https://iss.example.com",
+				address:    "alice@bücher.example",
+				rfc822Name: "alice@xn--bcher-kva.example",
+			},
+			WantErr: false,
+		},
+		`Mixed-case domain is lowercased`: {
+			Claims: map[string]interface{}{
+				"aud":            "sigstore",
+				"iss":            "https://iss.example.com",
+				"sub":            "doesntmatter",
+				"email":          "Alice@Example.COM",
+				"email_verified": true,
+			},
+			Config: config.FulcioConfig{
+				OIDCIssuers: map[string]config.OIDCIssuer{
+					"https://iss.example.com": {
+						IssuerURL: "https://iss.example.com",
+						Type:      config.IssuerTypeEmail,
+						ClientID:  "sigstore",
+					},
+				},
+			},
+			ExpectedPrincipal: principal{
+				issuer:     "https://iss.example.com",
+				address:    "Alice@Example.COM",
+				rfc822Name: "Alice@example.com",
+			},
+			WantErr: false,
+		},
+		`Non-ASCII local part should error`: {
+			Claims: map[string]interface{}{
+				"aud":            "sigstore",
+				"iss":            "https://iss.example.com",
+				"sub":            "doesntmatter",
+				"email":          "ålice@example.com",
+				"email_verified": true,
+			},
+			Config: config.FulcioConfig{
+				OIDCIssuers: map[string]config.OIDCIssuer{
+					"https://iss.example.com": {
+						IssuerURL: "https://iss.example.com",
+						Type:      config.IssuerTypeEmail,
+						ClientID:  "sigstore",
+					},
+				},
+			},
+			WantErr: true,
+		},
 		`Email not verified should error`: {
 			Claims: map[string]interface{}{
 				"aud":            "sigstore",
@@ -220,6 +293,65 @@ func TestPrincipalFromIDToken(t *testing.T) {
 	}
 }
 
+func TestNormalizeEmail(t *testing.T) {
+	tests := map[string]struct {
+		Address string
+		Want    string
+		WantErr bool
+	}{
+		`Lowercase address is unchanged`: {
+			Address: "alice@example.com",
+			Want:    "alice@example.com",
+		},
+		`Mixed-case domain is lowercased`: {
+			Address: "alice@Example.COM",
+			Want:    "alice@example.com",
+		},
+		`Local part case is preserved`: {
+			Address: "Foo.Bar@Example.com",
+			Want:    "Foo.Bar@example.com",
+		},
+		`Internationalized domain is lowercased`: {
+			Address: "alice@BÜCHER.example",
+			Want:    "alice@bücher.example",
+		},
+		`Multiple @ should error`: {
+			Address: "alice@bob@example.com",
+			WantErr: true,
+		},
+		`Missing @ should error`: {
+			Address: "example.com",
+			WantErr: true,
+		},
+		`Empty local part should error`: {
+			Address: "@example.com",
+			WantErr: true,
+		},
+		`Empty domain should error`: {
+			Address: "alice@",
+			WantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := NormalizeEmail(test.Address)
+			if err != nil {
+				if !test.WantErr {
+					t.Fatal("didn't expect error", err)
+				}
+				return
+			}
+			if test.WantErr {
+				t.Fatalf("expected error but got %q", got)
+			}
+			if got != test.Want {
+				t.Errorf("got %q, expected %q", got, test.Want)
+			}
+		})
+	}
+}
+
 // reflect hack because "claims" field is unexported by oidc IDToken
 // https://github.com/coreos/go-oidc/pull/329
 func withClaims(token *oidc.IDToken, data []byte) {
@@ -292,8 +424,9 @@ func TestEmbed(t *testing.T) {
 	}{
 		`should set issuer extension and email subject`: {
 			Principal: principal{
-				issuer:  `https://iss.example.com`,
-				address: `alice@example.com`,
+				issuer:     `https://iss.example.com`,
+				address:    `alice@example.com`,
+				rfc822Name: `alice@example.com`,
 			},
 			WantErr: false,
 			WantFacts: map[string]func(x509.Certificate) error{
@@ -334,6 +467,54 @@ func TestEmbed(t *testing.T) {
 	}
 }
 
+func TestEmbedRFC822Name(t *testing.T) {
+	p := principal{
+		issuer:     `https://iss.example.com`,
+		address:    `alice@bücher.example`,
+		rfc822Name: `alice@xn--bcher-kva.example`,
+	}
+	cert := &x509.Certificate{SerialNumber: big.NewInt(1)}
+	if err := p.Embed(context.TODO(), cert); err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, cert, cert, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var san []byte
+	for _, ext := range parsed.Extensions {
+		if ext.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 17}) {
+			san = ext.Value
+		}
+	}
+	if san == nil {
+		t.Fatal("no SAN extension")
+	}
+	var names []asn1.RawValue
+	if rest, err := asn1.Unmarshal(san, &names); err != nil || len(rest) != 0 {
+		t.Fatalf("failed to parse SAN extension: %v", err)
+	}
+	if len(names) != 1 {
+		t.Fatalf("expected exactly one SAN, got %d", len(names))
+	}
+	// rfc822Name is [1] IA5String in GeneralName
+	if names[0].Class != asn1.ClassContextSpecific || names[0].Tag != 1 {
+		t.Fatalf("expected rfc822Name SAN, got class %d tag %d", names[0].Class, names[0].Tag)
+	}
+	if got := string(names[0].Bytes); got != `alice@xn--bcher-kva.example` {
+		t.Fatalf("expected rfc822Name alice@xn--bcher-kva.example, got %s", got)
+	}
+}
+
 func factIssuerIs(issuer string) func(x509.Certificate) error {
 	return factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}, issuer)
 }
@@ -18,10 +18,12 @@ import (
 	"context"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"net/url"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/config"
 	"github.com/sigstore/fulcio/pkg/identity"
 )
 
@@ -52,6 +54,12 @@ type workflowPrincipal struct {
 
 	// Git ref being built
 	ref string
+
+	// Deployment environment of the job, if any
+	environment string
+
+	// Claims recorded in the v2 extensions
+	v2 certificate.GitHubWorkflowClaims
 }
 
 func WorkflowPrincipalFromIDToken(ctx context.Context, token *oidc.IDToken) (identity.Principal, error) {
@@ -62,10 +70,15 @@ func WorkflowPrincipalFromIDToken(ctx context.Context, token *oidc.IDToken) (ide
 		Repository     string `json:"repository"`
 		Workflow       string `json:"workflow"`
 		Ref            string `json:"ref"`
+		Environment    string `json:"environment"`
 	}
 	if err := token.Claims(&claims); err != nil {
 		return nil, err
 	}
+	var v2 certificate.GitHubWorkflowClaims
+	if err := token.Claims(&v2); err != nil {
+		return nil, err
+	}
 
 	if claims.JobWorkflowRef == "" {
 		return nil, errors.New("missing job_workflow_ref claim in ID token")
@@ -85,19 +98,40 @@ func WorkflowPrincipalFromIDToken(ctx context.Context, token *oidc.IDToken) (ide
 	if claims.Ref == "" {
 		return nil, errors.New("missing ref claim in ID token")
 	}
+	if cfg := config.FromContext(ctx); cfg != nil {
+		if iss, ok := cfg.GetIssuer(token.Issuer); ok && len(iss.GitHubEnvironments) > 0 {
+			if !environmentAllowed(claims.Environment, iss.GitHubEnvironments) {
+				return nil, fmt.Errorf("environment %q is not allowed for this issuer", claims.Environment)
+			}
+		}
+	}
 
 	return &workflowPrincipal{
-		subject:    token.Subject,
-		issuer:     token.Issuer,
-		url:        `https://github.com/` + claims.JobWorkflowRef,
-		sha:        claims.Sha,
-		trigger:    claims.Trigger,
-		repository: claims.Repository,
-		workflow:   claims.Workflow,
-		ref:        claims.Ref,
+		subject:     token.Subject,
+		issuer:      token.Issuer,
+		url:         `https://github.com/` + claims.JobWorkflowRef,
+		sha:         claims.Sha,
+		trigger:     claims.Trigger,
+		repository:  claims.Repository,
+		workflow:    claims.Workflow,
+		ref:         claims.Ref,
+		environment: claims.Environment,
+		v2:          v2,
 	}, nil
 }
 
+func environmentAllowed(environment string, allowed []string) bool {
+	if environment == "" {
+		return false
+	}
+	for _, a := range allowed {
+		if a == environment {
+			return true
+		}
+	}
+	return false
+}
+
 func (w workflowPrincipal) Name(ctx context.Context) string {
 	return w.subject
 }
@@ -111,17 +145,28 @@ func (w workflowPrincipal) Embed(ctx context.Context, cert *x509.Certificate) er
 	cert.URIs = []*url.URL{parsed}
 
 	// Embed additional information into custom extensions
+	arc, err := config.FromContext(ctx).ExtensionArc()
+	if err != nil {
+		return err
+	}
 	cert.ExtraExtensions, err = certificate.Extensions{
-		Issuer:                   w.issuer,
-		GithubWorkflowTrigger:    w.trigger,
-		GithubWorkflowSHA:        w.sha,
-		GithubWorkflowName:       w.workflow,
-		GithubWorkflowRepository: w.repository,
-		GithubWorkflowRef:        w.ref,
+		Arc:                       arc,
+		Issuer:                    w.issuer,
+		GithubWorkflowTrigger:     w.trigger,
+		GithubWorkflowSHA:         w.sha,
+		GithubWorkflowName:        w.workflow,
+		GithubWorkflowRepository:  w.repository,
+		GithubWorkflowRef:         w.ref,
+		GithubWorkflowEnvironment: w.environment,
 	}.Render()
 	if err != nil {
 		return err
 	}
+	v2, err := w.v2.Render()
+	if err != nil {
+		return err
+	}
+	cert.ExtraExtensions = append(cert.ExtraExtensions, v2...)
 
 	return nil
 }
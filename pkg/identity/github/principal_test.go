@@ -28,6 +28,8 @@ import (
 	"unsafe"
 
 	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/config"
 	"github.com/sigstore/fulcio/pkg/identity"
 )
 
@@ -60,6 +62,14 @@ func TestWorkflowPrincipalFromIDToken(t *testing.T) {
 				repository: "sigstore/fulcio",
 				workflow:   "foo",
 				ref:        "refs/heads/main",
+				v2: certificate.GitHubWorkflowClaims{
+					Issuer:         "https://token.actions.githubusercontent.com",
+					JobWorkflowRef: "sigstore/fulcio/.github/workflows/foo.yaml@refs/heads/main",
+					Repository:     "sigstore/fulcio",
+					SHA:            "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+					Ref:            "refs/heads/main",
+					EventName:      "push",
+				},
 			},
 			WantErr: false,
 		},
@@ -192,6 +202,87 @@ func TestWorkflowPrincipalFromIDToken(t *testing.T) {
 	}
 }
 
+func TestWorkflowPrincipalEnvironments(t *testing.T) {
+	tests := map[string]struct {
+		Environment string
+		Allowed     []string
+		WantErr     bool
+	}{
+		`Environment is recorded without an allowlist`: {
+			Environment: "staging",
+		},
+		`No environment is accepted without an allowlist`: {},
+		`Allowed environment is accepted`: {
+			Environment: "production",
+			Allowed:     []string{"production", "release"},
+		},
+		`Disallowed environment is rejected`: {
+			Environment: "staging",
+			Allowed:     []string{"production"},
+			WantErr:     true,
+		},
+		`Missing environment is rejected with an allowlist`: {
+			Allowed: []string{"production"},
+			WantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.FulcioConfig{
+				OIDCIssuers: map[string]config.OIDCIssuer{
+					"https://token.actions.githubusercontent.com": {
+						IssuerURL:          "https://token.actions.githubusercontent.com",
+						ClientID:           "sigstore",
+						Type:               config.IssuerTypeGithubWorkflow,
+						GitHubEnvironments: test.Allowed,
+					},
+				},
+			}
+			ctx := config.With(context.Background(), cfg)
+
+			claims := map[string]interface{}{
+				"aud":              "sigstore",
+				"event_name":       "push",
+				"exp":              0,
+				"iss":              "https://token.actions.githubusercontent.com",
+				"job_workflow_ref": "sigstore/fulcio/.github/workflows/foo.yaml@refs/heads/main",
+				"ref":              "refs/heads/main",
+				"repository":       "sigstore/fulcio",
+				"sha":              "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+				"sub":              "repo:sigstore/fulcio:environment:production",
+				"workflow":         "foo",
+			}
+			if test.Environment != "" {
+				claims["environment"] = test.Environment
+			}
+			token := &oidc.IDToken{
+				Issuer:  claims["iss"].(string),
+				Subject: claims["sub"].(string),
+			}
+			raw, err := json.Marshal(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			withClaims(token, raw)
+
+			untyped, err := WorkflowPrincipalFromIDToken(ctx, token)
+			if err != nil {
+				if !test.WantErr {
+					t.Fatal("didn't expect error", err)
+				}
+				return
+			}
+			if test.WantErr {
+				t.Fatal("expected error but got none")
+			}
+			if got := untyped.(*workflowPrincipal).environment; got != test.Environment {
+				t.Errorf("got environment %q, expected %q", got, test.Environment)
+			}
+		})
+	}
+}
+
 // reflect hack because "claims" field is unexported by oidc IDToken
 // https://github.com/coreos/go-oidc/pull/329
 func withClaims(token *oidc.IDToken, data []byte) {
@@ -258,23 +349,34 @@ func TestEmbed(t *testing.T) {
 	}{
 		`Github workflow challenge should have all Github workflow extensions and issuer set`: {
 			Principal: &workflowPrincipal{
-				issuer:     "https://token.actions.githubusercontent.com",
-				subject:    "doesntmatter",
-				url:        `https://github.com/foo/bar/`,
-				sha:        "sha",
-				trigger:    "trigger",
-				workflow:   "workflowname",
-				repository: "repository",
-				ref:        "ref",
+				issuer:      "https://token.actions.githubusercontent.com",
+				subject:     "doesntmatter",
+				url:         `https://github.com/foo/bar/`,
+				sha:         "sha",
+				trigger:     "trigger",
+				workflow:    "workflowname",
+				repository:  "repository",
+				ref:         "ref",
+				environment: "production",
+				v2: certificate.GitHubWorkflowClaims{
+					Issuer:      "https://token.actions.githubusercontent.com",
+					Repository:  "foo/bar",
+					WorkflowSHA: "sha",
+				},
 			},
 			WantErr: false,
 			WantFacts: map[string]func(x509.Certificate) error{
-				`Certifificate should have correct issuer`:     factIssuerIs(`https://token.actions.githubusercontent.com`),
-				`Certificate has correct trigger extension`:    factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 2}, "trigger"),
-				`Certificate has correct SHA extension`:        factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 3}, "sha"),
-				`Certificate has correct workflow extension`:   factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 4}, "workflowname"),
-				`Certificate has correct repository extension`: factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 5}, "repository"),
-				`Certificate has correct ref extension`:        factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 6}, "ref"),
+				`Certificate has correct environment extension`:      factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcGitHubWorkflowEnvironment}, "production"),
+				`Certifificate should have correct issuer`:           factIssuerIs(`https://token.actions.githubusercontent.com`),
+				`Certificate has correct trigger extension`:          factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 2}, "trigger"),
+				`Certificate has correct SHA extension`:              factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 3}, "sha"),
+				`Certificate has correct workflow extension`:         factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 4}, "workflowname"),
+				`Certificate has correct repository extension`:       factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 5}, "repository"),
+				`Certificate has correct ref extension`:              factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 6}, "ref"),
+				`Certificate has correct v2 issuer extension`:        factV2ExtensionIs(certificate.OIDIssuerV2, "https://token.actions.githubusercontent.com"),
+				`Certificate has correct v2 repository extension`:    factV2ExtensionIs(certificate.OIDSourceRepositoryURI, "https://github.com/foo/bar"),
+				`Certificate has correct v2 config digest extension`: factV2ExtensionIs(certificate.OIDBuildConfigDigest, "sha"),
+				`Certificate has no v2 extensions for absent claims`: factExtensionAbsent(certificate.OIDBuildTrigger),
 			},
 		},
 		`Github workflow value with bad URL fails`: {
@@ -294,7 +396,9 @@ func TestEmbed(t *testing.T) {
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			var cert x509.Certificate
-			err := test.Principal.Embed(context.TODO(), &cert)
+			// Extensions without Sigstore OIDs need an arc
+			ctx := config.With(context.TODO(), &config.FulcioConfig{ExtensionOIDArc: "1.3.6.1.4.1.32473.1"})
+			err := test.Principal.Embed(ctx, &cert)
 			if err != nil {
 				if !test.WantErr {
 					t.Error(err)
@@ -331,3 +435,32 @@ func factExtensionIs(oid asn1.ObjectIdentifier, value string) func(x509.Certific
 		return errors.New("extension not set")
 	}
 }
+
+func factV2ExtensionIs(oid asn1.ObjectIdentifier, value string) func(x509.Certificate) error {
+	return func(cert x509.Certificate) error {
+		for _, ext := range cert.ExtraExtensions {
+			if ext.Id.Equal(oid) {
+				var got string
+				if _, err := asn1.Unmarshal(ext.Value, &got); err != nil {
+					return err
+				}
+				if got != value {
+					return fmt.Errorf("expected oid %v to be %s, but got %s", oid, value, got)
+				}
+				return nil
+			}
+		}
+		return errors.New("extension not set")
+	}
+}
+
+func factExtensionAbsent(oid asn1.ObjectIdentifier) func(x509.Certificate) error {
+	return func(cert x509.Certificate) error {
+		for _, ext := range cert.ExtraExtensions {
+			if ext.Id.Equal(oid) {
+				return fmt.Errorf("expected oid %v to be absent", oid)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/url"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/identity"
+)
+
+type pipelinePrincipal struct {
+	// Subject matches the 'sub' claim from the OIDC ID token, e.g.
+	// project_path:group/project:ref_type:branch:ref:main. This is what is
+	// signed as proof of possession for GitLab pipeline identities
+	subject string
+
+	// OIDC Issuer URL. Matches 'iss' claim from ID token, e.g.
+	// https://gitlab.com or the URL of a self-managed instance
+	issuer string
+
+	// The URL of the CI config the pipeline ran. This will be set as the
+	// SubjectAlternativeName URI in the final certificate.
+	url string
+
+	// Path of the project the pipeline ran for, e.g. group/project
+	projectPath string
+
+	// Reference to the CI config, from the 'ci_config_ref_uri' claim
+	ciConfigRefURI string
+
+	// ID of the pipeline
+	pipelineID string
+
+	// ID of the runner that ran the job
+	runnerID string
+}
+
+func PipelinePrincipalFromIDToken(ctx context.Context, token *oidc.IDToken) (identity.Principal, error) {
+	var claims struct {
+		ProjectPath    string      `json:"project_path"`
+		CIConfigRefURI string      `json:"ci_config_ref_uri"`
+		PipelineID     json.Number `json:"pipeline_id"`
+		RunnerID       json.Number `json:"runner_id"`
+	}
+	if err := token.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	if claims.ProjectPath == "" {
+		return nil, errors.New("missing project_path claim in ID token")
+	}
+	if claims.CIConfigRefURI == "" {
+		return nil, errors.New("missing ci_config_ref_uri claim in ID token")
+	}
+	if claims.PipelineID == "" {
+		return nil, errors.New("missing pipeline_id claim in ID token")
+	}
+	if claims.RunnerID == "" {
+		return nil, errors.New("missing runner_id claim in ID token")
+	}
+
+	return &pipelinePrincipal{
+		subject:        token.Subject,
+		issuer:         token.Issuer,
+		url:            `https://` + claims.CIConfigRefURI,
+		projectPath:    claims.ProjectPath,
+		ciConfigRefURI: claims.CIConfigRefURI,
+		pipelineID:     claims.PipelineID.String(),
+		runnerID:       claims.RunnerID.String(),
+	}, nil
+}
+
+func (p pipelinePrincipal) Name(ctx context.Context) string {
+	return p.subject
+}
+
+func (p pipelinePrincipal) Embed(ctx context.Context, cert *x509.Certificate) error {
+	// Set the CI config URL to SubjectAlternativeName on certificate
+	parsed, err := url.Parse(p.url)
+	if err != nil {
+		return err
+	}
+	cert.URIs = []*url.URL{parsed}
+
+	// Embed additional information into custom extensions
+	arc, err := config.FromContext(ctx).ExtensionArc()
+	if err != nil {
+		return err
+	}
+	cert.ExtraExtensions, err = certificate.Extensions{
+		Arc:                  arc,
+		Issuer:               p.issuer,
+		GitLabProjectPath:    p.projectPath,
+		GitLabCIConfigRefURI: p.ciConfigRefURI,
+		GitLabPipelineID:     p.pipelineID,
+		GitLabRunnerID:       p.runnerID,
+	}.Render()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
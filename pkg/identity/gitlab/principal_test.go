@@ -0,0 +1,262 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/identity"
+)
+
+// sampleClaims are the claims of an ID token from GitLab CI
+func sampleClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"aud":                   "sigstore",
+		"exp":                   0,
+		"iss":                   "https://gitlab.com",
+		"sub":                   "project_path:sigstore/fulcio:ref_type:branch:ref:main",
+		"namespace_id":          "1",
+		"namespace_path":        "sigstore",
+		"project_id":            "42",
+		"project_path":          "sigstore/fulcio",
+		"user_login":            "foo",
+		"pipeline_id":           "1212",
+		"pipeline_source":       "push",
+		"job_id":                "3434",
+		"ref":                   "main",
+		"ref_type":              "branch",
+		"ref_protected":         "true",
+		"runner_id":             5656,
+		"runner_environment":    "gitlab-hosted",
+		"sha":                   "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"ci_config_ref_uri":     "gitlab.com/sigstore/fulcio//.gitlab-ci.yml@refs/heads/main",
+		"ci_config_sha":         "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"project_visibility":    "public",
+		"deployment_tier":       "production",
+		"environment_protected": "true",
+	}
+}
+
+func TestPipelinePrincipalFromIDToken(t *testing.T) {
+	without := func(claim string) map[string]interface{} {
+		claims := sampleClaims()
+		delete(claims, claim)
+		return claims
+	}
+	tests := map[string]struct {
+		Claims          map[string]interface{}
+		ExpectPrincipal pipelinePrincipal
+		WantErr         bool
+		ErrContains     string
+	}{
+		`Valid token authenticates with correct claims`: {
+			Claims: sampleClaims(),
+			ExpectPrincipal: pipelinePrincipal{
+				subject:        "project_path:sigstore/fulcio:ref_type:branch:ref:main",
+				issuer:         "https://gitlab.com",
+				url:            "https://gitlab.com/sigstore/fulcio//.gitlab-ci.yml@refs/heads/main",
+				projectPath:    "sigstore/fulcio",
+				ciConfigRefURI: "gitlab.com/sigstore/fulcio//.gitlab-ci.yml@refs/heads/main",
+				pipelineID:     "1212",
+				runnerID:       "5656",
+			},
+			WantErr: false,
+		},
+		`Token missing project_path claim should be rejected`: {
+			Claims:      without("project_path"),
+			WantErr:     true,
+			ErrContains: "project_path",
+		},
+		`Token missing ci_config_ref_uri claim should be rejected`: {
+			Claims:      without("ci_config_ref_uri"),
+			WantErr:     true,
+			ErrContains: "ci_config_ref_uri",
+		},
+		`Token missing pipeline_id claim should be rejected`: {
+			Claims:      without("pipeline_id"),
+			WantErr:     true,
+			ErrContains: "pipeline_id",
+		},
+		`Token missing runner_id claim should be rejected`: {
+			Claims:      without("runner_id"),
+			WantErr:     true,
+			ErrContains: "runner_id",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			token := &oidc.IDToken{
+				Issuer:  test.Claims["iss"].(string),
+				Subject: test.Claims["sub"].(string),
+			}
+			claims, err := json.Marshal(test.Claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			withClaims(token, claims)
+
+			untyped, err := PipelinePrincipalFromIDToken(context.TODO(), token)
+			if err != nil {
+				if !test.WantErr {
+					t.Fatal("didn't expect error", err)
+				}
+				if !strings.Contains(err.Error(), test.ErrContains) {
+					t.Fatalf("expected error %s to contain %s", err, test.ErrContains)
+				}
+				return
+			}
+			if err == nil && test.WantErr {
+				t.Fatal("expected error but got none")
+			}
+
+			principal, ok := untyped.(*pipelinePrincipal)
+			if !ok {
+				t.Errorf("Got wrong principal type %v", untyped)
+			}
+			if *principal != test.ExpectPrincipal {
+				t.Errorf("got %v principal and expected %v", *principal, test.ExpectPrincipal)
+			}
+		})
+	}
+}
+
+func withClaims(token *oidc.IDToken, data []byte) {
+	val := reflect.Indirect(reflect.ValueOf(token))
+	member := val.FieldByName("claims")
+	pointer := unsafe.Pointer(member.UnsafeAddr())
+	realPointer := (*[]byte)(pointer)
+	*realPointer = data
+}
+
+func TestName(t *testing.T) {
+	claims := sampleClaims()
+	token := &oidc.IDToken{
+		Issuer:  claims["iss"].(string),
+		Subject: claims["sub"].(string),
+	}
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withClaims(token, raw)
+
+	principal, err := PipelinePrincipalFromIDToken(context.TODO(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotName := principal.Name(context.TODO()); gotName != claims["sub"] {
+		t.Errorf("got %s and expected %s", gotName, claims["sub"])
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	tests := map[string]struct {
+		Principal identity.Principal
+		WantErr   bool
+		WantFacts map[string]func(x509.Certificate) error
+	}{
+		`GitLab pipeline challenge should have all GitLab extensions and issuer set`: {
+			Principal: &pipelinePrincipal{
+				subject:        "doesntmatter",
+				issuer:         "https://gitlab.com",
+				url:            "https://gitlab.com/sigstore/fulcio//.gitlab-ci.yml@refs/heads/main",
+				projectPath:    "sigstore/fulcio",
+				ciConfigRefURI: "gitlab.com/sigstore/fulcio//.gitlab-ci.yml@refs/heads/main",
+				pipelineID:     "1212",
+				runnerID:       "5656",
+			},
+			WantErr: false,
+			WantFacts: map[string]func(x509.Certificate) error{
+				`Certificate should have correct issuer`:                   factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}, "https://gitlab.com"),
+				`Certificate has correct project path extension`:           factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcGitLabProjectPath}, "sigstore/fulcio"),
+				`Certificate has correct CI config ref URI extension`:      factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcGitLabCIConfigRefURI}, "gitlab.com/sigstore/fulcio//.gitlab-ci.yml@refs/heads/main"),
+				`Certificate has correct pipeline ID extension`:            factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcGitLabPipelineID}, "1212"),
+				`Certificate has correct runner ID extension`:              factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcGitLabRunnerID}, "5656"),
+				`Certificate has the CI config ref URI as the subject SAN`: factSANIs("https://gitlab.com/sigstore/fulcio//.gitlab-ci.yml@refs/heads/main"),
+			},
+		},
+		`GitLab pipeline value with bad URL fails`: {
+			Principal: &pipelinePrincipal{
+				subject:     "doesntmatter",
+				issuer:      "https://gitlab.com",
+				url:         "\nbadurl",
+				projectPath: "sigstore/fulcio",
+			},
+			WantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var cert x509.Certificate
+			// Extensions without Sigstore OIDs need an arc
+			ctx := config.With(context.TODO(), &config.FulcioConfig{ExtensionOIDArc: "1.3.6.1.4.1.32473.1"})
+			err := test.Principal.Embed(ctx, &cert)
+			if err != nil {
+				if !test.WantErr {
+					t.Error(err)
+				}
+				return
+			} else if test.WantErr {
+				t.Error("expected error")
+			}
+			for factName, fact := range test.WantFacts {
+				t.Run(factName, func(t *testing.T) {
+					if err := fact(cert); err != nil {
+						t.Error(err)
+					}
+				})
+			}
+		})
+	}
+}
+
+func factSANIs(uri string) func(x509.Certificate) error {
+	return func(cert x509.Certificate) error {
+		if len(cert.URIs) != 1 || cert.URIs[0].String() != uri {
+			return fmt.Errorf("expected URI SAN %s, got %v", uri, cert.URIs)
+		}
+		return nil
+	}
+}
+
+func factExtensionIs(oid asn1.ObjectIdentifier, value string) func(x509.Certificate) error {
+	return func(cert x509.Certificate) error {
+		for _, ext := range cert.ExtraExtensions {
+			if ext.Id.Equal(oid) {
+				if !bytes.Equal(ext.Value, []byte(value)) {
+					return fmt.Errorf("expected oid %v to be %s, but got %s", oid, value, ext.Value)
+				}
+				return nil
+			}
+		}
+		return errors.New("extension not set")
+	}
+}
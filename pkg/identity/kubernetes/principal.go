@@ -17,13 +17,24 @@ package kubernetes
 import (
 	"context"
 	"crypto/x509"
+	"errors"
+	"fmt"
 	"net/url"
+	"regexp"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/config"
 	"github.com/sigstore/fulcio/pkg/identity"
 )
 
+// Namespaces are DNS-1123 labels, and service account names DNS-1123
+// subdomains, so both are safe to use in a URI path.
+var (
+	namespaceRegexp      = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	serviceAccountRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
 type principal struct {
 	// Subject ('sub') from ID token
 	subject string
@@ -34,20 +45,43 @@ type principal struct {
 	// URI to be set in certificate. URI is of the form
 	// https://kubernetes.io/namespaces/<namespace>/serviceaccounts/<serviceaccount>.
 	uri string
+
+	// Namespace of the service account
+	namespace string
+
+	// Name of the service account
+	serviceAccount string
 }
 
 func PrincipalFromIDToken(ctx context.Context, token *oidc.IDToken) (identity.Principal, error) {
-	k8sURI, err := kubernetesToken(token)
+	if cfg := config.FromContext(ctx); cfg != nil {
+		if iss, ok := cfg.GetIssuer(token.Issuer); ok && !audienceAllowed(token.Audience, iss.ClientID) {
+			return nil, fmt.Errorf("token audience %v does not include expected audience %s", token.Audience, iss.ClientID)
+		}
+	}
+
+	namespace, serviceAccount, err := kubernetesToken(token)
 	if err != nil {
 		return nil, err
 	}
 	return principal{
-		subject: token.Subject,
-		issuer:  token.Issuer,
-		uri:     k8sURI,
+		subject:        token.Subject,
+		issuer:         token.Issuer,
+		uri:            "https://kubernetes.io/namespaces/" + namespace + "/serviceaccounts/" + serviceAccount,
+		namespace:      namespace,
+		serviceAccount: serviceAccount,
 	}, nil
 }
 
+func audienceAllowed(audience []string, expected string) bool {
+	for _, aud := range audience {
+		if aud == expected {
+			return true
+		}
+	}
+	return false
+}
+
 func (p principal) Name(context.Context) string {
 	return p.subject
 }
@@ -59,8 +93,15 @@ func (p principal) Embed(ctx context.Context, cert *x509.Certificate) error {
 	}
 	cert.URIs = []*url.URL{parsed}
 
+	arc, err := config.FromContext(ctx).ExtensionArc()
+	if err != nil {
+		return err
+	}
 	cert.ExtraExtensions, err = certificate.Extensions{
-		Issuer: p.issuer,
+		Arc:                      arc,
+		Issuer:                   p.issuer,
+		KubernetesNamespace:      p.namespace,
+		KubernetesServiceAccount: p.serviceAccount,
 	}.Render()
 	if err != nil {
 		return err
@@ -69,7 +110,10 @@ func (p principal) Embed(ctx context.Context, cert *x509.Certificate) error {
 	return nil
 }
 
-func kubernetesToken(token *oidc.IDToken) (string, error) {
+// kubernetesToken returns the namespace and name of the service account a
+// token is bound to, from the claims of projected tokens or, failing that,
+// of legacy service account tokens.
+func kubernetesToken(token *oidc.IDToken) (string, string, error) {
 	// Extract custom claims
 	var claims struct {
 		// "kubernetes.io": {
@@ -94,11 +138,31 @@ func kubernetesToken(token *oidc.IDToken) (string, error) {
 				UID  string `json:"uid"`
 			} `json:"serviceaccount"`
 		} `json:"kubernetes.io"`
+
+		// Legacy service account tokens have flat claims
+		LegacyNamespace      string `json:"kubernetes.io/serviceaccount/namespace"`
+		LegacyServiceAccount string `json:"kubernetes.io/serviceaccount/service-account.name"`
 	}
 	if err := token.Claims(&claims); err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	// We use this in URIs, so it has to be a URI.
-	return "https://kubernetes.io/namespaces/" + claims.Kubernetes.Namespace + "/serviceaccounts/" + claims.Kubernetes.ServiceAccount.Name, nil
+	namespace, serviceAccount := claims.Kubernetes.Namespace, claims.Kubernetes.ServiceAccount.Name
+	if namespace == "" && serviceAccount == "" {
+		namespace, serviceAccount = claims.LegacyNamespace, claims.LegacyServiceAccount
+	}
+	if namespace == "" {
+		return "", "", errors.New("missing kubernetes.io namespace claim in ID token")
+	}
+	if serviceAccount == "" {
+		return "", "", errors.New("missing kubernetes.io service account name claim in ID token")
+	}
+	// We use these in URIs, so they have to be valid in a URI path.
+	if len(namespace) > 63 || !namespaceRegexp.MatchString(namespace) {
+		return "", "", fmt.Errorf("invalid namespace %q in ID token", namespace)
+	}
+	if len(serviceAccount) > 253 || !serviceAccountRegexp.MatchString(serviceAccount) {
+		return "", "", fmt.Errorf("invalid service account name %q in ID token", serviceAccount)
+	}
+	return namespace, serviceAccount, nil
 }
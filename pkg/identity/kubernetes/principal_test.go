@@ -29,6 +29,8 @@ import (
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/google/go-cmp/cmp"
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/fulcio/pkg/config"
 )
 
 func TestPrincipalFromIDToken(t *testing.T) {
@@ -55,19 +57,103 @@ func TestPrincipalFromIDToken(t *testing.T) {
 				"sub": "system:serviceaccount:foo:baz",
 			},
 			ExpectedPrincipal: principal{
-				issuer:  "https://iss.example.com",
-				subject: "system:serviceaccount:foo:baz",
-				uri:     "https://kubernetes.io/namespaces/foo/serviceaccounts/baz",
+				issuer:         "https://iss.example.com",
+				subject:        "system:serviceaccount:foo:baz",
+				uri:            "https://kubernetes.io/namespaces/foo/serviceaccounts/baz",
+				namespace:      "foo",
+				serviceAccount: "baz",
 			},
 			WantErr: false,
 		},
+		`Legacy service account token authenticates with correct claims`: {
+			Claims: map[string]interface{}{
+				"aud":                                    []string{"sigstore"},
+				"iss":                                    "kubernetes/serviceaccount",
+				"kubernetes.io/serviceaccount/namespace": "foo",
+				"kubernetes.io/serviceaccount/service-account.name": "baz",
+				"sub": "system:serviceaccount:foo:baz",
+			},
+			ExpectedPrincipal: principal{
+				issuer:         "kubernetes/serviceaccount",
+				subject:        "system:serviceaccount:foo:baz",
+				uri:            "https://kubernetes.io/namespaces/foo/serviceaccounts/baz",
+				namespace:      "foo",
+				serviceAccount: "baz",
+			},
+			WantErr: false,
+		},
+		`Token missing namespace claim is rejected`: {
+			Claims: map[string]interface{}{
+				"aud": []string{"sigstore"},
+				"iss": "https://iss.example.com",
+				"kubernetes.io": map[string]interface{}{
+					"serviceaccount": map[string]string{
+						"name": "baz",
+						"uid":  "5cb6264f-e283-4365-9a1f-d5a15090527e",
+					},
+				},
+				"sub": "system:serviceaccount:foo:baz",
+			},
+			WantErr: true,
+		},
+		`Token missing service account name claim is rejected`: {
+			Claims: map[string]interface{}{
+				"aud": []string{"sigstore"},
+				"iss": "https://iss.example.com",
+				"kubernetes.io": map[string]interface{}{
+					"namespace": "foo",
+				},
+				"sub": "system:serviceaccount:foo:baz",
+			},
+			WantErr: true,
+		},
+		`Token with invalid namespace is rejected`: {
+			Claims: map[string]interface{}{
+				"aud": []string{"sigstore"},
+				"iss": "https://iss.example.com",
+				"kubernetes.io": map[string]interface{}{
+					"namespace": "../foo",
+					"serviceaccount": map[string]string{
+						"name": "baz",
+					},
+				},
+				"sub": "system:serviceaccount:foo:baz",
+			},
+			WantErr: true,
+		},
+		`Token for another audience is rejected`: {
+			Claims: map[string]interface{}{
+				"aud": []string{"kubernetes"},
+				"iss": "https://iss.example.com",
+				"kubernetes.io": map[string]interface{}{
+					"namespace": "foo",
+					"serviceaccount": map[string]string{
+						"name": "baz",
+					},
+				},
+				"sub": "system:serviceaccount:foo:baz",
+			},
+			WantErr: true,
+		},
 	}
 
+	cfg := &config.FulcioConfig{
+		OIDCIssuers: map[string]config.OIDCIssuer{
+			"https://iss.example.com": {
+				IssuerURL: "https://iss.example.com",
+				ClientID:  "sigstore",
+				Type:      config.IssuerTypeKubernetes,
+			},
+		},
+	}
+	ctx := config.With(context.Background(), cfg)
+
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			token := &oidc.IDToken{
-				Issuer:  test.Claims["iss"].(string),
-				Subject: test.Claims["sub"].(string),
+				Issuer:   test.Claims["iss"].(string),
+				Subject:  test.Claims["sub"].(string),
+				Audience: test.Claims["aud"].([]string),
 			}
 			claims, err := json.Marshal(test.Claims)
 			if err != nil {
@@ -75,7 +161,7 @@ func TestPrincipalFromIDToken(t *testing.T) {
 			}
 			withClaims(token, claims)
 
-			untyped, err := PrincipalFromIDToken(context.TODO(), token)
+			untyped, err := PrincipalFromIDToken(ctx, token)
 			if err != nil {
 				if !test.WantErr {
 					t.Fatal("didn't expect error", err)
@@ -165,12 +251,16 @@ func TestEmbed(t *testing.T) {
 	}{
 		`Good Kubernetes value`: {
 			Principal: principal{
-				issuer: `https://k8s.example.com`,
-				uri:    "https://kubernetes.io/namespaces/foo/serviceaccounts/bar",
+				issuer:         `https://k8s.example.com`,
+				uri:            "https://kubernetes.io/namespaces/foo/serviceaccounts/bar",
+				namespace:      "foo",
+				serviceAccount: "bar",
 			},
 			WantErr: false,
 			WantFacts: map[string]func(x509.Certificate) error{
 				`Issuer	is k8s.example.com`: factIssuerIs(`https://k8s.example.com`),
+				`Namespace is foo`:          factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcKubernetesNamespace}, "foo"),
+				`Service account is bar`:    factExtensionIs(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1, certificate.ArcKubernetesServiceAccount}, "bar"),
 				`SAN is https://k8s.example.com`: func(cert x509.Certificate) error {
 					WantURI, err := url.Parse("https://kubernetes.io/namespaces/foo/serviceaccounts/bar")
 					if err != nil {
@@ -198,7 +288,9 @@ func TestEmbed(t *testing.T) {
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			var cert x509.Certificate
-			err := test.Principal.Embed(context.TODO(), &cert)
+			// Extensions without Sigstore OIDs need an arc
+			ctx := config.With(context.TODO(), &config.FulcioConfig{ExtensionOIDArc: "1.3.6.1.4.1.32473.1"})
+			err := test.Principal.Embed(ctx, &cert)
 			if err != nil {
 				if !test.WantErr {
 					t.Error(err)
@@ -0,0 +1,170 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package santemplate renders Subject Alternative Names from the claims of
+// an ID token, so operators can choose which claims form an identity.
+package santemplate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"unicode"
+)
+
+// claimFunc is added to the end of every action, to turn the claim it
+// outputs into a string and check it can't inject other names.
+const claimFunc = "claim"
+
+// funcs are the only functions a template may call. text/template's
+// builtins, like printf and call, aren't allowed.
+var funcs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+}
+
+// SANTemplate is a text/template over the claims of an ID token, such as
+// "{{.preferred_username}}!example.com", that renders the OtherName of an
+// identity.
+//
+// Templates may only output claims, optionally piped through the lower,
+// upper, trimPrefix and trimSuffix functions. Conditionals, loops, variables
+// and the text/template builtin functions are rejected.
+type SANTemplate struct {
+	text string
+	tmpl *template.Template
+}
+
+// Parse parses a SANTemplate, checking it only uses the allowed actions and
+// functions.
+func Parse(text string) (*SANTemplate, error) {
+	tmpl, err := template.New("SANTemplate").
+		Option("missingkey=error").
+		Funcs(funcs).
+		Funcs(template.FuncMap{claimFunc: claimString}).
+		Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Name() != tmpl.Name() {
+			return nil, fmt.Errorf("SANTemplate must not define template %q", t.Name())
+		}
+	}
+	if err := restrict(tmpl.Tree.Root); err != nil {
+		return nil, err
+	}
+	return &SANTemplate{text: text, tmpl: tmpl}, nil
+}
+
+// String returns the text of the template.
+func (t *SANTemplate) String() string {
+	return t.text
+}
+
+// Render renders the template with claims, which are decoded from the JSON
+// payload of a token. Claims output by the template must be present, and be
+// strings, numbers or booleans without control characters or ! separators,
+// so that a claim can't change the structure of the name.
+func (t *SANTemplate) Render(claims map[string]interface{}) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, claims); err != nil {
+		return "", fmt.Errorf("rendering SANTemplate: %w", err)
+	}
+	if b.Len() == 0 {
+		return "", errors.New("SANTemplate rendered an empty name")
+	}
+	return b.String(), nil
+}
+
+// restrict checks the nodes of a template only output claims with the
+// allowed functions, and appends claimFunc to each action's pipeline.
+func restrict(list *parse.ListNode) error {
+	for _, node := range list.Nodes {
+		switch node := node.(type) {
+		case *parse.TextNode, *parse.CommentNode:
+		case *parse.ActionNode:
+			if err := restrictPipe(node.Pipe); err != nil {
+				return err
+			}
+			node.Pipe.Cmds = append(node.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Pos:      node.Pos,
+				Args:     []parse.Node{parse.NewIdentifier(claimFunc).SetPos(node.Pos)},
+			})
+		default:
+			return fmt.Errorf("SANTemplate must not contain %s", node)
+		}
+	}
+	return nil
+}
+
+func restrictPipe(pipe *parse.PipeNode) error {
+	if len(pipe.Decl) > 0 {
+		return fmt.Errorf("SANTemplate must not declare variables: %s", pipe)
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			switch arg := arg.(type) {
+			case *parse.FieldNode, *parse.StringNode:
+			case *parse.IdentifierNode:
+				if _, ok := funcs[arg.Ident]; !ok {
+					return fmt.Errorf("SANTemplate must not call function %q", arg.Ident)
+				}
+			case *parse.PipeNode:
+				if err := restrictPipe(arg); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("SANTemplate must not contain %s", arg)
+			}
+		}
+	}
+	return nil
+}
+
+// claimString returns a claim value as a string, checking that it's a
+// scalar without characters that could inject more names.
+func claimString(v interface{}) (string, error) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		s = v.String()
+	case bool:
+		s = strconv.FormatBool(v)
+	case nil:
+		return "", errors.New("claim is null")
+	default:
+		return "", fmt.Errorf("claim is a %T, not a string", v)
+	}
+	if strings.Contains(s, "!") {
+		return "", fmt.Errorf("claim %q must not contain !", s)
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			return "", fmt.Errorf("claim %q contains control character %U", s, r)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package santemplate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	claims := map[string]interface{}{
+		"sub":                "1234",
+		"aud":                "sigstore",
+		"preferred_username": "Alice",
+		"bang":               "alice!evil.example.com",
+		"newline":            "alice\nbob",
+		"null":               nil,
+		"number":             float64(42),
+		"verified":           true,
+		"groups":             []interface{}{"admins"},
+		"federated": map[string]interface{}{
+			"user": "bob",
+		},
+	}
+
+	tests := map[string]struct {
+		template string
+		want     string
+		wantErr  string
+	}{
+		`Claims are substituted`: {
+			template: "{{.sub}}@{{.aud}}",
+			want:     "1234@sigstore",
+		},
+		`Functions can be applied to claims`: {
+			template: `{{.preferred_username | lower}}!example.com`,
+			want:     "alice!example.com",
+		},
+		`Functions take arguments`: {
+			template: `{{trimPrefix "A" .preferred_username}}!example.com`,
+			want:     "lice!example.com",
+		},
+		`Nested claims can be used`: {
+			template: "{{.federated.user}}!example.com",
+			want:     "bob!example.com",
+		},
+		`Numbers and booleans are formatted`: {
+			template: "{{.number}}-{{.verified}}!example.com",
+			want:     "42-true!example.com",
+		},
+		`Missing claims are an error`: {
+			template: "{{.nope}}!example.com",
+			wantErr:  `map has no entry for key "nope"`,
+		},
+		`Missing nested claims are an error`: {
+			template: "{{.federated.nope}}!example.com",
+			wantErr:  `map has no entry for key "nope"`,
+		},
+		`Null claims are an error`: {
+			template: "{{.null}}!example.com",
+			wantErr:  "claim is null",
+		},
+		`Non-scalar claims are an error`: {
+			template: "{{.groups}}!example.com",
+			wantErr:  "not a string",
+		},
+		`Claims can't inject a separator`: {
+			template: "{{.bang}}!example.com",
+			wantErr:  "must not contain !",
+		},
+		`Claims can't inject control characters`: {
+			template: "{{.newline}}!example.com",
+			wantErr:  "control character",
+		},
+		`Claims can't inject a separator through functions`: {
+			template: `{{.bang | trimSuffix ".com"}}!example.com`,
+			wantErr:  "must not contain !",
+		},
+		`Empty names are an error`: {
+			template: `{{/* nothing */}}`,
+			wantErr:  "empty name",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := Parse(test.template)
+			if err != nil {
+				t.Fatalf("Parse() = %v", err)
+			}
+			got, err := tmpl.Render(claims)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error containing %q, got %q, %v", test.wantErr, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render() = %v", err)
+			}
+			if got != test.want {
+				t.Fatalf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseRestricted(t *testing.T) {
+	tests := map[string]string{
+		`{{printf "%s!evil.example.com" .sub}}`:           `must not call function "printf"`,
+		`{{call .sub}}`:                                   `must not call function "call"`,
+		`{{index .groups 0}}`:                             `must not call function "index"`,
+		`{{if .sub}}{{.sub}}{{end}}!example.com`:          "must not contain",
+		`{{range .groups}}{{.}}{{end}}!example.com`:       "must not contain",
+		`{{with .federated}}{{.user}}{{end}}`:             "must not contain",
+		`{{$x := .sub}}{{$x}}!example.com`:                "must not declare variables",
+		`{{.}}!example.com`:                               "must not contain",
+		`{{define "x"}}{{.sub}}{{end}}{{template "x" .}}`: "must not",
+		`{{claim .sub}}`:                                  `must not call function "claim"`,
+		`{{.sub`:                                          "unclosed action",
+	}
+	for text, wantErr := range tests {
+		if _, err := Parse(text); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("Parse(%q) = %v, expected error containing %q", text, err, wantErr)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spiffe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+)
+
+// TrustDomainPolicy restricts SPIFFE IDs to an allowlist of trust domains.
+// An allowed trust domain may start with a "*." label, which matches exactly
+// one label in its place, e.g. *.internal allows prod.internal but not
+// internal or a.prod.internal.
+type TrustDomainPolicy struct {
+	Allowed []string
+}
+
+// Check checks uri is a SPIFFE ID in canonical form, in an allowed trust
+// domain. IDs with dot segments in the path, such as
+// spiffe://evil/../allowed, are rejected, so the trust domain is always the
+// authority of the URI.
+func (p TrustDomainPolicy) Check(uri string) error {
+	id, err := spiffeid.FromString(uri)
+	if err != nil {
+		return fmt.Errorf("invalid spiffe ID %s: %w", uri, err)
+	}
+	if id.String() != uri {
+		return fmt.Errorf("spiffe ID %s is not in canonical form %s", uri, id)
+	}
+	return p.checkID(id)
+}
+
+func (p TrustDomainPolicy) checkID(id spiffeid.ID) error {
+	trustDomain := id.TrustDomain().String()
+	for _, allowed := range p.Allowed {
+		if strings.HasPrefix(allowed, "*") {
+			suffix := allowed[1:]
+			if !strings.HasPrefix(suffix, ".") {
+				return fmt.Errorf("unable to parse trust domain from configuration %s: wildcard must be a whole label", allowed)
+			}
+			if _, err := spiffeid.TrustDomainFromString(suffix[1:]); err != nil {
+				return fmt.Errorf("unable to parse trust domain from configuration %s: %w", allowed, err)
+			}
+			if label := strings.TrimSuffix(trustDomain, suffix); label != trustDomain && label != "" && !strings.Contains(label, ".") {
+				return nil
+			}
+			continue
+		}
+
+		parsedTrustDomain, err := spiffeid.TrustDomainFromString(allowed)
+		if err != nil {
+			return fmt.Errorf("unable to parse trust domain from configuration %s: %w", allowed, err)
+		}
+		if id.TrustDomain().Compare(parsedTrustDomain) == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("spiffe ID trust domain %s doesn't match configured trust domains %s", id.TrustDomain(), strings.Join(p.Allowed, ", "))
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spiffe
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrustDomainPolicyCheck(t *testing.T) {
+	policy := TrustDomainPolicy{Allowed: []string{"allowed", "example.com", "*.internal"}}
+	tests := map[string]struct {
+		uri     string
+		wantErr string
+	}{
+		"exact match":                     {"spiffe://example.com/foo", ""},
+		"exact match of single label":     {"spiffe://allowed/foo", ""},
+		"wildcard match":                  {"spiffe://prod.internal/foo/bar", ""},
+		"wildcard needs a label":          {"spiffe://internal/foo", "doesn't match configured trust domains"},
+		"wildcard matches only one label": {"spiffe://a.prod.internal/foo", "doesn't match configured trust domains"},
+		"wildcard suffix is a label":      {"spiffe://prodinternal/foo", "doesn't match configured trust domains"},
+		"subdomain of exact match":        {"spiffe://foo.example.com/foo", "doesn't match configured trust domains"},
+		"other trust domain":              {"spiffe://evil/foo", "doesn't match configured trust domains"},
+		"spoofed path":                    {"spiffe://evil/../allowed", "invalid spiffe ID"},
+		"spoofed encoded path":            {"spiffe://evil/%2e%2e/allowed", "invalid spiffe ID"},
+		"trust domain in userinfo":        {"spiffe://allowed@evil/foo", "invalid spiffe ID"},
+		"trust domain with port":          {"spiffe://allowed:443/foo", "invalid spiffe ID"},
+		"uppercase trust domain":          {"spiffe://EXAMPLE.com/foo", "invalid spiffe ID"},
+		"not canonical":                   {"spiffe://example.com/foo/", "invalid spiffe ID"},
+		"not spiffe":                      {"https://example.com/foo", "invalid spiffe ID"},
+		"empty":                           {"", "invalid spiffe ID"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := policy.Check(test.uri)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Check(%q) = %v", test.uri, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("Check(%q) = %v, want error containing %q", test.uri, err, test.wantErr)
+			}
+		})
+	}
+
+	// Nothing is allowed without an allowlist
+	if err := (TrustDomainPolicy{}).Check("spiffe://example.com/foo"); err == nil {
+		t.Fatal("expected error with empty allowlist")
+	}
+	// Misconfigured wildcards are reported
+	for _, allowed := range []string{"*internal", "*.", "*.EXAMPLE"} {
+		if err := (TrustDomainPolicy{Allowed: []string{allowed}}).Check("spiffe://prod.internal/foo"); err == nil || !strings.Contains(err.Error(), "unable to parse trust domain") {
+			t.Fatalf("expected configuration error for %q, got %v", allowed, err)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package spiffe
 import (
 	"context"
 	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
@@ -32,6 +33,10 @@ type principal struct {
 	// spiffe ID
 	id string
 
+	// Other spiffe IDs held by the workload, from the configured
+	// SPIFFEIDsClaim
+	additionalIDs []string
+
 	// OIDC issuer url
 	issuer string
 }
@@ -42,33 +47,75 @@ func PrincipalFromIDToken(ctx context.Context, token *oidc.IDToken) (identity.Pr
 		return nil, errors.New("invalid configuration for OIDC ID Token issuer")
 	}
 
-	if err := validSpiffeID(token.Subject, cfg.SPIFFETrustDomain); err != nil {
+	trustDomains := append([]string{cfg.SPIFFETrustDomain}, cfg.SPIFFETrustDomains...)
+	if err := validSpiffeID(token.Subject, trustDomains); err != nil {
 		return nil, err
 	}
 
+	var additionalIDs []string
+	if cfg.SPIFFEIDsClaim != "" {
+		ids, err := spiffeIDsFromClaim(token, cfg.SPIFFEIDsClaim)
+		if err != nil {
+			return nil, err
+		}
+		seen := map[string]bool{token.Subject: true}
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if err := validSpiffeID(id, trustDomains); err != nil {
+				return nil, fmt.Errorf("%s claim: %w", cfg.SPIFFEIDsClaim, err)
+			}
+			additionalIDs = append(additionalIDs, id)
+		}
+	}
+
 	return principal{
-		id:     token.Subject,
-		issuer: token.Issuer,
+		id:            token.Subject,
+		additionalIDs: additionalIDs,
+		issuer:        token.Issuer,
 	}, nil
 
 }
 
-func validSpiffeID(id, trustDomain string) error {
-	parsedTrustDomain, err := spiffeid.TrustDomainFromString(trustDomain)
-	if err != nil {
-		return fmt.Errorf("unable to parse trust domain from configuration %s: %w", trustDomain, err)
+// spiffeIDsFromClaim reads a claim that holds a single SPIFFE ID or a list of
+// them. A missing claim holds no IDs.
+func spiffeIDsFromClaim(token *oidc.IDToken, claim string) ([]string, error) {
+	var claims map[string]json.RawMessage
+	if err := token.Claims(&claims); err != nil {
+		return nil, err
+	}
+	raw, ok := claims[claim]
+	if !ok {
+		return nil, nil
 	}
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return []string{id}, nil
+	}
+	var ids []string
+	if err := json.Unmarshal(raw, &ids); err != nil {
+		return nil, fmt.Errorf("%s claim must be a string or list of strings", claim)
+	}
+	return ids, nil
+}
 
+// validSpiffeID checks id is a canonical SPIFFE ID in one of trustDomains.
+func validSpiffeID(id string, trustDomains []string) error {
 	parsedID, err := spiffeid.FromString(id)
 	if err != nil {
 		return fmt.Errorf("invalid spiffe ID provided: %s", id)
 	}
 
-	if parsedID.TrustDomain().Compare(parsedTrustDomain) != 0 {
-		return fmt.Errorf("spiffe ID trust domain %s doesn't match configured trust domain %s", parsedID.TrustDomain(), trustDomain)
+	// The SPIFFE ID is carried verbatim in the subject, so reject any
+	// subject that is not already in canonical form rather than
+	// certifying a normalized SAN that differs from it.
+	if parsedID.String() != id {
+		return fmt.Errorf("spiffe ID %s does not match token subject %s", parsedID, id)
 	}
 
-	return nil
+	return TrustDomainPolicy{Allowed: trustDomains}.checkID(parsedID)
 }
 
 func (p principal) Name(context.Context) string {
@@ -76,12 +123,24 @@ func (p principal) Name(context.Context) string {
 }
 
 func (p principal) Embed(ctx context.Context, cert *x509.Certificate) error {
-	parsed, err := url.Parse(p.id)
-	if err != nil {
-		return err
+	uris := make([]*url.URL, 0, 1+len(p.additionalIDs))
+	for _, id := range append([]string{p.id}, p.additionalIDs...) {
+		parsed, err := url.Parse(id)
+		if err != nil {
+			return err
+		}
+		if _, err := spiffeid.FromURI(parsed); err != nil {
+			return fmt.Errorf("invalid spiffe ID %s: %w", id, err)
+		}
+		// The SAN must be exactly the ID from the token
+		if parsed.String() != id {
+			return fmt.Errorf("spiffe ID SAN %s does not match token spiffe ID %s", parsed, id)
+		}
+		uris = append(uris, parsed)
 	}
-	cert.URIs = []*url.URL{parsed}
+	cert.URIs = uris
 
+	var err error
 	cert.ExtraExtensions, err = certificate.Extensions{
 		Issuer: p.issuer,
 	}.Render()
@@ -19,10 +19,13 @@ import (
 	"context"
 	"crypto/x509"
 	"encoding/asn1"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
+	"reflect"
 	"testing"
+	"unsafe"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/google/go-cmp/cmp"
@@ -51,10 +54,38 @@ func TestPrincipalFromIDToken(t *testing.T) {
 			Token:   &oidc.IDToken{Issuer: "https://issuer.example.com", Subject: "spiffe://foo.example.com/foo/bar"},
 			WantErr: true,
 		},
+		`Additional allowed trust domain authenticates`: {
+			Token: &oidc.IDToken{Issuer: "https://multi.example.com", Subject: "spiffe://foo.example.com/foo/bar"},
+			Principal: principal{
+				issuer: "https://multi.example.com",
+				id:     "spiffe://foo.example.com/foo/bar",
+			},
+			WantErr: false,
+		},
+		`Trust domain outside the allowlist should error`: {
+			Token:   &oidc.IDToken{Issuer: "https://multi.example.com", Subject: "spiffe://bar.example.com/foo/bar"},
+			WantErr: true,
+		},
+		`Wildcard trust domain authenticates`: {
+			Token: &oidc.IDToken{Issuer: "https://multi.example.com", Subject: "spiffe://prod.internal/foo/bar"},
+			Principal: principal{
+				issuer: "https://multi.example.com",
+				id:     "spiffe://prod.internal/foo/bar",
+			},
+			WantErr: false,
+		},
+		`Trust domain under a wildcard label should error`: {
+			Token:   &oidc.IDToken{Issuer: "https://multi.example.com", Subject: "spiffe://a.prod.internal/foo/bar"},
+			WantErr: true,
+		},
 		`Invalid ID should error`: {
 			Token:   &oidc.IDToken{Issuer: "https://issuer.example.com", Subject: "not-a-spiffe-id"},
 			WantErr: true,
 		},
+		`Tampered subject that is not a canonical spiffe ID should error`: {
+			Token:   &oidc.IDToken{Issuer: "https://issuer.example.com", Subject: "spiffe://example.com/foo/../bar"},
+			WantErr: true,
+		},
 	}
 
 	cfg := &config.FulcioConfig{
@@ -65,6 +96,13 @@ func TestPrincipalFromIDToken(t *testing.T) {
 				Type:              "spiffe",
 				SPIFFETrustDomain: "example.com",
 			},
+			"https://multi.example.com": {
+				IssuerURL:          "https://multi.example.com",
+				ClientID:           "sigstore",
+				Type:               "spiffe",
+				SPIFFETrustDomain:  "example.com",
+				SPIFFETrustDomains: []string{"foo.example.com", "*.internal"},
+			},
 		},
 	}
 	ctx := config.With(context.Background(), cfg)
@@ -86,13 +124,107 @@ func TestPrincipalFromIDToken(t *testing.T) {
 			if !ok {
 				t.Errorf("Got wrong principal type %v", untyped)
 			}
-			if p != test.Principal {
+			if !reflect.DeepEqual(p, test.Principal) {
 				t.Errorf("got %v principal and expected %v", p, test.Principal)
 			}
 		})
 	}
 }
 
+func TestPrincipalFromIDTokenWithSPIFFEIDsClaim(t *testing.T) {
+	tests := map[string]struct {
+		Claims        map[string]interface{}
+		AdditionalIDs []string
+		WantErr       bool
+	}{
+		`Token with two additional spiffe IDs`: {
+			Claims: map[string]interface{}{
+				"spiffe_ids": []string{"spiffe://example.com/foo/baz", "spiffe://example.com/qux"},
+			},
+			AdditionalIDs: []string{"spiffe://example.com/foo/baz", "spiffe://example.com/qux"},
+		},
+		`Claim with a single spiffe ID`: {
+			Claims:        map[string]interface{}{"spiffe_ids": "spiffe://example.com/foo/baz"},
+			AdditionalIDs: []string{"spiffe://example.com/foo/baz"},
+		},
+		`Duplicates of the subject are dropped`: {
+			Claims: map[string]interface{}{
+				"spiffe_ids": []string{"spiffe://example.com/foo/bar", "spiffe://example.com/qux", "spiffe://example.com/qux"},
+			},
+			AdditionalIDs: []string{"spiffe://example.com/qux"},
+		},
+		`Missing claim only uses the subject`: {
+			Claims: map[string]interface{}{},
+		},
+		`Spiffe ID in another trust domain should error`: {
+			Claims: map[string]interface{}{
+				"spiffe_ids": []string{"spiffe://example.com/qux", "spiffe://other.example.com/qux"},
+			},
+			WantErr: true,
+		},
+		`Non-canonical spiffe ID should error`: {
+			Claims:  map[string]interface{}{"spiffe_ids": []string{"spiffe://example.com/foo/../qux"}},
+			WantErr: true,
+		},
+		`Claim that is not a string or list of strings should error`: {
+			Claims:  map[string]interface{}{"spiffe_ids": 42},
+			WantErr: true,
+		},
+	}
+
+	cfg := &config.FulcioConfig{
+		OIDCIssuers: map[string]config.OIDCIssuer{
+			"https://issuer.example.com": {
+				IssuerURL:         "https://issuer.example.com",
+				ClientID:          "sigstore",
+				Type:              "spiffe",
+				SPIFFETrustDomain: "example.com",
+				SPIFFEIDsClaim:    "spiffe_ids",
+			},
+		},
+	}
+	ctx := config.With(context.Background(), cfg)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			token := &oidc.IDToken{Issuer: "https://issuer.example.com", Subject: "spiffe://example.com/foo/bar"}
+			claims, err := json.Marshal(test.Claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			withClaims(token, claims)
+
+			untyped, err := PrincipalFromIDToken(ctx, token)
+			if err != nil {
+				if !test.WantErr {
+					t.Fatal("didn't expect error", err)
+				}
+				return
+			}
+			if test.WantErr {
+				t.Fatal("expected error but got none")
+			}
+
+			want := principal{
+				issuer:        "https://issuer.example.com",
+				id:            "spiffe://example.com/foo/bar",
+				additionalIDs: test.AdditionalIDs,
+			}
+			if !reflect.DeepEqual(untyped, want) {
+				t.Errorf("got %v principal and expected %v", untyped, want)
+			}
+		})
+	}
+}
+
+func withClaims(token *oidc.IDToken, data []byte) {
+	val := reflect.Indirect(reflect.ValueOf(token))
+	member := val.FieldByName("claims")
+	pointer := unsafe.Pointer(member.UnsafeAddr())
+	realPointer := (*[]byte)(pointer)
+	*realPointer = data
+}
+
 func TestName(t *testing.T) {
 	tests := map[string]struct {
 		Token        *oidc.IDToken
@@ -159,6 +291,34 @@ func TestEmbed(t *testing.T) {
 				},
 			},
 		},
+		`Multiple spiffe IDs`: {
+			Principal: principal{
+				issuer:        `example.com`,
+				id:            `spiffe://example.com/foo/bar`,
+				additionalIDs: []string{`spiffe://example.com/foo/baz`},
+			},
+			WantErr: false,
+			WantFacts: map[string]func(x509.Certificate) error{
+				`SANs are the subject then the additional IDs`: func(cert x509.Certificate) error {
+					var got []string
+					for _, uri := range cert.URIs {
+						got = append(got, uri.String())
+					}
+					if diff := cmp.Diff([]string{"spiffe://example.com/foo/bar", "spiffe://example.com/foo/baz"}, got); diff != "" {
+						return errors.New(diff)
+					}
+					return nil
+				},
+			},
+		},
+		`Non-spiffe additional ID fails`: {
+			Principal: principal{
+				issuer:        `example.com`,
+				id:            `spiffe://example.com/foo/bar`,
+				additionalIDs: []string{`https://example.com/foo/baz`},
+			},
+			WantErr: true,
+		},
 		`Spiffe value with bad URL fails`: {
 			Principal: principal{
 				issuer: `example.com`,
@@ -166,6 +326,20 @@ func TestEmbed(t *testing.T) {
 			},
 			WantErr: true,
 		},
+		`Spiffe value that does not exactly match the SAN fails`: {
+			Principal: principal{
+				issuer: `example.com`,
+				id:     "SPIFFE://example.com/foo/bar",
+			},
+			WantErr: true,
+		},
+		`Non-spiffe URI fails`: {
+			Principal: principal{
+				issuer: `example.com`,
+				id:     "https://example.com/foo/bar",
+			},
+			WantErr: true,
+		},
 		`Empty issuer url should fail to render extensions`: {
 			Principal: principal{
 				issuer: "",
@@ -218,35 +392,49 @@ func factExtensionIs(oid asn1.ObjectIdentifier, value string) func(x509.Certific
 
 func TestValidSpiffeID(t *testing.T) {
 	tests := map[string]struct {
-		ID          string
-		TrustDomain string
-		WantErr     bool
+		ID           string
+		TrustDomains []string
+		WantErr      bool
 	}{
 		`Valid ID with matching trust domain results in no error`: {
-			ID:          `spiffe://foo.com/bar`,
-			TrustDomain: `foo.com`,
-			WantErr:     false,
+			ID:           `spiffe://foo.com/bar`,
+			TrustDomains: []string{`foo.com`},
+			WantErr:      false,
 		},
 		`Invalid trust domain errors`: {
-			ID:          `spiffe://foo.com/bar`,
-			TrustDomain: `not#a#trust#domain`,
-			WantErr:     true,
+			ID:           `spiffe://foo.com/bar`,
+			TrustDomains: []string{`not#a#trust#domain`},
+			WantErr:      true,
 		},
 		`Trust domain mismatch should error`: {
-			ID:          `spiffe://foo.com/bar`,
-			TrustDomain: `bar.com`,
-			WantErr:     true,
+			ID:           `spiffe://foo.com/bar`,
+			TrustDomains: []string{`bar.com`},
+			WantErr:      true,
+		},
+		`Valid ID in any allowed trust domain results in no error`: {
+			ID:           `spiffe://foo.com/bar`,
+			TrustDomains: []string{`bar.com`, `foo.com`},
+			WantErr:      false,
+		},
+		`No allowed trust domains should error`: {
+			ID:      `spiffe://foo.com/bar`,
+			WantErr: true,
+		},
+		`Subject with different SAN encoding should error`: {
+			ID:           `spiffe://foo.com/bar%2Fbaz`,
+			TrustDomains: []string{`foo.com`},
+			WantErr:      true,
 		},
 		`Invalid spiffe id should error`: {
-			ID:          `not#a#spiffe#id`,
-			TrustDomain: `bar.com`,
-			WantErr:     true,
+			ID:           `not#a#spiffe#id`,
+			TrustDomains: []string{`bar.com`},
+			WantErr:      true,
 		},
 	}
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			err := validSpiffeID(test.ID, test.TrustDomain)
+			err := validSpiffeID(test.ID, test.TrustDomains)
 			if err != nil {
 				if !test.WantErr {
 					t.Error("unepected error", err)
@@ -0,0 +1,105 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spiffe
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/sigstore/fulcio/pkg/identity/username"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+)
+
+var oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+// MarshalURISANS creates a Subject Alternative Name extension with a
+// uniformResourceIdentifier GeneralName holding the SPIFFE ID id, which must
+// be in canonical form. RFC 5280, 4.2.1.6:
+//
+//	GeneralName ::= CHOICE {
+//	     ...
+//	     uniformResourceIdentifier       [6]     IA5String,
+//	     ... }
+func MarshalURISANS(id string, critical bool) (*pkix.Extension, error) {
+	parsed, err := spiffeid.FromString(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spiffe ID %s: %w", id, err)
+	}
+	if parsed.String() != id {
+		return nil, fmt.Errorf("spiffe ID %s is not in canonical form %s", id, parsed)
+	}
+	return username.MarshalGeneralNames([]asn1.RawValue{
+		{Class: asn1.ClassContextSpecific, Tag: username.TagURI, Bytes: []byte(id)},
+	}, critical)
+}
+
+// UnmarshalURISANS extracts the SPIFFE ID from the uniformResourceIdentifier
+// field in the Subject Alternative Name extensions. There must be exactly
+// one, and it must be an absolute spiffe:// URI in canonical form. If
+// trustDomains is not empty, the ID must be in one of them. SANs of other
+// types are ignored.
+func UnmarshalURISANS(exts []pkix.Extension, trustDomains []string) (string, error) {
+	var uris []string
+	for _, e := range exts {
+		if !e.Id.Equal(oidSubjectAltName) {
+			continue
+		}
+
+		var names []asn1.RawValue
+		rest, err := asn1.Unmarshal(e.Value, &names)
+		if err != nil {
+			return "", err
+		} else if len(rest) != 0 {
+			return "", errors.New("trailing data after X.509 extension")
+		}
+		for _, name := range names {
+			if name.Class == asn1.ClassContextSpecific && name.Tag == username.TagURI && !name.IsCompound {
+				uris = append(uris, string(name.Bytes))
+			}
+		}
+	}
+
+	if len(uris) == 0 {
+		return "", errors.New("no URI SAN found")
+	}
+	if len(uris) != 1 {
+		return "", errors.New("expected only one URI SAN")
+	}
+
+	u, err := url.Parse(uris[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid URI SAN %q: %w", uris[0], err)
+	}
+	if !u.IsAbs() {
+		return "", fmt.Errorf("URI SAN %q is not absolute", uris[0])
+	}
+	id, err := spiffeid.FromURI(u)
+	if err != nil {
+		return "", fmt.Errorf("invalid spiffe ID %s: %w", uris[0], err)
+	}
+	if id.String() != uris[0] {
+		return "", fmt.Errorf("spiffe ID %s is not in canonical form %s", uris[0], id)
+	}
+	if len(trustDomains) > 0 {
+		if err := (TrustDomainPolicy{Allowed: trustDomains}).checkID(id); err != nil {
+			return "", err
+		}
+	}
+	return uris[0], nil
+}
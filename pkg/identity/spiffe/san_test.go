@@ -0,0 +1,184 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spiffe
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/identity/username"
+)
+
+func TestMarshalAndUnmarshalURISANS(t *testing.T) {
+	id := "spiffe://example.com/workload"
+
+	ext, err := MarshalURISANS(id, true)
+	if err != nil {
+		t.Fatalf("MarshalURISANS() = %v", err)
+	}
+	if !ext.Critical || !ext.Id.Equal(oidSubjectAltName) {
+		t.Fatalf("expected critical SAN extension, got %v", ext)
+	}
+	got, err := UnmarshalURISANS([]pkix.Extension{*ext}, nil)
+	if err != nil {
+		t.Fatalf("UnmarshalURISANS() = %v", err)
+	}
+	if got != id {
+		t.Fatalf("expected %s, got %s", id, got)
+	}
+
+	// The extension is decoded as a URI SAN by crypto/x509
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(1),
+		NotBefore:       time.Now(),
+		NotAfter:        time.Now().Add(10 * time.Minute),
+		ExtraExtensions: []pkix.Extension{*ext},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cert.URIs) != 1 || cert.URIs[0].String() != id {
+		t.Fatalf("expected URI SAN %s, got %v", id, cert.URIs)
+	}
+
+	for _, invalid := range []string{"", "https://example.com/workload", "spiffe://example.com/a%2Fb", "spiffe://EXAMPLE.com/workload"} {
+		if _, err := MarshalURISANS(invalid, true); err == nil {
+			t.Fatalf("expected error marshaling %q", invalid)
+		}
+	}
+}
+
+func TestUnmarshalURISANS(t *testing.T) {
+	uriSAN := func(uri string) asn1.RawValue {
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: username.TagURI, Bytes: []byte(uri)}
+	}
+	sans := func(t *testing.T, names ...asn1.RawValue) []pkix.Extension {
+		ext, err := username.MarshalGeneralNames(names, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []pkix.Extension{*ext}
+	}
+	dns := asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, Bytes: []byte("example.com")}
+	email := asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, Bytes: []byte("foo@example.com")}
+
+	tests := map[string]struct {
+		Exts         []pkix.Extension
+		TrustDomains []string
+		Want         string
+		WantErr      string
+	}{
+		"spiffe ID among other SANs": {
+			Exts: sans(t, dns, uriSAN("spiffe://example.com/workload"), email),
+			Want: "spiffe://example.com/workload",
+		},
+		"allowed trust domain": {
+			Exts:         sans(t, uriSAN("spiffe://example.com/workload")),
+			TrustDomains: []string{"other.com", "example.com"},
+			Want:         "spiffe://example.com/workload",
+		},
+		"disallowed trust domain": {
+			Exts:         sans(t, uriSAN("spiffe://example.com/workload")),
+			TrustDomains: []string{"other.com"},
+			WantErr:      "doesn't match configured trust domains",
+		},
+		"no URI SAN": {
+			Exts:    sans(t, dns, email),
+			WantErr: "no URI SAN found",
+		},
+		"multiple URI SANs": {
+			Exts:    sans(t, uriSAN("spiffe://example.com/a"), uriSAN("spiffe://example.com/b")),
+			WantErr: "expected only one URI SAN",
+		},
+		"relative URI": {
+			Exts:    sans(t, uriSAN("//example.com/workload")),
+			WantErr: "not absolute",
+		},
+		"non-spiffe scheme": {
+			Exts:    sans(t, uriSAN("https://example.com/workload")),
+			WantErr: "invalid spiffe ID",
+		},
+		"non-canonical spiffe ID": {
+			Exts:    sans(t, uriSAN("spiffe://example.com/a%2Fb")),
+			WantErr: "spiffe ID",
+		},
+		"malformed extension": {
+			Exts:    []pkix.Extension{{Id: oidSubjectAltName, Value: []byte{0x30, 0x05}}},
+			WantErr: "truncated",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := UnmarshalURISANS(test.Exts, test.TrustDomains)
+			if test.WantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.WantErr) {
+					t.Fatalf("expected error containing %q, got %q, %v", test.WantErr, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnmarshalURISANS() = %v", err)
+			}
+			if got != test.Want {
+				t.Fatalf("expected %s, got %s", test.Want, got)
+			}
+		})
+	}
+
+	// URIs parsed by crypto/x509 are found in the certificate extensions
+	u, _ := url.Parse("spiffe://example.com/workload")
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(1),
+		NotBefore:      time.Now(),
+		NotAfter:       time.Now().Add(10 * time.Minute),
+		URIs:           []*url.URL{u},
+		DNSNames:       []string{"example.com"},
+		EmailAddresses: []string{"foo@example.com"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := UnmarshalURISANS(cert.Extensions, []string{"example.com"}); err != nil || got != u.String() {
+		t.Fatalf("UnmarshalURISANS() = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sigstore/fulcio/pkg/certificate"
+)
+
+// CheckOtherNameConstraints checks that the hostname of the
+// <username>!<hostname> OtherName otherName is within the DNS name
+// constraints of an issuing CA, such as the PermittedDNSDomains and
+// ExcludedDNSDomains of its x509.Certificate, as RFC 5280, 4.2.1.10 applies
+// them to dNSNames. The hostname must be within one of the permitted
+// subtrees, unless there are none, and within none of the excluded subtrees.
+//
+// A constraint like example.com covers example.com and its subdomains,
+// while .example.com, or *.example.com, only covers its subdomains. An empty
+// constraint covers every hostname. Hostnames and constraints are compared
+// ignoring case and any trailing dot.
+//
+// A wildcard hostname, such as *.pool.internal, stands for every host it
+// matches under OtherNameMatchesHost: it is permitted only if all of them are
+// within a permitted subtree, and excluded if any of them is within an
+// excluded subtree.
+func CheckOtherNameConstraints(otherName string, permitted, excluded []string) error {
+	if len(permitted) == 0 && len(excluded) == 0 {
+		return nil
+	}
+	_, host, ok := splitOtherName(otherName)
+	if !ok {
+		return fmt.Errorf("OtherName %q has no hostname to check against name constraints", otherName)
+	}
+	if !validHostname(host) {
+		return fmt.Errorf("OtherName %q has an invalid hostname %q", otherName, host)
+	}
+	host = canonicalHost(host)
+	within, overlaps := withinDNSConstraint, withinDNSConstraint
+	if suffix := strings.TrimPrefix(host, "*."); suffix != host {
+		host = suffix
+		within, overlaps = wildcardWithinDNSConstraint, wildcardOverlapsDNSConstraint
+	}
+
+	for _, constraint := range excluded {
+		if overlaps(host, constraint) {
+			return fmt.Errorf("OtherName hostname %q is excluded by name constraint %q", host, constraint)
+		}
+	}
+	if len(permitted) == 0 {
+		return nil
+	}
+	for _, constraint := range permitted {
+		if within(host, constraint) {
+			return nil
+		}
+	}
+	return fmt.Errorf("OtherName hostname %q is not permitted by name constraints %q", host, permitted)
+}
+
+// withinDNSConstraint reports whether the canonical hostname host is within
+// the subtree constraint.
+func withinDNSConstraint(host, constraint string) bool {
+	constraint = canonicalHost(strings.TrimPrefix(constraint, "*"))
+	switch {
+	case constraint == "":
+		return true
+	case constraint[0] == '.':
+		return len(host) > len(constraint) && strings.HasSuffix(host, constraint)
+	}
+	return host == constraint || strings.HasSuffix(host, "."+constraint)
+}
+
+// wildcardWithinDNSConstraint reports whether every host matched by the
+// wildcard *.suffix, for the canonical hostname suffix, is within the subtree
+// constraint. As the wildcard stands for at least one label, that holds when
+// suffix itself is the constraint or within it.
+func wildcardWithinDNSConstraint(suffix, constraint string) bool {
+	constraint = canonicalHost(strings.TrimPrefix(strings.TrimPrefix(constraint, "*"), "."))
+	return constraint == "" || suffix == constraint || strings.HasSuffix(suffix, "."+constraint)
+}
+
+// wildcardOverlapsDNSConstraint reports whether any host matched by the
+// wildcard *.suffix is within the subtree constraint: either all of them are,
+// or the constraint names a single host <label>.suffix the wildcard matches.
+func wildcardOverlapsDNSConstraint(suffix, constraint string) bool {
+	if wildcardWithinDNSConstraint(suffix, constraint) {
+		return true
+	}
+	constraint = canonicalHost(constraint)
+	label, rest, ok := strings.Cut(constraint, ".")
+	return ok && label != "" && label != "*" && rest == suffix
+}
+
+// CheckCertificateOtherNameConstraints checks every username OtherName in the
+// Subject Alternative Name extensions of cert, in both Extensions and
+// ExtraExtensions, against the DNS name constraints of issuer with
+// CheckOtherNameConstraints, so a CA doesn't sign a certificate its
+// constraints forbid.
+func CheckCertificateOtherNameConstraints(cert, issuer *x509.Certificate) error {
+	if len(issuer.PermittedDNSDomains) == 0 && len(issuer.ExcludedDNSDomains) == 0 {
+		return nil
+	}
+	for _, exts := range [][]pkix.Extension{cert.Extensions, cert.ExtraExtensions} {
+		// PackSANS may split the names over several extensions, and other
+		// kinds of OtherName aren't constrained by dNSName subtrees
+		otherNames, err := unmarshalSANS(exts, certificate.OIDOtherName, scanOptions{lenient: true, skipOtherOIDs: true})
+		if errors.Is(err, ErrNoOtherName) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		for _, otherName := range otherNames {
+			if err := CheckOtherNameConstraints(otherName, issuer.PermittedDNSDomains, issuer.ExcludedDNSDomains); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func canonicalHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
@@ -0,0 +1,253 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+)
+
+func TestCheckOtherNameConstraints(t *testing.T) {
+	tests := map[string]struct {
+		otherName string
+		permitted []string
+		excluded  []string
+		wantErr   string
+	}{
+		"no constraints": {
+			otherName: "alice!anything.example.net",
+		},
+		"no constraints without a hostname": {
+			otherName: "alice",
+		},
+		"permitted hostname": {
+			otherName: "alice!corp.com",
+			permitted: []string{"corp.com"},
+		},
+		"permitted subdomain": {
+			otherName: "alice!build.eu.corp.com",
+			permitted: []string{"other.com", "corp.com"},
+		},
+		"permitted subdomains only": {
+			otherName: "alice!corp.com",
+			permitted: []string{".corp.com"},
+			wantErr:   "not permitted",
+		},
+		"permitted wildcard subdomain": {
+			otherName: "alice!host.corp.com",
+			permitted: []string{"*.corp.com"},
+		},
+		"permitted wildcard doesn't match the domain": {
+			otherName: "alice!corp.com",
+			permitted: []string{"*.corp.com"},
+			wantErr:   "not permitted",
+		},
+		"hostname outside permitted subtrees": {
+			otherName: "user!host.evil.com",
+			permitted: []string{"*.corp.com"},
+			wantErr:   "not permitted",
+		},
+		"constraint must match whole labels": {
+			otherName: "alice!evilcorp.com",
+			permitted: []string{"corp.com"},
+			wantErr:   "not permitted",
+		},
+		"constraint doesn't match as a prefix": {
+			otherName: "alice!corp.com.evil.com",
+			permitted: []string{"corp.com"},
+			wantErr:   "not permitted",
+		},
+		"case and trailing dots are ignored": {
+			otherName: "alice!Build.CORP.com.",
+			permitted: []string{"corp.COM."},
+		},
+		"empty permitted constraint matches everything": {
+			otherName: "alice!example.net",
+			permitted: []string{""},
+		},
+		"excluded hostname": {
+			otherName: "alice!secret.corp.com",
+			excluded:  []string{"secret.corp.com"},
+			wantErr:   "excluded",
+		},
+		"excluded subdomain": {
+			otherName: "alice!a.secret.corp.com",
+			excluded:  []string{".secret.corp.com"},
+			wantErr:   "excluded",
+		},
+		"excluded takes precedence over permitted": {
+			otherName: "alice!a.secret.corp.com",
+			permitted: []string{"corp.com"},
+			excluded:  []string{"secret.corp.com"},
+			wantErr:   "excluded",
+		},
+		"outside excluded subtree": {
+			otherName: "alice!public.corp.com",
+			permitted: []string{"corp.com"},
+			excluded:  []string{"secret.corp.com"},
+		},
+		"empty permitted allows anything not excluded": {
+			otherName: "alice!example.net",
+			excluded:  []string{"corp.com"},
+		},
+		"empty permitted still applies exclusions": {
+			otherName: "alice!host.corp.com",
+			excluded:  []string{"corp.com"},
+			wantErr:   "excluded",
+		},
+		"no hostname with constraints": {
+			otherName: "alice",
+			excluded:  []string{"corp.com"},
+			wantErr:   "no hostname",
+		},
+		"several separators with constraints": {
+			otherName: "alice!corp.com!evil.com",
+			permitted: []string{"corp.com"},
+			wantErr:   "no hostname",
+		},
+		"wildcard within permitted domain": {
+			otherName: "alice!*.pool.corp.com",
+			permitted: []string{"corp.com"},
+		},
+		"wildcard over permitted subdomains": {
+			otherName: "alice!*.pool.corp.com",
+			permitted: []string{".pool.corp.com"},
+		},
+		"wildcard over permitted wildcard": {
+			otherName: "alice!*.corp.com",
+			permitted: []string{"*.corp.com"},
+		},
+		"wildcard wider than permitted host": {
+			otherName: "alice!*.pool.corp.com",
+			permitted: []string{"a.pool.corp.com"},
+			wantErr:   "not permitted",
+		},
+		"wildcard outside permitted subtrees": {
+			otherName: "alice!*.evil.com",
+			permitted: []string{"corp.com"},
+			wantErr:   "not permitted",
+		},
+		"wildcard matching an excluded host": {
+			otherName: "alice!*.pool.corp.com",
+			permitted: []string{"corp.com"},
+			excluded:  []string{"secret.pool.corp.com"},
+			wantErr:   "excluded",
+		},
+		"wildcard within an excluded domain": {
+			otherName: "alice!*.pool.corp.com",
+			excluded:  []string{"corp.com"},
+			wantErr:   "excluded",
+		},
+		"wildcard doesn't match deeper excluded hosts": {
+			otherName: "alice!*.pool.corp.com",
+			permitted: []string{"corp.com"},
+			excluded:  []string{"a.secret.pool.corp.com", ".secret.pool.corp.com", "*.secret.pool.corp.com"},
+		},
+		"wildcard over a whole top level domain": {
+			otherName: "alice!*.com",
+			permitted: []string{"corp.com"},
+			wantErr:   "invalid hostname",
+		},
+		"invalid hostname": {
+			otherName: "alice!host..corp.com",
+			permitted: []string{"corp.com"},
+			wantErr:   "invalid hostname",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := CheckOtherNameConstraints(test.otherName, test.permitted, test.excluded)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckOtherNameConstraints() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckCertificateOtherNameConstraints(t *testing.T) {
+	issuer := &x509.Certificate{
+		PermittedDNSDomains: []string{"corp.com"},
+		ExcludedDNSDomains:  []string{"secret.corp.com"},
+	}
+	split, err := MarshalSANSMulti([]string{"alice!build.corp.com", "bob!host.evil.com"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		names   []string
+		issuer  *x509.Certificate
+		wantErr string
+	}{
+		"permitted name": {
+			names:  []string{"alice!build.corp.com"},
+			issuer: issuer,
+		},
+		"permitted wildcard": {
+			names:  []string{"alice!*.pool.corp.com"},
+			issuer: issuer,
+		},
+		"name outside permitted subtrees": {
+			names:   []string{"alice!build.corp.com", "bob!host.evil.com"},
+			issuer:  issuer,
+			wantErr: "not permitted",
+		},
+		"excluded wildcard": {
+			names:   []string{"alice!*.secret.corp.com"},
+			issuer:  issuer,
+			wantErr: "excluded",
+		},
+		"unconstrained issuer": {
+			names:  []string{"bob!host.evil.com"},
+			issuer: &x509.Certificate{},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			san, err := MarshalSANSMulti(test.names, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = CheckCertificateOtherNameConstraints(&x509.Certificate{ExtraExtensions: []pkix.Extension{*san}}, test.issuer)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckCertificateOtherNameConstraints() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("names split over several extensions", func(t *testing.T) {
+		cert := &x509.Certificate{ExtraExtensions: []pkix.Extension{*split}}
+		if err := PackSANS(cert, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := CheckCertificateOtherNameConstraints(cert, issuer); err == nil || !strings.Contains(err.Error(), "not permitted") {
+			t.Fatalf("expected the second extension to be checked, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,184 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"encoding/asn1"
+
+	"github.com/sigstore/fulcio/pkg/certificate"
+)
+
+// MarshalSANS and UnmarshalSANS run for every certificate issued and
+// verified, so OtherNames are encoded and decoded by hand rather than with
+// the reflection-based encoding/asn1.
+
+// DER identifier octets of the elements of an OtherName GeneralName.
+const (
+	derTagSequence        = 0x30
+	derTagOID             = 0x06
+	derTagUTF8String      = 0x0c
+	derTagIA5String       = 0x16
+	derTagPrintableString = 0x13
+	derTagBMPString       = 0x1e
+)
+
+// Bits of a DER identifier octet.
+const (
+	derClassMask            = 0xc0
+	derClassContextSpecific = 0x80
+	derConstructed          = 0x20
+	derTagNumberMask        = 0x1f
+)
+
+// oidOtherNameDER is the DER encoding of the Sigstore OtherName OID.
+var oidOtherNameDER = mustMarshal(certificate.OIDOtherName)
+
+func mustMarshal(v interface{}) []byte {
+	b, err := asn1.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// marshalOtherNameOID returns the DER encoding of an OtherName type. The
+// result must not be modified.
+func marshalOtherNameOID(oid asn1.ObjectIdentifier) ([]byte, error) {
+	if err := validateOtherNameOID(oid); err != nil {
+		return nil, err
+	}
+	if oid.Equal(certificate.OIDOtherName) {
+		return oidOtherNameDER, nil
+	}
+	return asn1.Marshal(oid)
+}
+
+// derHeaderLen returns the length of the identifier and length octets of an
+// element with n bytes of content.
+func derHeaderLen(n int) int {
+	if n <= 0x7f {
+		return 2
+	}
+	l := 2
+	for ; n > 0; n >>= 8 {
+		l++
+	}
+	return l
+}
+
+// appendDERHeader appends the identifier and length octets of an element
+// with n bytes of content to b.
+func appendDERHeader(b []byte, tag byte, n int) []byte {
+	b = append(b, tag)
+	if n <= 0x7f {
+		return append(b, byte(n))
+	}
+	size := derHeaderLen(n) - 2
+	b = append(b, 0x80|byte(size))
+	for i := size - 1; i >= 0; i-- {
+		b = append(b, byte(n>>(8*i)))
+	}
+	return b
+}
+
+// readDERElement reads an element with a single identifier octet from the
+// start of b, requiring the minimal length encoding of DER. It returns the
+// identifier octet, the whole element, its content, and the bytes after it.
+func readDERElement(b []byte) (tag byte, element, content, rest []byte, err error) {
+	tag, n, offset, err := readDERHeader(b)
+	if err != nil {
+		return 0, nil, nil, nil, err
+	}
+	if len(b)-offset < n {
+		return 0, nil, nil, nil, asn1.SyntaxError{Msg: "data truncated"}
+	}
+	return tag, b[:offset+n], b[offset : offset+n], b[offset+n:], nil
+}
+
+// readDERHeader reads the identifier and length octets of an element from
+// the start of b, as readDERElement does, returning the identifier octet,
+// the declared length of the content and the offset it starts at. The
+// content may be shorter than declared.
+func readDERHeader(b []byte) (tag byte, n, offset int, err error) {
+	if len(b) < 2 {
+		return 0, 0, 0, asn1.SyntaxError{Msg: "data truncated"}
+	}
+	tag = b[0]
+	if tag&derTagNumberMask == derTagNumberMask {
+		return 0, 0, 0, asn1.StructuralError{Msg: "unexpected high tag number"}
+	}
+	n, offset = int(b[1]), 2
+	if n&0x80 != 0 {
+		size := n & 0x7f
+		// Lengths above 2^24 can't be in a certificate extension
+		if size == 0 || size > 3 || len(b) < 2+size {
+			return 0, 0, 0, asn1.SyntaxError{Msg: "bad length"}
+		}
+		n = 0
+		for _, c := range b[2 : 2+size] {
+			n = n<<8 | int(c)
+		}
+		offset += size
+		if derHeaderLen(n) != offset {
+			return 0, 0, 0, asn1.StructuralError{Msg: "non-minimal length"}
+		}
+	}
+	return tag, n, offset, nil
+}
+
+// indefiniteLength returns the offset in b of the first length octet of an
+// indefinite length encoding, which BER allows but DER doesn't, among the
+// elements in b and the elements nested in them. It returns -1 if there is
+// none, or if b can't be parsed far enough to find one.
+func indefiniteLength(b []byte) int {
+	for offset := 0; offset < len(b); {
+		i := offset + 1
+		if b[offset]&derTagNumberMask == derTagNumberMask {
+			for i < len(b) && b[i]&0x80 != 0 {
+				i++
+			}
+			i++
+		}
+		if i >= len(b) {
+			return -1
+		}
+		if b[i] == 0x80 {
+			return i
+		}
+		n := int(b[i])
+		i++
+		if n&0x80 != 0 {
+			size := n & 0x7f
+			if size > 3 || len(b)-i < size {
+				return -1
+			}
+			n = 0
+			for _, c := range b[i : i+size] {
+				n = n<<8 | int(c)
+			}
+			i += size
+		}
+		if len(b)-i < n {
+			return -1
+		}
+		if b[offset]&derConstructed != 0 {
+			if j := indefiniteLength(b[i : i+n]); j != -1 {
+				return i + j
+			}
+		}
+		offset = i + n
+	}
+	return -1
+}
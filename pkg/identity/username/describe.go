@@ -0,0 +1,118 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"net"
+	"unicode/utf8"
+)
+
+// SANDescription describes the Subject Alternative Names of a certificate,
+// for debugging. It can be marshaled as JSON.
+type SANDescription struct {
+	Extensions []SANExtensionDescription `json:"extensions"`
+}
+
+// SANExtensionDescription describes one Subject Alternative Name extension.
+type SANExtensionDescription struct {
+	Critical bool                     `json:"critical"`
+	Names    []GeneralNameDescription `json:"names"`
+}
+
+// GeneralNameDescription describes a GeneralName with its context-specific
+// Tag. Type is one of otherName, rfc822Name, dnsName, uri or ipAddress, with
+// the decoded Value, and for otherNames the type OID. GeneralNames of other
+// types, or that can't be decoded, have the type unknown. Their contents, or
+// the DER-encoded value of an otherName that isn't a string, are in Raw as
+// hex.
+type GeneralNameDescription struct {
+	Type  string `json:"type"`
+	Tag   int    `json:"tag"`
+	Value string `json:"value,omitempty"`
+	OID   string `json:"oid,omitempty"`
+	Raw   string `json:"raw,omitempty"`
+}
+
+// DescribeSANS describes every GeneralName in the Subject Alternative Name
+// extensions among exts, in order. Unlike UnmarshalSANS, it doesn't fail on
+// unexpected GeneralNames, only if an extension isn't a sequence of
+// GeneralNames.
+func DescribeSANS(exts []pkix.Extension) (SANDescription, error) {
+	desc := SANDescription{Extensions: []SANExtensionDescription{}}
+	for _, e := range exts {
+		if !e.Id.Equal(oidSubjectAltName) {
+			continue
+		}
+
+		var names []asn1.RawValue
+		rest, err := asn1.Unmarshal(e.Value, &names)
+		if err != nil {
+			return SANDescription{}, sentinelError{ErrMalformedSAN, err}
+		} else if len(rest) != 0 {
+			return SANDescription{}, sentinelError{ErrMalformedSAN, errors.New("trailing data after X.509 extension")}
+		}
+
+		ext := SANExtensionDescription{Critical: e.Critical, Names: make([]GeneralNameDescription, 0, len(names))}
+		for _, v := range names {
+			name := describeGeneralName(v)
+			name.Tag = v.Tag
+			ext.Names = append(ext.Names, name)
+		}
+		desc.Extensions = append(desc.Extensions, ext)
+	}
+	return desc, nil
+}
+
+var generalNameTypes = map[int]string{
+	TagRFC822Name: "rfc822Name",
+	TagDNSName:    "dnsName",
+	TagURI:        "uri",
+}
+
+func describeGeneralName(v asn1.RawValue) GeneralNameDescription {
+	unknown := GeneralNameDescription{Type: "unknown", Raw: hex.EncodeToString(v.Bytes)}
+	if v.Class != asn1.ClassContextSpecific {
+		return unknown
+	}
+	switch v.Tag {
+	case TagOtherName:
+		var raw rawOtherName
+		if _, err := asn1.UnmarshalWithParams(v.FullBytes, &raw, "tag:0"); err != nil {
+			return unknown
+		}
+		desc := GeneralNameDescription{Type: "otherName", OID: raw.ID.String()}
+		if _, value, err := parseOtherName(v); err == nil {
+			desc.Value = value
+		} else {
+			desc.Raw = hex.EncodeToString(raw.Value.Bytes)
+		}
+		return desc
+	case TagRFC822Name, TagDNSName, TagURI:
+		if !utf8.Valid(v.Bytes) {
+			return unknown
+		}
+		return GeneralNameDescription{Type: generalNameTypes[v.Tag], Value: string(v.Bytes)}
+	case TagIPAddress:
+		if len(v.Bytes) != net.IPv4len && len(v.Bytes) != net.IPv6len {
+			return unknown
+		}
+		return GeneralNameDescription{Type: "ipAddress", Value: net.IP(v.Bytes).String()}
+	}
+	return unknown
+}
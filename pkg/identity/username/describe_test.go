@@ -0,0 +1,106 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDescribeSANS(t *testing.T) {
+	tagged := func(tag int, compound bool, value []byte) asn1.RawValue {
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, IsCompound: compound, Bytes: value}
+	}
+	otherName, err := MarshalSANS("foo!example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []asn1.RawValue
+	if _, err := asn1.Unmarshal(otherName.Value, &names); err != nil {
+		t.Fatal(err)
+	}
+	// An OtherName of another type, whose value is an INTEGER
+	intOtherName, err := asn1.MarshalWithParams(struct {
+		ID    asn1.ObjectIdentifier
+		Value int `asn1:"explicit,tag:0"`
+	}{asn1.ObjectIdentifier{1, 2, 3}, 42}, "tag:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names = append(names,
+		asn1.RawValue{FullBytes: intOtherName},
+		tagged(TagRFC822Name, false, []byte("foo@example.com")),
+		tagged(TagDNSName, false, []byte("example.com")),
+		tagged(TagURI, false, []byte("https://example.com")),
+		tagged(TagIPAddress, false, []byte{192, 0, 2, 1}),
+		tagged(TagIPAddress, false, []byte{1, 2, 3}),
+		tagged(8, false, []byte{0x2a, 0x03}),
+	)
+	packed, err := MarshalGeneralNames(names, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	split, err := MarshalGeneralNames([]asn1.RawValue{tagged(TagDNSName, false, []byte{0xff})}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exts := []pkix.Extension{
+		{Id: asn1.ObjectIdentifier{2, 5, 29, 19}, Critical: true, Value: []byte{0x30, 0x00}},
+		*packed,
+		*split,
+	}
+
+	desc, err := DescribeSANS(exts)
+	if err != nil {
+		t.Fatalf("DescribeSANS() = %v", err)
+	}
+	got, err := json.Marshal(desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"extensions":[` +
+		`{"critical":true,"names":[` +
+		`{"type":"otherName","tag":0,"value":"foo!example.com","oid":"1.3.6.1.4.1.57264.1.7"},` +
+		`{"type":"otherName","tag":0,"oid":"1.2.3","raw":"02012a"},` +
+		`{"type":"rfc822Name","tag":1,"value":"foo@example.com"},` +
+		`{"type":"dnsName","tag":2,"value":"example.com"},` +
+		`{"type":"uri","tag":6,"value":"https://example.com"},` +
+		`{"type":"ipAddress","tag":7,"value":"192.0.2.1"},` +
+		`{"type":"unknown","tag":7,"raw":"010203"},` +
+		`{"type":"unknown","tag":8,"raw":"2a03"}]},` +
+		`{"critical":false,"names":[` +
+		`{"type":"unknown","tag":2,"raw":"ff"}]}]}`
+	if string(got) != want {
+		t.Fatalf("DescribeSANS() = %s\nwant %s", got, want)
+	}
+
+	// Without SANs, there's nothing to describe
+	desc, err = DescribeSANS(nil)
+	if err != nil {
+		t.Fatalf("DescribeSANS() = %v", err)
+	}
+	if got, _ := json.Marshal(desc); string(got) != `{"extensions":[]}` {
+		t.Fatalf("DescribeSANS() = %s", got)
+	}
+
+	// A SAN extension that isn't a sequence can't be described
+	_, err = DescribeSANS([]pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Value: []byte{0x30, 0x05}}})
+	if !errors.Is(err, ErrMalformedSAN) {
+		t.Fatalf("expected ErrMalformedSAN, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+)
+
+// SANFingerprint returns the hex SHA-256 digest of the canonical form of the
+// GeneralNames in the Subject Alternative Name extensions, so certificates
+// issued for the same identity have the same fingerprint, for rate limiting
+// or deduplication. The names are treated as a set, so their order, any
+// duplicates, how they're split across extensions and whether the
+// extensions are critical don't change the fingerprint. Nor do these
+// encoding differences:
+//   - OtherName strings are compared as UTF-8, whichever string type holds
+//     them
+//   - DNS names and the domains of email addresses are compared case
+//     insensitively
+//   - IPv4 addresses are compared in their 4 byte form
+//
+// The digest is over the DER encodings of the distinct canonical names,
+// concatenated in byte order.
+func SANFingerprint(exts []pkix.Extension) (string, error) {
+	var canonical [][]byte
+	found := false
+	for _, e := range exts {
+		if !e.Id.Equal(oidSubjectAltName) {
+			continue
+		}
+		found = true
+
+		var names []asn1.RawValue
+		rest, err := asn1.Unmarshal(e.Value, &names)
+		if err != nil {
+			return "", sentinelError{ErrMalformedSAN, err}
+		} else if len(rest) != 0 {
+			return "", sentinelError{ErrMalformedSAN, errors.New("trailing data after X.509 extension")}
+		}
+		for _, name := range names {
+			b, err := canonicalGeneralName(name)
+			if err != nil {
+				return "", err
+			}
+			canonical = append(canonical, b)
+		}
+	}
+	if !found {
+		return "", errors.New("no subject alternative name extension")
+	}
+
+	sort.Slice(canonical, func(i, j int) bool {
+		return bytes.Compare(canonical[i], canonical[j]) < 0
+	})
+	h := sha256.New()
+	for i, b := range canonical {
+		if i > 0 && bytes.Equal(b, canonical[i-1]) {
+			continue
+		}
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// canonicalGeneralName returns the DER encoding of a GeneralName in a
+// canonical form, so equivalent names have the same encoding.
+func canonicalGeneralName(name asn1.RawValue) ([]byte, error) {
+	if name.Class != asn1.ClassContextSpecific {
+		return asn1.Marshal(asn1.RawValue{Class: name.Class, Tag: name.Tag, IsCompound: name.IsCompound, Bytes: name.Bytes})
+	}
+	value := name.Bytes
+	switch {
+	case name.Tag == TagOtherName && name.IsCompound:
+		id, tag, element, content, err := parseOtherNameElement(name)
+		if err != nil {
+			return nil, err
+		}
+		if s, err := decodeOtherNameString(tag, content); err == nil {
+			return appendOtherName(nil, id, derTagUTF8String, s), nil
+		}
+		return appendOtherNameElement(nil, id, element), nil
+	case name.Tag == TagDNSName && !name.IsCompound:
+		value = []byte(strings.ToLower(string(value)))
+	case name.Tag == TagRFC822Name && !name.IsCompound:
+		if i := bytes.LastIndexByte(value, '@'); i != -1 {
+			value = append(append([]byte(nil), value[:i]...), strings.ToLower(string(value[i:]))...)
+		}
+	case name.Tag == TagIPAddress && !name.IsCompound:
+		if ip := net.IP(value).To4(); len(value) == net.IPv6len && ip != nil {
+			value = ip
+		}
+	}
+	return asn1.Marshal(asn1.RawValue{Class: name.Class, Tag: name.Tag, IsCompound: name.IsCompound, Bytes: value})
+}
@@ -0,0 +1,130 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestSANFingerprint(t *testing.T) {
+	generalName := func(tag int, value string) asn1.RawValue {
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, Bytes: []byte(value)}
+	}
+	otherName := func(t *testing.T, name string, enc StringEncoding) asn1.RawValue {
+		ext, err := MarshalSANSWithEncoding(name, enc, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []asn1.RawValue
+		if _, err := asn1.Unmarshal(ext.Value, &names); err != nil {
+			t.Fatal(err)
+		}
+		return names[0]
+	}
+	ipName := func(ip net.IP) asn1.RawValue {
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: TagIPAddress, Bytes: ip}
+	}
+	fingerprint := func(t *testing.T, exts ...pkix.Extension) string {
+		t.Helper()
+		fp, err := SANFingerprint(exts)
+		if err != nil {
+			t.Fatalf("SANFingerprint() = %v", err)
+		}
+		return fp
+	}
+
+	alice := otherName(t, "alice!example.com", StringEncodingUTF8)
+	uri := generalName(TagURI, "https://example.com/alice")
+	email := generalName(TagRFC822Name, "alice@example.com")
+	dns := generalName(TagDNSName, "alice.example.com")
+	want := fingerprint(t, *mustMarshalGeneralNames(t, alice, uri, email, dns))
+
+	// The fingerprint must not change between releases, or identities would
+	// no longer match fingerprints that were already recorded
+	const golden = "4e8fb661947fe7543f403f714e1f1753fe387fd3166f228cb948466084b2ddd1"
+	if want != golden {
+		t.Errorf("SANFingerprint() = %s, want %s", want, golden)
+	}
+
+	equivalent := map[string][]pkix.Extension{
+		"reordered":  {*mustMarshalGeneralNames(t, dns, email, uri, alice)},
+		"duplicated": {*mustMarshalGeneralNames(t, alice, uri, alice, email, dns, uri)},
+		"split across extensions": {
+			*mustMarshalGeneralNames(t, alice),
+			*mustMarshalGeneralNames(t, uri, email, dns),
+		},
+		"critical": {func() pkix.Extension {
+			ext := *mustMarshalGeneralNames(t, alice, uri, email, dns)
+			ext.Critical = true
+			return ext
+		}()},
+		"OtherName as a BMPString":   {*mustMarshalGeneralNames(t, otherName(t, "alice!example.com", StringEncodingBMP), uri, email, dns)},
+		"OtherName as an IA5String":  {*mustMarshalGeneralNames(t, otherName(t, "alice!example.com", StringEncodingIA5), uri, email, dns)},
+		"DNS name in upper case":     {*mustMarshalGeneralNames(t, alice, uri, email, generalName(TagDNSName, "ALICE.Example.COM"))},
+		"email domain in upper case": {*mustMarshalGeneralNames(t, alice, uri, generalName(TagRFC822Name, "alice@EXAMPLE.com"), dns)},
+		"other extensions": {
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}, Value: []byte("https://issuer.example.com")},
+			*mustMarshalGeneralNames(t, alice, uri, email, dns),
+		},
+	}
+	for name, exts := range equivalent {
+		t.Run(name, func(t *testing.T) {
+			if got := fingerprint(t, exts...); got != want {
+				t.Fatalf("SANFingerprint() = %s, want %s", got, want)
+			}
+		})
+	}
+
+	different := map[string][]pkix.Extension{
+		"another OtherName":    {*mustMarshalGeneralNames(t, otherName(t, "bob!example.com", StringEncodingUTF8), uri, email, dns)},
+		"URI in upper case":    {*mustMarshalGeneralNames(t, alice, generalName(TagURI, "https://example.com/ALICE"), email, dns)},
+		"email local part":     {*mustMarshalGeneralNames(t, alice, uri, generalName(TagRFC822Name, "Alice@example.com"), dns)},
+		"missing name":         {*mustMarshalGeneralNames(t, alice, uri, email)},
+		"additional name":      {*mustMarshalGeneralNames(t, alice, uri, email, dns, generalName(TagDNSName, "bob.example.com"))},
+		"DNS name as an email": {*mustMarshalGeneralNames(t, alice, uri, email, generalName(TagRFC822Name, "alice.example.com"))},
+	}
+	for name, exts := range different {
+		t.Run(name, func(t *testing.T) {
+			if got := fingerprint(t, exts...); got == want {
+				t.Fatalf("expected SANFingerprint() to differ from %s", want)
+			}
+		})
+	}
+
+	t.Run("IPv4 addresses", func(t *testing.T) {
+		ip := net.ParseIP("192.0.2.1")
+		if fingerprint(t, *mustMarshalGeneralNames(t, ipName(ip.To4()))) != fingerprint(t, *mustMarshalGeneralNames(t, ipName(ip.To16()))) {
+			t.Fatal("expected 4 and 16 byte IPv4 addresses to have the same fingerprint")
+		}
+	})
+}
+
+func TestSANFingerprintFailures(t *testing.T) {
+	if _, err := SANFingerprint(nil); err == nil {
+		t.Fatal("expected error for no SAN extension")
+	}
+	malformed := pkix.Extension{Id: oidSubjectAltName, Value: []byte{0x30, 0x05}}
+	if _, err := SANFingerprint([]pkix.Extension{malformed}); !errors.Is(err, ErrMalformedSAN) {
+		t.Fatalf("expected ErrMalformedSAN, got %v", err)
+	}
+	badOtherName := asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: TagOtherName, IsCompound: true, Bytes: []byte{0x05, 0x00}}
+	if _, err := SANFingerprint([]pkix.Extension{*mustMarshalGeneralNames(t, badOtherName)}); !errors.Is(err, ErrInvalidOtherName) {
+		t.Fatalf("expected ErrInvalidOtherName, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+)
+
+// BuildIdentityCert returns a copy of template with a Subject Alternative
+// Name extension holding otherName, created with MarshalSANS, for a
+// certificate whose identity is only in its SANs. Any DNS, email, IP and URI
+// SANs of the template are moved into the same extension, as with PackSANS.
+//
+// RFC 5280 requires the extension to be critical when the subject is empty,
+// so if the template has an empty subject and critical is false, the error
+// matches ErrSANNotCritical with errors.Is. The template must not already
+// have a Subject Alternative Name extension in ExtraExtensions. template is
+// not modified.
+func BuildIdentityCert(template *x509.Certificate, otherName string, critical bool, opts ...MarshalOption) (*x509.Certificate, error) {
+	for _, e := range template.ExtraExtensions {
+		if e.Id.Equal(oidSubjectAltName) {
+			return nil, sentinelError{ErrMultipleSANExtensions, errors.New("template already has a subject alternative name extension")}
+		}
+	}
+	empty, err := subjectEmpty(template)
+	if err != nil {
+		return nil, err
+	}
+	if empty && !critical {
+		return nil, sentinelError{ErrSANNotCritical, errors.New("subject is empty, so the subject alternative name extension must be critical")}
+	}
+
+	ext, err := MarshalSANS(otherName, critical, opts...)
+	if err != nil {
+		return nil, err
+	}
+	cert := *template
+	cert.ExtraExtensions = append(append([]pkix.Extension(nil), template.ExtraExtensions...), *ext)
+	if err := PackSANS(&cert, false); err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// subjectEmpty reports whether the subject of a certificate template is an
+// empty distinguished name. crypto/x509 uses RawSubject in place of Subject
+// when it's set.
+func subjectEmpty(template *x509.Certificate) (bool, error) {
+	if len(template.RawSubject) == 0 {
+		return len(template.Subject.ToRDNSequence()) == 0, nil
+	}
+	var rdns pkix.RDNSequence
+	rest, err := asn1.Unmarshal(template.RawSubject, &rdns)
+	if err != nil {
+		return false, err
+	} else if len(rest) != 0 {
+		return false, errors.New("trailing data after subject")
+	}
+	return len(rdns) == 0, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/url"
+	"testing"
+)
+
+func TestBuildIdentityCertEmptySubjectNonCritical(t *testing.T) {
+	template := &x509.Certificate{SerialNumber: big.NewInt(1)}
+	if _, err := BuildIdentityCert(template, "alice!example.com", false); !errors.Is(err, ErrSANNotCritical) {
+		t.Fatalf("expected ErrSANNotCritical, got %v", err)
+	}
+
+	// An empty RawSubject overrides a non-empty Subject
+	template = &x509.Certificate{
+		Subject:    pkix.Name{CommonName: "alice"},
+		RawSubject: []byte{0x30, 0x00},
+	}
+	if _, err := BuildIdentityCert(template, "alice!example.com", false); !errors.Is(err, ErrSANNotCritical) {
+		t.Fatalf("expected ErrSANNotCritical with empty RawSubject, got %v", err)
+	}
+}
+
+func TestBuildIdentityCertEmptySubjectCritical(t *testing.T) {
+	u, err := url.Parse("https://example.com/alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		URIs:         []*url.URL{u},
+	}
+	built, err := BuildIdentityCert(template, "alice!example.com", true)
+	if err != nil {
+		t.Fatalf("BuildIdentityCert() = %v", err)
+	}
+	if len(template.ExtraExtensions) != 0 || len(template.URIs) != 1 {
+		t.Fatal("expected template to be untouched")
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, built, built, priv.Public(), priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := UnmarshalSANSStrict(cert.Extensions, true); err != nil || got != "alice!example.com" {
+		t.Fatalf("UnmarshalSANSStrict() = %q, %v", got, err)
+	}
+	if len(cert.URIs) != 1 || cert.URIs[0].String() != u.String() {
+		t.Fatalf("expected URI SAN to be kept, got %v", cert.URIs)
+	}
+}
+
+func TestBuildIdentityCertWithSubject(t *testing.T) {
+	template := &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}}
+	built, err := BuildIdentityCert(template, "alice!example.com", false)
+	if err != nil {
+		t.Fatalf("BuildIdentityCert() = %v", err)
+	}
+	if got, err := UnmarshalSANS(built.ExtraExtensions); err != nil || got != "alice!example.com" {
+		t.Fatalf("UnmarshalSANS() = %q, %v", got, err)
+	}
+	if built.ExtraExtensions[0].Critical {
+		t.Fatal("expected SAN extension not to be critical")
+	}
+}
+
+func TestBuildIdentityCertExistingSAN(t *testing.T) {
+	san, err := MarshalSANS("bob!example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{ExtraExtensions: []pkix.Extension{*san}}
+	if _, err := BuildIdentityCert(template, "alice!example.com", true); !errors.Is(err, ErrMultipleSANExtensions) {
+		t.Fatalf("expected ErrMultipleSANExtensions, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MatchOptions configures how MatchOtherName compares the hostname half of
+// a <username>!<hostname> OtherName. The username half is always compared
+// exactly.
+type MatchOptions struct {
+	// CaseInsensitiveHost compares hostnames ignoring ASCII case, as DNS
+	// does.
+	CaseInsensitiveHost bool
+	// WildcardHost lets an expected hostname start with a "*." label, which
+	// matches exactly one label in its place, e.g. *.example.com matches
+	// build.example.com but not example.com or a.build.example.com.
+	WildcardHost bool
+}
+
+// MatchOtherName reports whether the OtherName got, read from a certificate,
+// matches the expected OtherName want. Names without exactly one ! are only
+// matched exactly, and an empty name never matches.
+//
+// Comparisons take time independent of the contents being compared, though
+// not of their lengths.
+func MatchOtherName(got, want string, opts MatchOptions) bool {
+	if got == "" || want == "" {
+		return false
+	}
+	gotUser, gotHost, gotOK := splitOtherName(got)
+	wantUser, wantHost, wantOK := splitOtherName(want)
+	if !gotOK || !wantOK {
+		return constantTimeEqual(got, want)
+	}
+
+	if opts.CaseInsensitiveHost {
+		gotHost, wantHost = strings.ToLower(gotHost), strings.ToLower(wantHost)
+	}
+	hostMatch := hostMatches(gotHost, wantHost, opts.WildcardHost)
+	// Compare the usernames even if the hostnames differ, so the time
+	// taken doesn't reveal which half matched
+	userMatch := constantTimeEqual(gotUser, wantUser)
+	return userMatch && hostMatch
+}
+
+// OtherNameMatchesHost reports whether the hostname half of otherName, which
+// may be a wildcard such as user!*.pool.internal, matches concreteHost, as in
+// RFC 6125, 6.4.3. Hostnames are compared ignoring ASCII case, and a
+// wildcard only matches one non-empty leftmost label, so *.pool.internal
+// matches a.pool.internal but not a.b.pool.internal or pool.internal. The
+// username half isn't compared, and concreteHost must not be a wildcard
+// itself.
+func OtherNameMatchesHost(otherName, concreteHost string) bool {
+	_, host, ok := splitOtherName(otherName)
+	if !ok || host == "" || concreteHost == "" || strings.Contains(concreteHost, "*") {
+		return false
+	}
+	return hostMatches(strings.ToLower(concreteHost), strings.ToLower(host), true)
+}
+
+// hostMatches reports whether the hostname got matches want, which may start
+// with a "*." label if wildcard is true.
+func hostMatches(got, want string, wildcard bool) bool {
+	if suffix := strings.TrimPrefix(want, "*"); wildcard && suffix != want && strings.HasPrefix(suffix, ".") {
+		// The wildcard stands for one non-empty label
+		i := strings.IndexByte(got, '.')
+		return i > 0 && constantTimeEqual(got[i:], suffix)
+	}
+	return constantTimeEqual(got, want)
+}
+
+// VerifyOtherNameMatchesClaim checks that the OtherName otherName is for the
+// authenticated username and hostname, e.g. the subject of an ID token and
+// the subject domain of its issuer. Both halves must match exactly, and the
+// error says which didn't.
+func VerifyOtherNameMatchesClaim(otherName string, claimedUsername, claimedHostname string) error {
+	if claimedUsername == "" {
+		return errors.New("authenticated username must not be empty")
+	}
+	if claimedHostname == "" {
+		return errors.New("authenticated hostname must not be empty")
+	}
+	if strings.Contains(claimedUsername, "!") {
+		return fmt.Errorf("authenticated username %q must not contain !", claimedUsername)
+	}
+	user, host, ok := splitOtherName(otherName)
+	if !ok {
+		return fmt.Errorf("OtherName %q must have the form <username>!<hostname>", otherName)
+	}
+
+	userMatch := constantTimeEqual(user, claimedUsername)
+	hostMatch := constantTimeEqual(host, claimedHostname)
+	switch {
+	case !userMatch && !hostMatch:
+		return fmt.Errorf("OtherName %q does not match authenticated username %q or hostname %q", otherName, claimedUsername, claimedHostname)
+	case !userMatch:
+		return fmt.Errorf("OtherName username %q does not match authenticated username %q", user, claimedUsername)
+	case !hostMatch:
+		return fmt.Errorf("OtherName hostname %q does not match authenticated hostname %q", host, claimedHostname)
+	}
+	return nil
+}
+
+// splitOtherName splits a <username>!<hostname> OtherName, returning false if
+// it doesn't have exactly one !.
+func splitOtherName(name string) (string, string, bool) {
+	i := strings.IndexByte(name, '!')
+	if i < 0 || strings.IndexByte(name[i+1:], '!') >= 0 {
+		return "", "", false
+	}
+	return name[:i], name[i+1:], true
+}
+
+func constantTimeEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
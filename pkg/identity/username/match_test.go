@@ -0,0 +1,119 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchOtherName(t *testing.T) {
+	tests := map[string]struct {
+		got, want string
+		opts      MatchOptions
+		match     bool
+	}{
+		"exact":                          {"alice!example.com", "alice!example.com", MatchOptions{}, true},
+		"different username":             {"bob!example.com", "alice!example.com", MatchOptions{}, false},
+		"different hostname":             {"alice!example.org", "alice!example.com", MatchOptions{}, false},
+		"hostname case by default":       {"alice!Example.COM", "alice!example.com", MatchOptions{}, false},
+		"case-insensitive hostname":      {"alice!Example.COM", "alice!example.com", MatchOptions{CaseInsensitiveHost: true}, true},
+		"username case is significant":   {"Alice!example.com", "alice!example.com", MatchOptions{CaseInsensitiveHost: true}, false},
+		"wildcard without option":        {"alice!build.example.com", "alice!*.example.com", MatchOptions{}, false},
+		"literal wildcard":               {"alice!*.example.com", "alice!*.example.com", MatchOptions{}, true},
+		"wildcard matches one label":     {"alice!build.example.com", "alice!*.example.com", MatchOptions{WildcardHost: true}, true},
+		"wildcard needs a label":         {"alice!example.com", "alice!*.example.com", MatchOptions{WildcardHost: true}, false},
+		"wildcard label is not empty":    {"alice!.example.com", "alice!*.example.com", MatchOptions{WildcardHost: true}, false},
+		"wildcard matches only one":      {"alice!a.build.example.com", "alice!*.example.com", MatchOptions{WildcardHost: true}, false},
+		"wildcard checks username":       {"bob!build.example.com", "alice!*.example.com", MatchOptions{WildcardHost: true}, false},
+		"wildcard only as a label":       {"alice!buildexample.com", "alice!*example.com", MatchOptions{WildcardHost: true}, false},
+		"case-insensitive wildcard":      {"alice!Build.Example.com", "alice!*.example.COM", MatchOptions{CaseInsensitiveHost: true, WildcardHost: true}, true},
+		"no separator matched exactly":   {"alice", "alice", MatchOptions{CaseInsensitiveHost: true}, true},
+		"no separator is case-sensitive": {"ALICE", "alice", MatchOptions{CaseInsensitiveHost: true}, false},
+		"extra separator":                {"alice!example.com!x", "alice!example.com", MatchOptions{}, false},
+		"empty names":                    {"", "", MatchOptions{}, false},
+		"empty got":                      {"", "alice!example.com", MatchOptions{}, false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := MatchOtherName(test.got, test.want, test.opts); got != test.match {
+				t.Fatalf("MatchOtherName(%q, %q, %+v) = %v, want %v", test.got, test.want, test.opts, got, test.match)
+			}
+		})
+	}
+}
+
+func TestOtherNameMatchesHost(t *testing.T) {
+	tests := []struct {
+		otherName, host string
+		match           bool
+	}{
+		{"user!*.pool.internal", "a.pool.internal", true},
+		{"user!*.pool.internal", "A.Pool.Internal", true},
+		{"user!*.pool.internal", "a.b.pool.internal", false},
+		{"user!*.pool.internal", "pool.internal", false},
+		{"user!*.pool.internal", ".pool.internal", false},
+		{"user!*.pool.internal", "a.pool.internal.evil.com", false},
+		{"user!*.pool.internal", "*.pool.internal", false},
+		{"user!a.pool.internal", "a.pool.internal", true},
+		{"user!a.pool.internal", "b.pool.internal", false},
+		{"user!a*.pool.internal", "ab.pool.internal", false},
+		{"user", "user", false},
+		{"user!*.pool.internal", "", false},
+	}
+	for _, test := range tests {
+		if got := OtherNameMatchesHost(test.otherName, test.host); got != test.match {
+			t.Errorf("OtherNameMatchesHost(%q, %q) = %v, want %v", test.otherName, test.host, got, test.match)
+		}
+	}
+}
+
+func TestVerifyOtherNameMatchesClaim(t *testing.T) {
+	tests := map[string]struct {
+		otherName, username, hostname string
+		wantErr                       string
+	}{
+		"match":                {"alice!example.com", "alice", "example.com", ""},
+		"username mismatch":    {"bob!example.com", "alice", "example.com", `OtherName username "bob" does not match authenticated username "alice"`},
+		"hostname mismatch":    {"alice!example.org", "alice", "example.com", `OtherName hostname "example.org" does not match authenticated hostname "example.com"`},
+		"both mismatch":        {"bob!example.org", "alice", "example.com", `does not match authenticated username "alice" or hostname "example.com"`},
+		"username case":        {"Alice!example.com", "alice", "example.com", "OtherName username"},
+		"hostname case":        {"alice!EXAMPLE.com", "alice", "example.com", "OtherName hostname"},
+		"username prefix":      {"alic!example.com", "alice", "example.com", "OtherName username"},
+		"empty username half":  {"!example.com", "alice", "example.com", "OtherName username"},
+		"empty hostname half":  {"alice!", "alice", "example.com", "OtherName hostname"},
+		"missing separator":    {"aliceexample.com", "alice", "example.com", "must have the form"},
+		"extra separator":      {"alice!example.com!x", "alice", "example.com", "must have the form"},
+		"empty OtherName":      {"", "alice", "example.com", "must have the form"},
+		"empty username claim": {"!example.com", "", "example.com", "authenticated username must not be empty"},
+		"empty hostname claim": {"alice!", "alice", "", "authenticated hostname must not be empty"},
+		"empty claims":         {"!", "", "", "must not be empty"},
+		"separator in claim":   {"alice!bob!example.com", "alice!bob", "example.com", "must not contain !"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := VerifyOtherNameMatchesClaim(test.otherName, test.username, test.hostname)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("VerifyOtherNameMatchesClaim() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
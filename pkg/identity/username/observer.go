@@ -0,0 +1,116 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// Operation is what the username package was doing when it failed, as
+// reported to an Observer.
+type Operation string
+
+const (
+	OperationMarshal   Operation = "marshal"
+	OperationUnmarshal Operation = "unmarshal"
+)
+
+// FailureReason is why marshaling or unmarshaling a Subject Alternative Name
+// failed, as reported to an Observer. Each corresponds to one of the sentinel
+// errors, so they can be used as metric labels.
+type FailureReason string
+
+const (
+	ReasonNoOtherName           FailureReason = "no_other_name"
+	ReasonMultipleOtherNames    FailureReason = "multiple_other_names"
+	ReasonUnexpectedOID         FailureReason = "unexpected_oid"
+	ReasonInvalidOtherName      FailureReason = "invalid_other_name"
+	ReasonNotDER                FailureReason = "not_der"
+	ReasonMalformedSAN          FailureReason = "malformed_san"
+	ReasonMultipleSANExtensions FailureReason = "multiple_san_extensions"
+	ReasonSANNotCritical        FailureReason = "san_not_critical"
+	ReasonOtherNameTooLong      FailureReason = "other_name_too_long"
+	// ReasonOther is any failure without a sentinel error, such as a name
+	// rejected by WithStrictValidation.
+	ReasonOther FailureReason = "other"
+)
+
+// Observer is notified each time the package fails to marshal or unmarshal
+// a Subject Alternative Name, e.g. to count failures by reason. It's called
+// synchronously from any goroutine, so it must be safe for concurrent use
+// and shouldn't block.
+type Observer interface {
+	ObserveFailure(op Operation, reason FailureReason)
+}
+
+type nopObserver struct{}
+
+func (nopObserver) ObserveFailure(Operation, FailureReason) {}
+
+// observerBox lets observer hold Observers of different concrete types,
+// which atomic.Value doesn't allow directly.
+type observerBox struct{ Observer }
+
+var observer atomic.Value
+
+func init() {
+	observer.Store(observerBox{nopObserver{}})
+}
+
+// SetObserver sets the Observer notified of failures, replacing the default
+// which does nothing. A nil o restores the default.
+func SetObserver(o Observer) {
+	if o == nil {
+		o = nopObserver{}
+	}
+	observer.Store(observerBox{o})
+}
+
+// observeFailure notifies the Observer of err, if it's not nil.
+func observeFailure(op Operation, err error) {
+	if err == nil {
+		return
+	}
+	observer.Load().(observerBox).ObserveFailure(op, FailureReasonOf(err))
+}
+
+// failureReasons are checked in order, so that the more specific ErrNotDER
+// is reported rather than the ErrMalformedSAN it's returned with.
+var failureReasons = []struct {
+	sentinel error
+	reason   FailureReason
+}{
+	{ErrNotDER, ReasonNotDER},
+	{ErrMalformedSAN, ReasonMalformedSAN},
+	{ErrNoOtherName, ReasonNoOtherName},
+	{ErrMultipleOtherNames, ReasonMultipleOtherNames},
+	{ErrUnexpectedOID, ReasonUnexpectedOID},
+	{ErrInvalidOtherName, ReasonInvalidOtherName},
+	{ErrMultipleSANExtensions, ReasonMultipleSANExtensions},
+	{ErrSANNotCritical, ReasonSANNotCritical},
+	{ErrOtherNameTooLong, ReasonOtherNameTooLong},
+}
+
+// FailureReasonOf returns the FailureReason of an error from the package, by
+// the first sentinel error it matches, or ReasonOther if it matches none.
+func FailureReasonOf(err error) FailureReason {
+	for _, r := range failureReasons {
+		if errors.Is(err, r.sentinel) {
+			return r.reason
+		}
+	}
+	return ReasonOther
+}
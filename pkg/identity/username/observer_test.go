@@ -0,0 +1,138 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeObserver struct {
+	mu       sync.Mutex
+	failures []string
+}
+
+func (o *fakeObserver) ObserveFailure(op Operation, reason FailureReason) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.failures = append(o.failures, string(op)+"/"+string(reason))
+}
+
+func setFakeObserver(t *testing.T) *fakeObserver {
+	t.Helper()
+	o := &fakeObserver{}
+	SetObserver(o)
+	t.Cleanup(func() { SetObserver(nil) })
+	return o
+}
+
+func TestObserverUnmarshalFailures(t *testing.T) {
+	// san takes the results of marshaling, so calls can be nested
+	san := func(ext *pkix.Extension, err error) pkix.Extension {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *ext
+	}
+	oidOther := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+	dnsName := asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: TagDNSName, Bytes: []byte("example.com")}
+	name := san(MarshalSANS("alice!example.com", true))
+	nonCritical := san(MarshalSANS("alice!example.com", false))
+
+	tests := map[string]struct {
+		exts      []pkix.Extension
+		unmarshal func([]pkix.Extension) (string, error)
+		want      FailureReason
+	}{
+		"no OtherName": {
+			exts: []pkix.Extension{san(MarshalGeneralNames([]asn1.RawValue{dnsName}, true))},
+			want: ReasonNoOtherName,
+		},
+		"wrong OID": {
+			exts: []pkix.Extension{san(MarshalSANSWithOID("alice!example.com", oidOther, true))},
+			want: ReasonUnexpectedOID,
+		},
+		"malformed ASN.1": {
+			exts: []pkix.Extension{{Id: oidSubjectAltName, Value: []byte{0x30, 0x05}}},
+			want: ReasonMalformedSAN,
+		},
+		"indefinite length": {
+			exts: []pkix.Extension{{Id: oidSubjectAltName, Value: []byte{0x30, 0x80, 0x00, 0x00}}},
+			want: ReasonNotDER,
+		},
+		"multiple OtherNames": {
+			exts: []pkix.Extension{san(MarshalSANSMulti([]string{"alice!example.com", "bob!example.com"}, true))},
+			want: ReasonMultipleOtherNames,
+		},
+		"multiple extensions": {
+			exts: []pkix.Extension{name, name},
+			want: ReasonMultipleSANExtensions,
+		},
+		"not critical": {
+			exts: []pkix.Extension{nonCritical},
+			unmarshal: func(exts []pkix.Extension) (string, error) {
+				return UnmarshalSANSStrict(exts, true)
+			},
+			want: ReasonSANNotCritical,
+		},
+	}
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			o := setFakeObserver(t)
+			unmarshal := test.unmarshal
+			if unmarshal == nil {
+				unmarshal = UnmarshalSANS
+			}
+			if _, err := unmarshal(test.exts); err == nil {
+				t.Fatal("expected unmarshaling to fail")
+			}
+			want := []string{string(OperationUnmarshal) + "/" + string(test.want)}
+			if strings.Join(o.failures, ",") != strings.Join(want, ",") {
+				t.Fatalf("observed %v, want %v", o.failures, want)
+			}
+		})
+	}
+}
+
+func TestObserverMarshalFailures(t *testing.T) {
+	o := setFakeObserver(t)
+	if _, err := MarshalSANS("alice!example.com", true, WithMaxLength(5)); err == nil {
+		t.Fatal("expected marshaling to fail")
+	}
+	if _, err := MarshalSANS("alice", true, WithStrictValidation()); err == nil {
+		t.Fatal("expected marshaling to fail")
+	}
+	want := "marshal/other_name_too_long,marshal/other"
+	if got := strings.Join(o.failures, ","); got != want {
+		t.Fatalf("observed %q, want %q", got, want)
+	}
+}
+
+func TestObserverSuccess(t *testing.T) {
+	o := setFakeObserver(t)
+	ext, err := MarshalSANS("alice!example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnmarshalSANS([]pkix.Extension{*ext}); err != nil {
+		t.Fatal(err)
+	}
+	if len(o.failures) != 0 {
+		t.Fatalf("expected no failures to be observed, got %v", o.failures)
+	}
+}
@@ -15,14 +15,107 @@
 package username
 
 import (
+	"bytes"
+	"context"
+	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"errors"
 	"fmt"
+	"net"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 
+	"github.com/asaskevich/govalidator"
 	"github.com/sigstore/fulcio/pkg/certificate"
 )
 
+var oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+// Errors returned when unmarshaling OtherName SANs, which callers can match
+// with errors.Is.
+var (
+	// ErrNoOtherName is returned when there is no username OtherName.
+	ErrNoOtherName = errors.New("no OtherName found")
+	// ErrMultipleOtherNames is returned when one OtherName is expected, but
+	// there are several.
+	ErrMultipleOtherNames = errors.New("expected only one OtherName")
+	// ErrUnexpectedOID is returned when an OtherName has a type other than
+	// the expected OID.
+	ErrUnexpectedOID = errors.New("unexpected OID for OtherName")
+	// ErrInvalidOtherName is returned when an OtherName can't be parsed, or
+	// isn't DER encoded.
+	ErrInvalidOtherName = errors.New("could not parse requested OtherName SAN")
+	// ErrMalformedSAN is returned when a Subject Alternative Name extension
+	// isn't a valid sequence of GeneralNames.
+	ErrMalformedSAN = errors.New("malformed subject alternative name extension")
+	// ErrMultipleSANExtensions is returned when a certificate has more than
+	// one Subject Alternative Name extension, which RFC 5280 forbids.
+	ErrMultipleSANExtensions = errors.New("multiple SAN extensions present")
+	// ErrSANNotCritical is returned by UnmarshalSANSStrict and
+	// BuildIdentityCert when a Subject Alternative Name extension must be
+	// critical, but isn't.
+	ErrSANNotCritical = errors.New("subject alternative name extension is not critical")
+	// ErrNoExtensions is returned by OtherNameFromCSR when a certificate
+	// request has no extensions.
+	ErrNoExtensions = errors.New("certificate request has no extensions")
+	// ErrOtherNameTooLong is returned when an OtherName is longer than the
+	// maximum length allowed when marshaling, or when unmarshaling.
+	ErrOtherNameTooLong = errors.New("OtherName exceeds maximum length")
+	// ErrNotDER is returned, along with ErrMalformedSAN, when a Subject
+	// Alternative Name extension uses an indefinite length BER encoding.
+	// Parsers that accept BER may disagree on what a certificate contains,
+	// so it's rejected before parsing.
+	ErrNotDER = errors.New("not DER encoded")
+)
+
+// sentinelError matches sentinel with errors.Is, while keeping the message of
+// the underlying error.
+type sentinelError struct {
+	sentinel error
+	err      error
+}
+
+func (e sentinelError) Error() string        { return e.err.Error() }
+func (e sentinelError) Unwrap() error        { return e.err }
+func (e sentinelError) Is(target error) bool { return target == e.sentinel }
+
+// Tags of the GeneralName types, RFC 5280, 4.2.1.6. These are the
+// GeneralNames that Fulcio marshals and that crypto/x509 parses.
+const (
+	TagOtherName  = 0
+	TagRFC822Name = 1
+	TagDNSName    = 2
+	TagURI        = 6
+	TagIPAddress  = 7
+)
+
+// generalNameTag decodes the identifier octet b of a GeneralName, or of
+// another context-specific element such as the explicitly tagged value of an
+// OtherName, into its tag and whether it's constructed.
+func generalNameTag(b byte) (tag int, constructed bool, err error) {
+	if b&derClassMask != derClassContextSpecific {
+		return 0, false, asn1.StructuralError{Msg: "bad GeneralName class"}
+	}
+	if b&derTagNumberMask == derTagNumberMask {
+		return 0, false, asn1.StructuralError{Msg: "unexpected high tag number"}
+	}
+	return int(b & derTagNumberMask), b&derConstructed != 0, nil
+}
+
+// generalNameIdentifier is the inverse of generalNameTag, returning the
+// identifier octet of a context-specific element with the tag, which must be
+// below 31.
+func generalNameIdentifier(tag int, constructed bool) byte {
+	b := derClassContextSpecific | byte(tag)
+	if constructed {
+		b |= derConstructed
+	}
+	return b
+}
+
 // OtherName describes a name related to a certificate which is not in one
 // of the standard name formats. RFC 5280, 4.2.1.6:
 //
@@ -36,6 +129,107 @@ type OtherName struct {
 	Value string `asn1:"utf8,explicit,tag:0"`
 }
 
+// MarshalOption configures how OtherName SANs are marshaled.
+type MarshalOption func(*marshalOptions)
+
+type marshalOptions struct {
+	strict    bool
+	maxLength int
+}
+
+// DefaultMaxOtherNameLength is the maximum length in bytes of a marshaled
+// OtherName, unless overridden with WithMaxLength. It matches the limit on
+// DNS names, which is plenty for a username and hostname.
+const DefaultMaxOtherNameLength = 255
+
+// maxDecodedOtherNameLength bounds the length of OtherName values decoded
+// from untrusted certificates. It's well above DefaultMaxOtherNameLength, so
+// names marshaled with a raised limit can still be read back.
+const maxDecodedOtherNameLength = 1 << 16
+
+func newMarshalOptions(opts []MarshalOption) marshalOptions {
+	o := marshalOptions{maxLength: DefaultMaxOtherNameLength}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// check returns an error if name can't be marshaled with the options.
+func (o marshalOptions) check(name string) error {
+	if err := o.checkLength(len(name)); err != nil {
+		return err
+	}
+	if o.strict {
+		return ValidateOtherName(name)
+	}
+	return nil
+}
+
+func (o marshalOptions) checkLength(n int) error {
+	if o.maxLength > 0 && n > o.maxLength {
+		return fmt.Errorf("%w: %d bytes, maximum is %d", ErrOtherNameTooLong, n, o.maxLength)
+	}
+	return nil
+}
+
+// WithStrictValidation checks each name with ValidateOtherName before it is
+// marshaled. By default any string is accepted.
+func WithStrictValidation() MarshalOption {
+	return func(o *marshalOptions) {
+		o.strict = true
+	}
+}
+
+// WithMaxLength sets the maximum length in bytes of each name, instead of
+// DefaultMaxOtherNameLength. A limit of zero or less allows any length.
+func WithMaxLength(n int) MarshalOption {
+	return func(o *marshalOptions) {
+		o.maxLength = n
+	}
+}
+
+// ValidateOtherName checks name has the <username>!<hostname> shape of a
+// username OtherName: one ! separator, a non-empty username and a valid DNS
+// hostname, with no control characters or @ anywhere. The hostname may be a
+// wildcard for a pool of hosts, with a single leftmost * label over at least
+// two labels, such as user!*.pool.internal, which OtherNameMatchesHost
+// matches against concrete hosts.
+func ValidateOtherName(name string) error {
+	if !utf8.ValidString(name) {
+		return fmt.Errorf("OtherName %q is not valid UTF-8", name)
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("OtherName %q contains control character %U", name, r)
+		}
+	}
+	if strings.Contains(name, "@") {
+		return fmt.Errorf("OtherName %q must not contain @", name)
+	}
+	parts := strings.Split(name, "!")
+	if len(parts) != 2 {
+		return fmt.Errorf("OtherName %q must have the form <username>!<hostname>", name)
+	}
+	if parts[0] == "" {
+		return fmt.Errorf("OtherName %q has an empty username", name)
+	}
+	if !validHostname(parts[1]) {
+		return fmt.Errorf("OtherName %q has an invalid hostname %q", name, parts[1])
+	}
+	return nil
+}
+
+// validHostname reports whether hostname is a DNS name, or a "*." label
+// followed by a DNS name of at least two labels, so a wildcard can't cover a
+// whole top level domain.
+func validHostname(hostname string) bool {
+	if suffix := strings.TrimPrefix(hostname, "*."); suffix != hostname {
+		return strings.Contains(strings.TrimSuffix(suffix, "."), ".") && govalidator.IsDNSName(suffix)
+	}
+	return govalidator.IsDNSName(hostname)
+}
+
 // MarshalSANS creates a Subject Alternative Name extension
 // with an OtherName sequence. RFC 5280, 4.2.1.6:
 //
@@ -45,81 +239,1168 @@ type OtherName struct {
 //
 //	otherName                       [0]     OtherName,
 //	... }
-func MarshalSANS(name string, critical bool) (*pkix.Extension, error) {
-	var rawValues []asn1.RawValue
-	o := OtherName{
-		ID:    certificate.OIDOtherName,
-		Value: name,
+func MarshalSANS(name string, critical bool, opts ...MarshalOption) (*pkix.Extension, error) {
+	return MarshalSANSMultiWithOID([]string{name}, certificate.OIDOtherName, critical, opts...)
+}
+
+// MarshalSANSWithOID is like MarshalSANS, but the OtherName has the type oid
+// rather than the Sigstore OtherName OID, e.g. an OID under a private
+// enterprise number.
+func MarshalSANSWithOID(name string, oid asn1.ObjectIdentifier, critical bool, opts ...MarshalOption) (*pkix.Extension, error) {
+	return MarshalSANSMultiWithOID([]string{name}, oid, critical, opts...)
+}
+
+// MarshalSANSMulti creates a Subject Alternative Name extension with an
+// OtherName GeneralName for each of names, in order. At least one name is
+// required.
+func MarshalSANSMulti(names []string, critical bool, opts ...MarshalOption) (*pkix.Extension, error) {
+	return MarshalSANSMultiWithOID(names, certificate.OIDOtherName, critical, opts...)
+}
+
+// MarshalSANSMultiWithOID is like MarshalSANSMulti, but the OtherNames have
+// the type oid.
+func MarshalSANSMultiWithOID(names []string, oid asn1.ObjectIdentifier, critical bool, opts ...MarshalOption) (*pkix.Extension, error) {
+	return marshalSANS(names, oid, StringEncodingUTF8, critical, opts)
+}
+
+// OIDName is an OtherName with the type OID, for MarshalSANSWithOIDs.
+type OIDName struct {
+	OID  asn1.ObjectIdentifier
+	Name string
+}
+
+// MarshalSANSWithOIDs creates a Subject Alternative Name extension with an
+// OtherName GeneralName for each of names, in order, each with its own type.
+// This lets a certificate carry the same identity under an old and a new
+// OID, e.g. {certificate.OIDOtherName, name} and {newOID, name}, so verifiers
+// of either can read it with UnmarshalSANSByOID. At least one name is
+// required.
+func MarshalSANSWithOIDs(names []OIDName, critical bool, opts ...MarshalOption) (*pkix.Extension, error) {
+	if len(names) == 0 {
+		err := errors.New("at least one OtherName is required")
+		observeFailure(OperationMarshal, err)
+		return nil, err
+	}
+	var generalNames []asn1.RawValue
+	for _, n := range names {
+		ext, err := MarshalSANSMultiWithOID([]string{n.Name}, n.OID, critical, opts...)
+		if err != nil {
+			return nil, err
+		}
+		var otherNames []asn1.RawValue
+		if _, err := asn1.Unmarshal(ext.Value, &otherNames); err != nil {
+			return nil, err
+		}
+		generalNames = append(generalNames, otherNames...)
+	}
+	return MarshalGeneralNames(generalNames, critical)
+}
+
+// MarshalSANSContext is like MarshalSANS, but first checks ctx, returning
+// ctx.Err() if it's cancelled or past its deadline, so a request handler can
+// stop issuing once its deadline passes.
+func MarshalSANSContext(ctx context.Context, name string, critical bool, opts ...MarshalOption) (*pkix.Extension, error) {
+	return MarshalSANSMultiWithOIDContext(ctx, []string{name}, certificate.OIDOtherName, critical, opts...)
+}
+
+// MarshalSANSMultiWithOIDContext is like MarshalSANSMultiWithOID, but checks
+// ctx as MarshalSANSContext does.
+func MarshalSANSMultiWithOIDContext(ctx context.Context, names []string, oid asn1.ObjectIdentifier, critical bool, opts ...MarshalOption) (*pkix.Extension, error) {
+	// Encoding never blocks, so ctx only needs checking before starting
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return marshalSANS(names, oid, StringEncodingUTF8, critical, opts)
+}
+
+// StringEncoding is an ASN.1 string type that OtherName values can be
+// encoded as.
+type StringEncoding int
+
+const (
+	// StringEncodingUTF8 encodes values as UTF8String, the default.
+	StringEncodingUTF8 StringEncoding = iota
+	// StringEncodingIA5 encodes values as IA5String, which only holds
+	// ASCII.
+	StringEncodingIA5
+	// StringEncodingBMP encodes values as BMPString, UCS-2, which only holds
+	// characters of the Basic Multilingual Plane. Older Windows CryptoAPI
+	// consumers expect it.
+	StringEncodingBMP
+)
+
+// MarshalSANSWithEncoding is like MarshalSANS, but encodes the OtherName
+// value as enc rather than UTF8String. It fails if name can't be represented
+// in enc.
+func MarshalSANSWithEncoding(name string, enc StringEncoding, critical bool, opts ...MarshalOption) (*pkix.Extension, error) {
+	return marshalSANS([]string{name}, certificate.OIDOtherName, enc, critical, opts)
+}
+
+// MarshalSANSRawValue creates a Subject Alternative Name extension with an
+// OtherName of type oid whose value is of any ASN.1 type, such as a SEQUENCE
+// for an identity richer than a string. value is encoded as asn1.Marshal
+// encodes a RawValue, so FullBytes is used as is if set, and must be a
+// single DER element. The maximum length applies to the encoded value, but
+// WithStrictValidation doesn't apply.
+func MarshalSANSRawValue(value asn1.RawValue, oid asn1.ObjectIdentifier, critical bool, opts ...MarshalOption) (*pkix.Extension, error) {
+	ext, err := marshalSANRawValue(value, oid, critical, opts)
+	observeFailure(OperationMarshal, err)
+	return ext, err
+}
+
+func marshalSANRawValue(value asn1.RawValue, oid asn1.ObjectIdentifier, critical bool, opts []MarshalOption) (*pkix.Extension, error) {
+	o := newMarshalOptions(opts)
+	oidDER, err := marshalOtherNameOID(oid)
+	if err != nil {
+		return nil, err
 	}
-	bytes, err := asn1.MarshalWithParams(o, "tag:0")
+	element, err := asn1.Marshal(value)
 	if err != nil {
 		return nil, err
 	}
-	rawValues = append(rawValues, asn1.RawValue{FullBytes: bytes})
+	if _, _, _, rest, err := readDERElement(element); err != nil {
+		return nil, fmt.Errorf("OtherName value is not valid DER: %w", err)
+	} else if len(rest) != 0 {
+		return nil, errors.New("OtherName value must be a single element")
+	}
+	if err := o.checkLength(len(element)); err != nil {
+		return nil, err
+	}
+
+	otherName := appendOtherNameElement(nil, oidDER, element)
+	b := make([]byte, 0, derHeaderLen(len(otherName))+len(otherName))
+	b = appendDERHeader(b, derTagSequence, len(otherName))
+	return &pkix.Extension{
+		Id:       oidSubjectAltName,
+		Critical: critical,
+		Value:    append(b, otherName...),
+	}, nil
+}
 
-	sans, err := asn1.Marshal(rawValues)
+func marshalSANS(names []string, oid asn1.ObjectIdentifier, enc StringEncoding, critical bool, opts []MarshalOption) (*pkix.Extension, error) {
+	ext, err := encodeSANS(names, oid, enc, critical, opts)
+	observeFailure(OperationMarshal, err)
+	return ext, err
+}
+
+func encodeSANS(names []string, oid asn1.ObjectIdentifier, enc StringEncoding, critical bool, opts []MarshalOption) (*pkix.Extension, error) {
+	o := newMarshalOptions(opts)
+	oidDER, err := marshalOtherNameOID(oid)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, errors.New("at least one OtherName is required")
+	}
+	for _, name := range names {
+		if err := o.check(name); err != nil {
+			return nil, err
+		}
+	}
+	tag, values, err := encodeStrings(names, enc)
 	if err != nil {
 		return nil, err
 	}
+
+	// Size the extension first, so it's encoded into a single buffer
+	total := 0
+	for _, value := range values {
+		total += otherNameLen(oidDER, value)
+	}
+	b := make([]byte, 0, derHeaderLen(total)+total)
+	b = appendDERHeader(b, derTagSequence, total)
+	for _, value := range values {
+		b = appendOtherName(b, oidDER, tag, value)
+	}
 	return &pkix.Extension{
-		Id:       asn1.ObjectIdentifier{2, 5, 29, 17},
+		Id:       oidSubjectAltName,
+		Critical: critical,
+		Value:    b,
+	}, nil
+}
+
+// BatchError is returned by MarshalSANSBatch when one of the names can't be
+// marshaled.
+type BatchError struct {
+	// Index of the name in the batch
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("OtherName %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error { return e.Err }
+
+// MarshalSANSBatch is like calling MarshalSANS for each of names, returning
+// the extensions in the same order, but encodes them all into one buffer.
+// If a name can't be marshaled, it returns a *BatchError with the index of
+// the first that failed.
+func MarshalSANSBatch(names []string, critical bool, opts ...MarshalOption) ([]*pkix.Extension, error) {
+	o := newMarshalOptions(opts)
+	total := 0
+	for i, name := range names {
+		if err := o.check(name); err != nil {
+			observeFailure(OperationMarshal, err)
+			return nil, &BatchError{Index: i, Err: err}
+		}
+		n := otherNameLen(oidOtherNameDER, name)
+		total += derHeaderLen(n) + n
+	}
+
+	b := make([]byte, 0, total)
+	exts := make([]pkix.Extension, len(names))
+	out := make([]*pkix.Extension, len(names))
+	for i, name := range names {
+		start := len(b)
+		b = appendDERHeader(b, derTagSequence, otherNameLen(oidOtherNameDER, name))
+		b = appendOtherName(b, oidOtherNameDER, derTagUTF8String, name)
+		exts[i] = pkix.Extension{
+			Id:       oidSubjectAltName,
+			Critical: critical,
+			// Limit the capacity, so appending to one value can't
+			// overwrite the next
+			Value: b[start:len(b):len(b)],
+		}
+		out[i] = &exts[i]
+	}
+	return out, nil
+}
+
+// encodeStrings returns the DER tag of enc, and the contents of each of
+// names encoded as it.
+func encodeStrings(names []string, enc StringEncoding) (byte, []string, error) {
+	switch enc {
+	case StringEncodingUTF8:
+		return derTagUTF8String, names, nil
+	case StringEncodingIA5:
+		for _, name := range names {
+			for i := 0; i < len(name); i++ {
+				if name[i] >= utf8.RuneSelf {
+					return 0, nil, fmt.Errorf("OtherName %q can't be encoded as an IA5String", name)
+				}
+			}
+		}
+		return derTagIA5String, names, nil
+	case StringEncodingBMP:
+		values := make([]string, len(names))
+		for i, name := range names {
+			if !utf8.ValidString(name) {
+				return 0, nil, fmt.Errorf("OtherName %q is not valid UTF-8", name)
+			}
+			b := make([]byte, 0, 2*len(name))
+			for _, r := range name {
+				if r > 0xffff {
+					return 0, nil, fmt.Errorf("OtherName %q can't be encoded as a BMPString, %U is outside the Basic Multilingual Plane", name, r)
+				}
+				b = append(b, byte(r>>8), byte(r))
+			}
+			values[i] = string(b)
+		}
+		return derTagBMPString, values, nil
+	}
+	return 0, nil, fmt.Errorf("unknown string encoding %d", enc)
+}
+
+// appendOtherName appends an OtherName GeneralName with the type oidDER and
+// a string value with the contents value and the DER tag tag to b.
+func appendOtherName(b []byte, oidDER []byte, tag byte, value string) []byte {
+	str := derHeaderLen(len(value)) + len(value)
+	explicit := derHeaderLen(str) + str
+	b = appendDERHeader(b, generalNameIdentifier(TagOtherName, true), len(oidDER)+explicit)
+	b = append(b, oidDER...)
+	b = appendDERHeader(b, generalNameIdentifier(0, true), str)
+	b = appendDERHeader(b, tag, len(value))
+	return append(b, value...)
+}
+
+// appendOtherNameElement appends an OtherName GeneralName with the type
+// oidDER and the DER encoded value element to b.
+func appendOtherNameElement(b []byte, oidDER []byte, element []byte) []byte {
+	explicit := derHeaderLen(len(element)) + len(element)
+	b = appendDERHeader(b, generalNameIdentifier(TagOtherName, true), len(oidDER)+explicit)
+	b = append(b, oidDER...)
+	b = appendDERHeader(b, generalNameIdentifier(0, true), len(element))
+	return append(b, element...)
+}
+
+// otherNameLen returns the length of an OtherName GeneralName encoded with
+// the type oidDER and a string value with the contents value.
+func otherNameLen(oidDER []byte, value string) int {
+	str := derHeaderLen(len(value)) + len(value)
+	explicit := derHeaderLen(str) + str
+	content := len(oidDER) + explicit
+	return derHeaderLen(content) + content
+}
+
+// MarshalGeneralNames creates a Subject Alternative Name extension from
+// DER-encoded GeneralNames.
+func MarshalGeneralNames(names []asn1.RawValue, critical bool) (*pkix.Extension, error) {
+	sans, err := asn1.Marshal(names)
+	if err != nil {
+		return nil, err
+	}
+	return &pkix.Extension{
+		Id:       oidSubjectAltName,
 		Critical: critical,
 		Value:    sans,
 	}, nil
 }
 
-// UnmarshalSANs extracts a UTF-8 string from the OtherName
-// field in the Subject Alternative Name extension.
-func UnmarshalSANS(exts []pkix.Extension) (string, error) {
-	var otherNames []string
+// AppendOtherName returns a copy of the Subject Alternative Name extension
+// existing, with a username OtherName for name added after its current
+// GeneralNames. The extension keeps its criticality. If existing is nil, it
+// returns a new critical extension holding just the OtherName, as
+// MarshalSANS does.
+func AppendOtherName(existing *pkix.Extension, name string, opts ...MarshalOption) (*pkix.Extension, error) {
+	if existing == nil {
+		return MarshalSANS(name, true, opts...)
+	}
+	if !existing.Id.Equal(oidSubjectAltName) {
+		return nil, fmt.Errorf("extension %v is not a subject alternative name extension", existing.Id)
+	}
+
+	var names []asn1.RawValue
+	rest, err := asn1.Unmarshal(existing.Value, &names)
+	if err != nil {
+		return nil, sentinelError{ErrMalformedSAN, err}
+	} else if len(rest) != 0 {
+		return nil, sentinelError{ErrMalformedSAN, errors.New("trailing data after X.509 extension")}
+	}
+
+	ext, err := MarshalSANS(name, existing.Critical, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var otherName []asn1.RawValue
+	if _, err := asn1.Unmarshal(ext.Value, &otherName); err != nil {
+		return nil, err
+	}
+	return MarshalGeneralNames(append(names, otherName...), existing.Critical)
+}
+
+// MarshalIPSANS creates a Subject Alternative Name extension with an
+// iPAddress GeneralName for each of ips, in order. At least one IP address
+// is required.
+func MarshalIPSANS(ips []net.IP, critical bool) (*pkix.Extension, error) {
+	if len(ips) == 0 {
+		return nil, errors.New("at least one IP address is required")
+	}
+	return MarshalSANSWithIPs(nil, ips, critical)
+}
+
+// MarshalSANSWithIPs creates a Subject Alternative Name extension with a
+// username OtherName for each of names, followed by an iPAddress GeneralName
+// for each of ips. At least one name or IP address is required.
+func MarshalSANSWithIPs(names []string, ips []net.IP, critical bool, opts ...MarshalOption) (*pkix.Extension, error) {
+	if len(names) == 0 && len(ips) == 0 {
+		return nil, errors.New("at least one OtherName or IP address is required")
+	}
+	others := make([]asn1.RawValue, 0, len(ips))
+	for _, ip := range ips {
+		name, err := ipGeneralName(ip)
+		if err != nil {
+			return nil, err
+		}
+		others = append(others, name)
+	}
+	return marshalSANSWith(names, others, critical, opts)
+}
 
+// MarshalSANSWithDNSNames creates a Subject Alternative Name extension with a
+// username OtherName for each of names, followed by a dNSName GeneralName for
+// each of dnsNames, for verifiers that expect a hostname as well. Each DNS
+// name must be a valid hostname. At least one name or DNS name is required.
+// critical applies to the whole extension: a GeneralName can't be critical
+// on its own.
+func MarshalSANSWithDNSNames(names []string, dnsNames []string, critical bool, opts ...MarshalOption) (*pkix.Extension, error) {
+	if len(names) == 0 && len(dnsNames) == 0 {
+		return nil, errors.New("at least one OtherName or DNS name is required")
+	}
+	others := make([]asn1.RawValue, 0, len(dnsNames))
+	for _, dns := range dnsNames {
+		if !govalidator.IsDNSName(dns) {
+			return nil, fmt.Errorf("invalid DNS name %q", dns)
+		}
+		others = append(others, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: TagDNSName, Bytes: []byte(dns)})
+	}
+	return marshalSANSWith(names, others, critical, opts)
+}
+
+// marshalSANSWith creates a Subject Alternative Name extension with a
+// username OtherName for each of names, followed by the GeneralNames others.
+func marshalSANSWith(names []string, others []asn1.RawValue, critical bool, opts []MarshalOption) (*pkix.Extension, error) {
+	var generalNames []asn1.RawValue
+	if len(names) > 0 {
+		ext, err := MarshalSANSMulti(names, critical, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := asn1.Unmarshal(ext.Value, &generalNames); err != nil {
+			return nil, err
+		}
+	}
+	return MarshalGeneralNames(append(generalNames, others...), critical)
+}
+
+// ipGeneralName returns an iPAddress GeneralName, with an IPv4 address in 4
+// bytes and an IPv6 address in 16 bytes, as RFC 5280, 4.2.1.6 requires.
+func ipGeneralName(ip net.IP) (asn1.RawValue, error) {
+	b := ip.To4()
+	if b == nil {
+		b = ip.To16()
+	}
+	if b == nil {
+		return asn1.RawValue{}, fmt.Errorf("invalid IP address %v", ip)
+	}
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: TagIPAddress, Bytes: b}, nil
+}
+
+// IPAddressesFromSANS returns the iPAddress GeneralNames in the Subject
+// Alternative Name extensions among exts, in order, or nil if there are
+// none. Other GeneralNames are skipped.
+func IPAddressesFromSANS(exts []pkix.Extension) ([]net.IP, error) {
+	var ips []net.IP
 	for _, e := range exts {
-		if !e.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 17}) {
+		if !e.Id.Equal(oidSubjectAltName) {
 			continue
 		}
+		var names []asn1.RawValue
+		rest, err := asn1.Unmarshal(e.Value, &names)
+		if err != nil {
+			return nil, sentinelError{ErrMalformedSAN, err}
+		} else if len(rest) != 0 {
+			return nil, sentinelError{ErrMalformedSAN, errors.New("trailing data after X.509 extension")}
+		}
+		for _, v := range names {
+			if v.Class != asn1.ClassContextSpecific || v.Tag != TagIPAddress {
+				continue
+			}
+			if v.IsCompound || (len(v.Bytes) != net.IPv4len && len(v.Bytes) != net.IPv6len) {
+				return nil, fmt.Errorf("invalid IP address SAN %x", v.Bytes)
+			}
+			ips = append(ips, net.IP(append([]byte(nil), v.Bytes...)))
+		}
+	}
+	return ips, nil
+}
 
-		var seq asn1.RawValue
-		rest, err := asn1.Unmarshal(e.Value, &seq)
+// DNSNamesFromSANS returns the dNSName GeneralNames in the Subject
+// Alternative Name extensions among exts, in order, or nil if there are
+// none. Other GeneralNames are skipped.
+func DNSNamesFromSANS(exts []pkix.Extension) ([]string, error) {
+	var dnsNames []string
+	for _, e := range exts {
+		if !e.Id.Equal(oidSubjectAltName) {
+			continue
+		}
+		var names []asn1.RawValue
+		rest, err := asn1.Unmarshal(e.Value, &names)
 		if err != nil {
-			return "", err
+			return nil, sentinelError{ErrMalformedSAN, err}
 		} else if len(rest) != 0 {
-			return "", fmt.Errorf("trailing data after X.509 extension")
+			return nil, sentinelError{ErrMalformedSAN, errors.New("trailing data after X.509 extension")}
 		}
-		if !seq.IsCompound || seq.Tag != 16 || seq.Class != 0 {
-			return "", asn1.StructuralError{Msg: "bad SAN sequence"}
+		for _, v := range names {
+			if v.Class != asn1.ClassContextSpecific || v.Tag != TagDNSName {
+				continue
+			}
+			// dNSNames are IA5Strings
+			if v.IsCompound || bytes.IndexFunc(v.Bytes, func(r rune) bool { return r >= utf8.RuneSelf }) != -1 {
+				return nil, fmt.Errorf("invalid DNS name SAN %q", v.Bytes)
+			}
+			dnsNames = append(dnsNames, string(v.Bytes))
 		}
+	}
+	return dnsNames, nil
+}
+
+// PackSANS moves the DNS, email, IP and URI SANs of a certificate template
+// into Subject Alternative Name extensions, if the template already has one
+// in ExtraExtensions. Otherwise crypto/x509 would silently drop them, since
+// it doesn't generate a SAN extension when one is supplied. The SANs are
+// added to the existing extension, or if split is true, to a second
+// extension with the same criticality.
+func PackSANS(cert *x509.Certificate, split bool) error {
+	idx := -1
+	for i, e := range cert.ExtraExtensions {
+		if e.Id.Equal(oidSubjectAltName) {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return nil
+	}
 
-		rest = seq.Bytes
-		for len(rest) > 0 {
-			var v asn1.RawValue
-			rest, err = asn1.Unmarshal(rest, &v)
+	names, err := templateGeneralNames(cert)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	existing := cert.ExtraExtensions[idx]
+	if !split {
+		var current []asn1.RawValue
+		rest, err := asn1.Unmarshal(existing.Value, &current)
+		if err != nil {
+			return err
+		} else if len(rest) != 0 {
+			return errors.New("trailing data after X.509 extension")
+		}
+		names = append(current, names...)
+	}
+	ext, err := MarshalGeneralNames(names, existing.Critical)
+	if err != nil {
+		return err
+	}
+	if split {
+		cert.ExtraExtensions = append(cert.ExtraExtensions, *ext)
+	} else {
+		cert.ExtraExtensions[idx] = *ext
+	}
+
+	clearTemplateSANS(cert)
+	return nil
+}
+
+// SetSANCriticality marks the Subject Alternative Name extensions of a
+// certificate template critical or not. If the template has no extension in
+// ExtraExtensions, crypto/x509 generates one from the DNS, email, IP and URI
+// fields, which it marks critical when the subject is empty. So to make
+// those SANs non-critical, they're moved into an extension.
+func SetSANCriticality(cert *x509.Certificate, critical bool) error {
+	found := false
+	for i, e := range cert.ExtraExtensions {
+		if e.Id.Equal(oidSubjectAltName) {
+			cert.ExtraExtensions[i].Critical = critical
+			found = true
+		}
+	}
+	if found || critical {
+		return nil
+	}
+
+	names, err := templateGeneralNames(cert)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	ext, err := MarshalGeneralNames(names, false)
+	if err != nil {
+		return err
+	}
+	cert.ExtraExtensions = append(cert.ExtraExtensions, *ext)
+	clearTemplateSANS(cert)
+	return nil
+}
+
+// templateGeneralNames returns the DNS, email, IP and URI SANs of a
+// certificate template as GeneralNames.
+func templateGeneralNames(cert *x509.Certificate) ([]asn1.RawValue, error) {
+	var names []asn1.RawValue
+	for _, email := range cert.EmailAddresses {
+		names = append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: TagRFC822Name, Bytes: []byte(email)})
+	}
+	for _, dns := range cert.DNSNames {
+		names = append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: TagDNSName, Bytes: []byte(dns)})
+	}
+	for _, u := range cert.URIs {
+		names = append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: TagURI, Bytes: []byte(u.String())})
+	}
+	for _, ip := range cert.IPAddresses {
+		name, err := ipGeneralName(ip)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func clearTemplateSANS(cert *x509.Certificate) {
+	cert.EmailAddresses = nil
+	cert.DNSNames = nil
+	cert.URIs = nil
+	cert.IPAddresses = nil
+}
+
+// UnmarshalSANs extracts a UTF-8 string from the OtherName
+// field in the Subject Alternative Name extension. The OtherName may be
+// packed with other SANs in the extension. Certificates with SANs split
+// across several extensions must be read with UnmarshalSANSLenient.
+//
+// Errors match, with errors.Is:
+//   - ErrNoOtherName if there is no OtherName
+//   - ErrMultipleOtherNames if there is more than one
+//   - ErrMultipleSANExtensions if there is more than one Subject
+//     Alternative Name extension
+//   - ErrUnexpectedOID if an OtherName has another type
+//   - ErrInvalidOtherName if an OtherName can't be parsed
+//   - ErrMalformedSAN if an extension isn't a sequence of GeneralNames
+//   - ErrSANNotCritical, from UnmarshalSANSStrict only, if an extension
+//     isn't critical
+func UnmarshalSANS(exts []pkix.Extension) (string, error) {
+	return UnmarshalSANSWithOID(exts, certificate.OIDOtherName)
+}
+
+// UnmarshalSANSStrict is like UnmarshalSANS, but if requireCritical is true,
+// it returns ErrSANNotCritical unless every Subject Alternative Name extension
+// is critical, as RFC 5280 requires for certificates with an empty subject
+// such as Fulcio's.
+func UnmarshalSANSStrict(exts []pkix.Extension, requireCritical bool) (string, error) {
+	if requireCritical {
+		for _, e := range exts {
+			if e.Id.Equal(oidSubjectAltName) && !e.Critical {
+				observeFailure(OperationUnmarshal, ErrSANNotCritical)
+				return "", ErrSANNotCritical
+			}
+		}
+	}
+	return UnmarshalSANS(exts)
+}
+
+// UnmarshalOtherNameParts is like UnmarshalSANS, but splits the OtherName
+// into its username and hostname, checking it with ValidateOtherName, so it
+// must have exactly one ! separator between a non-empty username and a
+// valid hostname. If it isn't, the error matches ErrInvalidOtherName with
+// errors.Is.
+func UnmarshalOtherNameParts(exts []pkix.Extension) (username, hostname string, err error) {
+	otherName, err := UnmarshalSANS(exts)
+	if err != nil {
+		return "", "", err
+	}
+	if err := ValidateOtherName(otherName); err != nil {
+		err = sentinelError{ErrInvalidOtherName, err}
+		observeFailure(OperationUnmarshal, err)
+		return "", "", err
+	}
+	username, hostname, _ = splitOtherName(otherName)
+	return username, hostname, nil
+}
+
+// UnmarshalSANSWithOID is like UnmarshalSANS, but expects the OtherName to
+// have the type oid rather than the Sigstore OtherName OID.
+func UnmarshalSANSWithOID(exts []pkix.Extension, oid asn1.ObjectIdentifier) (string, error) {
+	otherName, err := unmarshalSAN(exts, oid, scanOptions{})
+	observeFailure(OperationUnmarshal, err)
+	return otherName, err
+}
+
+// UnmarshalSANSByOID is like UnmarshalSANSWithOID, but skips OtherNames of
+// other types rather than failing with ErrUnexpectedOID, so the OtherName of
+// type oid can be read from a certificate that also holds the same identity
+// under another OID, as MarshalSANSWithOIDs produces while migrating
+// verifiers between OIDs. Exactly one OtherName of type oid is required.
+func UnmarshalSANSByOID(exts []pkix.Extension, oid asn1.ObjectIdentifier) (string, error) {
+	otherName, err := unmarshalSAN(exts, oid, scanOptions{skipOtherOIDs: true})
+	observeFailure(OperationUnmarshal, err)
+	return otherName, err
+}
+
+// multipleOtherNamesError returns an error matching ErrMultipleOtherNames
+// that lists the OtherNames found, so the offending certificate can be
+// identified.
+func multipleOtherNamesError(otherNames []string) error {
+	return fmt.Errorf("%w, found %d: %q", ErrMultipleOtherNames, len(otherNames), otherNames)
+}
+
+// UnmarshalSANSMulti extracts the UTF-8 strings from every OtherName field
+// in the Subject Alternative Name extensions, in order. It fails if there is
+// no OtherName. Errors match the same sentinels as UnmarshalSANS.
+func UnmarshalSANSMulti(exts []pkix.Extension) ([]string, error) {
+	return UnmarshalSANSMultiWithOID(exts, certificate.OIDOtherName)
+}
+
+// UnmarshalSANSMultiWithOID is like UnmarshalSANSMulti, but expects the
+// OtherNames to have the type oid.
+func UnmarshalSANSMultiWithOID(exts []pkix.Extension, oid asn1.ObjectIdentifier) ([]string, error) {
+	otherNames, err := unmarshalSANS(exts, oid, scanOptions{})
+	observeFailure(OperationUnmarshal, err)
+	return otherNames, err
+}
+
+// UnmarshalSANSLenient is like UnmarshalSANS, but accepts several Subject
+// Alternative Name extensions, as PackSANS produces when splitting SANs, and
+// extensions whose value is several concatenated sequences of GeneralNames,
+// as some CAs produce, rather than failing with trailing data. The
+// extensions and sequences are parsed in turn and must together hold
+// exactly one OtherName. It also accepts an OtherName value wrapped in one
+// redundant explicit [0] tag, as some tools produce in CSRs, reading the
+// string inside.
+//
+// This accepts extensions that don't conform to RFC 5280, so only use it to
+// read certificates from CAs known to produce them.
+func UnmarshalSANSLenient(exts []pkix.Extension) (string, error) {
+	otherName, err := unmarshalSAN(exts, certificate.OIDOtherName, scanOptions{lenient: true})
+	observeFailure(OperationUnmarshal, err)
+	return otherName, err
+}
+
+// UnmarshalSANSRawValue is like UnmarshalSANSWithOID, but returns the value
+// of the OtherName whatever its ASN.1 type, so that callers can decode their
+// own schema, e.g. with asn1.Unmarshal(value.FullBytes, &v). String values
+// are returned undecoded too, with the Tag of their string type.
+func UnmarshalSANSRawValue(exts []pkix.Extension, oid asn1.ObjectIdentifier) (asn1.RawValue, error) {
+	value, err := unmarshalSANRawValue(exts, oid)
+	observeFailure(OperationUnmarshal, err)
+	return value, err
+}
+
+func unmarshalSANRawValue(exts []pkix.Extension, oid asn1.ObjectIdentifier) (asn1.RawValue, error) {
+	var values []asn1.RawValue
+	err := eachSANOtherName(exts, oid, scanOptions{}, func(tag byte, element, content []byte) error {
+		values = append(values, asn1.RawValue{
+			Class:      int(tag >> 6),
+			Tag:        int(tag & derTagNumberMask),
+			IsCompound: tag&derConstructed != 0,
+			Bytes:      content,
+			FullBytes:  element,
+		})
+		return nil
+	})
+	if err != nil {
+		return asn1.RawValue{}, err
+	}
+	switch len(values) {
+	case 0:
+		return asn1.RawValue{}, ErrNoOtherName
+	case 1:
+		return values[0], nil
+	}
+	return asn1.RawValue{}, fmt.Errorf("%w, found %d", ErrMultipleOtherNames, len(values))
+}
+
+// unmarshalSAN returns the only OtherName of type oid, reading the
+// extensions as scan sets out.
+func unmarshalSAN(exts []pkix.Extension, oid asn1.ObjectIdentifier, scan scanOptions) (string, error) {
+	otherNames, err := unmarshalSANS(exts, oid, scan)
+	if err != nil {
+		return "", err
+	}
+	if len(otherNames) != 1 {
+		return "", multipleOtherNamesError(otherNames)
+	}
+	return otherNames[0], nil
+}
+
+// unmarshalSANS implements UnmarshalSANSMultiWithOID, reading the
+// extensions as scan sets out.
+func unmarshalSANS(exts []pkix.Extension, oid asn1.ObjectIdentifier, scan scanOptions) ([]string, error) {
+	var otherNames []string
+	err := eachSANOtherName(exts, oid, scan, func(tag byte, _, content []byte) error {
+		value, err := decodeOtherNameString(tag, content)
+		if err != nil {
+			return err
+		}
+		otherNames = append(otherNames, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(otherNames) == 0 {
+		return nil, ErrNoOtherName
+	}
+
+	return otherNames, nil
+}
+
+// scanOptions set out how eachSANOtherName reads extensions.
+type scanOptions struct {
+	// lenient allows several Subject Alternative Name extensions, several
+	// concatenated sequences of GeneralNames in an extension, and OtherName
+	// values wrapped in a second explicit [0] tag.
+	lenient bool
+	// skipOtherOIDs skips OtherNames with a type other than the expected
+	// OID, rather than failing with ErrUnexpectedOID.
+	skipOtherOIDs bool
+}
+
+// eachSANOtherName calls fn as eachOtherName does for the OtherNames in each
+// Subject Alternative Name extension among exts.
+func eachSANOtherName(exts []pkix.Extension, oid asn1.ObjectIdentifier, scan scanOptions, fn func(tag byte, element, content []byte) error) error {
+	oidDER, err := marshalOtherNameOID(oid)
+	if err != nil {
+		return err
+	}
+	if !scan.lenient {
+		n := 0
+		for _, e := range exts {
+			if e.Id.Equal(oidSubjectAltName) {
+				n++
+			}
+		}
+		if n > 1 {
+			return fmt.Errorf("%w: found %d", ErrMultipleSANExtensions, n)
+		}
+	}
+
+	for _, e := range exts {
+		// Zero value extensions, with no Id, are skipped here too
+		if !e.Id.Equal(oidSubjectAltName) {
+			continue
+		}
+		if len(e.Value) == 0 {
+			return sentinelError{ErrMalformedSAN, errors.New("empty SAN extension")}
+		}
+		if i := indefiniteLength(e.Value); i != -1 {
+			return sentinelError{ErrMalformedSAN, fmt.Errorf("%w: indefinite length at offset %d", ErrNotDER, i)}
+		}
+
+		for value := e.Value; ; {
+			// Check the declared length first, as truncation is the
+			// common failure and encoding/asn1 doesn't say by how much
+			if _, n, offset, err := readDERHeader(value); err == nil && len(value)-offset < n {
+				return sentinelError{ErrMalformedSAN, fmt.Errorf("SAN extension truncated: declared %d bytes, have %d", n, len(value)-offset)}
+			}
+			var seq asn1.RawValue
+			rest, err := asn1.Unmarshal(value, &seq)
 			if err != nil {
-				return "", err
+				return sentinelError{ErrMalformedSAN, err}
+			} else if len(rest) != 0 && !scan.lenient {
+				return sentinelError{ErrMalformedSAN, errors.New("trailing data after X.509 extension")}
+			}
+			if !seq.IsCompound || seq.Tag != 16 || seq.Class != 0 {
+				return sentinelError{ErrMalformedSAN, asn1.StructuralError{Msg: "bad SAN sequence"}}
 			}
 
-			// skip all GeneralName fields except OtherName
-			if v.Tag != 0 {
-				continue
+			if err := eachOtherName(seq.Bytes, oid, oidDER, scan, fn); err != nil {
+				return err
 			}
 
-			var other OtherName
-			_, err := asn1.UnmarshalWithParams(v.FullBytes, &other, "tag:0")
+			if len(rest) == 0 {
+				break
+			}
+			value = rest
+		}
+	}
+	return nil
+}
+
+// eachOtherName calls fn with the identifier octet, whole element and
+// content of the value of each OtherName among the encoded GeneralNames in
+// names. The OtherNames must have the type oid, encoded as oidDER, unless
+// scan.skipOtherOIDs is set, when OtherNames of other types are skipped.
+func eachOtherName(names []byte, oid asn1.ObjectIdentifier, oidDER []byte, scan scanOptions, fn func(tag byte, element, content []byte) error) error {
+	for rest := names; len(rest) > 0; {
+		var v asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &v)
+		if err != nil {
+			return sentinelError{ErrMalformedSAN, err}
+		}
+
+		// skip all GeneralName fields except OtherName
+		if v.Tag != TagOtherName {
+			continue
+		}
+
+		id, tag, element, content, err := parseOtherNameElement(v)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(id, oidDER) {
+			var other asn1.ObjectIdentifier
+			if rest, err := asn1.Unmarshal(id, &other); err != nil || len(rest) != 0 {
+				return fmt.Errorf("%w: invalid type", ErrInvalidOtherName)
+			}
+			if scan.skipOtherOIDs {
+				continue
+			}
+			return fmt.Errorf("%w, expected %v, got %v", ErrUnexpectedOID, oid, other)
+		}
+		if scan.lenient && tag == generalNameIdentifier(0, true) {
+			// Some tools wrap the value in a redundant second explicit
+			// tag, [0] { [0] { UTF8String } }, so unwrap one more layer
+			var rest []byte
+			tag, element, content, rest, err = readDERElement(content)
 			if err != nil {
-				return "", fmt.Errorf("could not parse requested OtherName SAN: %v", err)
+				return fmt.Errorf("%w: %v", ErrInvalidOtherName, err)
+			} else if len(rest) != 0 {
+				return fmt.Errorf("%w: trailing data after value", ErrInvalidOtherName)
+			}
+		}
+		if err := fn(tag, element, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rawOtherName is an OtherName with a value of any type. encoding/asn1
+// doesn't apply explicit tags to a RawValue, so Value holds the [0] tag
+// around the value.
+type rawOtherName struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+// parseOtherName decodes an OtherName GeneralName, returning the DER encoding
+// of its type and its value. Fulcio encodes the value as a UTF8String by
+// default, but an IA5String, PrintableString or BMPString is accepted too.
+func parseOtherName(v asn1.RawValue) ([]byte, string, error) {
+	id, tag, _, content, err := parseOtherNameElement(v)
+	if err != nil {
+		return nil, "", err
+	}
+	value, err := decodeOtherNameString(tag, content)
+	if err != nil {
+		return nil, "", err
+	}
+	return id, value, nil
+}
+
+// parseOtherNameElement decodes an OtherName GeneralName, returning the DER
+// encoding of its type, and the identifier octet, whole element and content
+// of its value, which may be of any type.
+func parseOtherNameElement(v asn1.RawValue) (id []byte, tag byte, element, content []byte, err error) {
+	if v.Class != asn1.ClassContextSpecific || !v.IsCompound {
+		return nil, 0, nil, nil, fmt.Errorf("%w: not a sequence", ErrInvalidOtherName)
+	}
+	tag, id, _, rest, err := readDERElement(v.Bytes)
+	if err != nil {
+		return nil, 0, nil, nil, fmt.Errorf("%w: %v", ErrInvalidOtherName, err)
+	} else if tag != derTagOID {
+		return nil, 0, nil, nil, fmt.Errorf("%w: type is not an OID", ErrInvalidOtherName)
+	}
+	tag, _, explicit, rest, err := readDERElement(rest)
+	if err != nil {
+		return nil, 0, nil, nil, fmt.Errorf("%w: %v", ErrInvalidOtherName, err)
+	} else if n, constructed, err := generalNameTag(tag); err != nil || n != 0 || !constructed {
+		return nil, 0, nil, nil, fmt.Errorf("%w: value is not explicitly tagged", ErrInvalidOtherName)
+	} else if len(rest) != 0 {
+		return nil, 0, nil, nil, fmt.Errorf("%w: trailing data after value", ErrInvalidOtherName)
+	}
+	tag, element, content, rest, err = readDERElement(explicit)
+	if err != nil {
+		return nil, 0, nil, nil, fmt.Errorf("%w: %v", ErrInvalidOtherName, err)
+	} else if len(rest) != 0 {
+		return nil, 0, nil, nil, fmt.Errorf("%w: trailing data after value", ErrInvalidOtherName)
+	} else if len(content) > maxDecodedOtherNameLength {
+		return nil, 0, nil, nil, fmt.Errorf("%w: %d bytes, maximum is %d", ErrOtherNameTooLong, len(content), maxDecodedOtherNameLength)
+	}
+	return id, tag, element, content, nil
+}
+
+// decodeOtherNameString decodes the content of an OtherName value with the
+// identifier octet tag, which must be one of the supported string types.
+func decodeOtherNameString(tag byte, value []byte) (string, error) {
+	switch tag {
+	case derTagUTF8String:
+		if !utf8.Valid(value) {
+			return "", fmt.Errorf("%w: invalid UTF-8 in UTF8String", ErrInvalidOtherName)
+		}
+	case derTagIA5String:
+		for _, b := range value {
+			if b >= utf8.RuneSelf {
+				return "", fmt.Errorf("%w: invalid character in IA5String", ErrInvalidOtherName)
+			}
+		}
+	case derTagPrintableString:
+		for _, b := range value {
+			if !isPrintable(b) {
+				return "", fmt.Errorf("%w: invalid character in PrintableString", ErrInvalidOtherName)
 			}
-			if !other.ID.Equal(certificate.OIDOtherName) {
-				return "", fmt.Errorf("unexpected OID for OtherName, expected %v, got %v", certificate.OIDOtherName, other.ID)
+		}
+	case derTagBMPString:
+		if len(value)%2 != 0 {
+			return "", fmt.Errorf("%w: odd length BMPString", ErrInvalidOtherName)
+		}
+		var b strings.Builder
+		b.Grow(len(value))
+		for i := 0; i < len(value); i += 2 {
+			r := rune(value[i])<<8 | rune(value[i+1])
+			if utf16.IsSurrogate(r) {
+				return "", fmt.Errorf("%w: invalid character in BMPString", ErrInvalidOtherName)
 			}
-			otherNames = append(otherNames, other.Value)
+			b.WriteRune(r)
 		}
+		return b.String(), nil
+	default:
+		return "", fmt.Errorf("%w: value is not a supported string type", ErrInvalidOtherName)
 	}
+	return string(value), nil
+}
 
-	if len(otherNames) == 0 {
-		return "", errors.New("no OtherName found")
+// isPrintable reports whether b is in the PrintableString character set,
+// X.680, 41.4.
+func isPrintable(b byte) bool {
+	return 'a' <= b && b <= 'z' ||
+		'A' <= b && b <= 'Z' ||
+		'0' <= b && b <= '9' ||
+		strings.IndexByte(" '()+,-./:=?", b) >= 0
+}
+
+// validateOtherNameOID checks oid is a well-formed type for an OtherName,
+// following the encoding rules of X.690, 8.19.
+func validateOtherNameOID(oid asn1.ObjectIdentifier) error {
+	if len(oid) == 0 {
+		return errors.New("OtherName OID must not be empty")
 	}
-	if len(otherNames) != 1 {
-		return "", errors.New("expected only one OtherName")
+	if len(oid) < 2 || oid[0] > 2 || (oid[0] < 2 && oid[1] >= 40) {
+		return fmt.Errorf("invalid OtherName OID %v", oid)
+	}
+	for _, arc := range oid {
+		if arc < 0 {
+			return fmt.Errorf("invalid OtherName OID %v", oid)
+		}
 	}
+	return nil
+}
 
-	return otherNames[0], nil
+// OtherNameFromCertificate returns the username OtherName of a parsed
+// certificate, found in its Subject Alternative Name extension. SANs of other
+// types are ignored, as is the criticality of the extension. It returns
+// ErrNoOtherName if the certificate has none, ErrMultipleSANExtensions if it
+// has more than one SAN extension, and another error if the SANs are
+// malformed or there is more than one OtherName.
+func OtherNameFromCertificate(cert *x509.Certificate) (string, error) {
+	if cert == nil {
+		return "", errors.New("certificate is nil")
+	}
+	return UnmarshalSANS(cert.Extensions)
+}
+
+// SANExtensionsEqual reports whether two Subject Alternative Name extensions
+// contain the same set of names, regardless of the order they're encoded in.
+// OtherNames are compared by type and value, DNS names case-insensitively,
+// and other GeneralNames by their encoding. The criticality of the
+// extensions isn't compared.
+//
+// An extension with no value is treated as a missing SAN, which equals only
+// another missing SAN, and not an extension with an empty sequence of names.
+// It returns an error if either extension isn't a Subject Alternative Name
+// extension or can't be decoded.
+func SANExtensionsEqual(a, b pkix.Extension) (bool, error) {
+	namesA, err := sanNameSet(a)
+	if err != nil {
+		return false, err
+	}
+	namesB, err := sanNameSet(b)
+	if err != nil {
+		return false, err
+	}
+	if (namesA == nil) != (namesB == nil) || len(namesA) != len(namesB) {
+		return false, nil
+	}
+	for name := range namesA {
+		if !namesB[name] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// sanNameSet decodes the GeneralNames of a Subject Alternative Name extension
+// into a set of comparable keys. It returns a nil set if the extension has no
+// value.
+func sanNameSet(e pkix.Extension) (map[string]bool, error) {
+	if len(e.Value) == 0 {
+		return nil, nil
+	}
+	if !e.Id.Equal(oidSubjectAltName) {
+		return nil, fmt.Errorf("extension %v is not a subject alternative name extension", e.Id)
+	}
+
+	var names []asn1.RawValue
+	rest, err := asn1.Unmarshal(e.Value, &names)
+	if err != nil {
+		return nil, sentinelError{ErrMalformedSAN, err}
+	} else if len(rest) != 0 {
+		return nil, sentinelError{ErrMalformedSAN, errors.New("trailing data after X.509 extension")}
+	}
+
+	set := make(map[string]bool, len(names))
+	for _, v := range names {
+		if v.Class != asn1.ClassContextSpecific {
+			return nil, sentinelError{ErrMalformedSAN, asn1.StructuralError{Msg: "bad GeneralName class"}}
+		}
+		var key string
+		switch v.Tag {
+		case TagOtherName:
+			var other rawOtherName
+			if _, err := asn1.UnmarshalWithParams(v.FullBytes, &other, "tag:0"); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidOtherName, err)
+			}
+			key = fmt.Sprintf("0:%v:%x", other.ID, other.Value.FullBytes)
+		case TagDNSName:
+			key = fmt.Sprintf("%d:%s", v.Tag, strings.ToLower(string(v.Bytes)))
+		default:
+			key = fmt.Sprintf("%d:%x", v.Tag, v.Bytes)
+		}
+		set[key] = true
+	}
+	return set, nil
+}
+
+// oidExtensionRequest is the PKCS #9 extensionRequest attribute, which holds
+// the extensions requested by a CSR.
+var oidExtensionRequest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 14}
+
+// OtherNameFromCSR returns the username OtherName requested by a parsed
+// certificate request, from the SANs in its extensionRequest attribute. The
+// extensions are read from the request's Extensions, or if those are empty,
+// from the attribute in the raw request. It returns ErrNoExtensions if the
+// request has no extensions, and otherwise fails as UnmarshalSANS does.
+func OtherNameFromCSR(csr *x509.CertificateRequest) (string, error) {
+	if csr == nil {
+		return "", errors.New("certificate request is nil")
+	}
+	exts := csr.Extensions
+	if len(exts) == 0 && len(csr.RawTBSCertificateRequest) > 0 {
+		var err error
+		exts, err = csrExtensions(csr.RawTBSCertificateRequest)
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(exts) == 0 {
+		return "", ErrNoExtensions
+	}
+	return UnmarshalSANS(exts)
+}
+
+// csrExtensions parses the extensions in the extensionRequest attribute of a
+// DER-encoded CertificationRequestInfo, RFC 2986, 4.1.
+func csrExtensions(tbs []byte) ([]pkix.Extension, error) {
+	var info struct {
+		Version       int
+		Subject       asn1.RawValue
+		PublicKey     asn1.RawValue
+		RawAttributes []asn1.RawValue `asn1:"tag:0"`
+	}
+	if rest, err := asn1.Unmarshal(tbs, &info); err != nil {
+		return nil, fmt.Errorf("parsing certificate request: %w", err)
+	} else if len(rest) != 0 {
+		return nil, errors.New("trailing data after certificate request")
+	}
+
+	var exts []pkix.Extension
+	for _, raw := range info.RawAttributes {
+		var attr struct {
+			Type   asn1.ObjectIdentifier
+			Values []asn1.RawValue `asn1:"set"`
+		}
+		if _, err := asn1.Unmarshal(raw.FullBytes, &attr); err != nil {
+			return nil, fmt.Errorf("parsing certificate request attribute: %w", err)
+		}
+		if !attr.Type.Equal(oidExtensionRequest) {
+			continue
+		}
+		for _, value := range attr.Values {
+			var requested []pkix.Extension
+			if _, err := asn1.Unmarshal(value.FullBytes, &requested); err != nil {
+				return nil, fmt.Errorf("parsing requested extensions: %w", err)
+			}
+			exts = append(exts, requested...)
+		}
+	}
+	return exts, nil
 }
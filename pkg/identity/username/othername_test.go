@@ -15,11 +15,29 @@
 package username
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	mathrand "math/rand"
+	"net"
+	"net/url"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/quick"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sigstore/fulcio/pkg/certificate"
 )
 
 func TestMarshalAndUnmarshalSANS(t *testing.T) {
@@ -63,6 +81,592 @@ func TestMarshalAndUnmarshalSANS(t *testing.T) {
 	}
 }
 
+func TestMarshalAndUnmarshalSANSMulti(t *testing.T) {
+	names := []string{"foo!example.com", "bar!example.com", "baz!example.com"}
+
+	ext, err := MarshalSANSMulti(names, true)
+	if err != nil {
+		t.Fatalf("unexpected error for MarshalSANSMulti: %v", err)
+	}
+	if !ext.Critical {
+		t.Fatalf("expected extension to be critical")
+	}
+	var generalNames []asn1.RawValue
+	if _, err := asn1.Unmarshal(ext.Value, &generalNames); err != nil {
+		t.Fatalf("unexpected error parsing GeneralNames: %v", err)
+	}
+	if len(generalNames) != len(names) {
+		t.Fatalf("expected %d GeneralNames, got %d", len(names), len(generalNames))
+	}
+
+	got, err := UnmarshalSANSMulti([]pkix.Extension{*ext})
+	if err != nil {
+		t.Fatalf("unexpected error for UnmarshalSANSMulti: %v", err)
+	}
+	if !reflect.DeepEqual(got, names) {
+		t.Fatalf("unexpected OtherNames, expected %v, got %v", names, got)
+	}
+
+	// A single name encodes the same as MarshalSANS
+	single, err := MarshalSANS(names[0], true)
+	if err != nil {
+		t.Fatalf("unexpected error for MarshalSANS: %v", err)
+	}
+	multi, err := MarshalSANSMulti(names[:1], true)
+	if err != nil {
+		t.Fatalf("unexpected error for MarshalSANSMulti: %v", err)
+	}
+	if !reflect.DeepEqual(single, multi) {
+		t.Fatalf("expected single name to match MarshalSANS")
+	}
+
+	// UnmarshalSANS only accepts one
+	if _, err := UnmarshalSANS([]pkix.Extension{*ext}); !errors.Is(err, ErrMultipleOtherNames) || !strings.Contains(err.Error(), fmt.Sprintf("found %d", len(names))) {
+		t.Fatalf("expected error with multiple OtherNames, got %v", err)
+	}
+
+	for _, empty := range [][]string{nil, {}} {
+		if _, err := MarshalSANSMulti(empty, true); err == nil || !strings.Contains(err.Error(), "at least one OtherName is required") {
+			t.Fatalf("expected error without names, got %v", err)
+		}
+	}
+}
+
+func TestMarshalAndUnmarshalSANSWithOID(t *testing.T) {
+	otherName := "foo!example.com"
+	oid := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1, 1}
+
+	ext, err := MarshalSANSWithOID(otherName, oid, true)
+	if err != nil {
+		t.Fatalf("unexpected error for MarshalSANSWithOID: %v", err)
+	}
+	on, err := UnmarshalSANSWithOID([]pkix.Extension{*ext}, oid)
+	if err != nil {
+		t.Fatalf("unexpected error for UnmarshalSANSWithOID: %v", err)
+	}
+	if on != otherName {
+		t.Fatalf("unexpected OtherName, expected %s, got %s", otherName, on)
+	}
+
+	// The Sigstore OID is expected by default, and other OIDs are rejected
+	if _, err := UnmarshalSANS([]pkix.Extension{*ext}); err == nil || !strings.Contains(err.Error(), "unexpected OID for OtherName") {
+		t.Fatalf("expected error with custom OID, got %v", err)
+	}
+	sigstore, err := MarshalSANS(otherName, true)
+	if err != nil {
+		t.Fatalf("unexpected error for MarshalSANS: %v", err)
+	}
+	if _, err := UnmarshalSANSWithOID([]pkix.Extension{*sigstore}, oid); err == nil || !strings.Contains(err.Error(), "unexpected OID for OtherName") {
+		t.Fatalf("expected error with Sigstore OID, got %v", err)
+	}
+
+	for _, invalid := range []asn1.ObjectIdentifier{
+		nil,
+		{},
+		{1},
+		{3, 1},
+		{1, 40},
+		{1, 3, -6},
+	} {
+		if _, err := MarshalSANSWithOID(otherName, invalid, true); err == nil || !strings.Contains(err.Error(), "OtherName OID") {
+			t.Fatalf("expected error marshaling with OID %v, got %v", invalid, err)
+		}
+		if _, err := UnmarshalSANSWithOID([]pkix.Extension{*ext}, invalid); err == nil || !strings.Contains(err.Error(), "OtherName OID") {
+			t.Fatalf("expected error unmarshaling with OID %v, got %v", invalid, err)
+		}
+	}
+}
+
+func TestMarshalSANSWithOIDs(t *testing.T) {
+	oldName, newName := "foo!example.com", "foo!new.example.com"
+	newOID := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1, 1}
+
+	ext, err := MarshalSANSWithOIDs([]OIDName{
+		{OID: certificate.OIDOtherName, Name: oldName},
+		{OID: newOID, Name: newName},
+	}, true)
+	if err != nil {
+		t.Fatalf("MarshalSANSWithOIDs() = %v", err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), ExtraExtensions: []pkix.Extension{*ext}}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each OtherName is read independently
+	if got, err := UnmarshalSANSByOID(cert.Extensions, certificate.OIDOtherName); err != nil || got != oldName {
+		t.Fatalf("UnmarshalSANSByOID() with Sigstore OID = %q, %v", got, err)
+	}
+	if got, err := UnmarshalSANSByOID(cert.Extensions, newOID); err != nil || got != newName {
+		t.Fatalf("UnmarshalSANSByOID() with new OID = %q, %v", got, err)
+	}
+	// but UnmarshalSANS still rejects the other OID
+	if _, err := UnmarshalSANS(cert.Extensions); !errors.Is(err, ErrUnexpectedOID) {
+		t.Fatalf("expected ErrUnexpectedOID, got %v", err)
+	}
+
+	// Failures
+	if _, err := UnmarshalSANSByOID(cert.Extensions, asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1, 2}); !errors.Is(err, ErrNoOtherName) {
+		t.Fatalf("expected ErrNoOtherName without an OtherName of the OID, got %v", err)
+	}
+	twice, err := MarshalSANSWithOIDs([]OIDName{
+		{OID: newOID, Name: oldName},
+		{OID: certificate.OIDOtherName, Name: oldName},
+		{OID: newOID, Name: newName},
+	}, true)
+	if err != nil {
+		t.Fatalf("MarshalSANSWithOIDs() = %v", err)
+	}
+	if _, err := UnmarshalSANSByOID([]pkix.Extension{*twice}, newOID); !errors.Is(err, ErrMultipleOtherNames) {
+		t.Fatalf("expected ErrMultipleOtherNames with two OtherNames of the OID, got %v", err)
+	}
+	if _, err := MarshalSANSWithOIDs(nil, true); err == nil {
+		t.Fatal("expected error without names")
+	}
+	if _, err := MarshalSANSWithOIDs([]OIDName{{OID: asn1.ObjectIdentifier{1}, Name: oldName}}, true); err == nil {
+		t.Fatal("expected error with invalid OID")
+	}
+	if _, err := MarshalSANSWithOIDs([]OIDName{{OID: newOID, Name: "foo"}}, true, WithStrictValidation()); err == nil {
+		t.Fatal("expected error with invalid OtherName")
+	}
+}
+
+func TestMarshalSANSMatchesEncodingASN1(t *testing.T) {
+	// OtherNames are encoded by hand, so check the encoding matches
+	// encoding/asn1 on either side of each length boundary
+	for _, n := range []int{0, 1, 90, 127, 128, 255, 256, 65535, 65536} {
+		for _, oid := range []asn1.ObjectIdentifier{{1, 3, 6, 1, 4, 1, 57264, 1, 7}, {1, 2, 3}} {
+			name := strings.Repeat("a", n)
+			ext, err := MarshalSANSMultiWithOID([]string{name, "foo!example.com"}, oid, true, WithMaxLength(0))
+			if err != nil {
+				t.Fatalf("MarshalSANSMultiWithOID() = %v", err)
+			}
+
+			var names []asn1.RawValue
+			for _, value := range []string{name, "foo!example.com"} {
+				b, err := asn1.MarshalWithParams(OtherName{ID: oid, Value: value}, "tag:0")
+				if err != nil {
+					t.Fatal(err)
+				}
+				names = append(names, asn1.RawValue{FullBytes: b})
+			}
+			want, err := asn1.Marshal(names)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(ext.Value, want) {
+				t.Fatalf("%d byte OtherName with OID %v encoded as %x, want %x", n, oid, ext.Value, want)
+			}
+
+			got, err := UnmarshalSANSMultiWithOID([]pkix.Extension{*ext}, oid)
+			if err != nil {
+				t.Fatalf("UnmarshalSANSMultiWithOID() = %v", err)
+			}
+			if !reflect.DeepEqual(got, []string{name, "foo!example.com"}) {
+				t.Fatalf("%d byte OtherName with OID %v decoded as %q", n, oid, got)
+			}
+		}
+	}
+}
+
+func TestMarshalSANSRawValue(t *testing.T) {
+	oid := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 7}
+
+	// A structured value, a sequence of OID and UTF8String pairs
+	type attribute struct {
+		ID    asn1.ObjectIdentifier
+		Value string `asn1:"utf8"`
+	}
+	attributes := []attribute{
+		{ID: asn1.ObjectIdentifier{2, 5, 4, 3}, Value: "foo"},
+		{ID: asn1.ObjectIdentifier{2, 5, 4, 10}, Value: "example.com"},
+	}
+	b, err := asn1.Marshal(attributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext, err := MarshalSANSRawValue(asn1.RawValue{FullBytes: b}, oid, true)
+	if err != nil {
+		t.Fatalf("MarshalSANSRawValue() = %v", err)
+	}
+	if !ext.Critical || !ext.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 17}) {
+		t.Fatalf("unexpected extension %v", ext)
+	}
+	value, err := UnmarshalSANSRawValue([]pkix.Extension{*ext}, oid)
+	if err != nil {
+		t.Fatalf("UnmarshalSANSRawValue() = %v", err)
+	}
+	if value.Class != asn1.ClassUniversal || value.Tag != asn1.TagSequence || !value.IsCompound {
+		t.Fatalf("expected a SEQUENCE, got class %d tag %d", value.Class, value.Tag)
+	}
+	var got []attribute
+	if rest, err := asn1.Unmarshal(value.FullBytes, &got); err != nil || len(rest) != 0 {
+		t.Fatalf("decoding value: %v", err)
+	}
+	if !reflect.DeepEqual(got, attributes) {
+		t.Fatalf("decoded %v, want %v", got, attributes)
+	}
+	// The value isn't a string
+	if _, err := UnmarshalSANSWithOID([]pkix.Extension{*ext}, oid); !errors.Is(err, ErrInvalidOtherName) {
+		t.Fatalf("expected ErrInvalidOtherName for structured value, got %v", err)
+	}
+
+	// A string value encodes as MarshalSANS does, and is returned undecoded
+	ext, err = MarshalSANSRawValue(asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte("foo!example.com")}, oid, true)
+	if err != nil {
+		t.Fatalf("MarshalSANSRawValue() = %v", err)
+	}
+	want, err := MarshalSANS("foo!example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ext, want) {
+		t.Fatalf("string value encoded as %x, want %x", ext.Value, want.Value)
+	}
+	value, err = UnmarshalSANSRawValue([]pkix.Extension{*want}, oid)
+	if err != nil {
+		t.Fatalf("UnmarshalSANSRawValue() = %v", err)
+	}
+	if value.Tag != asn1.TagUTF8String || string(value.Bytes) != "foo!example.com" {
+		t.Fatalf("expected UTF8String foo!example.com, got tag %d %q", value.Tag, value.Bytes)
+	}
+
+	// failure: the value must be a single element, within the maximum length
+	if _, err := MarshalSANSRawValue(asn1.RawValue{FullBytes: append(b, b...)}, oid, true); err == nil || !strings.Contains(err.Error(), "single element") {
+		t.Fatalf("expected error for two elements, got %v", err)
+	}
+	if _, err := MarshalSANSRawValue(asn1.RawValue{FullBytes: []byte{0x30, 0x05}}, oid, true); err == nil || !strings.Contains(err.Error(), "not valid DER") {
+		t.Fatalf("expected error for truncated value, got %v", err)
+	}
+	if _, err := MarshalSANSRawValue(asn1.RawValue{FullBytes: b}, oid, true, WithMaxLength(len(b)-1)); !errors.Is(err, ErrOtherNameTooLong) {
+		t.Fatalf("expected ErrOtherNameTooLong, got %v", err)
+	}
+
+	// failure: exactly one OtherName is expected
+	multi, err := MarshalSANSMulti([]string{"foo!example.com", "bar!example.com"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnmarshalSANSRawValue([]pkix.Extension{*multi}, oid); !errors.Is(err, ErrMultipleOtherNames) {
+		t.Fatalf("expected ErrMultipleOtherNames, got %v", err)
+	}
+	if _, err := UnmarshalSANSRawValue(nil, oid); !errors.Is(err, ErrNoOtherName) {
+		t.Fatalf("expected ErrNoOtherName, got %v", err)
+	}
+}
+
+func TestMarshalSANSWithEncoding(t *testing.T) {
+	tests := map[string]struct {
+		name    string
+		enc     StringEncoding
+		hex     string
+		wantErr string
+	}{
+		"UTF8String": {
+			name: "foo!example.com",
+			enc:  StringEncodingUTF8,
+			hex:  "3021a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d",
+		},
+		"IA5String": {
+			name: "foo!example.com",
+			enc:  StringEncodingIA5,
+			hex:  "3021a01f060a2b0601040183bf300107a011160f666f6f216578616d706c652e636f6d",
+		},
+		"BMPString": {
+			name: "foo!example.com",
+			enc:  StringEncodingBMP,
+			hex:  "3030a02e060a2b0601040183bf300107a0201e1e0066006f006f0021006500780061006d0070006c0065002e0063006f006d",
+		},
+		"BMPString outside ASCII": {
+			name: "josé!例.com",
+			enc:  StringEncodingBMP,
+			hex:  "3026a024060a2b0601040183bf300107a0161e14006a006f007300e900214f8b002e0063006f006d",
+		},
+		"IA5String outside ASCII": {
+			name:    "josé!example.com",
+			enc:     StringEncodingIA5,
+			wantErr: "can't be encoded as an IA5String",
+		},
+		"BMPString outside the BMP": {
+			name:    "foo🚀!example.com",
+			enc:     StringEncodingBMP,
+			wantErr: "outside the Basic Multilingual Plane",
+		},
+		"BMPString with invalid UTF-8": {
+			name:    "foo\xff!example.com",
+			enc:     StringEncodingBMP,
+			wantErr: "not valid UTF-8",
+		},
+		"unknown encoding": {
+			name:    "foo!example.com",
+			enc:     StringEncoding(42),
+			wantErr: "unknown string encoding",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ext, err := MarshalSANSWithEncoding(test.name, test.enc, true)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MarshalSANSWithEncoding() = %v", err)
+			}
+			if got := hex.EncodeToString(ext.Value); got != test.hex {
+				t.Fatalf("MarshalSANSWithEncoding() = %s, want %s", got, test.hex)
+			}
+			if !ext.Critical {
+				t.Fatal("expected critical extension")
+			}
+			got, err := UnmarshalSANS([]pkix.Extension{*ext})
+			if err != nil || got != test.name {
+				t.Fatalf("UnmarshalSANS() = %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestMarshalSANSBatch(t *testing.T) {
+	names := []string{"foo!example.com", "bar!example.com", "", strings.Repeat("a", 200) + "!example.com"}
+	exts, err := MarshalSANSBatch(names, true)
+	if err != nil {
+		t.Fatalf("MarshalSANSBatch() = %v", err)
+	}
+	if len(exts) != len(names) {
+		t.Fatalf("expected %d extensions, got %d", len(names), len(exts))
+	}
+	wants := make([]*pkix.Extension, len(names))
+	for i, name := range names {
+		if wants[i], err = MarshalSANS(name, true); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(exts[i], wants[i]) {
+			t.Fatalf("extension %d is %v, want %v", i, exts[i], wants[i])
+		}
+	}
+	// Values share a buffer, but appending to one leaves the next alone
+	_ = append(exts[0].Value, 0xff)
+	if !bytes.Equal(exts[1].Value, wants[1].Value) {
+		t.Fatal("appending to one value modified the next")
+	}
+
+	if exts, err := MarshalSANSBatch(nil, true); err != nil || len(exts) != 0 {
+		t.Fatalf("MarshalSANSBatch(nil) = %v, %v", exts, err)
+	}
+
+	_, err = MarshalSANSBatch([]string{"foo!example.com", "bar!example.com", "baz", "qux"}, true, WithStrictValidation())
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 2 || !strings.Contains(err.Error(), "OtherName 2: ") {
+		t.Fatalf("expected BatchError for index 2, got %v", err)
+	}
+}
+
+func TestValidateOtherName(t *testing.T) {
+	tests := map[string]string{
+		"foo!example.com":       "",
+		"foo.bar!host":          "",
+		"foo!sub.example.com":   "",
+		"foo":                   "must have the form",
+		"foo!bar!example.com":   "must have the form",
+		"!example.com":          "empty username",
+		"foo!":                  "invalid hostname",
+		"foo!exa mple.com":      "invalid hostname",
+		"foo!*.pool.internal":   "",
+		"foo!*.internal":        "invalid hostname",
+		"foo!a*.pool.internal":  "invalid hostname",
+		"foo!a.*.pool.internal": "invalid hostname",
+		"foo!*.*.pool.internal": "invalid hostname",
+		"foo!*":                 "invalid hostname",
+		"foo@example.com":       "must not contain @",
+		"foo!example.com\x00":   "control character",
+		"foo\n!example.com":     "control character",
+		"foo\xff!example.com":   "not valid UTF-8",
+	}
+	for name, wantErr := range tests {
+		err := ValidateOtherName(name)
+		if wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateOtherName(%q) = %v", name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("ValidateOtherName(%q) = %v, expected error containing %q", name, err, wantErr)
+		}
+	}
+}
+
+func TestMarshalSANSWithStrictValidation(t *testing.T) {
+	// Free-form values are still accepted by default
+	if _, err := MarshalSANS("foo\nbar", true); err != nil {
+		t.Fatalf("unexpected error for MarshalSANS: %v", err)
+	}
+	if _, err := MarshalSANS("foo\nbar", true, WithStrictValidation()); err == nil || !strings.Contains(err.Error(), "control character") {
+		t.Fatalf("expected error with strict validation, got %v", err)
+	}
+	if _, err := MarshalSANSMulti([]string{"foo!example.com", "bar"}, true, WithStrictValidation()); err == nil || !strings.Contains(err.Error(), "must have the form") {
+		t.Fatalf("expected error for each name with strict validation, got %v", err)
+	}
+	if _, err := MarshalSANS("foo!*.pool.internal", true, WithStrictValidation()); err != nil {
+		t.Fatalf("expected wildcard hostname to be accepted with strict validation, got %v", err)
+	}
+	ext, err := MarshalSANS("foo!example.com", true, WithStrictValidation())
+	if err != nil {
+		t.Fatalf("unexpected error for MarshalSANS: %v", err)
+	}
+	if on, err := UnmarshalSANS([]pkix.Extension{*ext}); err != nil || on != "foo!example.com" {
+		t.Fatalf("UnmarshalSANS() = %q, %v", on, err)
+	}
+}
+
+func TestMarshalSANSContext(t *testing.T) {
+	want, err := MarshalSANS("foo!example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := MarshalSANSContext(context.Background(), "foo!example.com", true)
+	if err != nil {
+		t.Fatalf("MarshalSANSContext() = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("MarshalSANSContext() = %v, want %v", got, want)
+	}
+	// Options are applied as for MarshalSANS
+	if _, err := MarshalSANSContext(context.Background(), "foo", true, WithStrictValidation()); err == nil {
+		t.Fatal("expected error for invalid OtherName with strict validation")
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := MarshalSANSContext(cancelled, "foo!example.com", true); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := MarshalSANSMultiWithOIDContext(expired, []string{"foo!example.com", "bar!example.com"}, certificate.OIDOtherName, true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestMarshalSANSMaxLength(t *testing.T) {
+	atLimit := strings.Repeat("a", DefaultMaxOtherNameLength-len("!example.com")) + "!example.com"
+	if _, err := MarshalSANS(atLimit, true); err != nil {
+		t.Fatalf("unexpected error for %d byte OtherName: %v", len(atLimit), err)
+	}
+	overLimit := "a" + atLimit
+	if _, err := MarshalSANS(overLimit, true); !errors.Is(err, ErrOtherNameTooLong) || !strings.Contains(err.Error(), "256 bytes, maximum is 255") {
+		t.Fatalf("expected error for %d byte OtherName, got %v", len(overLimit), err)
+	}
+	// The limit is in bytes, not characters
+	if _, err := MarshalSANS(strings.Repeat("é", 128), true); !errors.Is(err, ErrOtherNameTooLong) {
+		t.Fatalf("expected error for 256 byte OtherName, got %v", err)
+	}
+	if _, err := MarshalSANSMulti([]string{"foo!example.com", overLimit}, true); !errors.Is(err, ErrOtherNameTooLong) {
+		t.Fatalf("expected error for each name, got %v", err)
+	}
+	_, err := MarshalSANSBatch([]string{"foo!example.com", overLimit}, true)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, ErrOtherNameTooLong) {
+		t.Fatalf("expected BatchError for index 1, got %v", err)
+	}
+
+	// The limit can be raised, or removed
+	if _, err := MarshalSANS(overLimit, true, WithMaxLength(len(overLimit))); err != nil {
+		t.Fatalf("unexpected error with raised limit: %v", err)
+	}
+	if _, err := MarshalSANS(overLimit+"a", true, WithMaxLength(len(overLimit))); !errors.Is(err, ErrOtherNameTooLong) {
+		t.Fatalf("expected error over raised limit, got %v", err)
+	}
+	if _, err := MarshalSANS(strings.Repeat("a", 4096), true, WithMaxLength(0)); err != nil {
+		t.Fatalf("unexpected error without limit: %v", err)
+	}
+
+	// Decoded values are bounded too
+	for n, wantErr := range map[int]bool{maxDecodedOtherNameLength: false, maxDecodedOtherNameLength + 1: true} {
+		ext, err := MarshalSANS(strings.Repeat("a", n), true, WithMaxLength(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		name, err := UnmarshalSANS([]pkix.Extension{*ext})
+		if wantErr && !errors.Is(err, ErrOtherNameTooLong) {
+			t.Fatalf("expected error unmarshaling %d byte OtherName, got %v", n, err)
+		} else if !wantErr && (err != nil || len(name) != n) {
+			t.Fatalf("UnmarshalSANS() of %d byte OtherName = %d bytes, %v", n, len(name), err)
+		}
+	}
+}
+
+func TestMarshalSANSDefiniteLength(t *testing.T) {
+	// Names with short and long form lengths at each level of the encoding
+	var names []string
+	for _, n := range []int{0, 1, 100, 127, 128, 255, 256, 1000, 65535, 65536} {
+		names = append(names, strings.Repeat("a", n)+"!example.com")
+	}
+	var exts []*pkix.Extension
+	add := func(ext *pkix.Extension, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		exts = append(exts, ext)
+	}
+	for _, name := range names {
+		add(MarshalSANS(name, true, WithMaxLength(0)))
+		add(MarshalSANSWithEncoding(name, StringEncodingBMP, true, WithMaxLength(0)))
+	}
+	add(MarshalSANSMulti(names, true, WithMaxLength(0)))
+	add(MarshalSANSWithIPs(names, []net.IP{net.ParseIP("192.0.2.1")}, true, WithMaxLength(0)))
+	add(MarshalSANSWithDNSNames(names, []string{"foo.example.com"}, true, WithMaxLength(0)))
+
+	for _, ext := range exts {
+		if i := indefiniteLength(ext.Value); i != -1 {
+			t.Fatalf("found indefinite length at offset %d of %x", i, ext.Value)
+		}
+		// and it's DER that encoding/asn1 accepts
+		var generalNames []asn1.RawValue
+		if rest, err := asn1.Unmarshal(ext.Value, &generalNames); err != nil || len(rest) != 0 {
+			t.Fatalf("asn1.Unmarshal() = %x, %v", rest, err)
+		}
+	}
+}
+
+func TestUnmarshalSANSZeroValueExtensions(t *testing.T) {
+	ext, err := MarshalSANS("foo!example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Extensions with no Id are skipped, wherever they are
+	for name, exts := range map[string][]pkix.Extension{
+		"before":  {{}, *ext},
+		"after":   {*ext, {}},
+		"between": {{}, *ext, {Critical: true}},
+	} {
+		for unmarshalName, unmarshal := range map[string]func([]pkix.Extension) (string, error){
+			"UnmarshalSANS":        UnmarshalSANS,
+			"UnmarshalSANSLenient": UnmarshalSANSLenient,
+		} {
+			if got, err := unmarshal(exts); err != nil || got != "foo!example.com" {
+				t.Errorf("%s() with zero value extension %s = %q, %v", unmarshalName, name, got, err)
+			}
+		}
+	}
+	if _, err := UnmarshalSANS([]pkix.Extension{{}}); !errors.Is(err, ErrNoOtherName) {
+		t.Fatalf("expected ErrNoOtherName for only a zero value extension, got %v", err)
+	}
+}
+
 func TestUnmarshalSANsFailures(t *testing.T) {
 	var err error
 
@@ -77,15 +681,40 @@ func TestUnmarshalSANsFailures(t *testing.T) {
 		t.Fatalf("expected error finding no OtherName, got %v", err)
 	}
 
-	// failure: bad sequence
+	// failure: empty extension
 	ext = &pkix.Extension{
 		Id:       asn1.ObjectIdentifier{2, 5, 29, 17},
 		Critical: true,
 		Value:    []byte{},
 	}
 	_, err = UnmarshalSANS([]pkix.Extension{*ext})
-	if err == nil || !strings.Contains(err.Error(), "sequence truncated") {
-		t.Fatalf("expected error with invalid ASN.1, got %v", err)
+	if !errors.Is(err, ErrMalformedSAN) || !strings.Contains(err.Error(), "empty SAN extension") {
+		t.Fatalf("expected error with empty extension, got %v", err)
+	}
+
+	// failure: nil extension value
+	ext = &pkix.Extension{
+		Id:       asn1.ObjectIdentifier{2, 5, 29, 17},
+		Critical: true,
+	}
+	for name, unmarshal := range map[string]func([]pkix.Extension) (string, error){
+		"UnmarshalSANS":        UnmarshalSANS,
+		"UnmarshalSANSLenient": UnmarshalSANSLenient,
+	} {
+		if _, err = unmarshal([]pkix.Extension{*ext}); !errors.Is(err, ErrMalformedSAN) || !strings.Contains(err.Error(), "empty SAN extension") {
+			t.Fatalf("expected %s error with nil extension value, got %v", name, err)
+		}
+	}
+
+	// failure: bad sequence
+	ext = &pkix.Extension{
+		Id:       asn1.ObjectIdentifier{2, 5, 29, 17},
+		Critical: true,
+		Value:    []byte{0x30, 0x05},
+	}
+	_, err = UnmarshalSANS([]pkix.Extension{*ext})
+	if !errors.Is(err, ErrMalformedSAN) || err.Error() != "SAN extension truncated: declared 5 bytes, have 0" {
+		t.Fatalf("expected error with truncated sequence, got %v", err)
 	}
 
 	// failure: extra data after valid sequence
@@ -160,6 +789,30 @@ func TestUnmarshalSANsFailures(t *testing.T) {
 		t.Fatalf("expected error with invalid OtherName, got %v", err)
 	}
 
+	// failure: OtherName value has an explicit tag with the wrong length (a0110c0f -> a0300c0f)
+	b, _ = hex.DecodeString("3021a01f060a2b0601040183bf300107a0300c0f666f6f216578616d706c652e636f6d")
+	ext = &pkix.Extension{
+		Id:       asn1.ObjectIdentifier{2, 5, 29, 17},
+		Critical: true,
+		Value:    b,
+	}
+	_, err = UnmarshalSANS([]pkix.Extension{*ext})
+	if !errors.Is(err, ErrInvalidOtherName) {
+		t.Fatalf("expected error with wrong explicit tag length, got %v", err)
+	}
+
+	// failure: OtherName value has a long form length (0c0f -> 0c810f)
+	b, _ = hex.DecodeString("3022a020060a2b0601040183bf300107a0120c810f666f6f216578616d706c652e636f6d")
+	ext = &pkix.Extension{
+		Id:       asn1.ObjectIdentifier{2, 5, 29, 17},
+		Critical: true,
+		Value:    b,
+	}
+	_, err = UnmarshalSANS([]pkix.Extension{*ext})
+	if !errors.Is(err, ErrInvalidOtherName) {
+		t.Fatalf("expected error with non-DER OtherName, got %v", err)
+	}
+
 	// failure: OtherName has wrong OID (2b0601040183bf300107 -> 2b0601040183bf300108)
 	b, _ = hex.DecodeString("3021a01f060a2b0601040183bf300108a0110c0f666f6f216578616d706c652e636f6d")
 	ext = &pkix.Extension{
@@ -180,7 +833,1310 @@ func TestUnmarshalSANsFailures(t *testing.T) {
 		Value:    b,
 	}
 	_, err = UnmarshalSANS([]pkix.Extension{*ext})
-	if err == nil || !strings.Contains(err.Error(), "expected only one OtherName") {
+	if err == nil || err.Error() != `expected only one OtherName, found 2: ["foo!example.com" "foo!example.com"]` {
 		t.Fatalf("expected error with multiple OtherName fields, got %v", err)
 	}
+
+	// failure: the SAN extension appears twice, even if only one has an
+	// OtherName
+	b, _ = hex.DecodeString("3021a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d")
+	ext = &pkix.Extension{
+		Id:       asn1.ObjectIdentifier{2, 5, 29, 17},
+		Critical: true,
+		Value:    b,
+	}
+	email := pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: []byte{0x30, 0x05, 0x81, 0x03, 'a', '@', 'b'}}
+	_, err = UnmarshalSANS([]pkix.Extension{*ext, email})
+	if !errors.Is(err, ErrMultipleSANExtensions) || err.Error() != "multiple SAN extensions present: found 2" {
+		t.Fatalf("expected error with multiple SAN extensions, got %v", err)
+	}
+}
+
+func TestUnmarshalSANSTruncated(t *testing.T) {
+	const otherName = "3021a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d"
+	tests := map[string]struct {
+		hex       string
+		unmarshal func([]pkix.Extension) (string, error)
+		want      string
+	}{
+		"short length":            {otherName[:len(otherName)-6], UnmarshalSANS, "SAN extension truncated: declared 33 bytes, have 30"},
+		"long length":             {"30820100" + otherName[4:], UnmarshalSANS, "SAN extension truncated: declared 256 bytes, have 33"},
+		"lenient second sequence": {otherName + "3010" + otherName[4:10], UnmarshalSANSLenient, "SAN extension truncated: declared 16 bytes, have 3"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := hex.DecodeString(test.hex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = test.unmarshal([]pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: b}})
+			if !errors.Is(err, ErrMalformedSAN) || err.Error() != test.want {
+				t.Fatalf("expected ErrMalformedSAN %q, got %v", test.want, err)
+			}
+		})
+	}
+
+	// The whole extension is unaffected
+	b, err := hex.DecodeString(otherName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := UnmarshalSANS([]pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: b}}); err != nil || got != "foo!example.com" {
+		t.Fatalf("UnmarshalSANS() = %q, %v", got, err)
+	}
+}
+
+func TestUnmarshalSANSSentinelErrors(t *testing.T) {
+	tests := map[string]struct {
+		Value string
+		Want  error
+	}{
+		"no OtherName":       {"3021a11f060a2b0601040183bf300108a0110c0f666f6f216578616d706c652e636f6d", ErrNoOtherName},
+		"multiple":           {"3042a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6da01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d", ErrMultipleOtherNames},
+		"wrong OID":          {"3021a01f060a2b0601040183bf300108a0110c0f666f6f216578616d706c652e636f6d", ErrUnexpectedOID},
+		"invalid OtherName":  {"3021a01f060a2b0601040183bf300108a1110c0f666f6f216578616d706c652e636f6d", ErrInvalidOtherName},
+		"non-DER OtherName":  {"3021a01f060a2b0601040183bf300107a0300c0f666f6f216578616d706c652e636f6d", ErrInvalidOtherName},
+		"truncated sequence": {"", ErrMalformedSAN},
+		"trailing data":      {"3021a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d30", ErrMalformedSAN},
+		"not a sequence":     {"0221a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d", ErrMalformedSAN},
+	}
+	sentinels := []error{ErrNoOtherName, ErrMultipleOtherNames, ErrUnexpectedOID, ErrInvalidOtherName, ErrMalformedSAN}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := hex.DecodeString(test.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = UnmarshalSANS([]pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: b}})
+			for _, sentinel := range sentinels {
+				if got := errors.Is(err, sentinel); got != (sentinel == test.Want) {
+					t.Errorf("errors.Is(%v, %v) = %v", err, sentinel, got)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalOtherNameParts(t *testing.T) {
+	tests := map[string]struct {
+		otherName          string
+		username, hostname string
+		wantErr            string
+	}{
+		"well-formed":       {otherName: "alice!example.com", username: "alice", hostname: "example.com"},
+		"missing separator": {otherName: "alice", wantErr: "must have the form"},
+		"multiple !":        {otherName: "alice!bob!example.com", wantErr: "must have the form"},
+		"empty username":    {otherName: "!example.com", wantErr: "empty username"},
+		"empty hostname":    {otherName: "alice!", wantErr: "invalid hostname"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ext, err := MarshalSANS(test.otherName, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			username, hostname, err := UnmarshalOtherNameParts([]pkix.Extension{*ext})
+			if test.wantErr != "" {
+				if !errors.Is(err, ErrInvalidOtherName) || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected ErrInvalidOtherName containing %q, got %q, %q, %v", test.wantErr, username, hostname, err)
+				}
+				return
+			}
+			if err != nil || username != test.username || hostname != test.hostname {
+				t.Fatalf("UnmarshalOtherNameParts() = %q, %q, %v", username, hostname, err)
+			}
+		})
+	}
+
+	if _, _, err := UnmarshalOtherNameParts(nil); !errors.Is(err, ErrNoOtherName) {
+		t.Fatalf("expected ErrNoOtherName, got %v", err)
+	}
+}
+
+func TestUnmarshalSANSStrict(t *testing.T) {
+	otherName := "foo!example.com"
+	critical, err := MarshalSANS(otherName, true)
+	if err != nil {
+		t.Fatalf("unexpected error for MarshalSANS: %v", err)
+	}
+	nonCritical, err := MarshalSANS(otherName, false)
+	if err != nil {
+		t.Fatalf("unexpected error for MarshalSANS: %v", err)
+	}
+	email := &pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Value: []byte{0x30, 0x05, 0x81, 0x03, 'a', '@', 'b'}}
+	var names []asn1.RawValue
+	if _, err := asn1.Unmarshal(nonCritical.Value, &names); err != nil {
+		t.Fatalf("unexpected error decoding SANs: %v", err)
+	}
+	packed, err := MarshalGeneralNames(append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: TagRFC822Name, Bytes: []byte("a@b")}), false)
+	if err != nil {
+		t.Fatalf("unexpected error for MarshalGeneralNames: %v", err)
+	}
+
+	tests := map[string]struct {
+		Exts            []pkix.Extension
+		RequireCritical bool
+		WantErr         error
+	}{
+		"critical SAN is accepted":                       {[]pkix.Extension{*critical}, true, nil},
+		"non-critical SAN is rejected":                   {[]pkix.Extension{*nonCritical}, true, ErrSANNotCritical},
+		"non-critical split SAN is rejected":             {[]pkix.Extension{*critical, *email}, true, ErrSANNotCritical},
+		"non-critical SAN is accepted when not required": {[]pkix.Extension{*nonCritical}, false, nil},
+		"missing OtherName is still an error":            {[]pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: email.Value}}, true, ErrNoOtherName},
+		"OtherName packed with other SANs is accepted":   {[]pkix.Extension{*packed}, false, nil},
+		"multiple SAN extensions are an error":           {[]pkix.Extension{*nonCritical, *email}, false, ErrMultipleSANExtensions},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			on, err := UnmarshalSANSStrict(test.Exts, test.RequireCritical)
+			if test.WantErr != nil {
+				if !errors.Is(err, test.WantErr) {
+					t.Fatalf("expected %v, got %v", test.WantErr, err)
+				}
+				return
+			}
+			if err != nil || on != otherName {
+				t.Fatalf("UnmarshalSANSStrict() = %q, %v", on, err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalSANSIndefiniteLength(t *testing.T) {
+	// BER encodings of the foo!example.com OtherName, with an indefinite
+	// length, 0x80, and end-of-contents octets in place of one definite
+	// length
+	const (
+		oid   = "060a2b0601040183bf300107"
+		value = "a0110c0f666f6f216578616d706c652e636f6d"
+	)
+	tests := map[string]struct {
+		hex    string
+		offset int
+	}{
+		"SAN sequence":   {"3080" + "a01f" + oid + value + "0000", 1},
+		"OtherName":      {"3023" + "a080" + oid + value + "0000", 3},
+		"explicit value": {"3023" + "a021" + oid + "a080" + value[4:] + "0000" + "0000", 17},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := hex.DecodeString(test.hex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exts := []pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: b}}
+			for unmarshalName, unmarshal := range map[string]func([]pkix.Extension) (string, error){
+				"UnmarshalSANS":        UnmarshalSANS,
+				"UnmarshalSANSLenient": UnmarshalSANSLenient,
+			} {
+				got, err := unmarshal(exts)
+				if !errors.Is(err, ErrNotDER) || !errors.Is(err, ErrMalformedSAN) {
+					t.Fatalf("expected %s() to fail with ErrNotDER and ErrMalformedSAN, got %q, %v", unmarshalName, got, err)
+				}
+				if want := fmt.Sprintf("indefinite length at offset %d", test.offset); !strings.Contains(err.Error(), want) {
+					t.Fatalf("expected %s() error to contain %q, got %v", unmarshalName, want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalSANSLenient(t *testing.T) {
+	// The valid sequence from the extra data failure case, and sequences
+	// with an email address and a second OtherName
+	const (
+		otherName       = "3021a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d"
+		email           = "30058103614062"
+		secondOtherName = "3021a01f060a2b0601040183bf300107a0110c0f626172216578616d706c652e636f6d"
+	)
+	tests := map[string]struct {
+		hex     string
+		want    string
+		wantErr error
+	}{
+		"single sequence":                     {otherName, "foo!example.com", nil},
+		"concatenated sequences":              {otherName + email, "foo!example.com", nil},
+		"OtherName in a later sequence":       {email + otherName, "foo!example.com", nil},
+		"OtherNames in several sequences":     {otherName + secondOtherName, "", ErrMultipleOtherNames},
+		"no OtherName in any sequence":        {email + email, "", ErrNoOtherName},
+		"truncated trailing sequence":         {otherName + "30", "", ErrMalformedSAN},
+		"trailing data that isn't a sequence": {otherName + "0400", "", ErrMalformedSAN},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := hex.DecodeString(test.hex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exts := []pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: b}}
+			got, err := UnmarshalSANSLenient(exts)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("expected %v, got %q, %v", test.wantErr, got, err)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Fatalf("UnmarshalSANSLenient() = %q, %v", got, err)
+			}
+
+			// The strict default still rejects concatenated sequences
+			if test.hex != otherName {
+				if _, err := UnmarshalSANS(exts); !errors.Is(err, ErrMalformedSAN) {
+					t.Fatalf("expected UnmarshalSANS to fail with ErrMalformedSAN, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalSANSDoubleExplicit(t *testing.T) {
+	// The foo!example.com OtherName with its value wrapped in a second
+	// explicit [0] tag, as some tools produce in CSRs, and in a third
+	const (
+		oid   = "060a2b0601040183bf300107"
+		value = "a0110c0f666f6f216578616d706c652e636f6d"
+	)
+	decode := func(s string) []pkix.Extension {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: b}}
+	}
+	double := decode("3023" + "a021" + oid + "a013" + value)
+	triple := decode("3025" + "a023" + oid + "a015" + "a013" + value)
+
+	if got, err := UnmarshalSANSLenient(double); err != nil || got != "foo!example.com" {
+		t.Fatalf("UnmarshalSANSLenient() = %q, %v", got, err)
+	}
+	if got, err := UnmarshalSANS(double); !errors.Is(err, ErrInvalidOtherName) {
+		t.Fatalf("expected UnmarshalSANS() to fail with ErrInvalidOtherName, got %q, %v", got, err)
+	}
+	// Only one extra layer is unwrapped
+	if got, err := UnmarshalSANSLenient(triple); !errors.Is(err, ErrInvalidOtherName) {
+		t.Fatalf("expected UnmarshalSANSLenient() to fail with ErrInvalidOtherName, got %q, %v", got, err)
+	}
+}
+
+func TestUnmarshalSANSStringTypes(t *testing.T) {
+	// OtherNames with the value "foo!example.com", or "foo.example.com" for
+	// PrintableString, as other tools encode them
+	tests := map[string]struct {
+		hex     string
+		want    string
+		wantErr bool
+	}{
+		"UTF8String": {
+			hex:  "3021a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d",
+			want: "foo!example.com",
+		},
+		"IA5String": {
+			hex:  "3021a01f060a2b0601040183bf300107a011160f666f6f216578616d706c652e636f6d",
+			want: "foo!example.com",
+		},
+		"PrintableString": {
+			hex:  "3021a01f060a2b0601040183bf300107a011130f666f6f2e6578616d706c652e636f6d",
+			want: "foo.example.com",
+		},
+		"UTF8String with invalid UTF-8": {
+			hex:     "3021a01f060a2b0601040183bf300107a0110c0f666f6fff6578616d706c652e636f6d",
+			wantErr: true,
+		},
+		"IA5String with non-ASCII character": {
+			hex:     "3021a01f060a2b0601040183bf300107a011160f666f6f806578616d706c652e636f6d",
+			wantErr: true,
+		},
+		"PrintableString with invalid character": {
+			hex:     "3021a01f060a2b0601040183bf300107a011130f666f6f216578616d706c652e636f6d",
+			wantErr: true,
+		},
+		"BMPString": {
+			hex:  "3030a02e060a2b0601040183bf300107a0201e1e0066006f006f0021006500780061006d0070006c0065002e0063006f006d",
+			want: "foo!example.com",
+		},
+		"BMPString with odd length": {
+			hex:     "3021a01f060a2b0601040183bf300107a0111e0f666f6f216578616d706c652e636f6d",
+			wantErr: true,
+		},
+		"BMPString with surrogate": {
+			hex:     "3016a014060a2b0601040183bf300107a0061e040061d83d",
+			wantErr: true,
+		},
+		"T61String": {
+			hex:     "3021a01f060a2b0601040183bf300107a011140f666f6f216578616d706c652e636f6d",
+			wantErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := hex.DecodeString(test.hex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ext := pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: b}
+			got, err := UnmarshalSANS([]pkix.Extension{ext})
+			if test.wantErr {
+				if !errors.Is(err, ErrInvalidOtherName) {
+					t.Fatalf("expected ErrInvalidOtherName, got %q, %v", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnmarshalSANS() = %v", err)
+			}
+			if got != test.want {
+				t.Fatalf("UnmarshalSANS() = %q, want %q", got, test.want)
+			}
+
+			// Marshaling always uses a UTF8String
+			marshaled, err := MarshalSANS(got, true)
+			if err != nil {
+				t.Fatalf("MarshalSANS() = %v", err)
+			}
+			if !bytes.Contains(marshaled.Value, append([]byte{asn1.TagUTF8String, byte(len(got))}, got...)) {
+				t.Fatalf("expected UTF8String value, got %x", marshaled.Value)
+			}
+		})
+	}
+}
+
+func TestAppendOtherName(t *testing.T) {
+	// A SAN extension from crypto/x509 with DNS and email entries
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(1),
+		DNSNames:       []string{"example.com"},
+		EmailAddresses: []string{"foo@example.com"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var existing *pkix.Extension
+	for i, ext := range parsed.Extensions {
+		if ext.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 17}) {
+			existing = &parsed.Extensions[i]
+		}
+	}
+	if existing == nil {
+		t.Fatal("expected a SAN extension")
+	}
+	original := *existing
+
+	ext, err := AppendOtherName(existing, "foo!example.com")
+	if err != nil {
+		t.Fatalf("AppendOtherName() = %v", err)
+	}
+	if ext.Critical != existing.Critical {
+		t.Fatalf("expected criticality %v, got %v", existing.Critical, ext.Critical)
+	}
+	if !reflect.DeepEqual(*existing, original) {
+		t.Fatal("existing extension was modified")
+	}
+
+	// The existing entries survive, and the OtherName can be read back
+	template = &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		ExtraExtensions: []pkix.Extension{*ext},
+	}
+	der, err = x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.DNSNames, []string{"example.com"}) {
+		t.Fatalf("expected DNS SANs to be preserved, got %v", parsed.DNSNames)
+	}
+	if !reflect.DeepEqual(parsed.EmailAddresses, []string{"foo@example.com"}) {
+		t.Fatalf("expected email SANs to be preserved, got %v", parsed.EmailAddresses)
+	}
+	name, err := OtherNameFromCertificate(parsed)
+	if err != nil || name != "foo!example.com" {
+		t.Fatalf("OtherNameFromCertificate() = %q, %v", name, err)
+	}
+
+	// Without an existing extension, it's the same as MarshalSANS
+	ext, err = AppendOtherName(nil, "foo!example.com")
+	if err != nil {
+		t.Fatalf("AppendOtherName() = %v", err)
+	}
+	want, err := MarshalSANS("foo!example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ext, want) {
+		t.Fatalf("expected %v, got %v", want, ext)
+	}
+
+	// Failures
+	if _, err := AppendOtherName(&pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 19}, Value: original.Value}, "foo!example.com"); err == nil {
+		t.Fatal("expected error appending to another extension")
+	}
+	if _, err := AppendOtherName(&pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Value: []byte{0x30, 0x05}}, "foo!example.com"); !errors.Is(err, ErrMalformedSAN) {
+		t.Fatalf("expected ErrMalformedSAN, got %v", err)
+	}
+	if _, err := AppendOtherName(existing, "foo", WithStrictValidation()); err == nil {
+		t.Fatal("expected error appending an invalid OtherName with strict validation")
+	}
+}
+
+func TestGeneralNameTag(t *testing.T) {
+	tests := []struct {
+		b           byte
+		tag         int
+		constructed bool
+		wantErr     bool
+	}{
+		{b: 0xa0, tag: TagOtherName, constructed: true},
+		{b: 0x81, tag: TagRFC822Name},
+		{b: 0x82, tag: TagDNSName},
+		{b: 0x86, tag: TagURI},
+		{b: 0x87, tag: TagIPAddress},
+		{b: 0xa4, tag: 4, constructed: true},
+		{b: 0x00, wantErr: true}, // universal
+		{b: 0x30, wantErr: true}, // universal SEQUENCE
+		{b: 0x60, wantErr: true}, // application
+		{b: 0xe0, wantErr: true}, // private
+		{b: 0xbf, wantErr: true}, // high tag number
+	}
+	for _, test := range tests {
+		tag, constructed, err := generalNameTag(test.b)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("generalNameTag(%#x) = %d, %v, expected error", test.b, tag, constructed)
+			}
+			continue
+		}
+		if err != nil || tag != test.tag || constructed != test.constructed {
+			t.Errorf("generalNameTag(%#x) = %d, %v, %v, want %d, %v", test.b, tag, constructed, err, test.tag, test.constructed)
+			continue
+		}
+		if b := generalNameIdentifier(tag, constructed); b != test.b {
+			t.Errorf("generalNameIdentifier(%d, %v) = %#x, want %#x", tag, constructed, b, test.b)
+		}
+	}
+}
+
+func TestMarshalIPSANS(t *testing.T) {
+	v4 := net.ParseIP("192.0.2.1")
+	v6 := net.ParseIP("2001:db8::1")
+
+	ext, err := MarshalIPSANS([]net.IP{v4, v6}, false)
+	if err != nil {
+		t.Fatalf("MarshalIPSANS() = %v", err)
+	}
+	if ext.Critical {
+		t.Fatal("expected non-critical extension")
+	}
+	// IPv4 is encoded in 4 bytes, even from a 16 byte net.IP, and IPv6 in 16
+	if got, want := hex.EncodeToString(ext.Value), "30188704c00002018710"+"20010db8000000000000000000000001"; got != want {
+		t.Fatalf("MarshalIPSANS() = %s, want %s", got, want)
+	}
+	ips, err := IPAddressesFromSANS([]pkix.Extension{*ext})
+	if err != nil {
+		t.Fatalf("IPAddressesFromSANS() = %v", err)
+	}
+	if len(ips) != 2 || !ips[0].Equal(v4) || !ips[1].Equal(v6) {
+		t.Fatalf("IPAddressesFromSANS() = %v", ips)
+	}
+
+	// OtherNames and IP addresses in one extension
+	ext, err = MarshalSANSWithIPs([]string{"foo!example.com"}, []net.IP{v6, v4}, true)
+	if err != nil {
+		t.Fatalf("MarshalSANSWithIPs() = %v", err)
+	}
+	name, err := UnmarshalSANS([]pkix.Extension{*ext})
+	if err != nil || name != "foo!example.com" {
+		t.Fatalf("UnmarshalSANS() = %q, %v", name, err)
+	}
+	ips, err = IPAddressesFromSANS([]pkix.Extension{*ext})
+	if err != nil {
+		t.Fatalf("IPAddressesFromSANS() = %v", err)
+	}
+	if len(ips) != 2 || !ips[0].Equal(v6) || !ips[1].Equal(v4) {
+		t.Fatalf("IPAddressesFromSANS() = %v", ips)
+	}
+	// and crypto/x509 agrees
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), ExtraExtensions: []pkix.Extension{*ext}}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cert.IPAddresses) != 2 || !cert.IPAddresses[0].Equal(v6) || !cert.IPAddresses[1].Equal(v4) {
+		t.Fatalf("certificate has IP addresses %v", cert.IPAddresses)
+	}
+
+	// Without IP addresses
+	ext, err = MarshalSANS("foo!example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ips, err = IPAddressesFromSANS([]pkix.Extension{*ext})
+	if err != nil || ips != nil {
+		t.Fatalf("IPAddressesFromSANS() = %v, %v", ips, err)
+	}
+
+	// Failures
+	if _, err := MarshalIPSANS(nil, true); err == nil {
+		t.Fatal("expected error without IP addresses")
+	}
+	if _, err := MarshalSANSWithIPs(nil, nil, true); err == nil {
+		t.Fatal("expected error without names or IP addresses")
+	}
+	if _, err := MarshalIPSANS([]net.IP{{1, 2, 3}}, true); err == nil {
+		t.Fatal("expected error with invalid IP address")
+	}
+	if _, err := MarshalSANSWithIPs([]string{"foo"}, []net.IP{v4}, true, WithStrictValidation()); err == nil {
+		t.Fatal("expected error with invalid OtherName")
+	}
+	bad, err := MarshalGeneralNames([]asn1.RawValue{{Class: asn1.ClassContextSpecific, Tag: TagIPAddress, Bytes: []byte{1, 2, 3}}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := IPAddressesFromSANS([]pkix.Extension{*bad}); err == nil {
+		t.Fatal("expected error with 3 byte IP address")
+	}
+	if _, err := IPAddressesFromSANS([]pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Value: []byte{0x30, 0x05}}}); !errors.Is(err, ErrMalformedSAN) {
+		t.Fatalf("expected ErrMalformedSAN, got %v", err)
+	}
+}
+
+func TestMarshalSANSWithDNSNames(t *testing.T) {
+	ext, err := MarshalSANSWithDNSNames([]string{"foo!example.com"}, []string{"foo.example.com", "Bar.Example.COM"}, false)
+	if err != nil {
+		t.Fatalf("MarshalSANSWithDNSNames() = %v", err)
+	}
+	if ext.Critical {
+		t.Fatal("expected non-critical extension")
+	}
+	name, err := UnmarshalSANS([]pkix.Extension{*ext})
+	if err != nil || name != "foo!example.com" {
+		t.Fatalf("UnmarshalSANS() = %q, %v", name, err)
+	}
+	dnsNames, err := DNSNamesFromSANS([]pkix.Extension{*ext})
+	if err != nil {
+		t.Fatalf("DNSNamesFromSANS() = %v", err)
+	}
+	if len(dnsNames) != 2 || dnsNames[0] != "foo.example.com" || dnsNames[1] != "Bar.Example.COM" {
+		t.Fatalf("DNSNamesFromSANS() = %v", dnsNames)
+	}
+	// and crypto/x509 agrees
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), ExtraExtensions: []pkix.Extension{*ext}}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cert.DNSNames) != 2 || cert.DNSNames[0] != "foo.example.com" || cert.DNSNames[1] != "Bar.Example.COM" {
+		t.Fatalf("certificate has DNS names %v", cert.DNSNames)
+	}
+
+	// DNS names alone
+	ext, err = MarshalSANSWithDNSNames(nil, []string{"foo.example.com"}, true)
+	if err != nil {
+		t.Fatalf("MarshalSANSWithDNSNames() = %v", err)
+	}
+	if _, err := UnmarshalSANS([]pkix.Extension{*ext}); !errors.Is(err, ErrNoOtherName) {
+		t.Fatalf("expected ErrNoOtherName, got %v", err)
+	}
+
+	// Without DNS names
+	ext, err = MarshalSANS("foo!example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dnsNames, err = DNSNamesFromSANS([]pkix.Extension{*ext})
+	if err != nil || dnsNames != nil {
+		t.Fatalf("DNSNamesFromSANS() = %v, %v", dnsNames, err)
+	}
+
+	// Failures
+	if _, err := MarshalSANSWithDNSNames(nil, nil, true); err == nil {
+		t.Fatal("expected error without names or DNS names")
+	}
+	for _, dns := range []string{"", "foo bar.example.com", "foo!example.com", "-foo.example.com", "foo..example.com", "jos\u00e9.example.com", strings.Repeat("a", 64) + ".example.com"} {
+		if _, err := MarshalSANSWithDNSNames([]string{"foo!example.com"}, []string{dns}, true); err == nil {
+			t.Errorf("expected error with invalid DNS name %q", dns)
+		}
+	}
+	if _, err := MarshalSANSWithDNSNames([]string{"foo"}, []string{"foo.example.com"}, true, WithStrictValidation()); err == nil {
+		t.Fatal("expected error with invalid OtherName")
+	}
+	bad, err := MarshalGeneralNames([]asn1.RawValue{{Class: asn1.ClassContextSpecific, Tag: TagDNSName, Bytes: []byte("jos\xc3\xa9.example.com")}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DNSNamesFromSANS([]pkix.Extension{*bad}); err == nil {
+		t.Fatal("expected error with non-ASCII DNS name")
+	}
+	if _, err := DNSNamesFromSANS([]pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Value: []byte{0x30, 0x05}}}); !errors.Is(err, ErrMalformedSAN) {
+		t.Fatalf("expected ErrMalformedSAN, got %v", err)
+	}
+}
+
+func TestPackSANS(t *testing.T) {
+	otherName := "foo!example.com"
+	uri, _ := url.Parse("https://example.com/users/foo")
+	email := "foo@example.com"
+
+	template := func(t *testing.T) *x509.Certificate {
+		ext, err := MarshalSANS(otherName, true)
+		if err != nil {
+			t.Fatalf("unexpected error for MarshalSANs: %v", err)
+		}
+		return &x509.Certificate{
+			SerialNumber:    big.NewInt(1),
+			NotBefore:       time.Now(),
+			NotAfter:        time.Now().Add(10 * time.Minute),
+			URIs:            []*url.URL{uri},
+			EmailAddresses:  []string{email},
+			ExtraExtensions: []pkix.Extension{*ext},
+		}
+	}
+	sans := func(exts []pkix.Extension) []pkix.Extension {
+		var sans []pkix.Extension
+		for _, e := range exts {
+			if e.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 17}) {
+				sans = append(sans, e)
+			}
+		}
+		return sans
+	}
+
+	t.Run("single", func(t *testing.T) {
+		cert := template(t)
+		if err := PackSANS(cert, false); err != nil {
+			t.Fatalf("unexpected error for PackSANS: %v", err)
+		}
+		if len(cert.URIs) != 0 || len(cert.EmailAddresses) != 0 {
+			t.Fatalf("expected SANs to be moved into the extension")
+		}
+		exts := sans(cert.ExtraExtensions)
+		if len(exts) != 1 || !exts[0].Critical {
+			t.Fatalf("expected one critical SAN extension, got %v", exts)
+		}
+
+		// Issue the certificate and check all SANs survive parsing
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.CreateCertificate(rand.Reader, cert, cert, key.Public(), key)
+		if err != nil {
+			t.Fatalf("unexpected error creating certificate: %v", err)
+		}
+		parsed, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("unexpected error parsing certificate: %v", err)
+		}
+		if len(parsed.URIs) != 1 || parsed.URIs[0].String() != uri.String() {
+			t.Fatalf("unexpected URIs, expected %v, got %v", uri, parsed.URIs)
+		}
+		if len(parsed.EmailAddresses) != 1 || parsed.EmailAddresses[0] != email {
+			t.Fatalf("unexpected emails, expected %v, got %v", email, parsed.EmailAddresses)
+		}
+		on, err := UnmarshalSANS(parsed.Extensions)
+		if err != nil {
+			t.Fatalf("unexpected error for UnmarshalSANs: %v", err)
+		}
+		if on != otherName {
+			t.Fatalf("unexpected OtherName, expected %s, got %s", otherName, on)
+		}
+	})
+
+	t.Run("split", func(t *testing.T) {
+		cert := template(t)
+		if err := PackSANS(cert, true); err != nil {
+			t.Fatalf("unexpected error for PackSANS: %v", err)
+		}
+		if len(cert.URIs) != 0 || len(cert.EmailAddresses) != 0 {
+			t.Fatalf("expected SANs to be moved into an extension")
+		}
+		exts := sans(cert.ExtraExtensions)
+		if len(exts) != 2 || !exts[0].Critical || !exts[1].Critical {
+			t.Fatalf("expected two critical SAN extensions, got %v", exts)
+		}
+
+		// crypto/x509 rejects certificates with repeated extensions, so
+		// decode the extensions directly. Only the lenient decoding accepts
+		// them.
+		if _, err := UnmarshalSANS(cert.ExtraExtensions); !errors.Is(err, ErrMultipleSANExtensions) {
+			t.Fatalf("expected ErrMultipleSANExtensions, got %v", err)
+		}
+		on, err := UnmarshalSANSLenient(cert.ExtraExtensions)
+		if err != nil {
+			t.Fatalf("unexpected error for UnmarshalSANSLenient: %v", err)
+		}
+		if on != otherName {
+			t.Fatalf("unexpected OtherName, expected %s, got %s", otherName, on)
+		}
+		var names []asn1.RawValue
+		if _, err := asn1.Unmarshal(exts[1].Value, &names); err != nil {
+			t.Fatalf("unexpected error decoding SANs: %v", err)
+		}
+		if len(names) != 2 ||
+			names[0].Tag != TagRFC822Name || string(names[0].Bytes) != email ||
+			names[1].Tag != TagURI || string(names[1].Bytes) != uri.String() {
+			t.Fatalf("unexpected SANs in second extension: %v", names)
+		}
+	})
+
+	t.Run("no OtherName", func(t *testing.T) {
+		cert := &x509.Certificate{URIs: []*url.URL{uri}}
+		if err := PackSANS(cert, false); err != nil {
+			t.Fatalf("unexpected error for PackSANS: %v", err)
+		}
+		if len(cert.URIs) != 1 || len(cert.ExtraExtensions) != 0 {
+			t.Fatalf("expected certificate without SAN extension to be unchanged")
+		}
+	})
+}
+
+func TestSetSANCriticality(t *testing.T) {
+	email := "foo@example.com"
+	sanCriticality := func(exts []pkix.Extension) []bool {
+		var critical []bool
+		for _, e := range exts {
+			if e.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 17}) {
+				critical = append(critical, e.Critical)
+			}
+		}
+		return critical
+	}
+
+	t.Run("existing extension", func(t *testing.T) {
+		ext, err := MarshalSANS("foo!example.com", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert := &x509.Certificate{ExtraExtensions: []pkix.Extension{*ext}}
+		if err := SetSANCriticality(cert, false); err != nil {
+			t.Fatalf("unexpected error for SetSANCriticality: %v", err)
+		}
+		if got := sanCriticality(cert.ExtraExtensions); len(got) != 1 || got[0] {
+			t.Fatalf("expected one non-critical SAN extension, got %v", got)
+		}
+		if err := SetSANCriticality(cert, true); err != nil {
+			t.Fatalf("unexpected error for SetSANCriticality: %v", err)
+		}
+		if got := sanCriticality(cert.ExtraExtensions); len(got) != 1 || !got[0] {
+			t.Fatalf("expected one critical SAN extension, got %v", got)
+		}
+	})
+
+	t.Run("template SANs", func(t *testing.T) {
+		// Critical SANs are left for crypto/x509 to generate
+		cert := &x509.Certificate{EmailAddresses: []string{email}}
+		if err := SetSANCriticality(cert, true); err != nil {
+			t.Fatalf("unexpected error for SetSANCriticality: %v", err)
+		}
+		if len(cert.ExtraExtensions) != 0 || len(cert.EmailAddresses) != 1 {
+			t.Fatalf("expected template SANs to be kept, got %v", cert.ExtraExtensions)
+		}
+
+		if err := SetSANCriticality(cert, false); err != nil {
+			t.Fatalf("unexpected error for SetSANCriticality: %v", err)
+		}
+		if len(cert.EmailAddresses) != 0 {
+			t.Fatal("expected SANs to be moved into an extension")
+		}
+		if got := sanCriticality(cert.ExtraExtensions); len(got) != 1 || got[0] {
+			t.Fatalf("expected one non-critical SAN extension, got %v", got)
+		}
+		var names []asn1.RawValue
+		if _, err := asn1.Unmarshal(cert.ExtraExtensions[0].Value, &names); err != nil {
+			t.Fatal(err)
+		}
+		if len(names) != 1 || names[0].Tag != TagRFC822Name || string(names[0].Bytes) != email {
+			t.Fatalf("expected email SAN, got %v", names)
+		}
+	})
+
+	t.Run("no SANs", func(t *testing.T) {
+		cert := &x509.Certificate{}
+		if err := SetSANCriticality(cert, false); err != nil {
+			t.Fatalf("unexpected error for SetSANCriticality: %v", err)
+		}
+		if len(cert.ExtraExtensions) != 0 {
+			t.Fatalf("expected no extensions, got %v", cert.ExtraExtensions)
+		}
+	})
+}
+
+func TestOtherNameFromCertificate(t *testing.T) {
+	otherName := "foo!example.com"
+	uri, _ := url.Parse("https://example.com/users/foo")
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issue := func(t *testing.T, otherNames []string) *x509.Certificate {
+		t.Helper()
+		template := &x509.Certificate{
+			SerialNumber:   big.NewInt(1),
+			NotBefore:      time.Now(),
+			NotAfter:       time.Now().Add(10 * time.Minute),
+			DNSNames:       []string{"example.com"},
+			EmailAddresses: []string{"foo@example.com"},
+			URIs:           []*url.URL{uri},
+		}
+		if len(otherNames) > 0 {
+			ext, err := MarshalSANSMulti(otherNames, false)
+			if err != nil {
+				t.Fatalf("unexpected error for MarshalSANSMulti: %v", err)
+			}
+			template.ExtraExtensions = []pkix.Extension{*ext}
+			if err := PackSANS(template, false); err != nil {
+				t.Fatalf("unexpected error for PackSANS: %v", err)
+			}
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		if err != nil {
+			t.Fatalf("unexpected error creating certificate: %v", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("unexpected error parsing certificate: %v", err)
+		}
+		return cert
+	}
+
+	cert := issue(t, []string{otherName})
+	got, err := OtherNameFromCertificate(cert)
+	if err != nil {
+		t.Fatalf("unexpected error for OtherNameFromCertificate: %v", err)
+	}
+	if got != otherName {
+		t.Fatalf("unexpected OtherName, expected %s, got %s", otherName, got)
+	}
+	if len(cert.DNSNames) != 1 || len(cert.EmailAddresses) != 1 || len(cert.URIs) != 1 {
+		t.Fatalf("expected other SANs to be preserved")
+	}
+
+	// No OtherName among the other SANs
+	if _, err := OtherNameFromCertificate(issue(t, nil)); !errors.Is(err, ErrNoOtherName) {
+		t.Fatalf("expected ErrNoOtherName, got %v", err)
+	}
+
+	// More than one OtherName is an error, but not an absent one
+	_, err = OtherNameFromCertificate(issue(t, []string{otherName, "bar!example.com"}))
+	if err == nil || errors.Is(err, ErrNoOtherName) {
+		t.Fatalf("expected error with multiple OtherNames, got %v", err)
+	}
+
+	// A malformed OtherName is an error, but not an absent one
+	b, _ := hex.DecodeString("3021a01f060a2b0601040183bf300108a1110c0f666f6f216578616d706c652e636f6d")
+	cert = &x509.Certificate{Extensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Value: b}}}
+	_, err = OtherNameFromCertificate(cert)
+	if err == nil || errors.Is(err, ErrNoOtherName) {
+		t.Fatalf("expected error with malformed OtherName, got %v", err)
+	}
+
+	if _, err := OtherNameFromCertificate(nil); err == nil {
+		t.Fatalf("expected error for nil certificate")
+	}
+}
+
+func TestSANExtensionsEqual(t *testing.T) {
+	otherName := func(oid asn1.ObjectIdentifier, value string) asn1.RawValue {
+		b, err := asn1.MarshalWithParams(OtherName{ID: oid, Value: value}, "tag:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return asn1.RawValue{FullBytes: b}
+	}
+	tagged := func(tag int, value string) asn1.RawValue {
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, Bytes: []byte(value)}
+	}
+	san := func(critical bool, names ...asn1.RawValue) pkix.Extension {
+		if names == nil {
+			names = []asn1.RawValue{}
+		}
+		ext, err := MarshalGeneralNames(names, critical)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *ext
+	}
+	user := otherName(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 7}, "foo!example.com")
+	email := tagged(TagRFC822Name, "foo@example.com")
+	uri := tagged(TagURI, "https://example.com")
+
+	tests := map[string]struct {
+		a, b      pkix.Extension
+		wantEqual bool
+		wantErr   bool
+	}{
+		"same order": {
+			a:         san(true, user, email, uri),
+			b:         san(true, user, email, uri),
+			wantEqual: true,
+		},
+		"different order": {
+			a:         san(true, user, email, uri),
+			b:         san(true, uri, user, email),
+			wantEqual: true,
+		},
+		"criticality is ignored": {
+			a:         san(true, user),
+			b:         san(false, user),
+			wantEqual: true,
+		},
+		"DNS names are case-insensitive": {
+			a:         san(true, tagged(TagDNSName, "Example.COM")),
+			b:         san(true, tagged(TagDNSName, "example.com")),
+			wantEqual: true,
+		},
+		"emails are case-sensitive": {
+			a: san(true, tagged(TagRFC822Name, "Foo@example.com")),
+			b: san(true, email),
+		},
+		"extra name": {
+			a: san(true, user, email),
+			b: san(true, user, email, uri),
+		},
+		"different OtherName value": {
+			a: san(true, user),
+			b: san(true, otherName(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 7}, "bar!example.com")),
+		},
+		"different OtherName type": {
+			a: san(true, user),
+			b: san(true, otherName(asn1.ObjectIdentifier{1, 2, 3}, "foo!example.com")),
+		},
+		"same value with different name types": {
+			a: san(true, tagged(TagRFC822Name, "example.com")),
+			b: san(true, tagged(TagDNSName, "example.com")),
+		},
+		"both missing": {
+			wantEqual: true,
+		},
+		"both empty": {
+			a:         san(true),
+			b:         san(true),
+			wantEqual: true,
+		},
+		"missing and empty": {
+			a: pkix.Extension{Id: oidSubjectAltName, Critical: true},
+			b: san(true),
+		},
+		"missing and non-empty": {
+			b: san(true, user),
+		},
+		"not a SAN extension": {
+			a:       pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 19}, Value: san(true, user).Value},
+			b:       san(true, user),
+			wantErr: true,
+		},
+		"malformed": {
+			a:       san(true, user),
+			b:       pkix.Extension{Id: oidSubjectAltName, Value: []byte{0x30, 0x05, 0x00}},
+			wantErr: true,
+		},
+		"trailing data": {
+			a:       pkix.Extension{Id: oidSubjectAltName, Value: append(san(true, user).Value, 0x00)},
+			b:       san(true, user),
+			wantErr: true,
+		},
+		"malformed OtherName": {
+			a:       san(true, asn1.RawValue{FullBytes: []byte{0xa0, 0x02, 0x05, 0x00}}),
+			b:       san(true, user),
+			wantErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			equal, err := SANExtensionsEqual(test.a, test.b)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("SANExtensionsEqual() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if equal != test.wantEqual {
+				t.Fatalf("SANExtensionsEqual() = %v, want %v", equal, test.wantEqual)
+			}
+			// Equality is symmetric
+			if equal, _ := SANExtensionsEqual(test.b, test.a); equal != test.wantEqual {
+				t.Fatalf("SANExtensionsEqual() with arguments swapped = %v, want %v", equal, test.wantEqual)
+			}
+		})
+	}
+}
+
+func TestOtherNameFromCSR(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(t *testing.T, exts ...pkix.Extension) *x509.CertificateRequest {
+		t.Helper()
+		der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{ExtraExtensions: exts}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return csr
+	}
+	san, err := MarshalSANS("foo!example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csr := request(t, *san)
+	name, err := OtherNameFromCSR(csr)
+	if err != nil || name != "foo!example.com" {
+		t.Fatalf("OtherNameFromCSR() = %q, %v", name, err)
+	}
+
+	// Without Extensions, the extensionRequest attribute is parsed
+	csr.Extensions = nil
+	name, err = OtherNameFromCSR(csr)
+	if err != nil || name != "foo!example.com" {
+		t.Fatalf("OtherNameFromCSR() without Extensions = %q, %v", name, err)
+	}
+
+	if _, err := OtherNameFromCSR(request(t)); !errors.Is(err, ErrNoExtensions) {
+		t.Fatalf("expected ErrNoExtensions, got %v", err)
+	}
+	if _, err := OtherNameFromCSR(&x509.CertificateRequest{}); !errors.Is(err, ErrNoExtensions) {
+		t.Fatalf("expected ErrNoExtensions for unparsed request, got %v", err)
+	}
+	other := pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 19}, Critical: true, Value: []byte{0x30, 0x00}}
+	if _, err := OtherNameFromCSR(request(t, other)); !errors.Is(err, ErrNoOtherName) {
+		t.Fatalf("expected ErrNoOtherName, got %v", err)
+	}
+	if _, err := OtherNameFromCSR(nil); err == nil {
+		t.Fatal("expected error for nil request")
+	}
+	if _, err := OtherNameFromCSR(&x509.CertificateRequest{RawTBSCertificateRequest: []byte{0x30, 0x05}}); err == nil {
+		t.Fatal("expected error for malformed request")
+	}
+}
+
+func FuzzUnmarshalSANS(f *testing.F) {
+	for _, seed := range []string{
+		// valid OtherName
+		"3021a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d",
+		// failure cases from TestUnmarshalSANsFailures
+		"",
+		"3021a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d30",
+		"B021a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d",
+		"1021a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d",
+		"0221a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d",
+		"3021a11f060a2b0601040183bf300108a0110c0f666f6f216578616d706c652e636f6d",
+		"3021a01f060a2b0601040183bf300108a1110c0f666f6f216578616d706c652e636f6d",
+		"3021a01f060a2b0601040183bf300108a0110c0f666f6f216578616d706c652e636f6d",
+		"3042a01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6da01f060a2b0601040183bf300107a0110c0f666f6f216578616d706c652e636f6d",
+	} {
+		b, err := hex.DecodeString(seed)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+
+	f.Fuzz(func(t *testing.T, value []byte) {
+		ext := pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: value}
+		name, err := UnmarshalSANS([]pkix.Extension{ext})
+		if err != nil {
+			if name != "" {
+				t.Fatalf("UnmarshalSANS() returned name %q with error %v", name, err)
+			}
+			return
+		}
+
+		// Decoded names may be longer than DefaultMaxOtherNameLength, which
+		// only limits marshaling
+		marshaled, err := MarshalSANS(name, true, WithMaxLength(0))
+		if err != nil {
+			t.Fatalf("MarshalSANS(%q) = %v", name, err)
+		}
+		// Other GeneralNames are skipped when unmarshaling, and values of
+		// other string types are marshaled as UTF8Strings, so only an
+		// extension holding just a UTF8String OtherName encodes back the same
+		var names []asn1.RawValue
+		if _, err := asn1.Unmarshal(value, &names); err != nil {
+			t.Fatalf("unmarshaling GeneralNames of decoded extension: %v", err)
+		}
+		if len(names) == 1 {
+			var other rawOtherName
+			if _, err := asn1.UnmarshalWithParams(names[0].FullBytes, &other, "tag:0"); err != nil {
+				t.Fatalf("unmarshaling OtherName of decoded extension: %v", err)
+			}
+			isUTF8 := len(other.Value.Bytes) > 0 && other.Value.Bytes[0] == asn1.TagUTF8String
+			if isUTF8 && !bytes.Equal(marshaled.Value, value) {
+				t.Fatalf("OtherName %q encoded as %x, decoded from %x", name, marshaled.Value, value)
+			}
+		}
+		if roundTripped, err := UnmarshalSANS([]pkix.Extension{*marshaled}); err != nil || roundTripped != name {
+			t.Fatalf("UnmarshalSANS(MarshalSANS(%q)) = %q, %v", name, roundTripped, err)
+		}
+	})
+}
+
+func BenchmarkMarshalSANS(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := MarshalSANS("foo!example.com", true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalSANS(b *testing.B) {
+	ext, err := MarshalSANS("foo!example.com", true)
+	if err != nil {
+		b.Fatal(err)
+	}
+	exts := []pkix.Extension{*ext}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := UnmarshalSANS(exts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalSANSBatch(b *testing.B) {
+	names := make([]string, 1000)
+	for i := range names {
+		names[i] = fmt.Sprintf("user%d!example.com", i)
+	}
+	b.Run("loop", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, name := range names {
+				if _, err := MarshalSANS(name, true); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := MarshalSANSBatch(names, true); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// sanString is a valid UTF-8 string for testing/quick, mixing ASCII, !
+// separators, control characters and runes of every encoded length. Its
+// length in bytes is often exactly at a boundary: where DER switches to
+// long-form lengths, or DefaultMaxOtherNameLength.
+type sanString string
+
+var sanStringRunes = [][2]rune{
+	{0x00, 0x1f},                      // control characters
+	{0x20, 0x7e},                      // printable ASCII
+	{'!', '!'},                        // separator
+	{0x80, 0x7ff},                     // 2 bytes
+	{0x800, 0xd7ff}, {0xe000, 0xffff}, // 3 bytes, skipping surrogates
+	{0x10000, utf8.MaxRune}, // 4 bytes
+}
+
+func (sanString) Generate(r *mathrand.Rand, _ int) reflect.Value {
+	boundaries := []int{0, 1, 126, 127, 128, 129, 254, DefaultMaxOtherNameLength}
+	length := r.Intn(DefaultMaxOtherNameLength + 1)
+	if r.Intn(2) == 0 {
+		length = boundaries[r.Intn(len(boundaries))]
+	}
+	b := make([]byte, 0, length)
+	for len(b) < length {
+		span := sanStringRunes[r.Intn(len(sanStringRunes))]
+		c := span[0] + r.Int31n(span[1]-span[0]+1)
+		if len(b)+utf8.RuneLen(c) > length {
+			// Pad to exactly the length chosen
+			c = 'a'
+		}
+		b = utf8.AppendRune(b, c)
+	}
+	return reflect.ValueOf(sanString(b))
+}
+
+// TestMarshalSANSRoundTrip checks the invariant that MarshalSANS and
+// UnmarshalSANS are inverses: any valid UTF-8 string within the length limit
+// unmarshals to exactly the string marshaled, whatever its characters and
+// however long its DER length encoding. Strings MarshalSANS rejects, those
+// that are too long, are checked to fail with ErrOtherNameTooLong instead.
+func TestMarshalSANSRoundTrip(t *testing.T) {
+	roundTrip := func(s string, opts ...MarshalOption) error {
+		ext, err := MarshalSANS(s, true, opts...)
+		if err != nil {
+			return err
+		}
+		got, err := UnmarshalSANS([]pkix.Extension{*ext})
+		if err != nil {
+			return fmt.Errorf("UnmarshalSANS() = %w", err)
+		}
+		if got != s {
+			return fmt.Errorf("UnmarshalSANS() = %q", got)
+		}
+		return nil
+	}
+
+	quickConfig := &quick.Config{MaxCount: 1000}
+	if err := quick.Check(func(s sanString) bool {
+		if err := roundTrip(string(s)); err != nil {
+			t.Logf("%d byte name %q: %v", len(s), s, err)
+			return false
+		}
+		return true
+	}, quickConfig); err != nil {
+		t.Error(err)
+	}
+
+	// Arbitrary strings from testing/quick round trip too, unless they're
+	// too long
+	if err := quick.Check(func(s string) bool {
+		err := roundTrip(s)
+		if len(s) > DefaultMaxOtherNameLength {
+			return errors.Is(err, ErrOtherNameTooLong)
+		}
+		if err != nil {
+			t.Logf("%d byte name %q: %v", len(s), s, err)
+			return false
+		}
+		return true
+	}, quickConfig); err != nil {
+		t.Error(err)
+	}
+
+	// Long names, without a length limit, up to the most UnmarshalSANS
+	// decodes
+	for _, n := range []int{DefaultMaxOtherNameLength + 1, 1<<16 - 1, maxDecodedOtherNameLength} {
+		s := strings.Repeat("é", n/2) + strings.Repeat("!", n%2)
+		if err := roundTrip(s, WithMaxLength(0)); err != nil {
+			t.Errorf("%d byte name: %v", n, err)
+		}
+	}
 }
@@ -0,0 +1,33 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"crypto/x509"
+	"fmt"
+)
+
+// OtherNameFromPrecert returns the username OtherName of a DER-encoded
+// precertificate, so it can be compared with the name in the final
+// certificate. The critical CT poison extension (RFC 6962 section 3.1) that
+// makes a precertificate invalid is ignored. As with UnmarshalSANS, the
+// precertificate must have exactly one username OtherName.
+func OtherNameFromPrecert(precert []byte) (string, error) {
+	cert, err := x509.ParseCertificate(precert)
+	if err != nil {
+		return "", fmt.Errorf("parsing precertificate: %w", err)
+	}
+	return UnmarshalSANS(cert.Extensions)
+}
@@ -0,0 +1,77 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+var oidExtensionCTPoison = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 3}
+
+func TestOtherNameFromPrecert(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poison := pkix.Extension{Id: oidExtensionCTPoison, Critical: true, Value: asn1.NullBytes}
+	createCert := func(t *testing.T, exts ...pkix.Extension) []byte {
+		t.Helper()
+		template := &x509.Certificate{SerialNumber: big.NewInt(1), ExtraExtensions: exts}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, priv.Public(), priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+	san, err := MarshalSANS("alice!example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, der := range map[string][]byte{
+		"precertificate":    createCert(t, *san, poison),
+		"poison before SAN": createCert(t, poison, *san),
+		"final certificate": createCert(t, *san),
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := OtherNameFromPrecert(der)
+			if err != nil {
+				t.Fatalf("OtherNameFromPrecert() = %v", err)
+			}
+			if got != "alice!example.com" {
+				t.Fatalf("got %q, want %q", got, "alice!example.com")
+			}
+		})
+	}
+
+	t.Run("no OtherName", func(t *testing.T) {
+		if _, err := OtherNameFromPrecert(createCert(t, poison)); !errors.Is(err, ErrNoOtherName) {
+			t.Fatalf("expected ErrNoOtherName, got %v", err)
+		}
+	})
+	t.Run("not a certificate", func(t *testing.T) {
+		if _, err := OtherNameFromPrecert([]byte("not a certificate")); err == nil {
+			t.Fatal("expected error parsing precertificate")
+		}
+	})
+}
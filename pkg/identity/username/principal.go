@@ -27,6 +27,7 @@ import (
 	"github.com/sigstore/fulcio/pkg/certificate"
 	"github.com/sigstore/fulcio/pkg/config"
 	"github.com/sigstore/fulcio/pkg/identity"
+	"github.com/sigstore/fulcio/pkg/identity/santemplate"
 )
 
 type principal struct {
@@ -52,6 +53,31 @@ func PrincipalFromIDToken(ctx context.Context, token *oidc.IDToken) (identity.Pr
 	}
 
 	unIdentity := fmt.Sprintf("%s!%s", username, cfg.SubjectDomain)
+	claimedUsername := username
+	if cfg.SANTemplate != "" {
+		// The template chooses the username, and may render any hostname,
+		// so the hostname is checked against SubjectDomain below
+		tmpl, err := config.FromContext(ctx).SANTemplate(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if unIdentity, err = renderSANTemplate(token, tmpl); err != nil {
+			return nil, err
+		}
+		var ok bool
+		if claimedUsername, _, ok = splitOtherName(unIdentity); !ok {
+			return nil, fmt.Errorf("SANTemplate rendered %q, which must have the form <username>!<hostname>", unIdentity)
+		}
+	}
+	// Check the OtherName as it will be issued, after encoding it and
+	// parsing it back, is the authenticated identity
+	issued, err := roundTripOtherName(ctx, unIdentity)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyOtherNameMatchesClaim(issued, claimedUsername, cfg.SubjectDomain); err != nil {
+		return nil, err
+	}
 
 	return principal{
 		issuer:     token.Issuer,
@@ -60,6 +86,24 @@ func PrincipalFromIDToken(ctx context.Context, token *oidc.IDToken) (identity.Pr
 	}, nil
 }
 
+// roundTripOtherName marshals name into a SAN extension as Embed does, and
+// returns the OtherName parsed back from it.
+func roundTripOtherName(ctx context.Context, name string) (string, error) {
+	ext, err := MarshalSANSContext(ctx, name, false)
+	if err != nil {
+		return "", err
+	}
+	return UnmarshalSANS([]pkix.Extension{*ext})
+}
+
+func renderSANTemplate(token *oidc.IDToken, tmpl *santemplate.SANTemplate) (string, error) {
+	var claims map[string]interface{}
+	if err := token.Claims(&claims); err != nil {
+		return "", err
+	}
+	return tmpl.Render(claims)
+}
+
 func (p principal) Name(context.Context) string {
 	return p.username
 }
@@ -67,7 +111,8 @@ func (p principal) Name(context.Context) string {
 func (p principal) Embed(ctx context.Context, cert *x509.Certificate) error {
 	var exts []pkix.Extension
 
-	ext, err := MarshalSANS(p.unIdentity, true /*critical*/)
+	critical := config.FromContext(ctx).CriticalityPolicy().ShouldBeCritical(config.IdentityKindUsername)
+	ext, err := MarshalSANSContext(ctx, p.unIdentity, critical)
 	if err != nil {
 		return err
 	}
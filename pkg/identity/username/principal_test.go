@@ -21,7 +21,10 @@ import (
 	"encoding/asn1"
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"unsafe"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/google/go-cmp/cmp"
@@ -51,6 +54,14 @@ func TestPrincipalFromIDToken(t *testing.T) {
 			Token:   &oidc.IDToken{Issuer: "https://accounts.example.com", Subject: "alice@example.com"},
 			WantErr: true,
 		},
+		`empty username should error`: {
+			Token:   &oidc.IDToken{Issuer: "https://accounts.example.com", Subject: ""},
+			WantErr: true,
+		},
+		`username too long to issue should error`: {
+			Token:   &oidc.IDToken{Issuer: "https://accounts.example.com", Subject: strings.Repeat("a", DefaultMaxOtherNameLength)},
+			WantErr: true,
+		},
 		`invalid issuer should error`: {
 			Token:   &oidc.IDToken{Issuer: "https://notaccounts.example.com", Subject: "alice"},
 			WantErr: true,
@@ -93,6 +104,85 @@ func TestPrincipalFromIDToken(t *testing.T) {
 	}
 }
 
+func TestPrincipalFromIDTokenWithSANTemplate(t *testing.T) {
+	tests := map[string]struct {
+		Template   string
+		Claims     string
+		UnIdentity string
+		WantErr    string
+	}{
+		`Template renders the OtherName from claims`: {
+			Template:   "{{.preferred_username | lower}}!example.com",
+			Claims:     `{"sub": "alice", "preferred_username": "Alice.Smith"}`,
+			UnIdentity: "alice.smith!example.com",
+		},
+		`Missing claim should error`: {
+			Template: "{{.preferred_username}}!example.com",
+			Claims:   `{"sub": "alice"}`,
+			WantErr:  `no entry for key "preferred_username"`,
+		},
+		`Claim injecting a hostname should error`: {
+			Template: "{{.preferred_username}}!example.com",
+			Claims:   `{"sub": "alice", "preferred_username": "alice!evil.example.com"}`,
+			WantErr:  "must not contain !",
+		},
+		`Hostname other than SubjectDomain should error`: {
+			Template: "{{.preferred_username}}!{{.domain}}",
+			Claims:   `{"sub": "alice", "preferred_username": "alice", "domain": "evil.example.com"}`,
+			WantErr:  "does not match authenticated hostname",
+		},
+		`Name without hostname should error`: {
+			Template: "{{.preferred_username}}",
+			Claims:   `{"sub": "alice", "preferred_username": "alice"}`,
+			WantErr:  "must have the form",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.FulcioConfig{
+				OIDCIssuers: map[string]config.OIDCIssuer{
+					"https://accounts.example.com": {
+						IssuerURL:     "https://accounts.example.com",
+						ClientID:      "sigstore",
+						SubjectDomain: "example.com",
+						Type:          config.IssuerTypeUsername,
+						SANTemplate:   test.Template,
+					},
+				},
+			}
+			ctx := config.With(context.Background(), cfg)
+			token := &oidc.IDToken{Issuer: "https://accounts.example.com", Subject: "alice"}
+			withClaims(token, []byte(test.Claims))
+
+			untyped, err := PrincipalFromIDToken(ctx, token)
+			if test.WantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.WantErr) {
+					t.Fatalf("expected error containing %q, got %v", test.WantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("didn't expect error", err)
+			}
+			want := principal{issuer: "https://accounts.example.com", username: "alice", unIdentity: test.UnIdentity}
+			if p, ok := untyped.(principal); !ok || p != want {
+				t.Errorf("got %v principal and expected %v", untyped, want)
+			}
+		})
+	}
+}
+
+// reflect hack because "claims" field is unexported by oidc IDToken
+// https://github.com/coreos/go-oidc/pull/329
+func withClaims(token *oidc.IDToken, data []byte) {
+	val := reflect.Indirect(reflect.ValueOf(token))
+	member := val.FieldByName("claims")
+	pointer := unsafe.Pointer(member.UnsafeAddr())
+	realPointer := (*[]byte)(pointer)
+	*realPointer = data
+}
+
 func TestName(t *testing.T) {
 	tests := map[string]struct {
 		Token        *oidc.IDToken
@@ -134,6 +224,7 @@ func TestName(t *testing.T) {
 func TestEmbed(t *testing.T) {
 	tests := map[string]struct {
 		Principal principal
+		Config    *config.FulcioConfig
 		WantErr   bool
 		WantFacts map[string]func(x509.Certificate) error
 	}{
@@ -161,6 +252,27 @@ func TestEmbed(t *testing.T) {
 				},
 			},
 		},
+		`SAN criticality from config`: {
+			Principal: principal{
+				issuer:     `https://accounts.example.com`,
+				username:   "alice",
+				unIdentity: "alice!example.com",
+			},
+			Config: &config.FulcioConfig{
+				SANCriticality: config.CriticalityPolicy{config.IdentityKindUsername: false},
+			},
+			WantErr: false,
+			WantFacts: map[string]func(x509.Certificate) error{
+				`SAN is not critical`: func(cert x509.Certificate) error {
+					for _, ext := range cert.ExtraExtensions {
+						if ext.Id.Equal(oidSubjectAltName) && ext.Critical {
+							return errors.New("expected SAN not to be critical")
+						}
+					}
+					return nil
+				},
+			},
+		},
 		`Empty issuer url should fail to render extensions`: {
 			Principal: principal{
 				issuer:     "",
@@ -173,8 +285,12 @@ func TestEmbed(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
+			ctx := context.TODO()
+			if test.Config != nil {
+				ctx = config.With(ctx, test.Config)
+			}
 			var cert x509.Certificate
-			err := test.Principal.Embed(context.TODO(), &cert)
+			err := test.Principal.Embed(ctx, &cert)
 			if err != nil {
 				if !test.WantErr {
 					t.Error(err)
@@ -0,0 +1,128 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+)
+
+// RedactedUsername replaces the username of each username OtherName in the
+// certificates returned by RedactOtherName.
+const RedactedUsername = "REDACTED"
+
+// RedactOtherName returns a copy of cert for logging, with the username of
+// each username OtherName replaced by RedactedUsername. The hostname is kept,
+// so alice!example.com becomes REDACTED!example.com, and other names are left
+// as they are. The names are redacted in both Extensions and ExtraExtensions.
+//
+// The copy has no Raw or RawTBSCertificate, as the original encoding holds
+// the username and the signature doesn't cover the redacted names. cert is
+// not modified.
+func RedactOtherName(cert *x509.Certificate) (*x509.Certificate, error) {
+	return redactCertificate(cert, func(string) string { return RedactedUsername })
+}
+
+// RedactOtherNameWithKey is like RedactOtherName, but replaces each username
+// with "hmac-sha256:" and the hex HMAC-SHA256 of the username keyed with key,
+// so that the names of a deployment can be correlated across log entries. key
+// should be a secret of the deployment: usernames are easily guessed, so an
+// unkeyed or known key lets anyone recover them from the logs.
+func RedactOtherNameWithKey(cert *x509.Certificate, key []byte) (*x509.Certificate, error) {
+	if len(key) == 0 {
+		return nil, errors.New("username redaction key is empty")
+	}
+	return redactCertificate(cert, func(username string) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(username))
+		return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+	})
+}
+
+func redactCertificate(cert *x509.Certificate, redact func(string) string) (*x509.Certificate, error) {
+	exts, err := redactOtherNames(cert.Extensions, redact)
+	if err != nil {
+		return nil, err
+	}
+	extraExts, err := redactOtherNames(cert.ExtraExtensions, redact)
+	if err != nil {
+		return nil, err
+	}
+
+	redacted := *cert
+	redacted.Raw = nil
+	redacted.RawTBSCertificate = nil
+	redacted.Extensions = exts
+	redacted.ExtraExtensions = extraExts
+	return &redacted, nil
+}
+
+// redactOtherNames returns a copy of exts with the usernames of username
+// OtherNames replaced by redact in any Subject Alternative Name extension.
+func redactOtherNames(exts []pkix.Extension, redact func(string) string) ([]pkix.Extension, error) {
+	if exts == nil {
+		return nil, nil
+	}
+	redacted := make([]pkix.Extension, len(exts))
+	for i, e := range exts {
+		redacted[i] = e
+		if !e.Id.Equal(oidSubjectAltName) {
+			continue
+		}
+
+		var names []asn1.RawValue
+		rest, err := asn1.Unmarshal(e.Value, &names)
+		if err != nil {
+			return nil, sentinelError{ErrMalformedSAN, err}
+		} else if len(rest) != 0 {
+			return nil, sentinelError{ErrMalformedSAN, errors.New("trailing data after X.509 extension")}
+		}
+		for j, name := range names {
+			if name.Class != asn1.ClassContextSpecific || name.Tag != TagOtherName {
+				continue
+			}
+			id, value, err := parseOtherName(name)
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Equal(id, oidOtherNameDER) {
+				continue
+			}
+			names[j] = asn1.RawValue{FullBytes: appendOtherName(nil, id, derTagUTF8String, redactUsername(value, redact))}
+		}
+		ext, err := MarshalGeneralNames(names, e.Critical)
+		if err != nil {
+			return nil, err
+		}
+		redacted[i] = *ext
+	}
+	return redacted, nil
+}
+
+// redactUsername replaces the username in a <username>!<hostname> name with
+// redact(username). A name without a hostname is replaced entirely.
+func redactUsername(name string, redact func(string) string) string {
+	username, hostname, ok := splitOtherName(name)
+	if !ok {
+		return redact(name)
+	}
+	return redact(username) + "!" + hostname
+}
@@ -0,0 +1,170 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestRedactOtherName(t *testing.T) {
+	dnsName := asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: TagDNSName, Bytes: []byte("foo.example.com")}
+	san, err := AppendOtherName(mustMarshalGeneralNames(t, dnsName), "alice!example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(1),
+		ExtraExtensions: []pkix.Extension{*san},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, priv.Public(), priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redacted, err := RedactOtherName(cert)
+	if err != nil {
+		t.Fatalf("RedactOtherName() = %v", err)
+	}
+	// The username is replaced, but the hostname is kept
+	const want = RedactedUsername + "!example.com"
+	if got, err := UnmarshalSANS(redacted.Extensions); err != nil || got != want {
+		t.Fatalf("UnmarshalSANS() of redacted certificate = %q, %v, want %q", got, err, want)
+	}
+	// Other names are left as they are
+	if len(redacted.DNSNames) != 1 || redacted.DNSNames[0] != "foo.example.com" {
+		t.Fatalf("expected DNS name to be kept, got %v", redacted.DNSNames)
+	}
+	var names []asn1.RawValue
+	for _, ext := range redacted.Extensions {
+		if ext.Id.Equal(oidSubjectAltName) {
+			if _, err := asn1.Unmarshal(ext.Value, &names); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if len(names) != 2 || names[0].Tag != TagDNSName || !bytes.Equal(names[0].Bytes, dnsName.Bytes) {
+		t.Fatalf("expected DNS name to be kept in the SAN, got %v", names)
+	}
+	if redacted.Raw != nil || redacted.RawTBSCertificate != nil {
+		t.Fatal("expected raw encoding of redacted certificate to be dropped")
+	}
+
+	// The original certificate is untouched
+	if got, err := UnmarshalSANS(cert.Extensions); err != nil || got != "alice!example.com" {
+		t.Fatalf("UnmarshalSANS() of original certificate = %q, %v", got, err)
+	}
+	if !bytes.Equal(cert.Raw, der) {
+		t.Fatal("expected raw encoding of original certificate to be kept")
+	}
+
+	// Templates are redacted too
+	redacted, err = RedactOtherName(template)
+	if err != nil {
+		t.Fatalf("RedactOtherName() of template = %v", err)
+	}
+	if got, err := UnmarshalSANS(redacted.ExtraExtensions); err != nil || got != want {
+		t.Fatalf("UnmarshalSANS() of redacted template = %q, %v, want %q", got, err, want)
+	}
+	if got, err := UnmarshalSANS(template.ExtraExtensions); err != nil || got != "alice!example.com" {
+		t.Fatalf("UnmarshalSANS() of original template = %q, %v", got, err)
+	}
+}
+
+func TestRedactOtherNameWithoutHostname(t *testing.T) {
+	san, err := MarshalSANS("alice", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redacted, err := RedactOtherName(&x509.Certificate{Extensions: []pkix.Extension{*san}})
+	if err != nil {
+		t.Fatalf("RedactOtherName() = %v", err)
+	}
+	if got, err := UnmarshalSANS(redacted.Extensions); err != nil || got != RedactedUsername {
+		t.Fatalf("UnmarshalSANS() = %q, %v, want %q", got, err, RedactedUsername)
+	}
+}
+
+func TestRedactOtherNameWithKey(t *testing.T) {
+	san, err := MarshalSANS("alice!example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &x509.Certificate{Extensions: []pkix.Extension{*san}}
+
+	redact := func(key string) string {
+		t.Helper()
+		redacted, err := RedactOtherNameWithKey(cert, []byte(key))
+		if err != nil {
+			t.Fatalf("RedactOtherNameWithKey() = %v", err)
+		}
+		got, err := UnmarshalSANS(redacted.Extensions)
+		if err != nil {
+			t.Fatalf("UnmarshalSANS() = %v", err)
+		}
+		return got
+	}
+
+	// HMAC-SHA256 of "alice" keyed with "deployment-secret"
+	const want = "hmac-sha256:eaf65a9d6f292d2978c06d1c001899697f5a52353cc258a73e624925d38eba36!example.com"
+	if got := redact("deployment-secret"); got != want {
+		t.Fatalf("RedactOtherNameWithKey() = %q, want %q", got, want)
+	}
+	// Another deployment's key gives another name, and the unkeyed digest of
+	// the username is never used
+	if got := redact("other-secret"); got == want {
+		t.Fatal("expected a different key to give a different name")
+	}
+	if got := redact("deployment-secret"); strings.Contains(got, "2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90") {
+		t.Fatalf("expected username not to be redacted by its plain digest, got %q", got)
+	}
+
+	if _, err := RedactOtherNameWithKey(cert, nil); err == nil {
+		t.Fatal("expected an empty key to be rejected")
+	}
+}
+
+func TestRedactOtherNameMalformed(t *testing.T) {
+	cert := &x509.Certificate{Extensions: []pkix.Extension{{Id: oidSubjectAltName, Value: []byte{0x30, 0x05}}}}
+	if _, err := RedactOtherName(cert); !errors.Is(err, ErrMalformedSAN) {
+		t.Fatalf("expected ErrMalformedSAN, got %v", err)
+	}
+}
+
+func mustMarshalGeneralNames(t *testing.T, names ...asn1.RawValue) *pkix.Extension {
+	t.Helper()
+	ext, err := MarshalGeneralNames(names, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ext
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// SANPEMType is the type of the PEM block holding a SANExtension.
+const SANPEMType = "SUBJECT ALTERNATIVE NAME"
+
+// SANExtension is a Subject Alternative Name extension, such as one built by
+// MarshalSANS, with JSON and PEM forms so tools can inspect or exchange it
+// outside a certificate or certificate request.
+type SANExtension pkix.Extension
+
+// sanExtensionJSON is the JSON form of a SANExtension.
+type sanExtensionJSON struct {
+	OID      string                   `json:"oid"`
+	Critical bool                     `json:"critical"`
+	Names    []GeneralNameDescription `json:"names"`
+}
+
+// MarshalJSON returns the OID and criticality of the extension and its
+// decoded GeneralNames, as described by DescribeSANS.
+func (ext *SANExtension) MarshalJSON() ([]byte, error) {
+	names, err := ext.describe()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sanExtensionJSON{
+		OID:      ext.Id.String(),
+		Critical: ext.Critical,
+		Names:    names,
+	})
+}
+
+// ToPEM returns the DER encoding of the extension, the Extension structure
+// of RFC 5280, 4.1, in a PEM block of type SANPEMType.
+func (ext *SANExtension) ToPEM() ([]byte, error) {
+	if _, err := ext.describe(); err != nil {
+		return nil, err
+	}
+	der, err := asn1.Marshal(pkix.Extension(*ext))
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: SANPEMType, Bytes: der}), nil
+}
+
+// ParseSANPEM parses a SANExtension from the PEM form written by ToPEM. The
+// data must hold just the one PEM block.
+func ParseSANPEM(data []byte) (*SANExtension, error) {
+	block, rest := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	} else if block.Type != SANPEMType {
+		return nil, fmt.Errorf("PEM block has type %q, expected %q", block.Type, SANPEMType)
+	} else if len(bytes.TrimSpace(rest)) != 0 {
+		return nil, errors.New("trailing data after PEM block")
+	}
+
+	var ext pkix.Extension
+	if rest, err := asn1.Unmarshal(block.Bytes, &ext); err != nil {
+		return nil, sentinelError{ErrMalformedSAN, err}
+	} else if len(rest) != 0 {
+		return nil, sentinelError{ErrMalformedSAN, errors.New("trailing data after X.509 extension")}
+	}
+	san := SANExtension(ext)
+	if _, err := san.describe(); err != nil {
+		return nil, err
+	}
+	return &san, nil
+}
+
+// describe checks ext is a Subject Alternative Name extension and describes
+// its GeneralNames.
+func (ext *SANExtension) describe() ([]GeneralNameDescription, error) {
+	if !ext.Id.Equal(oidSubjectAltName) {
+		return nil, fmt.Errorf("extension %v is not a subject alternative name extension", ext.Id)
+	}
+	desc, err := DescribeSANS([]pkix.Extension{pkix.Extension(*ext)})
+	if err != nil {
+		return nil, err
+	}
+	return desc.Extensions[0].Names, nil
+}
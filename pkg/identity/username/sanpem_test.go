@@ -0,0 +1,144 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package username
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSANExtensionPEM(t *testing.T) {
+	ext, err := MarshalSANSWithIPs([]string{"alice!example.com"}, []net.IP{net.ParseIP("192.0.2.1")}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	san := (*SANExtension)(ext)
+
+	data, err := san.ToPEM()
+	if err != nil {
+		t.Fatalf("ToPEM() = %v", err)
+	}
+	if !strings.HasPrefix(string(data), "-----BEGIN SUBJECT ALTERNATIVE NAME-----\n") {
+		t.Fatalf("unexpected PEM block:\n%s", data)
+	}
+	parsed, err := ParseSANPEM(data)
+	if err != nil {
+		t.Fatalf("ParseSANPEM() = %v", err)
+	}
+	if !reflect.DeepEqual(parsed, san) {
+		t.Fatalf("ParseSANPEM() = %+v, want %+v", parsed, san)
+	}
+	if name, err := UnmarshalSANS([]pkix.Extension{pkix.Extension(*parsed)}); err != nil || name != "alice!example.com" {
+		t.Fatalf("UnmarshalSANS() of parsed extension = %q, %v", name, err)
+	}
+
+	// Non-critical extensions round trip too, as DER omits the default
+	ext.Critical = false
+	data, err = san.ToPEM()
+	if err != nil {
+		t.Fatalf("ToPEM() = %v", err)
+	}
+	if parsed, err := ParseSANPEM(data); err != nil || parsed.Critical {
+		t.Fatalf("ParseSANPEM() of non-critical extension = %+v, %v", parsed, err)
+	}
+}
+
+func TestSANExtensionJSON(t *testing.T) {
+	ext, err := MarshalSANSWithIPs([]string{"alice!example.com"}, []net.IP{net.ParseIP("192.0.2.1")}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Check the JSON of the extension after a round trip through PEM
+	data, err := (*SANExtension)(ext).ToPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	san, err := ParseSANPEM(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(san)
+	if err != nil {
+		t.Fatalf("json.Marshal() = %v", err)
+	}
+	const want = `{"oid":"2.5.29.17","critical":true,"names":[` +
+		`{"type":"otherName","tag":0,"value":"alice!example.com","oid":"1.3.6.1.4.1.57264.1.7"},` +
+		`{"type":"ipAddress","tag":7,"value":"192.0.2.1"}]}`
+	if string(got) != want {
+		t.Fatalf("json.Marshal() = %s, want %s", got, want)
+	}
+}
+
+func TestParseSANPEMFailures(t *testing.T) {
+	ext, err := MarshalSANS("alice!example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := (*SANExtension)(ext).ToPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := asn1.Marshal(pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}, Value: []byte("https://issuer.example.com")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	malformed, err := asn1.Marshal(pkix.Extension{Id: oidSubjectAltName, Value: []byte{0x30, 0x05}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		data    []byte
+		wantErr string
+		wantIs  error
+	}{
+		"not PEM":           {[]byte("alice!example.com"), "no PEM block", nil},
+		"wrong type":        {pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{}}), `type "CERTIFICATE"`, nil},
+		"trailing data":     {append(append([]byte(nil), valid...), valid...), "trailing data", nil},
+		"not an extension":  {pem.EncodeToMemory(&pem.Block{Type: SANPEMType, Bytes: []byte{0x05, 0x00}}), "", ErrMalformedSAN},
+		"another extension": {pem.EncodeToMemory(&pem.Block{Type: SANPEMType, Bytes: der}), "not a subject alternative name extension", nil},
+		"malformed SAN":     {pem.EncodeToMemory(&pem.Block{Type: SANPEMType, Bytes: malformed}), "", ErrMalformedSAN},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseSANPEM(test.data)
+			if test.wantIs != nil {
+				if !errors.Is(err, test.wantIs) {
+					t.Fatalf("expected %v, got %v", test.wantIs, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+
+	// Only Subject Alternative Name extensions are exported
+	other := &SANExtension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}, Value: []byte("https://issuer.example.com")}
+	if _, err := other.ToPEM(); err == nil {
+		t.Fatal("expected error exporting another extension")
+	}
+	if _, err := json.Marshal(other); err == nil {
+		t.Fatal("expected error marshaling another extension as JSON")
+	}
+}
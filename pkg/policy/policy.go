@@ -0,0 +1,124 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package policy decides whether a certificate may be issued for an
+// authenticated identity, before it is signed.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/identity"
+)
+
+// ErrDenied is wrapped by the errors of policies that reject a request, as
+// opposed to failing to evaluate it.
+var ErrDenied = errors.New("denied by pre-issuance policy")
+
+// PreIssuancePolicy decides whether a certificate may be issued for
+// principal, authenticated by a token with the given top level claims. It
+// returns nil to allow issuance, or an error wrapping ErrDenied to reject it.
+type PreIssuancePolicy interface {
+	Evaluate(ctx context.Context, principal identity.Principal, claims map[string]interface{}) error
+}
+
+// Chain is a PreIssuancePolicy that evaluates its policies in order. The
+// first policy that returns an error rejects the request.
+type Chain []PreIssuancePolicy
+
+func (c Chain) Evaluate(ctx context.Context, principal identity.Principal, claims map[string]interface{}) error {
+	for _, p := range c {
+		if err := p.Evaluate(ctx, principal, claims); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Allowlist only allows requests whose value is one of Values.
+type Allowlist struct {
+	// Claim is the top level string claim that is checked. If empty, the
+	// principal's name is checked.
+	Claim  string
+	Values []string
+}
+
+func (a Allowlist) Evaluate(ctx context.Context, principal identity.Principal, claims map[string]interface{}) error {
+	value, ok := lookup(ctx, a.Claim, principal, claims)
+	if !ok || !contains(a.Values, value) {
+		return fmt.Errorf("%s %q is not allowed: %w", describe(a.Claim), value, ErrDenied)
+	}
+	return nil
+}
+
+// Denylist rejects requests whose value is one of Values.
+type Denylist struct {
+	// Claim is the top level string claim that is checked. If empty, the
+	// principal's name is checked.
+	Claim  string
+	Values []string
+}
+
+func (d Denylist) Evaluate(ctx context.Context, principal identity.Principal, claims map[string]interface{}) error {
+	if value, ok := lookup(ctx, d.Claim, principal, claims); ok && contains(d.Values, value) {
+		return fmt.Errorf("%s %q is denied: %w", describe(d.Claim), value, ErrDenied)
+	}
+	return nil
+}
+
+// FromConfig returns a chain of the configured built-in policies.
+func FromConfig(policies []config.Policy) (Chain, error) {
+	chain := make(Chain, 0, len(policies))
+	for i, p := range policies {
+		switch p.Type {
+		case config.PolicyTypeAllowlist:
+			chain = append(chain, Allowlist{Claim: p.Claim, Values: p.Values})
+		case config.PolicyTypeDenylist:
+			chain = append(chain, Denylist{Claim: p.Claim, Values: p.Values})
+		default:
+			return nil, fmt.Errorf("policy %d: unknown type %q", i, p.Type)
+		}
+	}
+	return chain, nil
+}
+
+// lookup returns the value a policy checks. Claims that aren't strings have
+// no value.
+func lookup(ctx context.Context, claim string, principal identity.Principal, claims map[string]interface{}) (string, bool) {
+	if claim == "" {
+		return principal.Name(ctx), true
+	}
+	value, ok := claims[claim].(string)
+	return value, ok
+}
+
+func describe(claim string) string {
+	if claim == "" {
+		return "identity"
+	}
+	return "claim " + claim
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package policy
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/identity"
+)
+
+type testPrincipal string
+
+func (p testPrincipal) Name(context.Context) string {
+	return string(p)
+}
+
+func (p testPrincipal) Embed(context.Context, *x509.Certificate) error {
+	return nil
+}
+
+// countingPolicy records how many times it was evaluated
+type countingPolicy struct {
+	calls int
+}
+
+func (c *countingPolicy) Evaluate(context.Context, identity.Principal, map[string]interface{}) error {
+	c.calls++
+	return nil
+}
+
+func TestPolicies(t *testing.T) {
+	claims := map[string]interface{}{
+		"email":  "foo@example.com",
+		"groups": []interface{}{"admins"},
+	}
+	tests := map[string]struct {
+		Policy     PreIssuancePolicy
+		WantDenied bool
+	}{
+		"allowlisted identity": {
+			Policy: Allowlist{Values: []string{"bar@example.com", "foo@example.com"}},
+		},
+		"identity not in allowlist": {
+			Policy:     Allowlist{Values: []string{"bar@example.com"}},
+			WantDenied: true,
+		},
+		"allowlisted claim": {
+			Policy: Allowlist{Claim: "email", Values: []string{"foo@example.com"}},
+		},
+		"missing claim is not allowlisted": {
+			Policy:     Allowlist{Claim: "nickname", Values: []string{"foo"}},
+			WantDenied: true,
+		},
+		"claim that isn't a string is not allowlisted": {
+			Policy:     Allowlist{Claim: "groups", Values: []string{"admins"}},
+			WantDenied: true,
+		},
+		"denylisted identity": {
+			Policy:     Denylist{Values: []string{"foo@example.com"}},
+			WantDenied: true,
+		},
+		"identity not in denylist": {
+			Policy: Denylist{Values: []string{"bar@example.com"}},
+		},
+		"missing claim is not denylisted": {
+			Policy: Denylist{Claim: "nickname", Values: []string{"foo"}},
+		},
+		"empty chain": {
+			Policy: Chain{},
+		},
+		"chain of allowing policies": {
+			Policy: Chain{
+				Allowlist{Values: []string{"foo@example.com"}},
+				Denylist{Values: []string{"bar@example.com"}},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.Policy.Evaluate(context.Background(), testPrincipal("foo@example.com"), claims)
+			if test.WantDenied != errors.Is(err, ErrDenied) {
+				t.Fatalf("Evaluate() = %v, want denied %v", err, test.WantDenied)
+			}
+		})
+	}
+}
+
+func TestChainStopsAtDenial(t *testing.T) {
+	before, after := &countingPolicy{}, &countingPolicy{}
+	chain := Chain{
+		before,
+		Denylist{Values: []string{"foo@example.com"}},
+		after,
+	}
+	if err := chain.Evaluate(context.Background(), testPrincipal("foo@example.com"), nil); !errors.Is(err, ErrDenied) {
+		t.Fatalf("expected denial, got %v", err)
+	}
+	if before.calls != 1 || after.calls != 0 {
+		t.Fatalf("expected only the policy before the denial to run, got %d before and %d after", before.calls, after.calls)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	chain, err := FromConfig([]config.Policy{
+		{Type: config.PolicyTypeAllowlist, Claim: "email", Values: []string{"foo@example.com"}},
+		{Type: config.PolicyTypeDenylist, Values: []string{"foo@example.com"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 2 {
+		t.Fatalf("expected 2 policies, got %d", len(chain))
+	}
+	claims := map[string]interface{}{"email": "foo@example.com"}
+	if err := chain.Evaluate(context.Background(), testPrincipal("foo@example.com"), claims); !errors.Is(err, ErrDenied) {
+		t.Fatalf("expected the denylist to deny, got %v", err)
+	}
+
+	if _, err := FromConfig([]config.Policy{{Type: "cel", Values: []string{"true"}}}); err == nil {
+		t.Fatal("expected error for unknown policy type")
+	}
+}
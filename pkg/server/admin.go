@@ -0,0 +1,135 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/log"
+)
+
+const (
+	// AdminFlushCachesPath is the path of the admin endpoint that flushes
+	// caches.
+	AdminFlushCachesPath = "/admin/flushCaches"
+	// AdminRevokePath is the path of the admin endpoint that revokes a
+	// certificate.
+	AdminRevokePath = "/admin/revoke"
+
+	CacheJWKS      = "jwks"
+	CacheDiscovery = "discovery"
+)
+
+// NewAdminHandler returns a handler for admin endpoints. Requests must
+// present token as a bearer token in the Authorization header.
+//
+// POST AdminFlushCachesPath?cache=<name>[&cache=<name>...] flushes the named
+// caches, one of CacheJWKS or CacheDiscovery. Fulcio keeps no idempotency or
+// jti caches, so there is nothing else to flush. If a cache can't be
+// flushed, the handler fails and the existing cache entries are kept.
+//
+// POST AdminRevokePath?serial=<hex>[&reason=<code>] adds the certificate with
+// the serial number to revocations, with an optional RFC 5280 CRLReason code.
+// It is only served if revocations is not nil.
+func NewAdminHandler(cfg *config.FulcioConfig, revocations *RevocationList, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(AdminFlushCachesPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		caches := r.URL.Query()["cache"]
+		if len(caches) == 0 {
+			http.Error(w, "at least one cache must be specified", http.StatusBadRequest)
+			return
+		}
+		for _, cache := range caches {
+			if cache != CacheJWKS && cache != CacheDiscovery {
+				http.Error(w, fmt.Sprintf("unknown cache %q", cache), http.StatusBadRequest)
+				return
+			}
+		}
+
+		for _, cache := range caches {
+			var err error
+			switch cache {
+			case CacheJWKS:
+				err = cfg.FlushJWKS()
+			case CacheDiscovery:
+				err = cfg.FlushDiscovery(r.Context())
+			}
+			if err != nil {
+				log.ContextLogger(r.Context()).Errorw("flushing cache", "cache", cache, "error", err)
+				http.Error(w, fmt.Sprintf("error flushing %s cache", cache), http.StatusInternalServerError)
+				return
+			}
+			log.ContextLogger(r.Context()).Infow("Flushed cache", "cache", cache)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	if revocations != nil {
+		mux.HandleFunc(AdminRevokePath, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				w.Header().Set("Allow", http.MethodPost)
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			serial, ok := new(big.Int).SetString(strings.ReplaceAll(r.URL.Query().Get("serial"), ":", ""), 16)
+			if !ok {
+				http.Error(w, "serial must be a hex serial number", http.StatusBadRequest)
+				return
+			}
+			reason := 0
+			if v := r.URL.Query().Get("reason"); v != "" {
+				var err error
+				if reason, err = strconv.Atoi(v); err != nil || !config.ValidCRLReason(reason) {
+					http.Error(w, "reason must be a CRLReason code", http.StatusBadRequest)
+					return
+				}
+			}
+			if err := revocations.Revoke(serial, reason, time.Now()); err != nil {
+				log.ContextLogger(r.Context()).Errorw("revoking certificate", "serial", serial.Text(16), "error", err)
+				http.Error(w, "error revoking certificate", http.StatusInternalServerError)
+				return
+			}
+			log.ContextLogger(r.Context()).Infow("Revoked certificate", "serial", serial.Text(16), "reason", reason)
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+
+	return requireBearerToken(mux, token)
+}
+
+func requireBearerToken(next http.Handler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		got := strings.TrimPrefix(auth, "Bearer ")
+		if got == auth || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
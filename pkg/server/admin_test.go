@@ -0,0 +1,223 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/config"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestAdminFlushCaches(t *testing.T) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk := jose.JSONWebKey{Algorithm: string(jose.RS256), Key: pk}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: pk}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var issuer string
+	var discoveryRequests, jwksRequests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&discoveryRequests, 1)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   issuer,
+			"jwks_uri": issuer + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&jwksRequests, 1)
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{jwk.Public()}})
+	})
+	oidcServer := httptest.NewServer(mux)
+	t.Cleanup(oidcServer.Close)
+	issuer = oidcServer.URL
+
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, issuer, issuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	tok, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Issuer:   issuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  "foo@example.com",
+		Audience: jwt.Audience{"sigstore"},
+	}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify := func() {
+		t.Helper()
+		verifier, ok := cfg.GetVerifier(issuer)
+		if !ok {
+			t.Fatal("expected verifier for issuer")
+		}
+		if _, err := verifier.Verify(context.Background(), tok); err != nil {
+			t.Fatalf("Verify() = %v", err)
+		}
+	}
+	wantRequests := func(discovery, jwks int32) {
+		t.Helper()
+		if got := atomic.LoadInt32(&discoveryRequests); got != discovery {
+			t.Fatalf("expected %d discovery requests, got %d", discovery, got)
+		}
+		if got := atomic.LoadInt32(&jwksRequests); got != jwks {
+			t.Fatalf("expected %d JWKS requests, got %d", jwks, got)
+		}
+	}
+
+	const token = "s3cr3t"
+	admin := httptest.NewServer(NewAdminHandler(cfg, nil, token))
+	t.Cleanup(admin.Close)
+	flush := func(auth string, caches ...string) int {
+		t.Helper()
+		url := admin.URL + AdminFlushCachesPath
+		for i, cache := range caches {
+			sep := "&"
+			if i == 0 {
+				sep = "?"
+			}
+			url += sep + "cache=" + cache
+		}
+		req, err := http.NewRequest(http.MethodPost, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Keys are fetched once and then cached
+	verify()
+	verify()
+	wantRequests(1, 1)
+
+	// Flushing requires the admin token
+	if code := flush("", CacheJWKS); code != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized without token, got %d", code)
+	}
+	if code := flush("Bearer wrong", CacheJWKS); code != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized with wrong token, got %d", code)
+	}
+	if code := flush(token, CacheJWKS); code != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized without bearer scheme, got %d", code)
+	}
+	if code := flush("Bearer "+token, "jti"); code != http.StatusBadRequest {
+		t.Fatalf("expected bad request for unknown cache, got %d", code)
+	}
+	if code := flush("Bearer " + token); code != http.StatusBadRequest {
+		t.Fatalf("expected bad request without caches, got %d", code)
+	}
+	wantRequests(1, 1)
+
+	// A flushed JWKS cache re-fetches keys on the next request, without
+	// repeating discovery
+	if code := flush("Bearer "+token, CacheJWKS); code != http.StatusNoContent {
+		t.Fatalf("expected no content, got %d", code)
+	}
+	verify()
+	wantRequests(1, 2)
+
+	// Flushing discovery repeats discovery immediately, and keys are fetched
+	// again on the next request
+	if code := flush("Bearer "+token, CacheDiscovery); code != http.StatusNoContent {
+		t.Fatalf("expected no content, got %d", code)
+	}
+	wantRequests(2, 2)
+	verify()
+	wantRequests(2, 3)
+}
+
+func TestAdminRevoke(t *testing.T) {
+	const token = "s3cr3t"
+	path := filepath.Join(t.TempDir(), "revocations.json")
+	l, err := LoadRevocationList(path)
+	if err != nil {
+		t.Fatalf("LoadRevocationList() = %v", err)
+	}
+	admin := httptest.NewServer(NewAdminHandler(nil, l, token))
+	t.Cleanup(admin.Close)
+	revoke := func(method, query string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, admin.URL+AdminRevokePath+"?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := revoke(http.MethodGet, "serial=2a"); code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected method not allowed, got %d", code)
+	}
+	for _, query := range []string{"", "serial=xyz", "serial=2a&reason=7", "serial=2a&reason=one"} {
+		if code := revoke(http.MethodPost, query); code != http.StatusBadRequest {
+			t.Fatalf("expected bad request for %q, got %d", query, code)
+		}
+	}
+	if code := revoke(http.MethodPost, "serial=00:2a&reason=1"); code != http.StatusNoContent {
+		t.Fatalf("expected no content, got %d", code)
+	}
+
+	// The revocation is persisted
+	l, err = LoadRevocationList(path)
+	if err != nil {
+		t.Fatalf("LoadRevocationList() = %v", err)
+	}
+	rc, revoked, err := revokedIn([]RevocationSource{l}, big.NewInt(42))
+	if err != nil || !revoked || rc.Reason != 1 {
+		t.Fatalf("expected serial 42 revoked for key compromise, got %v, %v, %v", rc, revoked, err)
+	}
+}
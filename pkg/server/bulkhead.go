@@ -0,0 +1,59 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import "sync"
+
+// bulkheads limit the number of concurrent token verifications per issuer,
+// so that a slow identity provider can't tie up every request the server is
+// handling. A limit of 0 disables them.
+type bulkheads struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newBulkheads(limit int) *bulkheads {
+	return &bulkheads{
+		limit: limit,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+// acquire takes a slot in the bulkhead for issuer without waiting. If the
+// bulkhead is full, it returns false. Otherwise, release must be called once
+// the verification is done.
+func (b *bulkheads) acquire(issuer string) (release func(), ok bool) {
+	if b == nil || b.limit <= 0 {
+		return func() {}, true
+	}
+
+	b.mu.Lock()
+	slots, ok := b.slots[issuer]
+	if !ok {
+		slots = make(chan struct{}, b.limit)
+		b.slots[issuer] = slots
+	}
+	b.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/generated/protobuf"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// Tests that a slow issuer can only hold up its own bulkhead
+func TestAPIWithIssuerBulkheads(t *testing.T) {
+	fastSigner, fastIssuer := newOIDCIssuer(t)
+
+	// The slow issuer doesn't answer JWKS requests until unblocked
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk := jose.JSONWebKey{Algorithm: string(jose.RS256), Key: pk}
+	slowSigner, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: pk}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var slowIssuer string
+	jwksRequested := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   slowIssuer,
+			"jwks_uri": slowIssuer + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case jwksRequested <- struct{}{}:
+		default:
+		}
+		<-unblock
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{jwk.Public()}})
+	})
+	oidcServer := httptest.NewServer(mux)
+	t.Cleanup(oidcServer.Close)
+	t.Cleanup(func() { close(unblock) })
+	slowIssuer = oidcServer.URL
+
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			},
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, fastIssuer, fastIssuer, slowIssuer, slowIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca, WithIssuerConcurrencyLimit(1))
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	request := func(signer jose.Signer, issuer string) (*protobuf.SigningCertificate, error) {
+		tok, err := jwt.Signed(signer).Claims(jwt.Claims{
+			Issuer:   issuer,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+			Subject:  emailSubject,
+			Audience: jwt.Audience{"sigstore"},
+		}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+		if err != nil {
+			t.Errorf("CompactSerialize() = %v", err)
+			return nil, err
+		}
+		pubBytes, proof := generateKeyAndProof(emailSubject, t)
+		return client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+			Credentials: &protobuf.Credentials{
+				Credentials: &protobuf.Credentials_OidcIdentityToken{
+					OidcIdentityToken: tok,
+				},
+			},
+			Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+				PublicKeyRequest: &protobuf.PublicKeyRequest{
+					PublicKey: &protobuf.PublicKey{
+						Content: pubBytes,
+					},
+					ProofOfPossession: proof,
+				},
+			},
+		})
+	}
+
+	// Fill the slow issuer's bulkhead with a request stuck fetching keys
+	slowDone := make(chan error, 1)
+	go func() {
+		_, err := request(slowSigner, slowIssuer)
+		slowDone <- err
+	}()
+	select {
+	case <-jwksRequested:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the slow issuer's JWKS request")
+	}
+
+	// Further requests for the slow issuer are rejected
+	_, err = request(slowSigner, slowIssuer)
+	if err == nil || status.Code(err) != codes.ResourceExhausted || !strings.Contains(err.Error(), issuerBusy) {
+		t.Fatalf("expected ResourceExhausted for the slow issuer, got %v", err)
+	}
+
+	// The fast issuer is still served
+	resp, err := request(fastSigner, fastIssuer)
+	if err != nil {
+		t.Fatalf("SigningCert() = %v", err)
+	}
+	verifyResponse(resp, eca, fastIssuer, t)
+
+	// Once the slow issuer responds, its request completes
+	unblock <- struct{}{}
+	select {
+	case err := <-slowDone:
+		if err != nil {
+			t.Fatalf("SigningCert() for slow issuer = %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the slow issuer's request")
+	}
+}
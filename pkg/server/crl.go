@@ -0,0 +1,94 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net/http"
+	"time"
+
+	certauth "github.com/sigstore/fulcio/pkg/ca"
+	"github.com/sigstore/fulcio/pkg/log"
+)
+
+const (
+	// CRLPath is the path of the endpoint that serves a DER encoded CRL of
+	// revoked certificates.
+	CRLPath = "/api/v2/crl"
+
+	// crlValidity is how long a CRL may be cached
+	crlValidity = 5 * time.Minute
+)
+
+var oidCRLReason = asn1.ObjectIdentifier{2, 5, 29, 21}
+
+// NewCRLHandler returns a handler that serves a CRL of the certificates in
+// revocations, signed by the key of ca. The CRL is generated for each
+// request, so revocations are reflected immediately.
+func NewCRLHandler(ca certauth.SignerWithChain, revocations ...RevocationSource) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		crl, err := createCRL(ca, revocations, time.Now())
+		if err != nil {
+			log.ContextLogger(r.Context()).Errorw("creating CRL", "error", err)
+			http.Error(w, "error creating CRL", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pkix-crl")
+		_, _ = w.Write(crl)
+	})
+}
+
+func createCRL(ca certauth.SignerWithChain, revocations []RevocationSource, now time.Time) ([]byte, error) {
+	revoked, err := allRevokedIn(revocations)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]pkix.RevokedCertificate, 0, len(revoked))
+	for _, rc := range revoked {
+		entry := pkix.RevokedCertificate{
+			SerialNumber:   rc.SerialNumber,
+			RevocationTime: rc.RevokedAt,
+		}
+		// The reason code is omitted for unspecified, as RFC 5280 5.3.1
+		// recommends
+		if rc.Reason != 0 {
+			value, err := asn1.Marshal(asn1.Enumerated(rc.Reason))
+			if err != nil {
+				return nil, err
+			}
+			entry.Extensions = []pkix.Extension{{Id: oidCRLReason, Value: value}}
+		}
+		entries = append(entries, entry)
+	}
+
+	chain, signer := ca.GetSignerWithChain()
+	return x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		RevokedCertificates: entries,
+		// CRL numbers must increase, and nothing is persisted between CRLs
+		Number:     big.NewInt(now.UnixNano()),
+		ThisUpdate: now,
+		NextUpdate: now.Add(crlValidity),
+	}, chain[0], signer)
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+var (
+	// oidChallengePassword is the PKCS#9 challengePassword attribute
+	oidChallengePassword = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 7}
+	// oidSubjectKeyID is the subject key identifier extension
+	oidSubjectKeyID = asn1.ObjectIdentifier{2, 5, 29, 14}
+)
+
+// subjectKeyIDSize is the size of a SHA-1 digest, which is how subject key
+// identifiers are computed when the client doesn't request one.
+const subjectKeyIDSize = 20
+
+// tbsCertificateRequest is the CertificationRequestInfo of a PKCS#10 CSR.
+// crypto/x509 only parses attributes that are sets of type and value pairs,
+// which excludes challengePassword.
+type tbsCertificateRequest struct {
+	Version       int
+	Subject       asn1.RawValue
+	PublicKey     asn1.RawValue
+	RawAttributes []asn1.RawValue `asn1:"tag:0"`
+}
+
+type csrAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// tokenFromCSRChallengePassword returns the value of the challengePassword
+// attribute of a PEM or DER encoded CSR, or an empty string if it has none.
+// SCEP-style enrollment clients can only send a credential this way.
+func tokenFromCSRChallengePassword(csrBytes []byte) (string, error) {
+	csr, err := cryptoutils.ParseCSR(csrBytes)
+	if err != nil {
+		return "", err
+	}
+	var tbs tbsCertificateRequest
+	if rest, err := asn1.Unmarshal(csr.RawTBSCertificateRequest, &tbs); err != nil {
+		return "", err
+	} else if len(rest) != 0 {
+		return "", errors.New("trailing data after CSR")
+	}
+	for _, raw := range tbs.RawAttributes {
+		var attr csrAttribute
+		if _, err := asn1.Unmarshal(raw.FullBytes, &attr); err != nil {
+			return "", fmt.Errorf("parsing CSR attribute: %w", err)
+		}
+		if !attr.Type.Equal(oidChallengePassword) {
+			continue
+		}
+		if len(attr.Values) != 1 {
+			return "", errors.New("challengePassword must have exactly one value")
+		}
+		// challengePassword is a DirectoryString
+		switch value := attr.Values[0]; value.Tag {
+		case asn1.TagUTF8String, asn1.TagPrintableString, asn1.TagIA5String:
+			return string(value.Bytes), nil
+		default:
+			return "", fmt.Errorf("unsupported challengePassword string type %d", value.Tag)
+		}
+	}
+	return "", nil
+}
+
+// csrSubjectKeyID returns the subject key identifier requested in a CSR's
+// extensions, if it has one. The identifier must be as long as a computed
+// one.
+func csrSubjectKeyID(csr *x509.CertificateRequest) ([]byte, bool, error) {
+	for _, ext := range csr.Extensions {
+		if !ext.Id.Equal(oidSubjectKeyID) {
+			continue
+		}
+		var skid []byte
+		if rest, err := asn1.Unmarshal(ext.Value, &skid); err != nil {
+			return nil, false, fmt.Errorf("parsing subject key identifier: %w", err)
+		} else if len(rest) != 0 {
+			return nil, false, errors.New("trailing data after subject key identifier")
+		}
+		if len(skid) != subjectKeyIDSize {
+			return nil, false, fmt.Errorf("subject key identifier must be %d bytes, got %d", subjectKeyIDSize, len(skid))
+		}
+		return skid, true, nil
+	}
+	return nil, false, nil
+}
@@ -25,12 +25,29 @@ import (
 
 const (
 	invalidSignature       = "The signature supplied in the request could not be verified"
+	nonCanonicalChallenge  = "The identity in the token cannot be used as a challenge because it is not in canonical form"
 	invalidPublicKey       = "The public key supplied in the request could not be parsed"
 	invalidCSR             = "The certificate signing request could not be parsed"
+	invalidSubjectKeyID    = "The subject key identifier requested in the certificate signing request is invalid"
 	failedToEnterCertInCTL = "Error entering certificate in CTL"
 	failedToMarshalSCT     = "Error marshaling signed certificate timestamp"
 	failedToMarshalCert    = "Error marshaling code signing certificate"
+	failedToVerifyCert     = "Error verifying issued certificate"
 	insecurePublicKey      = "The public key supplied in the request is insecure"
+	invalidCertificate     = "The certificate supplied in the request could not be parsed"
+	invalidAccessToken     = "The access token supplied in the request does not match the identity token"
+	invalidProfile         = "The certificate profile requested is not allowed for this issuer"
+	sshCertUnsupported     = "SSH certificates are not supported by this server"
+	failedToCreateSSHCert  = "Error creating SSH certificate"
+	invalidNonce           = "The identity token does not contain a valid nonce issued by this server"
+	failedToCreateNonce    = "Error creating nonce"
+	tooManyNonces          = "Too many nonces are outstanding, try again later"
+	unexpectedClaims       = "The identity token contains claims that are not allowed for this issuer"
+	issuerBusy             = "Too many requests for this issuer are in progress, try again later"
+	signingQueueTimeout    = "Timed out waiting to sign the certificate, try again later"
+	tokenTooOld            = "The identity token was issued too long ago, request a new token"
+	deniedByPolicy         = "A certificate may not be issued for this identity by policy"
+	failedToEvaluatePolicy = "Error evaluating issuance policy"
 	//nolint
 	invalidCredentials = "There was an error processing the credentials for this request"
 	// nolint
@@ -0,0 +1,89 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// tokenExpiresInTrailer is the trailer reporting how long a token that
+	// was close to expiry had left when it was verified
+	tokenExpiresInTrailer = "fulcio-token-expires-in"
+
+	// expiryWarningInterval is how often a near-expiry warning is logged
+	// for each issuer
+	expiryWarningInterval = time.Minute
+)
+
+// expiryWarnings warns operators when tokens are close to expiry by the time
+// they are verified, which usually means a slow pipeline between the token
+// being minted and presented. Warnings are rate limited for each issuer, and
+// count the warnings suppressed since the last one. A threshold of 0
+// disables them.
+type expiryWarnings struct {
+	threshold time.Duration
+	now       func() time.Time
+
+	mu         sync.Mutex
+	last       map[string]time.Time
+	suppressed map[string]int
+}
+
+func newExpiryWarnings(threshold time.Duration) *expiryWarnings {
+	if threshold <= 0 {
+		return nil
+	}
+	return &expiryWarnings{
+		threshold:  threshold,
+		now:        time.Now,
+		last:       make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+}
+
+// check warns if a token from issuer that expires at expiry is within the
+// threshold of expiring, and reports the time left in a response trailer.
+func (w *expiryWarnings) check(ctx context.Context, issuer string, expiry time.Time) {
+	if w == nil || expiry.IsZero() {
+		return
+	}
+	now := w.now()
+	remaining := expiry.Sub(now)
+	if remaining >= w.threshold {
+		return
+	}
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(tokenExpiresInTrailer, remaining.String()))
+
+	w.mu.Lock()
+	if now.Sub(w.last[issuer]) < expiryWarningInterval {
+		w.suppressed[issuer]++
+		w.mu.Unlock()
+		return
+	}
+	suppressed := w.suppressed[issuer]
+	w.last[issuer] = now
+	w.suppressed[issuer] = 0
+	w.mu.Unlock()
+
+	log.ContextLogger(ctx).Warnw("Token was close to expiry when verified", "issuer", issuer, "remaining", remaining.String(), "threshold", w.threshold.String(), "suppressed", suppressed)
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/generated/protobuf"
+	"github.com/sigstore/fulcio/pkg/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func observeLogs(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(zapcore.WarnLevel)
+	orig := log.Logger
+	log.Logger = zap.New(core).Sugar()
+	t.Cleanup(func() { log.Logger = orig })
+	return logs
+}
+
+// Tests API warns about tokens close to expiry, and not healthy tokens
+func TestAPIWithTokenExpiryWarning(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca, WithTokenExpiryWarning(10*time.Minute))
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	request := func(expiry time.Time) metadata.MD {
+		tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+			Issuer:   emailIssuer,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Expiry:   jwt.NewNumericDate(expiry),
+			Subject:  emailSubject,
+			Audience: jwt.Audience{"sigstore"},
+		}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+		if err != nil {
+			t.Fatalf("CompactSerialize() = %v", err)
+		}
+
+		pubBytes, proof := generateKeyAndProof(emailSubject, t)
+		var trailer metadata.MD
+		resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+			Credentials: &protobuf.Credentials{
+				Credentials: &protobuf.Credentials_OidcIdentityToken{
+					OidcIdentityToken: tok,
+				},
+			},
+			Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+				PublicKeyRequest: &protobuf.PublicKeyRequest{
+					PublicKey: &protobuf.PublicKey{
+						Content: pubBytes,
+					},
+					ProofOfPossession: proof,
+				},
+			},
+		}, grpc.Trailer(&trailer))
+		if err != nil {
+			t.Fatalf("SigningCert() = %v", err)
+		}
+		verifyResponse(resp, eca, emailIssuer, t)
+		return trailer
+	}
+
+	t.Run("healthy token", func(t *testing.T) {
+		logs := observeLogs(t)
+		trailer := request(time.Now().Add(30 * time.Minute))
+		if got := trailer.Get(tokenExpiresInTrailer); len(got) != 0 {
+			t.Fatalf("expected no %s trailer, got %v", tokenExpiresInTrailer, got)
+		}
+		if logs.Len() != 0 {
+			t.Fatalf("expected no warnings, got %v", logs.All())
+		}
+	})
+
+	t.Run("near expiry token", func(t *testing.T) {
+		logs := observeLogs(t)
+		trailer := request(time.Now().Add(5 * time.Minute))
+		got := trailer.Get(tokenExpiresInTrailer)
+		if len(got) != 1 {
+			t.Fatalf("expected %s trailer, got %v", tokenExpiresInTrailer, trailer)
+		}
+		if remaining, err := time.ParseDuration(got[0]); err != nil || remaining <= 0 || remaining > 5*time.Minute {
+			t.Fatalf("expected remaining validity up to 5m, got %q", got[0])
+		}
+		warnings := logs.FilterMessage("Token was close to expiry when verified").All()
+		if len(warnings) != 1 {
+			t.Fatalf("expected one warning, got %v", logs.All())
+		}
+		if issuer := warnings[0].ContextMap()["issuer"]; issuer != emailIssuer {
+			t.Fatalf("expected warning for issuer %s, got %v", emailIssuer, issuer)
+		}
+	})
+}
+
+func TestExpiryWarningsRateLimit(t *testing.T) {
+	if newExpiryWarnings(0) != nil {
+		t.Fatal("expected warnings disabled without a threshold")
+	}
+
+	logs := observeLogs(t)
+	now := time.Now()
+	w := newExpiryWarnings(time.Minute)
+	w.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	w.check(ctx, "a", now.Add(time.Hour))
+	w.check(ctx, "a", now.Add(time.Second))
+	w.check(ctx, "a", now.Add(time.Second))
+	w.check(ctx, "a", now.Add(time.Second))
+	w.check(ctx, "b", now.Add(time.Second))
+	if logs.Len() != 2 {
+		t.Fatalf("expected one warning for each issuer, got %v", logs.All())
+	}
+
+	now = now.Add(expiryWarningInterval)
+	w.check(ctx, "a", now.Add(time.Second))
+	all := logs.All()
+	if len(all) != 3 {
+		t.Fatalf("expected another warning after the interval, got %v", all)
+	}
+	if suppressed := all[2].ContextMap()["suppressed"]; suppressed != int64(2) {
+		t.Fatalf("expected 2 suppressed warnings, got %v", suppressed)
+	}
+}
@@ -16,45 +16,186 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"crypto"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	ctclient "github.com/google/certificate-transparency-go/client"
 	certauth "github.com/sigstore/fulcio/pkg/ca"
+	"github.com/sigstore/fulcio/pkg/ca/sshca"
+	"github.com/sigstore/fulcio/pkg/certificate"
 	"github.com/sigstore/fulcio/pkg/challenges"
 	"github.com/sigstore/fulcio/pkg/config"
 	"github.com/sigstore/fulcio/pkg/ctl"
 	fulciogrpc "github.com/sigstore/fulcio/pkg/generated/protobuf"
+	"github.com/sigstore/fulcio/pkg/identity"
 	"github.com/sigstore/fulcio/pkg/log"
+	"github.com/sigstore/fulcio/pkg/policy"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"golang.org/x/crypto/ssh"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type grpcCAServer struct {
 	fulciogrpc.UnimplementedCAServer
 	ct *ctclient.LogClient
 	ca certauth.CertificateAuthority
+
+	serialCollisionRetries int
+	sshCA                  *sshca.SSHCA
+	statsd                 *StatsDClient
+	nonces                 *nonceStore
+	bulkheads              *bulkheads
+	signingQueue           *signingQueue
+	expiryWarnings         *expiryWarnings
+	ctLogIDs               [][32]byte
+	revocations            []RevocationSource
+
+	csrChallengePasswordToken bool
+	policies                  policy.Chain
+}
+
+// Option configures optional behaviour of the CA server.
+type Option func(*grpcCAServer)
+
+// WithSerialCollisionRetries sets how many times certificate issuance is
+// retried when the CA reports a serial number collision.
+func WithSerialCollisionRetries(retries int) Option {
+	return func(g *grpcCAServer) {
+		g.serialCollisionRetries = retries
+	}
+}
+
+// WithSSHCA enables issuing SSH user certificates alongside X.509
+// certificates when requested.
+func WithSSHCA(ca *sshca.SSHCA) Option {
+	return func(g *grpcCAServer) {
+		g.sshCA = ca
+	}
+}
+
+// WithStatsD sends the core issuance metrics to StatsD as well as
+// Prometheus.
+func WithStatsD(client *StatsDClient) Option {
+	return func(g *grpcCAServer) {
+		g.statsd = client
+	}
+}
+
+// WithIssuerConcurrencyLimit limits the number of tokens from each issuer
+// that are verified concurrently. Requests beyond the limit are rejected
+// with ResourceExhausted. Issuers are identified by their display name. A
+// limit of 0, the default, disables the limit.
+func WithIssuerConcurrencyLimit(limit int) Option {
+	return func(g *grpcCAServer) {
+		g.bulkheads = newBulkheads(limit)
+	}
+}
+
+// WithSigningConcurrencyLimit limits the number of certificates signed
+// concurrently. Requests beyond the limit wait for a slot until their
+// deadline. A limit of 0, the default, disables the limit.
+func WithSigningConcurrencyLimit(limit int) Option {
+	return func(g *grpcCAServer) {
+		g.signingQueue = newSigningQueue(limit)
+	}
+}
+
+// WithTokenExpiryWarning logs a rate-limited warning when a token has less
+// than threshold left before it expires once it is verified, and reports the
+// time left in a response trailer. A threshold of 0, the default, disables
+// the warning.
+func WithTokenExpiryWarning(threshold time.Duration) Option {
+	return func(g *grpcCAServer) {
+		g.expiryWarnings = newExpiryWarnings(threshold)
+	}
+}
+
+// WithCTLogIDExtension records the IDs of the CT logs that certificates are
+// submitted to in an extension of each certificate.
+func WithCTLogIDExtension(logIDs ...[32]byte) Option {
+	return func(g *grpcCAServer) {
+		g.ctLogIDs = logIDs
+	}
 }
 
-func NewGRPCCAServer(ct *ctclient.LogClient, ca certauth.CertificateAuthority) fulciogrpc.CAServer {
-	return &grpcCAServer{
-		ct: ct,
-		ca: ca,
+// WithRevocations refuses to issue certificates with serial numbers that are
+// revoked in any of sources. A revoked serial number is treated as a serial
+// number collision, so issuance is retried with a new one.
+func WithRevocations(sources ...RevocationSource) Option {
+	return func(g *grpcCAServer) {
+		g.revocations = append(g.revocations, sources...)
 	}
 }
 
+// WithMaxNonces limits the number of nonces issued by CreateNonce that may
+// be outstanding at once. Requests beyond the limit are rejected with
+// ResourceExhausted until nonces are used or expire. A limit of 0 disables
+// the limit.
+func WithMaxNonces(max int) Option {
+	return func(g *grpcCAServer) {
+		g.nonces.max = max
+	}
+}
+
+// WithCSRChallengePasswordToken reads the OIDC token from the
+// challengePassword attribute of a CSR, if no token is otherwise presented,
+// for legacy enrollment clients that can't send it any other way.
+func WithCSRChallengePasswordToken() Option {
+	return func(g *grpcCAServer) {
+		g.csrChallengePasswordToken = true
+	}
+}
+
+// WithPreIssuancePolicies adds policies that are evaluated before a
+// certificate is issued, after the built-in policies of the configuration.
+func WithPreIssuancePolicies(policies ...policy.PreIssuancePolicy) Option {
+	return func(g *grpcCAServer) {
+		g.policies = append(g.policies, policies...)
+	}
+}
+
+func NewGRPCCAServer(ct *ctclient.LogClient, ca certauth.CertificateAuthority, opts ...Option) fulciogrpc.CAServer {
+	g := &grpcCAServer{
+		ct:                     ct,
+		ca:                     ca,
+		serialCollisionRetries: DefaultSerialCollisionRetries,
+		nonces:                 newNonceStore(DefaultNonceTTL, DefaultMaxNonces),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
 const (
 	MetadataOIDCTokenKey = "oidcidentitytoken"
+
+	// DefaultSerialCollisionRetries is the default number of times issuance
+	// is retried after a serial number collision.
+	DefaultSerialCollisionRetries = 3
 )
 
 func (g *grpcCAServer) CreateSigningCertificate(ctx context.Context, request *fulciogrpc.CreateSigningCertificateRequest) (*fulciogrpc.SigningCertificate, error) {
+	start := time.Now()
+	result, err := g.createSigningCertificate(ctx, request)
+	g.recordIssuance(start, err)
+	return result, err
+}
+
+func (g *grpcCAServer) createSigningCertificate(ctx context.Context, request *fulciogrpc.CreateSigningCertificateRequest) (*fulciogrpc.SigningCertificate, error) {
 	logger := log.ContextLogger(ctx)
 
 	// OIDC token either is passed in gRPC field or was extracted from HTTP headers
@@ -70,12 +211,73 @@ func (g *grpcCAServer) CreateSigningCertificate(ctx context.Context, request *fu
 			}
 		}
 	}
+	// or, if enabled, was put in the CSR's challengePassword attribute
+	if token == "" && g.csrChallengePasswordToken && len(request.GetCertificateSigningRequest()) > 0 {
+		var err error
+		token, err = tokenFromCSRChallengePassword(request.GetCertificateSigningRequest())
+		if err != nil {
+			return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, invalidCSR)
+		}
+	}
 
-	// Authenticate OIDC ID token by checking signature
+	// Authenticate OIDC ID token by checking signature, within the issuer's
+	// bulkhead
+	release, ok := g.acquireBulkhead(ctx, token)
+	if !ok {
+		return nil, handleFulcioGRPCError(ctx, codes.ResourceExhausted, errors.New("issuer concurrency limit reached"), issuerBusy)
+	}
 	idtoken, err := authorize(ctx, token)
+	release()
 	if err != nil {
+		var unknown unknownIssuerError
+		if errors.As(err, &unknown) {
+			if msg, ok := config.FromContext(ctx).RenderUnknownIssuerMessage(unknown.issuer); ok {
+				return nil, handleFulcioGRPCError(ctx, codes.Unauthenticated, err, msg)
+			}
+		}
 		return nil, handleFulcioGRPCError(ctx, codes.Unauthenticated, err, invalidCredentials)
 	}
+	// If an access token was presented alongside the ID token, bind them by
+	// checking the at_hash claim
+	if accessToken := request.GetCredentials().GetAccessToken(); accessToken != "" {
+		if err := idtoken.VerifyAccessToken(accessToken); err != nil {
+			return nil, handleFulcioGRPCError(ctx, codes.Unauthenticated, err, invalidAccessToken)
+		}
+	}
+	// If the issuer requires it, the token must carry a nonce issued by
+	// CreateNonce that hasn't been used yet
+	iss, ok := config.FromContext(ctx).GetIssuer(idtoken.Issuer)
+	if ok && iss.RequireNonce {
+		if idtoken.Nonce == "" || !g.nonces.consume(idtoken.Nonce) {
+			return nil, handleFulcioGRPCError(ctx, codes.Unauthenticated, errors.New("missing or unknown nonce"), invalidNonce)
+		}
+	}
+	// Reject tokens issued too long ago, if the issuer limits their age
+	if ok {
+		maxAge, err := iss.MaxTokenAgeDuration()
+		if err != nil {
+			return nil, handleFulcioGRPCError(ctx, codes.Internal, err, invalidIdentityToken)
+		}
+		if maxAge > 0 && (idtoken.IssuedAt.IsZero() || time.Since(idtoken.IssuedAt) > maxAge) {
+			return nil, handleFulcioGRPCError(ctx, codes.Unauthenticated, fmt.Errorf("token issued at %v is older than %v", idtoken.IssuedAt, maxAge), tokenTooOld)
+		}
+	}
+	// Warn about tokens that only just arrived in time, which usually means
+	// a slow pipeline
+	if ok {
+		g.expiryWarnings.check(ctx, iss.DisplayName(), idtoken.Expiry)
+	}
+	// In strict claims mode, reject tokens with claims the issuer isn't
+	// expected to send
+	if ok && iss.StrictClaims {
+		var claims map[string]interface{}
+		if err := idtoken.Claims(&claims); err != nil {
+			return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, invalidIdentityToken)
+		}
+		if unexpected := iss.UnexpectedClaims(claims); len(unexpected) > 0 {
+			return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, fmt.Errorf("unexpected claims %v", unexpected), unexpectedClaims)
+		}
+	}
 	// Parse authenticated ID token into principal
 	// TODO:(nsmith5) replace this and authorize call above with
 	// just identity.IssuerPool.Authenticate()
@@ -84,6 +286,66 @@ func (g *grpcCAServer) CreateSigningCertificate(ctx context.Context, request *fu
 		return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, invalidIdentityToken)
 	}
 
+	// Check that the identity may be issued a certificate
+	if err := g.evaluatePolicies(ctx, principal, idtoken); err != nil {
+		if errors.Is(err, policy.ErrDenied) {
+			return nil, handleFulcioGRPCError(ctx, codes.PermissionDenied, err, deniedByPolicy)
+		}
+		return nil, handleFulcioGRPCError(ctx, codes.Internal, err, failedToEvaluatePolicy)
+	}
+
+	// Select the certificate profile, if one was requested
+	profile, err := config.FromContext(ctx).GetProfile(idtoken.Issuer, request.GetProfile())
+	if err != nil {
+		return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, invalidProfile)
+	}
+	if profile != nil {
+		ctx = config.WithProfile(ctx, profile)
+	}
+
+	if g.ct != nil && len(g.ctLogIDs) > 0 {
+		ctx = certauth.WithCTLogIDs(ctx, g.ctLogIDs)
+	}
+	if len(g.revocations) > 0 {
+		ctx = certauth.WithRevokedSerials(ctx, func(serial *big.Int) (bool, error) {
+			_, revoked, err := revokedIn(g.revocations, serial)
+			return revoked, err
+		})
+	}
+
+	// Record whether the identity is a person or a workload, and the kind of
+	// name in its SAN
+	if cfg := config.FromContext(ctx); cfg != nil {
+		if iss, ok := cfg.GetIssuer(idtoken.Issuer); ok {
+			if class := config.IssuerToIdentityClass(iss.Type); class != "" {
+				ctx = certauth.WithIdentityClass(ctx, class)
+			}
+			if kind := config.IssuerToIdentityKind(iss.Type); kind != "" {
+				ctx = certauth.WithIdentityKind(ctx, kind)
+			}
+		}
+	}
+
+	// Record the scopes granted to the token, if the configuration asks for
+	// them. A scope claim that isn't a space-delimited string is ignored.
+	if _, ok, _ := config.FromContext(ctx).ScopeExtensionObjectIdentifier(); ok {
+		var claims struct {
+			Scope interface{} `json:"scope"`
+		}
+		if err := idtoken.Claims(&claims); err != nil {
+			return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, invalidIdentityToken)
+		}
+		if scope, ok := claims.Scope.(string); ok {
+			if scopes := strings.Fields(scope); len(scopes) > 0 {
+				ctx = certauth.WithScopes(ctx, scopes)
+			}
+		}
+	}
+
+	if request.GetSshCertificate() && g.sshCA == nil {
+		return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, errors.New("no SSH CA configured"), sshCertUnsupported)
+	}
+
 	var publicKey crypto.PublicKey
 	// Verify caller is in possession of their private key and extract
 	// public key from request.
@@ -103,6 +365,18 @@ func (g *grpcCAServer) CreateSigningCertificate(ctx context.Context, request *fu
 		if err := csr.CheckSignature(); err != nil {
 			return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, invalidSignature)
 		}
+
+		// Use the client's subject key identifier, if the configuration
+		// allows it
+		if cfg := config.FromContext(ctx); cfg != nil && cfg.HonorCSRSubjectKeyID {
+			skid, ok, err := csrSubjectKeyID(csr)
+			if err != nil {
+				return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, invalidSubjectKeyID)
+			}
+			if ok {
+				ctx = certauth.WithSubjectKeyID(ctx, skid)
+			}
+		}
 	} else {
 		// Option 2: Check the signature for proof of possession of a private key
 		var (
@@ -128,6 +402,9 @@ func (g *grpcCAServer) CreateSigningCertificate(ctx context.Context, request *fu
 
 		// Check proof of possession signature
 		if err := challenges.CheckSignature(publicKey, proofOfPossession, principal.Name(ctx)); err != nil {
+			if errors.Is(err, challenges.ErrNonCanonicalChallenge) {
+				return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, nonCanonicalChallenge)
+			}
 			return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, invalidSignature)
 		}
 	}
@@ -138,8 +415,22 @@ func (g *grpcCAServer) CreateSigningCertificate(ctx context.Context, request *fu
 	// For CAs that do not support embedded SCTs or if the CT log is not configured
 	if sctCa, ok := g.ca.(certauth.EmbeddedSCTCA); !ok || g.ct == nil {
 		// currently configured CA doesn't support pre-certificate flow required to embed SCT in final certificate
-		csc, err = g.ca.CreateCertificate(ctx, principal, publicKey)
+		release, err := g.signingQueue.acquire(ctx)
+		if err != nil {
+			return nil, handleFulcioGRPCError(ctx, status.FromContextError(err).Code(), err, signingQueueTimeout)
+		}
+		err = g.retrySerialCollisions(ctx, func() (err error) {
+			csc, err = g.ca.CreateCertificate(ctx, principal, publicKey)
+			return err
+		})
+		release()
 		if err != nil {
+			if errors.Is(err, certauth.ErrSerialCollision) {
+				return nil, handleFulcioGRPCError(ctx, codes.Internal, err, genericCAError)
+			}
+			if errors.Is(err, certauth.ErrUnavailable) {
+				return nil, handleFulcioGRPCError(ctx, codes.Unavailable, err, genericCAError)
+			}
 			// if the error was due to invalid input in the request, return HTTP 400
 			if _, ok := err.(certauth.ValidationError); ok {
 				return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, err.Error())
@@ -147,10 +438,13 @@ func (g *grpcCAServer) CreateSigningCertificate(ctx context.Context, request *fu
 			// otherwise return a 500 error to reflect that it is a transient server issue that the client can't resolve
 			return nil, handleFulcioGRPCError(ctx, codes.Internal, err, genericCAError)
 		}
+		if err := csc.VerifyChain(); err != nil {
+			return nil, handleFulcioGRPCError(ctx, codes.Internal, err, failedToVerifyCert)
+		}
 
 		// Submit to CTL
 		if g.ct != nil {
-			sct, err := g.ct.AddChain(ctx, ctl.BuildCTChain(csc.FinalCertificate, csc.FinalChain))
+			sct, err := ctl.AddChain(ctx, g.ct, ctl.BuildCTChain(csc.FinalCertificate, csc.FinalChain))
 			if err != nil {
 				return nil, handleFulcioGRPCError(ctx, codes.Internal, err, failedToEnterCertInCTL)
 			}
@@ -188,23 +482,19 @@ func (g *grpcCAServer) CreateSigningCertificate(ctx context.Context, request *fu
 			result.GetSignedCertificateDetachedSct().SignedCertificateTimestamp = sctBytes
 		}
 	} else {
-		precert, err := sctCa.CreatePrecertificate(ctx, principal, publicKey)
+		// The slot is held from the precertificate to the final certificate,
+		// so the request only queues once
+		release, err := g.signingQueue.acquire(ctx)
 		if err != nil {
-			// if the error was due to invalid input in the request, return HTTP 400
-			if _, ok := err.(certauth.ValidationError); ok {
-				return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, err.Error())
-			}
-			// otherwise return a 500 error to reflect that it is a transient server issue that the client can't resolve
-			return nil, handleFulcioGRPCError(ctx, codes.Internal, err, genericCAError)
+			return nil, handleFulcioGRPCError(ctx, status.FromContextError(err).Code(), err, signingQueueTimeout)
 		}
-		// submit precertificate and chain to CT log
-		sct, err := g.ct.AddPreChain(ctx, ctl.BuildCTChain(precert.PreCert, precert.CertChain))
+		csc, err = g.issueWithEmbeddedSCT(ctx, sctCa, principal, publicKey)
+		release()
 		if err != nil {
-			return nil, handleFulcioGRPCError(ctx, codes.Internal, err, failedToEnterCertInCTL)
+			return nil, err
 		}
-		csc, err = sctCa.IssueFinalCertificate(ctx, precert, sct)
-		if err != nil {
-			return nil, handleFulcioGRPCError(ctx, codes.Internal, err, genericCAError)
+		if err := csc.VerifyChain(); err != nil {
+			return nil, handleFulcioGRPCError(ctx, codes.Internal, err, failedToVerifyCert)
 		}
 
 		finalPEM, err := csc.CertPEM()
@@ -226,11 +516,128 @@ func (g *grpcCAServer) CreateSigningCertificate(ctx context.Context, request *fu
 		}
 	}
 
-	metricNewEntries.Inc()
+	if request.GetSshCertificate() {
+		sshCert, err := g.sshCA.CreateCertificate(ctx, principal, publicKey, csc.FinalCertificate.NotBefore, csc.FinalCertificate.NotAfter)
+		if err != nil {
+			return nil, handleFulcioGRPCError(ctx, codes.Internal, err, failedToCreateSSHCert)
+		}
+		result.SshCertificate = string(ssh.MarshalAuthorizedKey(sshCert))
+	}
+
+	issuerName := idtoken.Issuer
+	if cfg := config.FromContext(ctx); cfg != nil {
+		if iss, ok := cfg.GetIssuer(idtoken.Issuer); ok {
+			issuerName = iss.DisplayName()
+		}
+	}
+	g.recordIssued(issuerName)
+	logger.Infow("Issued certificate", "issuer", issuerName)
+	logger.Debugw("Issued certificate", "issuer", issuerName, "issuerURL", idtoken.Issuer)
 
 	return result, nil
 }
 
+// evaluatePolicies runs the policies of the configuration, then those added
+// with WithPreIssuancePolicies.
+func (g *grpcCAServer) evaluatePolicies(ctx context.Context, principal identity.Principal, idtoken *oidc.IDToken) error {
+	var chain policy.Chain
+	if cfg := config.FromContext(ctx); cfg != nil {
+		var err error
+		if chain, err = policy.FromConfig(cfg.Policies); err != nil {
+			return err
+		}
+	}
+	chain = append(chain, g.policies...)
+	if len(chain) == 0 {
+		return nil
+	}
+	var claims map[string]interface{}
+	if err := idtoken.Claims(&claims); err != nil {
+		return err
+	}
+	return chain.Evaluate(ctx, principal, claims)
+}
+
+// issueWithEmbeddedSCT creates a precertificate, submits it to the CT log and
+// issues the final certificate with the SCT embedded. Errors are returned as
+// gRPC errors.
+func (g *grpcCAServer) issueWithEmbeddedSCT(ctx context.Context, sctCa certauth.EmbeddedSCTCA, principal identity.Principal, publicKey crypto.PublicKey) (*certauth.CodeSigningCertificate, error) {
+	var precert *certauth.CodeSigningPreCertificate
+	err := g.retrySerialCollisions(ctx, func() (err error) {
+		precert, err = sctCa.CreatePrecertificate(ctx, principal, publicKey)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, certauth.ErrSerialCollision) {
+			return nil, handleFulcioGRPCError(ctx, codes.Internal, err, genericCAError)
+		}
+		if errors.Is(err, certauth.ErrUnavailable) {
+			return nil, handleFulcioGRPCError(ctx, codes.Unavailable, err, genericCAError)
+		}
+		// if the error was due to invalid input in the request, return HTTP 400
+		if _, ok := err.(certauth.ValidationError); ok {
+			return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, err.Error())
+		}
+		// otherwise return a 500 error to reflect that it is a transient server issue that the client can't resolve
+		return nil, handleFulcioGRPCError(ctx, codes.Internal, err, genericCAError)
+	}
+	// submit precertificate and chain to CT log
+	sct, err := ctl.AddPreChain(ctx, g.ct, ctl.BuildCTChain(precert.PreCert, precert.CertChain))
+	if err != nil {
+		return nil, handleFulcioGRPCError(ctx, codes.Internal, err, failedToEnterCertInCTL)
+	}
+	csc, err := sctCa.IssueFinalCertificate(ctx, precert, sct)
+	if err != nil {
+		return nil, handleFulcioGRPCError(ctx, codes.Internal, err, genericCAError)
+	}
+	return csc, nil
+}
+
+// acquireBulkhead takes a slot in the bulkhead of the token's issuer. Tokens
+// that don't name a configured issuer are rejected by authorize without
+// contacting an identity provider, so they don't need a bulkhead.
+func (g *grpcCAServer) acquireBulkhead(ctx context.Context, token string) (release func(), ok bool) {
+	issuerURL, err := extractIssuer(token)
+	if err != nil {
+		return func() {}, true
+	}
+	iss, ok := config.FromContext(ctx).GetIssuer(issuerURL)
+	if !ok {
+		return func() {}, true
+	}
+	return g.bulkheads.acquire(iss.DisplayName())
+}
+
+// retrySerialCollisions calls create until it succeeds, fails with an error
+// other than a serial number collision, or the configured retries are
+// exhausted. Each attempt generates a new serial number.
+func (g *grpcCAServer) retrySerialCollisions(ctx context.Context, create func() error) error {
+	var err error
+	for attempt := 0; attempt <= g.serialCollisionRetries; attempt++ {
+		err = create()
+		if !errors.Is(err, certauth.ErrSerialCollision) {
+			return err
+		}
+		metricSerialCollisions.Inc()
+		log.ContextLogger(ctx).Warnw("Serial number collision", "attempt", attempt+1)
+	}
+	return err
+}
+
+func (g *grpcCAServer) CreateNonce(ctx context.Context, _ *fulciogrpc.CreateNonceRequest) (*fulciogrpc.Nonce, error) {
+	nonce, expires, err := g.nonces.issue()
+	if errors.Is(err, errTooManyNonces) {
+		return nil, handleFulcioGRPCError(ctx, codes.ResourceExhausted, err, tooManyNonces)
+	}
+	if err != nil {
+		return nil, handleFulcioGRPCError(ctx, codes.Internal, err, failedToCreateNonce)
+	}
+	return &fulciogrpc.Nonce{
+		Nonce:   nonce,
+		Expires: timestamppb.New(expires),
+	}, nil
+}
+
 func (g *grpcCAServer) GetTrustBundle(ctx context.Context, _ *fulciogrpc.GetTrustBundleRequest) (*fulciogrpc.TrustBundle, error) {
 	logger := log.ContextLogger(ctx)
 
@@ -244,7 +651,15 @@ func (g *grpcCAServer) GetTrustBundle(ctx context.Context, _ *fulciogrpc.GetTrus
 		Chains: []*fulciogrpc.CertificateChain{},
 	}
 
+	excludeRoot := false
+	if cfg := config.FromContext(ctx); cfg != nil {
+		excludeRoot = cfg.TrustBundleExcludeRoot
+	}
+
 	for _, chain := range trustBundle {
+		if excludeRoot {
+			chain = withoutRoot(chain)
+		}
 		certChain := &fulciogrpc.CertificateChain{}
 		for _, cert := range chain {
 			certPEM, err := cryptoutils.MarshalCertificateToPEM(cert)
@@ -258,6 +673,18 @@ func (g *grpcCAServer) GetTrustBundle(ctx context.Context, _ *fulciogrpc.GetTrus
 	return resp, nil
 }
 
+// withoutRoot drops the self-signed root at the end of chain, unless it is
+// the only certificate.
+func withoutRoot(chain []*x509.Certificate) []*x509.Certificate {
+	if len(chain) < 2 {
+		return chain
+	}
+	if last := chain[len(chain)-1]; bytes.Equal(last.RawSubject, last.RawIssuer) {
+		return chain[:len(chain)-1]
+	}
+	return chain
+}
+
 func (g *grpcCAServer) GetConfiguration(ctx context.Context, _ *fulciogrpc.GetConfigurationRequest) (*fulciogrpc.Configuration, error) {
 	logger := log.ContextLogger(ctx)
 
@@ -273,6 +700,81 @@ func (g *grpcCAServer) GetConfiguration(ctx context.Context, _ *fulciogrpc.GetCo
 	}, nil
 }
 
+func (g *grpcCAServer) VerifySigningCertificate(ctx context.Context, request *fulciogrpc.VerifySigningCertificateRequest) (*fulciogrpc.SigningCertificateVerification, error) {
+	logger := log.ContextLogger(ctx)
+
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(request.GetCertificate()))
+	if err != nil || len(certs) == 0 {
+		if err == nil {
+			err = errors.New("no certificate found in request")
+		}
+		return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, invalidCertificate)
+	}
+	leaf := certs[0]
+
+	exts, err := certificate.ParseExtensions(leaf.Extensions)
+	if err != nil {
+		return nil, handleFulcioGRPCError(ctx, codes.InvalidArgument, err, invalidCertificate)
+	}
+
+	trustBundle, err := g.ca.TrustBundle(ctx)
+	if err != nil {
+		logger.Error("Error retrieving trust bundle: ", err)
+		return nil, handleFulcioGRPCError(ctx, codes.Internal, err, genericCAError)
+	}
+
+	now := time.Now()
+	resp := &fulciogrpc.SigningCertificateVerification{
+		NotBefore: timestamppb.New(leaf.NotBefore),
+		NotAfter:  timestamppb.New(leaf.NotAfter),
+		Expired:   now.Before(leaf.NotBefore) || now.After(leaf.NotAfter),
+		Extensions: &fulciogrpc.CertificateExtensions{
+			Issuer:                   exts.Issuer,
+			GithubWorkflowTrigger:    exts.GithubWorkflowTrigger,
+			GithubWorkflowSha:        exts.GithubWorkflowSHA,
+			GithubWorkflowName:       exts.GithubWorkflowName,
+			GithubWorkflowRepository: exts.GithubWorkflowRepository,
+			GithubWorkflowRef:        exts.GithubWorkflowRef,
+		},
+	}
+	if err := verifyAgainstTrustBundle(certs, trustBundle); err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Trusted = true
+	}
+	return resp, nil
+}
+
+// verifyAgainstTrustBundle checks that the first certificate chains to a root
+// in the trust bundle. Any further certificates are treated as untrusted
+// intermediates. Verification happens at the start of the leaf's validity
+// period, since Fulcio certificates are short-lived and are expected to be
+// verified long after they expire.
+func verifyAgainstTrustBundle(certs []*x509.Certificate, trustBundle [][]*x509.Certificate) error {
+	roots := x509.NewCertPool()
+	intermediates := x509.NewCertPool()
+	for _, chain := range trustBundle {
+		if len(chain) == 0 {
+			continue
+		}
+		roots.AddCert(chain[len(chain)-1])
+		for _, cert := range chain[:len(chain)-1] {
+			intermediates.AddCert(cert)
+		}
+	}
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   certs[0].NotBefore,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	return err
+}
+
 func extractIssuer(token string) (string, error) {
 	parts := strings.Split(token, ".")
 	if len(parts) != 3 {
@@ -303,7 +805,17 @@ func actualAuthorize(ctx context.Context, token string) (*oidc.IDToken, error) {
 
 	verifier, ok := config.FromContext(ctx).GetVerifier(issuer)
 	if !ok {
-		return nil, fmt.Errorf("unsupported issuer: %s", issuer)
+		return nil, unknownIssuerError{issuer: issuer}
 	}
 	return verifier.Verify(ctx, token)
 }
+
+// unknownIssuerError is returned by authorize for tokens from an issuer that
+// isn't configured.
+type unknownIssuerError struct {
+	issuer string
+}
+
+func (e unknownIssuerError) Error() string {
+	return fmt.Sprintf("unsupported issuer: %s", e.issuer)
+}
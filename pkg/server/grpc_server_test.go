@@ -16,9 +16,11 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -26,6 +28,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
+	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
@@ -39,18 +42,28 @@ import (
 	"testing"
 	"time"
 
+	ct "github.com/google/certificate-transparency-go"
 	ctclient "github.com/google/certificate-transparency-go/client"
 	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sigstore/fulcio/pkg/ca"
+	"github.com/sigstore/fulcio/pkg/ca/baseca"
 	"github.com/sigstore/fulcio/pkg/ca/ephemeralca"
+	"github.com/sigstore/fulcio/pkg/ca/sshca"
+	"github.com/sigstore/fulcio/pkg/certificate"
 	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/ctl"
 	"github.com/sigstore/fulcio/pkg/generated/protobuf"
 	"github.com/sigstore/fulcio/pkg/identity"
 	"github.com/sigstore/fulcio/pkg/identity/username"
+	"github.com/sigstore/fulcio/pkg/policy"
+	"github.com/sigstore/fulcio/pkg/test"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"golang.org/x/crypto/ssh"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"gopkg.in/square/go-jose.v2"
@@ -64,6 +77,10 @@ const (
 
 var lis *bufconn.Listener
 
+// testExtensionArc is the ExtensionOIDArc of test configurations, under
+// 32473, the Private Enterprise Number for documentation
+var testExtensionArc = certificate.ExtensionArc{1, 3, 6, 1, 4, 1, 32473, 1}
+
 func passFulcioConfigThruContext(cfg *config.FulcioConfig) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		// For each request, infuse context with our snapshot of the FulcioConfig.
@@ -79,11 +96,11 @@ func passFulcioConfigThruContext(cfg *config.FulcioConfig) grpc.UnaryServerInter
 	}
 }
 
-func setupGRPCForTest(ctx context.Context, t *testing.T, cfg *config.FulcioConfig, ctl *ctclient.LogClient, ca ca.CertificateAuthority) (*grpc.Server, *grpc.ClientConn) {
+func setupGRPCForTest(ctx context.Context, t *testing.T, cfg *config.FulcioConfig, ctl *ctclient.LogClient, ca ca.CertificateAuthority, opts ...Option) (*grpc.Server, *grpc.ClientConn) {
 	t.Helper()
 	lis = bufconn.Listen(bufSize)
 	s := grpc.NewServer(grpc.UnaryInterceptor(passFulcioConfigThruContext(cfg)))
-	protobuf.RegisterCAServer(s, NewGRPCCAServer(ctl, ca))
+	protobuf.RegisterCAServer(s, NewGRPCCAServer(ctl, ca, opts...))
 	go func() {
 		if err := s.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
 			t.Errorf("Server exited with error: %v", err)
@@ -171,6 +188,88 @@ func TestGetTrustBundleSuccess(t *testing.T) {
 	}
 }
 
+// intermediateCA serves a trust bundle of an intermediate and its root.
+type intermediateCA struct {
+	*ephemeralca.EphemeralCA
+	chain []*x509.Certificate
+}
+
+func (ica *intermediateCA) TrustBundle(ctx context.Context) ([][]*x509.Certificate, error) {
+	return [][]*x509.Certificate{ica.chain}, nil
+}
+
+func TestGetTrustBundleExcludeRoot(t *testing.T) {
+	root, rootKey, err := test.GenerateRootCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	intermediate, _, err := test.GenerateSubordinateCA(root, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eca, err := ephemeralca.NewEphemeralCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ica := &intermediateCA{EphemeralCA: eca, chain: []*x509.Certificate{intermediate, root}}
+	ephemeralRoot, _ := eca.GetSignerWithChain()
+
+	for name, tc := range map[string]struct {
+		excludeRoot bool
+		ca          ca.CertificateAuthority
+		want        []*x509.Certificate
+	}{
+		"root included by default": {
+			ca:   ica,
+			want: []*x509.Certificate{intermediate, root},
+		},
+		"root excluded": {
+			excludeRoot: true,
+			ca:          ica,
+			want:        []*x509.Certificate{intermediate},
+		},
+		"lone root kept": {
+			excludeRoot: true,
+			ca:          eca,
+			want:        ephemeralRoot,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.FulcioConfig{TrustBundleExcludeRoot: tc.excludeRoot}
+			ctx := context.Background()
+			server, conn := setupGRPCForTest(ctx, t, cfg, nil, tc.ca)
+			defer func() {
+				server.Stop()
+				conn.Close()
+			}()
+
+			bundle, err := protobuf.NewCAClient(conn).GetTrustBundle(ctx, &protobuf.GetTrustBundleRequest{})
+			if err != nil {
+				t.Fatal("GetTrustBundle failed", err)
+			}
+			if len(bundle.Chains) != 1 {
+				t.Fatalf("expected 1 chain, got %d", len(bundle.Chains))
+			}
+			var got []*x509.Certificate
+			for _, certPEM := range bundle.Chains[0].Certificates {
+				certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(certPEM))
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, certs...)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %d certs, got %d", len(tc.want), len(got))
+			}
+			for i := range got {
+				if !got[i].Equal(tc.want[i]) {
+					t.Errorf("cert %d is %v, wanted %v", i, got[i].Subject, tc.want[i].Subject)
+				}
+			}
+		})
+	}
+}
+
 // Tests GetConfiguration API
 func TestGetConfiguration(t *testing.T) {
 	_, emailIssuer := newOIDCIssuer(t)
@@ -282,6 +381,18 @@ func TestGetConfiguration(t *testing.T) {
 			}
 		}
 
+		if iss.ChallengeType != protobuf.ChallengeType_PROOF_OF_POSSESSION {
+			t.Fatalf("expected proof of possession challenge, got %v", iss.ChallengeType)
+		}
+		expectedFields := []string{
+			"credentials.oidc_identity_token",
+			"public_key_request.public_key.content",
+			"public_key_request.proof_of_possession",
+		}
+		if !reflect.DeepEqual(iss.RequiredFields, expectedFields) {
+			t.Fatalf("expected required fields %v, got %v", expectedFields, iss.RequiredFields)
+		}
+
 		if issURL == spiffeIssuer {
 			if iss.SpiffeTrustDomain != "example.com" {
 				t.Fatalf("expected SPIFFE trust domain example.com, got %v", iss.SpiffeTrustDomain)
@@ -319,6 +430,7 @@ func TestAPIWithEmail(t *testing.T) {
 
 	// Create a FulcioConfig that supports these issuers.
 	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"ExtensionOIDArc": "1.3.6.1.4.1.32473.1",
 		"OIDCIssuers": {
 			%q: {
 				"IssuerURL": %q,
@@ -392,99 +504,2080 @@ func TestAPIWithEmail(t *testing.T) {
 		if leafCert.EmailAddresses[0] != c.ExpectedSubject {
 			t.Fatalf("subjects do not match: Expected %v, got %v", c.ExpectedSubject, leafCert.EmailAddresses[0])
 		}
+		// Email identities are people
+		classExt, found := findCustomExtension(leafCert, testExtensionArc.OID(certificate.ArcIdentityClass))
+		if !found {
+			t.Fatal("expected identity class in custom OID")
+		}
+		if string(classExt.Value) != certificate.IdentityClassHuman {
+			t.Fatalf("unexpected identity class, expected %s, got %s", certificate.IdentityClassHuman, string(classExt.Value))
+		}
 	}
 }
 
-// Tests API for username subject types
-func TestAPIWithUsername(t *testing.T) {
-	usernameSigner, usernameIssuer := newOIDCIssuer(t)
+// Tests that metrics are labeled with the issuer's friendly name
+func TestAPIMetricsUseIssuerName(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
 
-	issuerDomain, err := url.Parse(usernameIssuer)
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email",
+				"Name": "friendly-email"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
 	if err != nil {
-		t.Fatal("issuer URL could not be parsed", err)
+		t.Fatalf("config.Read() = %v", err)
 	}
 
-	// Create a FulcioConfig that supports these issuers.
+	emailSubject := "foo@example.com"
+
+	// Create an OIDC token using this issuer's signer.
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+
+	client := protobuf.NewCAClient(conn)
+
+	pubBytes, proof := generateKeyAndProof(emailSubject, t)
+
+	before := testutil.ToFloat64(metricNewEntries.WithLabelValues("friendly-email"))
+	_, err = client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+		Credentials: &protobuf.Credentials{
+			Credentials: &protobuf.Credentials_OidcIdentityToken{
+				OidcIdentityToken: tok,
+			},
+		},
+		Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+			PublicKeyRequest: &protobuf.PublicKeyRequest{
+				PublicKey: &protobuf.PublicKey{
+					Content: pubBytes,
+				},
+				ProofOfPossession: proof,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SigningCert() = %v", err)
+	}
+
+	if got := testutil.ToFloat64(metricNewEntries.WithLabelValues("friendly-email")) - before; got != 1 {
+		t.Fatalf("expected 1 new certificate labeled with the issuer name, got %v", got)
+	}
+	if got := testutil.ToFloat64(metricNewEntries.WithLabelValues(emailIssuer)); got != 0 {
+		t.Fatalf("expected no certificates labeled with the issuer URL, got %v", got)
+	}
+}
+
+// Tests issuance metrics are sent to StatsD
+func TestAPIMetricsStatsD(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
 	cfg, err := config.Read([]byte(fmt.Sprintf(`{
 		"OIDCIssuers": {
 			%q: {
 				"IssuerURL": %q,
 				"ClientID": "sigstore",
-				"SubjectDomain": %q,
-				"Type": "username"
+				"Type": "email",
+				"Name": "friendly-email"
 			}
 		}
-	}`, usernameIssuer, usernameIssuer, issuerDomain.Hostname())))
+	}`, emailIssuer, emailIssuer)))
 	if err != nil {
 		t.Fatalf("config.Read() = %v", err)
 	}
 
-	usernameSubject := "foo"
-	expectedUsernamedSubject := fmt.Sprintf("%s!%s", usernameSubject, issuerDomain.Hostname())
+	emailSubject := "foo@example.com"
 
-	tests := []oidcTestContainer{
-		{
-			Signer: usernameSigner, Issuer: usernameIssuer, Subject: usernameSubject, ExpectedSubject: expectedUsernamedSubject,
+	// Create an OIDC token using this issuer's signer.
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	// Stub StatsD listener
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	statsd, err := NewStatsDClient(listener.LocalAddr().String(), "fulcio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer statsd.Close()
+	receive := func(n int) []string {
+		t.Helper()
+		var metrics []string
+		buf := make([]byte, 1024)
+		for len(metrics) < n {
+			if err := listener.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+				t.Fatal(err)
+			}
+			l, _, err := listener.ReadFrom(buf)
+			if err != nil {
+				t.Fatalf("expected %d metrics, got %v: %v", n, metrics, err)
+			}
+			metrics = append(metrics, string(buf[:l]))
+		}
+		return metrics
+	}
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca, WithStatsD(statsd))
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+
+	client := protobuf.NewCAClient(conn)
+
+	pubBytes, proof := generateKeyAndProof(emailSubject, t)
+	request := &protobuf.CreateSigningCertificateRequest{
+		Credentials: &protobuf.Credentials{
+			Credentials: &protobuf.Credentials_OidcIdentityToken{
+				OidcIdentityToken: tok,
+			},
+		},
+		Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+			PublicKeyRequest: &protobuf.PublicKeyRequest{
+				PublicKey: &protobuf.PublicKey{
+					Content: pubBytes,
+				},
+				ProofOfPossession: proof,
+			},
 		},
 	}
-	for _, c := range tests {
-		// Create an OIDC token using this issuer's signer.
-		tok, err := jwt.Signed(c.Signer).Claims(jwt.Claims{
-			Issuer:   c.Issuer,
-			IssuedAt: jwt.NewNumericDate(time.Now()),
-			Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
-			Subject:  c.Subject,
-			Audience: jwt.Audience{"sigstore"},
-		}).Claims(customClaims{Email: c.Subject, EmailVerified: true}).CompactSerialize()
-		if err != nil {
-			t.Fatalf("CompactSerialize() = %v", err)
+	if _, err := client.CreateSigningCertificate(ctx, request); err != nil {
+		t.Fatalf("SigningCert() = %v", err)
+	}
+	metrics := receive(2)
+	if metrics[0] != "fulcio.new_certs:1|c|#issuer:friendly-email" {
+		t.Fatalf("unexpected issued metric %q", metrics[0])
+	}
+	if !strings.HasPrefix(metrics[1], "fulcio.new_cert_latency:") || !strings.HasSuffix(metrics[1], "|ms|#code:OK") {
+		t.Fatalf("unexpected latency metric %q", metrics[1])
+	}
+
+	// A request with a bad proof of possession fails
+	request.GetPublicKeyRequest().ProofOfPossession = []byte("bad")
+	if _, err := client.CreateSigningCertificate(ctx, request); err == nil {
+		t.Fatal("expected SigningCert() to fail")
+	}
+	metrics = receive(2)
+	if metrics[0] != "fulcio.new_certs_failures:1|c|#code:InvalidArgument" {
+		t.Fatalf("unexpected failure metric %q", metrics[0])
+	}
+	if !strings.HasPrefix(metrics[1], "fulcio.new_cert_latency:") || !strings.HasSuffix(metrics[1], "|ms|#code:InvalidArgument") {
+		t.Fatalf("unexpected latency metric %q", metrics[1])
+	}
+}
+
+// atHashClaims holds the access token hash claim for OIDC tokens
+type atHashClaims struct {
+	AtHash string `json:"at_hash,omitempty"`
+}
+
+// Tests API with an access token presented alongside the ID token
+func TestAPIWithAccessToken(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
 		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
 
-		ctClient, eca := createCA(cfg, t)
-		ctx := context.Background()
-		server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
-		defer func() {
-			server.Stop()
-			conn.Close()
-		}()
+	emailSubject := "foo@example.com"
+	accessToken := "access-token"
+	sum := sha256.Sum256([]byte(accessToken))
+	atHash := base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
 
-		client := protobuf.NewCAClient(conn)
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
 
-		pubBytes, proof := generateKeyAndProof(c.Subject, t)
+	client := protobuf.NewCAClient(conn)
 
-		// Hit the API to have it sign our certificate.
-		resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
-			Credentials: &protobuf.Credentials{
-				Credentials: &protobuf.Credentials_OidcIdentityToken{
-					OidcIdentityToken: tok,
+	tests := map[string]struct {
+		AtHash      string
+		AccessToken string
+		WantErr     bool
+	}{
+		"matching at_hash": {
+			AtHash:      atHash,
+			AccessToken: accessToken,
+		},
+		"mismatching at_hash": {
+			AtHash:      atHash,
+			AccessToken: "other-access-token",
+			WantErr:     true,
+		},
+		"access token without at_hash": {
+			AccessToken: accessToken,
+			WantErr:     true,
+		},
+		"at_hash without access token": {
+			AtHash: atHash,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Create an OIDC token using this issuer's signer.
+			tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+				Issuer:   emailIssuer,
+				IssuedAt: jwt.NewNumericDate(time.Now()),
+				Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+				Subject:  emailSubject,
+				Audience: jwt.Audience{"sigstore"},
+			}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).Claims(atHashClaims{AtHash: test.AtHash}).CompactSerialize()
+			if err != nil {
+				t.Fatalf("CompactSerialize() = %v", err)
+			}
+
+			pubBytes, proof := generateKeyAndProof(emailSubject, t)
+
+			resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+				Credentials: &protobuf.Credentials{
+					Credentials: &protobuf.Credentials_OidcIdentityToken{
+						OidcIdentityToken: tok,
+					},
+					AccessToken: test.AccessToken,
 				},
-			},
-			Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
-				PublicKeyRequest: &protobuf.PublicKeyRequest{
-					PublicKey: &protobuf.PublicKey{
-						Content: pubBytes,
+				Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+					PublicKeyRequest: &protobuf.PublicKeyRequest{
+						PublicKey: &protobuf.PublicKey{
+							Content: pubBytes,
+						},
+						ProofOfPossession: proof,
 					},
-					ProofOfPossession: proof,
 				},
-			},
+			})
+			if test.WantErr {
+				if err == nil || !strings.Contains(err.Error(), "The access token supplied in the request does not match the identity token") {
+					t.Fatalf("expected access token error, got %v", err)
+				}
+				if status.Code(err) != codes.Unauthenticated {
+					t.Fatalf("expected unauthenticated, got %v", status.Code(err))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SigningCert() = %v", err)
+			}
+			verifyResponse(resp, eca, emailIssuer, t)
 		})
-		if err != nil {
-			t.Fatalf("SigningCert() = %v", err)
+	}
+}
+
+// collidingCA reports a serial number collision for the first collisions
+// precertificates, then issues certificates as normal.
+type collidingCA struct {
+	*ephemeralca.EphemeralCA
+	collisions int
+	attempts   int
+}
+
+func (c *collidingCA) CreatePrecertificate(ctx context.Context, principal identity.Principal, publicKey crypto.PublicKey) (*ca.CodeSigningPreCertificate, error) {
+	c.attempts++
+	if c.attempts <= c.collisions {
+		return nil, fmt.Errorf("serial already issued: %w", ca.ErrSerialCollision)
+	}
+	return c.EphemeralCA.CreatePrecertificate(ctx, principal, publicKey)
+}
+
+// Tests API reports a CA backend that is temporarily unable to sign as
+// unavailable, so that clients retry
+func TestAPIWithUnavailableCA(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
 		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
 
-		leafCert := verifyResponse(resp, eca, c.Issuer, t)
+	emailSubject := "foo@example.com"
+
+	// Create an OIDC token using this issuer's signer.
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, &unavailableCA{EphemeralCA: eca})
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	pubBytes, proof := generateKeyAndProof(emailSubject, t)
+	_, err = client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+		Credentials: &protobuf.Credentials{
+			Credentials: &protobuf.Credentials_OidcIdentityToken{
+				OidcIdentityToken: tok,
+			},
+		},
+		Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+			PublicKeyRequest: &protobuf.PublicKeyRequest{
+				PublicKey: &protobuf.PublicKey{
+					Content: pubBytes,
+				},
+				ProofOfPossession: proof,
+			},
+		},
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected unavailable error, got %v", err)
+	}
+}
+
+// unavailableCA fails to sign as if its backend were down
+type unavailableCA struct {
+	*ephemeralca.EphemeralCA
+}
+
+func (u *unavailableCA) CreatePrecertificate(context.Context, identity.Principal, crypto.PublicKey) (*ca.CodeSigningPreCertificate, error) {
+	return nil, fmt.Errorf("signing: %w", ca.ErrUnavailable)
+}
+
+// Tests API refuses to return a certificate that doesn't verify against the
+// CA, with and without embedded SCTs
+func TestAPIWithCorruptCertificateSignature(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+
+	// Create an OIDC token using this issuer's signer.
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	for _, embedSCT := range []bool{true, false} {
+		t.Run(fmt.Sprintf("embedded SCT %v", embedSCT), func(t *testing.T) {
+			ctClient, eca := createCA(cfg, t)
+			if !embedSCT {
+				ctClient = nil
+			}
+			ctx := context.Background()
+			server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, &corruptCA{EphemeralCA: eca})
+			defer func() {
+				server.Stop()
+				conn.Close()
+			}()
+			client := protobuf.NewCAClient(conn)
+
+			pubBytes, proof := generateKeyAndProof(emailSubject, t)
+			resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+				Credentials: &protobuf.Credentials{
+					Credentials: &protobuf.Credentials_OidcIdentityToken{
+						OidcIdentityToken: tok,
+					},
+				},
+				Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+					PublicKeyRequest: &protobuf.PublicKeyRequest{
+						PublicKey: &protobuf.PublicKey{
+							Content: pubBytes,
+						},
+						ProofOfPossession: proof,
+					},
+				},
+			})
+			if status.Code(err) != codes.Internal {
+				t.Fatalf("expected internal error, got %v", err)
+			}
+			if resp != nil {
+				t.Fatal("expected no certificate to be returned")
+			}
+		})
+	}
+}
+
+// corruptCA issues certificates with a corrupted signature, as a signing
+// bug might
+type corruptCA struct {
+	*ephemeralca.EphemeralCA
+}
+
+func (c *corruptCA) CreateCertificate(ctx context.Context, principal identity.Principal, pub crypto.PublicKey) (*ca.CodeSigningCertificate, error) {
+	csc, err := c.EphemeralCA.CreateCertificate(ctx, principal, pub)
+	if err != nil {
+		return nil, err
+	}
+	return corruptSignature(csc)
+}
+
+func (c *corruptCA) IssueFinalCertificate(ctx context.Context, precert *ca.CodeSigningPreCertificate, sct *ct.SignedCertificateTimestamp) (*ca.CodeSigningCertificate, error) {
+	csc, err := c.EphemeralCA.IssueFinalCertificate(ctx, precert, sct)
+	if err != nil {
+		return nil, err
+	}
+	return corruptSignature(csc)
+}
+
+func corruptSignature(csc *ca.CodeSigningCertificate) (*ca.CodeSigningCertificate, error) {
+	der := append([]byte{}, csc.FinalCertificate.Raw...)
+	der[len(der)-1] ^= 0xff
+	return ca.CreateCSCFromDER(der, csc.FinalChain)
+}
+
+// Tests API with the token passed as metadata, as the REST gateway does with
+// an Authorization header
+func TestAPIWithTokenInMetadata(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+
+	// Create an OIDC token using this issuer's signer.
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	request := func(ctx context.Context, credentials *protobuf.Credentials) (*protobuf.SigningCertificate, error) {
+		pubBytes, proof := generateKeyAndProof(emailSubject, t)
+		return client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+			Credentials: credentials,
+			Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+				PublicKeyRequest: &protobuf.PublicKeyRequest{
+					PublicKey: &protobuf.PublicKey{
+						Content: pubBytes,
+					},
+					ProofOfPossession: proof,
+				},
+			},
+		})
+	}
+
+	t.Run("token only in metadata", func(t *testing.T) {
+		resp, err := request(metadata.AppendToOutgoingContext(ctx, MetadataOIDCTokenKey, tok), nil)
+		if err != nil {
+			t.Fatalf("SigningCert() = %v", err)
+		}
+		verifyResponse(resp, eca, emailIssuer, t)
+	})
+
+	t.Run("token in body takes precedence", func(t *testing.T) {
+		resp, err := request(metadata.AppendToOutgoingContext(ctx, MetadataOIDCTokenKey, "not-a-token"), &protobuf.Credentials{
+			Credentials: &protobuf.Credentials_OidcIdentityToken{
+				OidcIdentityToken: tok,
+			},
+		})
+		if err != nil {
+			t.Fatalf("SigningCert() = %v", err)
+		}
+		verifyResponse(resp, eca, emailIssuer, t)
+	})
+}
+
+// Tests API records the CT log ID in an extension when configured
+func TestAPIWithCTLogIDExtension(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"ExtensionOIDArc": "1.3.6.1.4.1.32473.1",
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+
+	// Create an OIDC token using this issuer's signer.
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	// The ID of a CT log is the hash of its public key
+	ctLogKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctLogKeyDER, err := x509.MarshalPKIXPublicKey(ctLogKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLogID := sha256.Sum256(ctLogKeyDER)
+	logID, err := ctl.LogID(ctLogKey.Public())
+	if err != nil {
+		t.Fatalf("ctl.LogID() = %v", err)
+	}
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca, WithCTLogIDExtension(logID))
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	pubBytes, proof := generateKeyAndProof(emailSubject, t)
+	resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+		Credentials: &protobuf.Credentials{
+			Credentials: &protobuf.Credentials_OidcIdentityToken{
+				OidcIdentityToken: tok,
+			},
+		},
+		Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+			PublicKeyRequest: &protobuf.PublicKeyRequest{
+				PublicKey: &protobuf.PublicKey{
+					Content: pubBytes,
+				},
+				ProofOfPossession: proof,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SigningCert() = %v", err)
+	}
+	leafCert := verifyResponse(resp, eca, emailIssuer, t)
+
+	var logIDs [][32]byte
+	for _, ext := range leafCert.Extensions {
+		if ext.Id.Equal(testExtensionArc.OID(certificate.ArcCTLogIDs)) {
+			if logIDs, err = certificate.ParseCTLogIDs(ext.Value); err != nil {
+				t.Fatalf("ParseCTLogIDs() = %v", err)
+			}
+		}
+	}
+	if len(logIDs) != 1 || logIDs[0] != wantLogID {
+		t.Fatalf("expected CT log IDs [%x], got %x", wantLogID, logIDs)
+	}
+}
+
+// Tests API with an issuer that limits the age of tokens
+func TestAPIWithMaxTokenAge(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email",
+				"MaxTokenAge": "5m"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	request := func(issuedAt time.Time) (*protobuf.SigningCertificate, error) {
+		// Create an OIDC token using this issuer's signer, which hasn't
+		// expired regardless of when it was issued.
+		tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+			Issuer:   emailIssuer,
+			IssuedAt: jwt.NewNumericDate(issuedAt),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+			Subject:  emailSubject,
+			Audience: jwt.Audience{"sigstore"},
+		}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+		if err != nil {
+			t.Fatalf("CompactSerialize() = %v", err)
+		}
+
+		pubBytes, proof := generateKeyAndProof(emailSubject, t)
+		return client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+			Credentials: &protobuf.Credentials{
+				Credentials: &protobuf.Credentials_OidcIdentityToken{
+					OidcIdentityToken: tok,
+				},
+			},
+			Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+				PublicKeyRequest: &protobuf.PublicKeyRequest{
+					PublicKey: &protobuf.PublicKey{
+						Content: pubBytes,
+					},
+					ProofOfPossession: proof,
+				},
+			},
+		})
+	}
+
+	t.Run("fresh token", func(t *testing.T) {
+		resp, err := request(time.Now().Add(-time.Minute))
+		if err != nil {
+			t.Fatalf("SigningCert() = %v", err)
+		}
+		verifyResponse(resp, eca, emailIssuer, t)
+	})
+
+	t.Run("too old token", func(t *testing.T) {
+		_, err := request(time.Now().Add(-10 * time.Minute))
+		if err == nil || status.Code(err) != codes.Unauthenticated || !strings.Contains(err.Error(), tokenTooOld) {
+			t.Fatalf("expected token too old error, got %v", err)
+		}
+	})
+}
+
+// Tests API rejects tokens from an unconfigured issuer with the configured
+// message
+func TestAPIWithUnknownIssuerMessage(t *testing.T) {
+	_, emailIssuer := newOIDCIssuer(t)
+	otherSigner, otherIssuer := newOIDCIssuer(t)
+
+	emailSubject := "foo@example.com"
+
+	// Create an OIDC token using an issuer that isn't configured.
+	tok, err := jwt.Signed(otherSigner).Claims(jwt.Claims{
+		Issuer:   otherIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	tests := map[string]struct {
+		message string
+		want    string
+	}{
+		"custom message": {
+			message: "issuer {{.Issuer}} is not configured; see https://docs.example.com/issuers",
+			want:    fmt.Sprintf("issuer %s is not configured; see https://docs.example.com/issuers", otherIssuer),
+		},
+		"default message": {
+			want: invalidCredentials,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Create a FulcioConfig that supports only the email issuer.
+			cfg, err := config.Read([]byte(fmt.Sprintf(`{
+				"OIDCIssuers": {
+					%q: {
+						"IssuerURL": %q,
+						"ClientID": "sigstore",
+						"Type": "email"
+					}
+				},
+				"UnknownIssuerMessage": %q
+			}`, emailIssuer, emailIssuer, test.message)))
+			if err != nil {
+				t.Fatalf("config.Read() = %v", err)
+			}
+
+			ctClient, eca := createCA(cfg, t)
+			ctx := context.Background()
+			server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+			defer func() {
+				server.Stop()
+				conn.Close()
+			}()
+			client := protobuf.NewCAClient(conn)
+
+			pubBytes, proof := generateKeyAndProof(emailSubject, t)
+			_, err = client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+				Credentials: &protobuf.Credentials{
+					Credentials: &protobuf.Credentials_OidcIdentityToken{
+						OidcIdentityToken: tok,
+					},
+				},
+				Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+					PublicKeyRequest: &protobuf.PublicKeyRequest{
+						PublicKey: &protobuf.PublicKey{
+							Content: pubBytes,
+						},
+						ProofOfPossession: proof,
+					},
+				},
+			})
+			if status.Code(err) != codes.Unauthenticated {
+				t.Fatalf("expected unauthenticated error, got %v", err)
+			}
+			if got := status.Convert(err).Message(); got != test.want {
+				t.Fatalf("expected message %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+// Tests API records the token's scopes in the configured extension
+func TestAPIWithScopeExtension(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		},
+		"ScopeExtensionOID": "1.2.3.4"
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	tests := map[string]struct {
+		scope interface{}
+		want  [][]string
+	}{
+		"multiple scopes": {
+			scope: "openid  email repo:read",
+			want:  [][]string{{"openid", "email", "repo:read"}},
+		},
+		"no scope": {},
+		"scope that isn't a string": {
+			scope: []string{"openid"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims := map[string]interface{}{"email": emailSubject, "email_verified": true}
+			if test.scope != nil {
+				claims["scope"] = test.scope
+			}
+			// Create an OIDC token using this issuer's signer.
+			tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+				Issuer:   emailIssuer,
+				IssuedAt: jwt.NewNumericDate(time.Now()),
+				Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+				Subject:  emailSubject,
+				Audience: jwt.Audience{"sigstore"},
+			}).Claims(claims).CompactSerialize()
+			if err != nil {
+				t.Fatalf("CompactSerialize() = %v", err)
+			}
+
+			pubBytes, proof := generateKeyAndProof(emailSubject, t)
+			resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+				Credentials: &protobuf.Credentials{
+					Credentials: &protobuf.Credentials_OidcIdentityToken{
+						OidcIdentityToken: tok,
+					},
+				},
+				Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+					PublicKeyRequest: &protobuf.PublicKeyRequest{
+						PublicKey: &protobuf.PublicKey{
+							Content: pubBytes,
+						},
+						ProofOfPossession: proof,
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("SigningCert() = %v", err)
+			}
+			leafCert := verifyResponse(resp, eca, emailIssuer, t)
+
+			var got [][]string
+			for _, ext := range leafCert.Extensions {
+				if ext.Id.Equal(asn1.ObjectIdentifier{1, 2, 3, 4}) {
+					scopes, err := certificate.ParseScopes(ext.Value)
+					if err != nil {
+						t.Fatalf("ParseScopes() = %v", err)
+					}
+					got = append(got, scopes)
+				}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("expected scopes %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+// Tests API rejects requests denied by a pre-issuance policy in the chain
+func TestAPIWithPreIssuancePolicies(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer, and allowlists the
+	// subject.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		},
+		"Policies": [
+			{"Type": "allowlist", "Claim": "email", "Values": ["foo@example.com", "bar@example.com"]}
+		]
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	request := func(client protobuf.CAClient, emailSubject string) (*protobuf.SigningCertificate, error) {
+		// Create an OIDC token using this issuer's signer.
+		tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+			Issuer:   emailIssuer,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+			Subject:  emailSubject,
+			Audience: jwt.Audience{"sigstore"},
+		}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+		if err != nil {
+			t.Fatalf("CompactSerialize() = %v", err)
+		}
+
+		pubBytes, proof := generateKeyAndProof(emailSubject, t)
+		return client.CreateSigningCertificate(context.Background(), &protobuf.CreateSigningCertificateRequest{
+			Credentials: &protobuf.Credentials{
+				Credentials: &protobuf.Credentials_OidcIdentityToken{
+					OidcIdentityToken: tok,
+				},
+			},
+			Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+				PublicKeyRequest: &protobuf.PublicKeyRequest{
+					PublicKey: &protobuf.PublicKey{
+						Content: pubBytes,
+					},
+					ProofOfPossession: proof,
+				},
+			},
+		})
+	}
+
+	ctClient, eca := createCA(cfg, t)
+	server, conn := setupGRPCForTest(context.Background(), t, cfg, ctClient, eca,
+		WithPreIssuancePolicies(policy.Denylist{Values: []string{"bar@example.com"}}))
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	// Allowed by every policy in the chain
+	resp, err := request(client, "foo@example.com")
+	if err != nil {
+		t.Fatalf("SigningCert() = %v", err)
+	}
+	verifyResponse(resp, eca, emailIssuer, t)
+
+	// Allowed by the configured allowlist, denied by the added denylist
+	if _, err := request(client, "bar@example.com"); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected permission denied by the denylist, got %v", err)
+	}
+
+	// Denied by the configured allowlist
+	if _, err := request(client, "baz@example.com"); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected permission denied by the allowlist, got %v", err)
+	}
+}
+
+// Tests API copies a subject key identifier from the CSR when configured to
+func TestAPIWithCSRSubjectKeyID(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	emailSubject := "foo@example.com"
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+	computed, err := cryptoutils.SKID(priv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	requested := bytes.Repeat([]byte{0x42}, 20)
+	createCSR := func(skid []byte) []byte {
+		t.Helper()
+		tmpl := &x509.CertificateRequest{Subject: pkix.Name{CommonName: "test"}}
+		if skid != nil {
+			value, err := asn1.Marshal(skid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tmpl.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 14}, Value: value}}
+		}
+		der, err := x509.CreateCertificateRequest(rand.Reader, tmpl, priv)
+		if err != nil {
+			t.Fatalf("error creating CSR: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+	}
+
+	for name, tc := range map[string]struct {
+		honor    bool
+		skid     []byte
+		want     []byte
+		wantCode codes.Code
+	}{
+		"honored": {
+			honor: true,
+			skid:  requested,
+			want:  requested,
+		},
+		"computed when absent": {
+			honor: true,
+			want:  computed,
+		},
+		"computed when disabled": {
+			skid: requested,
+			want: computed,
+		},
+		"wrong length": {
+			honor:    true,
+			skid:     []byte{0x42},
+			wantCode: codes.InvalidArgument,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := config.Read([]byte(fmt.Sprintf(`{
+				"HonorCSRSubjectKeyID": %t,
+				"OIDCIssuers": {
+					%q: {
+						"IssuerURL": %q,
+						"ClientID": "sigstore",
+						"Type": "email"
+					}
+				}
+			}`, tc.honor, emailIssuer, emailIssuer)))
+			if err != nil {
+				t.Fatalf("config.Read() = %v", err)
+			}
+
+			ctClient, eca := createCA(cfg, t)
+			ctx := context.Background()
+			server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+			defer func() {
+				server.Stop()
+				conn.Close()
+			}()
+
+			resp, err := protobuf.NewCAClient(conn).CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+				Credentials: &protobuf.Credentials{
+					Credentials: &protobuf.Credentials_OidcIdentityToken{
+						OidcIdentityToken: tok,
+					},
+				},
+				Key: &protobuf.CreateSigningCertificateRequest_CertificateSigningRequest{
+					CertificateSigningRequest: createCSR(tc.skid),
+				},
+			})
+			if tc.wantCode != codes.OK {
+				if status.Code(err) != tc.wantCode {
+					t.Fatalf("expected %v, got %v", tc.wantCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SigningCert() = %v", err)
+			}
+
+			leafCert := verifyResponse(resp, eca, emailIssuer, t)
+			if !bytes.Equal(leafCert.SubjectKeyId, tc.want) {
+				t.Fatalf("expected subject key identifier %x, got %x", tc.want, leafCert.SubjectKeyId)
+			}
+		})
+	}
+}
+
+// Tests API retries issuance after a serial number collision
+func TestAPIWithSerialCollision(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+
+	// Create an OIDC token using this issuer's signer.
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	tests := map[string]struct {
+		Collisions int
+		WantErr    bool
+	}{
+		"collision on first attempt succeeds on retry": {
+			Collisions: 1,
+		},
+		"collisions exceeding retries fail": {
+			Collisions: DefaultSerialCollisionRetries + 1,
+			WantErr:    true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctClient, eca := createCA(cfg, t)
+			cca := &collidingCA{EphemeralCA: eca, collisions: test.Collisions}
+			ctx := context.Background()
+			server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, cca)
+			defer func() {
+				server.Stop()
+				conn.Close()
+			}()
+
+			client := protobuf.NewCAClient(conn)
+
+			pubBytes, proof := generateKeyAndProof(emailSubject, t)
+
+			before := testutil.ToFloat64(metricSerialCollisions)
+			resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+				Credentials: &protobuf.Credentials{
+					Credentials: &protobuf.Credentials_OidcIdentityToken{
+						OidcIdentityToken: tok,
+					},
+				},
+				Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+					PublicKeyRequest: &protobuf.PublicKeyRequest{
+						PublicKey: &protobuf.PublicKey{
+							Content: pubBytes,
+						},
+						ProofOfPossession: proof,
+					},
+				},
+			})
+			collisions := testutil.ToFloat64(metricSerialCollisions) - before
+
+			if test.WantErr {
+				if err == nil {
+					t.Fatal("expected issuance to fail after exhausting retries")
+				}
+				if status.Code(err) != codes.Internal {
+					t.Fatalf("expected internal error, got %v", status.Code(err))
+				}
+				if cca.attempts != DefaultSerialCollisionRetries+1 {
+					t.Fatalf("expected %d attempts, got %d", DefaultSerialCollisionRetries+1, cca.attempts)
+				}
+				if collisions != float64(DefaultSerialCollisionRetries+1) {
+					t.Fatalf("expected %d collisions counted, got %v", DefaultSerialCollisionRetries+1, collisions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SigningCert() = %v", err)
+			}
+			verifyResponse(resp, eca, emailIssuer, t)
+			if cca.attempts != 2 {
+				t.Fatalf("expected 2 attempts, got %d", cca.attempts)
+			}
+			if collisions != 1 {
+				t.Fatalf("expected 1 collision counted, got %v", collisions)
+			}
+		})
+	}
+}
+
+// countingRevocations is a revocation source that counts how often it is
+// consulted
+type countingRevocations struct {
+	calls int
+	err   error
+}
+
+func (c *countingRevocations) RevokedCertificates() ([]config.RevokedCertificate, error) {
+	c.calls++
+	return nil, c.err
+}
+
+// Tests API checks the serial number of each certificate against the
+// revocations before it is signed
+func TestAPIWithRevocations(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+
+	// Create an OIDC token using this issuer's signer.
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	tests := map[string]struct {
+		Err     error
+		WantErr bool
+	}{
+		"serial that isn't revoked is issued": {},
+		"failure to read revocations fails issuance": {
+			Err:     errors.New("revocation list unavailable"),
+			WantErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctClient, eca := createCA(cfg, t)
+			revocations := &countingRevocations{err: test.Err}
+			ctx := context.Background()
+			server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca, WithRevocations(revocations))
+			defer func() {
+				server.Stop()
+				conn.Close()
+			}()
+
+			client := protobuf.NewCAClient(conn)
+
+			pubBytes, proof := generateKeyAndProof(emailSubject, t)
+
+			resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+				Credentials: &protobuf.Credentials{
+					Credentials: &protobuf.Credentials_OidcIdentityToken{
+						OidcIdentityToken: tok,
+					},
+				},
+				Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+					PublicKeyRequest: &protobuf.PublicKeyRequest{
+						PublicKey: &protobuf.PublicKey{
+							Content: pubBytes,
+						},
+						ProofOfPossession: proof,
+					},
+				},
+			})
+			if revocations.calls != 1 {
+				t.Fatalf("expected revocations to be checked once, got %d", revocations.calls)
+			}
+			if test.WantErr {
+				if err == nil {
+					t.Fatal("expected issuance to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SigningCert() = %v", err)
+			}
+			verifyResponse(resp, eca, emailIssuer, t)
+		})
+	}
+}
+
+// Tests API with tokens from each of an issuer's accepted iss values
+func TestAPIWithAdditionalIssuerURLs(t *testing.T) {
+	newSigner, newIssuer := newOIDCIssuer(t)
+	oldSigner, oldIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig with one issuer accepting both iss values.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"AdditionalIssuerURLs": [%q],
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, newIssuer, newIssuer, oldIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+
+	client := protobuf.NewCAClient(conn)
+
+	emailSubject := "foo@example.com"
+
+	tests := []oidcTestContainer{
+		{
+			Signer: newSigner, Issuer: newIssuer, Subject: emailSubject, ExpectedSubject: emailSubject,
+		},
+		{
+			Signer: oldSigner, Issuer: oldIssuer, Subject: emailSubject, ExpectedSubject: emailSubject,
+		},
+	}
+	for _, c := range tests {
+		// Create an OIDC token using this issuer's signer.
+		tok, err := jwt.Signed(c.Signer).Claims(jwt.Claims{
+			Issuer:   c.Issuer,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+			Subject:  c.Subject,
+			Audience: jwt.Audience{"sigstore"},
+		}).Claims(customClaims{Email: c.Subject, EmailVerified: true}).CompactSerialize()
+		if err != nil {
+			t.Fatalf("CompactSerialize() = %v", err)
+		}
+
+		pubBytes, proof := generateKeyAndProof(c.Subject, t)
+
+		resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+			Credentials: &protobuf.Credentials{
+				Credentials: &protobuf.Credentials_OidcIdentityToken{
+					OidcIdentityToken: tok,
+				},
+			},
+			Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+				PublicKeyRequest: &protobuf.PublicKeyRequest{
+					PublicKey: &protobuf.PublicKey{
+						Content: pubBytes,
+					},
+					ProofOfPossession: proof,
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("SigningCert() for issuer %s = %v", c.Issuer, err)
+		}
+
+		// The issuer extension records the iss of the token
+		leafCert := verifyResponse(resp, eca, c.Issuer, t)
+		if len(leafCert.EmailAddresses) != 1 || leafCert.EmailAddresses[0] != c.ExpectedSubject {
+			t.Fatalf("subjects do not match: Expected %v, got %v", c.ExpectedSubject, leafCert.EmailAddresses)
+		}
+	}
+}
+
+// Tests API with a certificate profile selected in the request
+func TestAPIWithProfile(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that allows this issuer one of two profiles.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email",
+				"Profiles": ["document-signing"]
+			}
+		},
+		"Profiles": {
+			"document-signing": {
+				"ExtKeyUsages": ["1.3.6.1.5.5.7.3.4"],
+				"Validity": "5m"
+			},
+			"timestamping": {
+				"ExtKeyUsages": ["1.3.6.1.5.5.7.3.8"]
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+
+	// Create an OIDC token using this issuer's signer.
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+
+	client := protobuf.NewCAClient(conn)
+
+	request := func(profile string) (*protobuf.SigningCertificate, error) {
+		pubBytes, proof := generateKeyAndProof(emailSubject, t)
+		return client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+			Credentials: &protobuf.Credentials{
+				Credentials: &protobuf.Credentials_OidcIdentityToken{
+					OidcIdentityToken: tok,
+				},
+			},
+			Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+				PublicKeyRequest: &protobuf.PublicKeyRequest{
+					PublicKey: &protobuf.PublicKey{
+						Content: pubBytes,
+					},
+					ProofOfPossession: proof,
+				},
+			},
+			Profile: profile,
+		})
+	}
+
+	// Allowed profile
+	resp, err := request("document-signing")
+	if err != nil {
+		t.Fatalf("SigningCert() = %v", err)
+	}
+	block, _ := pem.Decode([]byte(resp.GetSignedCertificateEmbeddedSct().Chain.Certificates[0]))
+	leafCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse the received leaf cert: %v", err)
+	}
+	if len(leafCert.ExtKeyUsage) != 1 || leafCert.ExtKeyUsage[0] != x509.ExtKeyUsageEmailProtection {
+		t.Fatalf("expected email protection extended key usage, got %v", leafCert.ExtKeyUsage)
+	}
+	if leafCert.NotAfter.Sub(leafCert.NotBefore) != 5*time.Minute {
+		t.Fatalf("expected 5 minute lifetime, got %v", leafCert.NotAfter.Sub(leafCert.NotBefore))
+	}
+	if len(leafCert.EmailAddresses) != 1 || leafCert.EmailAddresses[0] != emailSubject {
+		t.Fatalf("subjects do not match: Expected %v, got %v", emailSubject, leafCert.EmailAddresses)
+	}
+
+	// Profiles that exist but are not allowed for the issuer, or don't exist
+	for _, profile := range []string{"timestamping", "unknown"} {
+		_, err = request(profile)
+		if err == nil || !strings.Contains(err.Error(), "The certificate profile requested is not allowed for this issuer") {
+			t.Fatalf("expected profile error for %s, got %v", profile, err)
+		}
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected invalid argument, got %v", status.Code(err))
+		}
+	}
+
+	// No profile issues the default code signing certificate
+	resp, err = request("")
+	if err != nil {
+		t.Fatalf("SigningCert() = %v", err)
+	}
+	verifyResponse(resp, eca, emailIssuer, t)
+}
+
+// Tests API with an SSH certificate requested alongside the X.509 certificate
+func TestAPIWithSSHCertificate(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+
+	// Create an OIDC token using this issuer's signer.
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	_, caKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caSigner, err := ssh.NewSignerFromKey(caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(client protobuf.CAClient) (*protobuf.SigningCertificate, error) {
+		pubBytes, proof := generateKeyAndProof(emailSubject, t)
+		return client.CreateSigningCertificate(context.Background(), &protobuf.CreateSigningCertificateRequest{
+			Credentials: &protobuf.Credentials{
+				Credentials: &protobuf.Credentials_OidcIdentityToken{
+					OidcIdentityToken: tok,
+				},
+			},
+			Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+				PublicKeyRequest: &protobuf.PublicKeyRequest{
+					PublicKey: &protobuf.PublicKey{
+						Content: pubBytes,
+					},
+					ProofOfPossession: proof,
+				},
+			},
+			SshCertificate: true,
+		})
+	}
+
+	t.Run("without SSH CA", func(t *testing.T) {
+		ctClient, eca := createCA(cfg, t)
+		server, conn := setupGRPCForTest(context.Background(), t, cfg, ctClient, eca)
+		defer func() {
+			server.Stop()
+			conn.Close()
+		}()
+
+		_, err := request(protobuf.NewCAClient(conn))
+		if err == nil || status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "SSH certificates are not supported by this server") {
+			t.Fatalf("expected SSH certificates to be unsupported, got %v", err)
+		}
+	})
+
+	t.Run("with SSH CA", func(t *testing.T) {
+		ctClient, eca := createCA(cfg, t)
+		server, conn := setupGRPCForTest(context.Background(), t, cfg, ctClient, eca, WithSSHCA(sshca.New(caSigner)))
+		defer func() {
+			server.Stop()
+			conn.Close()
+		}()
+
+		resp, err := request(protobuf.NewCAClient(conn))
+		if err != nil {
+			t.Fatalf("SigningCert() = %v", err)
+		}
+		leafCert := verifyResponse(resp, eca, emailIssuer, t)
+
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(resp.SshCertificate))
+		if err != nil {
+			t.Fatalf("failed to parse SSH certificate: %v", err)
+		}
+		sshCert, ok := pub.(*ssh.Certificate)
+		if !ok {
+			t.Fatalf("expected SSH certificate, got %T", pub)
+		}
+		if sshCert.CertType != ssh.UserCert {
+			t.Fatalf("expected user certificate, got type %d", sshCert.CertType)
+		}
+		if len(sshCert.ValidPrincipals) != 1 || sshCert.ValidPrincipals[0] != emailSubject {
+			t.Fatalf("principals do not match: Expected %v, got %v", emailSubject, sshCert.ValidPrincipals)
+		}
+		if sshCert.ValidAfter != uint64(leafCert.NotBefore.Unix()) || sshCert.ValidBefore != uint64(leafCert.NotAfter.Unix()) {
+			t.Fatalf("SSH certificate validity [%d, %d] does not match X.509 validity [%v, %v]",
+				sshCert.ValidAfter, sshCert.ValidBefore, leafCert.NotBefore, leafCert.NotAfter)
+		}
+		if !bytes.Equal(sshCert.Key.Marshal(), mustSSHPublicKey(t, leafCert.PublicKey).Marshal()) {
+			t.Fatal("SSH certificate key does not match X.509 certificate key")
+		}
+
+		checker := ssh.CertChecker{
+			IsUserAuthority: func(auth ssh.PublicKey) bool {
+				return bytes.Equal(auth.Marshal(), caSigner.PublicKey().Marshal())
+			},
+		}
+		if err := checker.CheckCert(emailSubject, sshCert); err != nil {
+			t.Fatalf("SSH certificate does not verify: %v", err)
+		}
+	})
+}
+
+func mustSSHPublicKey(t *testing.T, pub crypto.PublicKey) ssh.PublicKey {
+	t.Helper()
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// Tests API with an issuer that requires a nonce issued by the server
+func TestAPIWithNonce(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email",
+				"RequireNonce": true
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	request := func(nonce string) (*protobuf.SigningCertificate, error) {
+		// Create an OIDC token using this issuer's signer, with the nonce
+		// claim set if nonce isn't empty.
+		tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+			Issuer:   emailIssuer,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+			Subject:  emailSubject,
+			Audience: jwt.Audience{"sigstore"},
+		}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).Claims(struct {
+			Nonce string `json:"nonce,omitempty"`
+		}{nonce}).CompactSerialize()
+		if err != nil {
+			t.Fatalf("CompactSerialize() = %v", err)
+		}
+
+		pubBytes, proof := generateKeyAndProof(emailSubject, t)
+		return client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+			Credentials: &protobuf.Credentials{
+				Credentials: &protobuf.Credentials_OidcIdentityToken{
+					OidcIdentityToken: tok,
+				},
+			},
+			Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+				PublicKeyRequest: &protobuf.PublicKeyRequest{
+					PublicKey: &protobuf.PublicKey{
+						Content: pubBytes,
+					},
+					ProofOfPossession: proof,
+				},
+			},
+		})
+	}
+	expectInvalidNonce := func(t *testing.T, err error) {
+		t.Helper()
+		if err == nil || status.Code(err) != codes.Unauthenticated || !strings.Contains(err.Error(), invalidNonce) {
+			t.Fatalf("expected invalid nonce error, got %v", err)
+		}
+	}
+
+	t.Run("matching nonce", func(t *testing.T) {
+		nonce, err := client.CreateNonce(ctx, &protobuf.CreateNonceRequest{})
+		if err != nil {
+			t.Fatalf("CreateNonce() = %v", err)
+		}
+		if nonce.Nonce == "" {
+			t.Fatal("expected non-empty nonce")
+		}
+		if !nonce.Expires.AsTime().After(time.Now()) {
+			t.Fatalf("expected nonce to expire in the future, got %v", nonce.Expires.AsTime())
+		}
+
+		resp, err := request(nonce.Nonce)
+		if err != nil {
+			t.Fatalf("SigningCert() = %v", err)
+		}
+		verifyResponse(resp, eca, emailIssuer, t)
+
+		// Nonces are single use
+		_, err = request(nonce.Nonce)
+		expectInvalidNonce(t, err)
+	})
+
+	t.Run("missing nonce", func(t *testing.T) {
+		_, err := request("")
+		expectInvalidNonce(t, err)
+	})
+
+	t.Run("mismatched nonce", func(t *testing.T) {
+		if _, err := client.CreateNonce(ctx, &protobuf.CreateNonceRequest{}); err != nil {
+			t.Fatalf("CreateNonce() = %v", err)
+		}
+		_, err := request("not-a-server-issued-nonce")
+		expectInvalidNonce(t, err)
+	})
+}
+
+// Tests API limits the number of outstanding nonces
+func TestAPIWithNonceLimit(t *testing.T) {
+	cfg, err := config.Read([]byte(`{"OIDCIssuers": {}}`))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca, WithMaxNonces(2))
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.CreateNonce(ctx, &protobuf.CreateNonceRequest{}); err != nil {
+			t.Fatalf("CreateNonce() = %v", err)
+		}
+	}
+	_, err = client.CreateNonce(ctx, &protobuf.CreateNonceRequest{})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted beyond the limit, got %v", err)
+	}
+}
+
+// Tests API with an issuer in strict claims mode
+func TestAPIWithStrictClaims(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	emailSubject := "foo@example.com"
+
+	// Create an OIDC token using this issuer's signer, with an extra claim
+	// that isn't declared in the configuration.
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(map[string]interface{}{
+		"email":          emailSubject,
+		"email_verified": true,
+		"groups":         []string{"admins"},
+	}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	tests := map[string]struct {
+		issuerConfig string
+		wantErr      bool
+	}{
+		"lenient": {
+			issuerConfig: `"Type": "email"`,
+		},
+		"strict with claim allowed": {
+			issuerConfig: `"Type": "email", "StrictClaims": true, "AllowedClaims": ["email", "email_verified", "groups"]`,
+		},
+		"strict with unexpected claim": {
+			issuerConfig: `"Type": "email", "StrictClaims": true, "AllowedClaims": ["email", "email_verified"]`,
+			wantErr:      true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Create a FulcioConfig that supports this issuer.
+			cfg, err := config.Read([]byte(fmt.Sprintf(`{
+				"OIDCIssuers": {
+					%q: {
+						"IssuerURL": %q,
+						"ClientID": "sigstore",
+						%s
+					}
+				}
+			}`, emailIssuer, emailIssuer, test.issuerConfig)))
+			if err != nil {
+				t.Fatalf("config.Read() = %v", err)
+			}
+
+			ctClient, eca := createCA(cfg, t)
+			ctx := context.Background()
+			server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+			defer func() {
+				server.Stop()
+				conn.Close()
+			}()
+			client := protobuf.NewCAClient(conn)
+
+			pubBytes, proof := generateKeyAndProof(emailSubject, t)
+			resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+				Credentials: &protobuf.Credentials{
+					Credentials: &protobuf.Credentials_OidcIdentityToken{
+						OidcIdentityToken: tok,
+					},
+				},
+				Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+					PublicKeyRequest: &protobuf.PublicKeyRequest{
+						PublicKey: &protobuf.PublicKey{
+							Content: pubBytes,
+						},
+						ProofOfPossession: proof,
+					},
+				},
+			})
+			if test.wantErr {
+				if err == nil || status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), unexpectedClaims) {
+					t.Fatalf("expected unexpected claims error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SigningCert() = %v", err)
+			}
+			verifyResponse(resp, eca, emailIssuer, t)
+		})
+	}
+}
+
+// Tests API for username subject types
+func TestAPIWithUsername(t *testing.T) {
+	usernameSigner, usernameIssuer := newOIDCIssuer(t)
+
+	issuerDomain, err := url.Parse(usernameIssuer)
+	if err != nil {
+		t.Fatal("issuer URL could not be parsed", err)
+	}
+
+	// Create a FulcioConfig that supports these issuers.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"SubjectDomain": %q,
+				"Type": "username"
+			}
+		}
+	}`, usernameIssuer, usernameIssuer, issuerDomain.Hostname())))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	usernameSubject := "foo"
+	expectedUsernamedSubject := fmt.Sprintf("%s!%s", usernameSubject, issuerDomain.Hostname())
+
+	tests := []oidcTestContainer{
+		{
+			Signer: usernameSigner, Issuer: usernameIssuer, Subject: usernameSubject, ExpectedSubject: expectedUsernamedSubject,
+		},
+	}
+	for _, c := range tests {
+		// Create an OIDC token using this issuer's signer.
+		tok, err := jwt.Signed(c.Signer).Claims(jwt.Claims{
+			Issuer:   c.Issuer,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+			Subject:  c.Subject,
+			Audience: jwt.Audience{"sigstore"},
+		}).Claims(customClaims{Email: c.Subject, EmailVerified: true}).CompactSerialize()
+		if err != nil {
+			t.Fatalf("CompactSerialize() = %v", err)
+		}
+
+		ctClient, eca := createCA(cfg, t)
+		ctx := context.Background()
+		server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+		defer func() {
+			server.Stop()
+			conn.Close()
+		}()
+
+		client := protobuf.NewCAClient(conn)
+
+		pubBytes, proof := generateKeyAndProof(c.Subject, t)
+
+		// Hit the API to have it sign our certificate.
+		resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+			Credentials: &protobuf.Credentials{
+				Credentials: &protobuf.Credentials_OidcIdentityToken{
+					OidcIdentityToken: tok,
+				},
+			},
+			Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+				PublicKeyRequest: &protobuf.PublicKeyRequest{
+					PublicKey: &protobuf.PublicKey{
+						Content: pubBytes,
+					},
+					ProofOfPossession: proof,
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("SigningCert() = %v", err)
+		}
+
+		leafCert := verifyResponse(resp, eca, c.Issuer, t)
+
+		// Expect no email subject
+		if len(leafCert.EmailAddresses) != 0 {
+			t.Fatalf("unexpected length of leaf certificate URIs, expected 0, got %d", len(leafCert.URIs))
+		}
+		otherName, err := username.UnmarshalSANS(leafCert.Extensions)
+		if err != nil {
+			t.Fatalf("error unmarshalling SANs: %v", err)
+		}
+		if otherName != c.ExpectedSubject {
+			t.Fatalf("subjects do not match: Expected %v, got %v", c.ExpectedSubject, otherName)
+		}
+	}
+}
+
+// Tests the API refuses username OtherNames outside the name constraints of
+// the issuing CA
+func TestAPIWithUsernameNameConstraints(t *testing.T) {
+	usernameSigner, usernameIssuer := newOIDCIssuerWithHostname(t, "localhost")
+
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"SubjectDomain": "localhost",
+				"Type": "username"
+			}
+		}
+	}`, usernameIssuer, usernameIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	tests := map[string]struct {
+		permitted []string
+		wantCode  codes.Code
+	}{
+		"permitted hostname": {
+			permitted: []string{"localhost"},
+			wantCode:  codes.OK,
+		},
+		"hostname outside permitted subtrees": {
+			permitted: []string{"corp.com"},
+			wantCode:  codes.InvalidArgument,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tok, err := jwt.Signed(usernameSigner).Claims(jwt.Claims{
+				Issuer:   usernameIssuer,
+				IssuedAt: jwt.NewNumericDate(time.Now()),
+				Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+				Subject:  "foo",
+				Audience: jwt.Audience{"sigstore"},
+			}).CompactSerialize()
+			if err != nil {
+				t.Fatalf("CompactSerialize() = %v", err)
+			}
+
+			ctClient, _ := createCA(cfg, t)
+			rootCert, rootKey, _ := test.GenerateRootCA()
+			subCert, subKey, err := test.GenerateConstrainedSubordinateCA(rootCert, rootKey, tc.permitted, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			eca := &ephemeralca.EphemeralCA{BaseCA: baseca.BaseCA{
+				SignerWithChain: &ca.SignerCerts{Certs: []*x509.Certificate{subCert, rootCert}, Signer: subKey},
+			}}
+			ctx := context.Background()
+			server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+			defer func() {
+				server.Stop()
+				conn.Close()
+			}()
+
+			client := protobuf.NewCAClient(conn)
+			pubBytes, proof := generateKeyAndProof("foo", t)
+			resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+				Credentials: &protobuf.Credentials{
+					Credentials: &protobuf.Credentials_OidcIdentityToken{
+						OidcIdentityToken: tok,
+					},
+				},
+				Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+					PublicKeyRequest: &protobuf.PublicKeyRequest{
+						PublicKey: &protobuf.PublicKey{
+							Content: pubBytes,
+						},
+						ProofOfPossession: proof,
+					},
+				},
+			})
+			if status.Code(err) != tc.wantCode {
+				t.Fatalf("SigningCert() = %v, want code %v", err, tc.wantCode)
+			}
+			if err != nil {
+				return
+			}
 
-		// Expect no email subject
-		if len(leafCert.EmailAddresses) != 0 {
-			t.Fatalf("unexpected length of leaf certificate URIs, expected 0, got %d", len(leafCert.URIs))
-		}
-		otherName, err := username.UnmarshalSANS(leafCert.Extensions)
-		if err != nil {
-			t.Fatalf("error unmarshalling SANs: %v", err)
-		}
-		if otherName != c.ExpectedSubject {
-			t.Fatalf("subjects do not match: Expected %v, got %v", c.ExpectedSubject, otherName)
-		}
+			block, _ := pem.Decode([]byte(resp.GetSignedCertificateEmbeddedSct().GetChain().GetCertificates()[0]))
+			if block == nil {
+				t.Fatal("missing PEM data")
+			}
+			leafCert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if otherName, err := username.UnmarshalSANS(leafCert.Extensions); err != nil || otherName != "foo!localhost" {
+				t.Fatalf("UnmarshalSANS() = %q, %v", otherName, err)
+			}
+			// The leaf is signed by the constrained CA
+			if err := leafCert.CheckSignatureFrom(subCert); err != nil {
+				t.Fatalf("CheckSignatureFrom() = %v", err)
+			}
+		})
 	}
 }
 
@@ -695,6 +2788,7 @@ func TestAPIWithGitHub(t *testing.T) {
 
 	// Create a FulcioConfig that supports these issuers.
 	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"ExtensionOIDArc": "1.3.6.1.4.1.32473.1",
 		"OIDCIssuers": {
 			%q: {
 				"IssuerURL": %q,
@@ -810,6 +2904,108 @@ func TestAPIWithGitHub(t *testing.T) {
 	if string(refExt.Value) != claims.Ref {
 		t.Fatalf("unexpected ref, expected %s, got %s", claims.Ref, string(refExt.Value))
 	}
+	// Workflow identities are workloads
+	classExt, found := findCustomExtension(leafCert, testExtensionArc.OID(certificate.ArcIdentityClass))
+	if !found {
+		t.Fatal("expected identity class in custom OID")
+	}
+	if string(classExt.Value) != certificate.IdentityClassMachine {
+		t.Fatalf("unexpected identity class, expected %s, got %s", certificate.IdentityClassMachine, string(classExt.Value))
+	}
+}
+
+// Tests API for GitLab CI pipeline subject types
+func TestAPIWithGitLab(t *testing.T) {
+	gitlabSigner, gitlabIssuer := newOIDCIssuer(t)
+
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"ExtensionOIDArc": "1.3.6.1.4.1.32473.1",
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "gitlab-pipeline"
+			}
+		}
+	}`, gitlabIssuer, gitlabIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	gitlabSubject := "project_path:my-group/my-project:ref_type:branch:ref:main"
+	claims := map[string]interface{}{
+		"project_path":      "my-group/my-project",
+		"ci_config_ref_uri": "gitlab.example.com/my-group/my-project//.gitlab-ci.yml@refs/heads/main",
+		"pipeline_id":       "1212",
+		"runner_id":         5656,
+	}
+
+	tok, err := jwt.Signed(gitlabSigner).Claims(jwt.Claims{
+		Issuer:   gitlabIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  gitlabSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	pubBytes, proof := generateKeyAndProof(gitlabSubject, t)
+	resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+		Credentials: &protobuf.Credentials{
+			Credentials: &protobuf.Credentials_OidcIdentityToken{
+				OidcIdentityToken: tok,
+			},
+		},
+		Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+			PublicKeyRequest: &protobuf.PublicKeyRequest{
+				PublicKey: &protobuf.PublicKey{
+					Content: pubBytes,
+				},
+				ProofOfPossession: proof,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SigningCert() = %v", err)
+	}
+
+	leafCert := verifyResponse(resp, eca, gitlabIssuer, t)
+
+	wantURI := "https://gitlab.example.com/my-group/my-project//.gitlab-ci.yml@refs/heads/main"
+	if len(leafCert.URIs) != 1 || leafCert.URIs[0].String() != wantURI {
+		t.Fatalf("expected SAN URI %s, got %v", wantURI, leafCert.URIs)
+	}
+	for oid, want := range map[string]string{
+		testExtensionArc.OID(certificate.ArcGitLabProjectPath).String():    "my-group/my-project",
+		testExtensionArc.OID(certificate.ArcGitLabCIConfigRefURI).String(): "gitlab.example.com/my-group/my-project//.gitlab-ci.yml@refs/heads/main",
+		testExtensionArc.OID(certificate.ArcGitLabPipelineID).String():     "1212",
+		testExtensionArc.OID(certificate.ArcGitLabRunnerID).String():       "5656",
+		testExtensionArc.OID(certificate.ArcIdentityClass).String():        certificate.IdentityClassMachine,
+	} {
+		var found bool
+		for _, ext := range leafCert.Extensions {
+			if ext.Id.String() == oid {
+				found = true
+				if string(ext.Value) != want {
+					t.Errorf("unexpected value for OID %s, expected %s, got %s", oid, want, ext.Value)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("expected extension with OID %s", oid)
+		}
+	}
 }
 
 // Tests API with issuer claim in different field in the OIDC token
@@ -972,6 +3168,134 @@ func TestAPIWithCSRChallenge(t *testing.T) {
 	}
 }
 
+// Tests API with the token in the challengePassword attribute of a CSR
+func TestAPIWithCSRChallengePasswordToken(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+
+	// Create an OIDC token using this issuer's signer.
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating private key: %v", err)
+	}
+	pemCSR := pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE REQUEST",
+		Bytes: createCSRWithChallengePassword(t, priv, tok),
+	})
+	request := func(client protobuf.CAClient) (*protobuf.SigningCertificate, error) {
+		return client.CreateSigningCertificate(context.Background(), &protobuf.CreateSigningCertificateRequest{
+			Key: &protobuf.CreateSigningCertificateRequest_CertificateSigningRequest{
+				CertificateSigningRequest: pemCSR,
+			},
+		})
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		ctClient, eca := createCA(cfg, t)
+		server, conn := setupGRPCForTest(context.Background(), t, cfg, ctClient, eca, WithCSRChallengePasswordToken())
+		defer func() {
+			server.Stop()
+			conn.Close()
+		}()
+
+		resp, err := request(protobuf.NewCAClient(conn))
+		if err != nil {
+			t.Fatalf("SigningCert() = %v", err)
+		}
+		leafCert := verifyResponse(resp, eca, emailIssuer, t)
+		if len(leafCert.EmailAddresses) != 1 || leafCert.EmailAddresses[0] != emailSubject {
+			t.Fatalf("subjects do not match: Expected %v, got %v", emailSubject, leafCert.EmailAddresses)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		ctClient, eca := createCA(cfg, t)
+		server, conn := setupGRPCForTest(context.Background(), t, cfg, ctClient, eca)
+		defer func() {
+			server.Stop()
+			conn.Close()
+		}()
+
+		if _, err := request(protobuf.NewCAClient(conn)); status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("expected unauthenticated without a token, got %v", err)
+		}
+	})
+}
+
+// createCSRWithChallengePassword returns a DER encoded CSR with a
+// challengePassword attribute, which crypto/x509 can't create.
+func createCSRWithChallengePassword(t *testing.T, priv *ecdsa.PrivateKey, password string) []byte {
+	t.Helper()
+	base, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "test"}}, priv)
+	if err != nil {
+		t.Fatalf("error creating CSR: %v", err)
+	}
+	baseCSR, err := x509.ParseCertificateRequest(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attr, err := asn1.Marshal(csrAttribute{
+		Type:   oidChallengePassword,
+		Values: []asn1.RawValue{{Tag: asn1.TagUTF8String, Bytes: []byte(password)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbs, err := asn1.Marshal(tbsCertificateRequest{
+		Subject:       asn1.RawValue{FullBytes: baseCSR.RawSubject},
+		PublicKey:     asn1.RawValue{FullBytes: baseCSR.RawSubjectPublicKeyInfo},
+		RawAttributes: []asn1.RawValue{{FullBytes: attr}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(tbs)
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := asn1.Marshal(struct {
+		TBS       asn1.RawValue
+		Algorithm pkix.AlgorithmIdentifier
+		Signature asn1.BitString
+	}{
+		TBS:       asn1.RawValue{FullBytes: tbs},
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+		Signature: asn1.BitString{Bytes: sig, BitLength: len(sig) * 8},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
 // Tests API with insecure pub key
 func TestAPIWithInsecurePublicKey(t *testing.T) {
 	emailSigner, emailIssuer := newOIDCIssuer(t)
@@ -1330,9 +3654,156 @@ func TestAPIWithInvalidCSRSignature(t *testing.T) {
 	}
 }
 
+// Tests API for verifying a certificate against the trust bundle
+func TestVerifySigningCertificate(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	// Create a FulcioConfig that supports this issuer.
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+
+	// Create an OIDC token using this issuer's signer.
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca)
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+
+	client := protobuf.NewCAClient(conn)
+
+	pubBytes, proof := generateKeyAndProof(emailSubject, t)
+
+	resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+		Credentials: &protobuf.Credentials{
+			Credentials: &protobuf.Credentials_OidcIdentityToken{
+				OidcIdentityToken: tok,
+			},
+		},
+		Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+			PublicKeyRequest: &protobuf.PublicKeyRequest{
+				PublicKey: &protobuf.PublicKey{
+					Content: pubBytes,
+				},
+				ProofOfPossession: proof,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SigningCert() = %v", err)
+	}
+	leafCert := verifyResponse(resp, eca, emailIssuer, t)
+
+	// A just-issued certificate chains to the trust bundle
+	issued := resp.GetSignedCertificateEmbeddedSct().Chain.Certificates[0]
+	verification, err := client.VerifySigningCertificate(ctx, &protobuf.VerifySigningCertificateRequest{
+		Certificate: issued,
+	})
+	if err != nil {
+		t.Fatalf("VerifySigningCertificate() = %v", err)
+	}
+	if !verification.Trusted {
+		t.Fatalf("expected issued certificate to be trusted, got error %q", verification.Error)
+	}
+	if verification.Error != "" {
+		t.Fatalf("expected no error, got %q", verification.Error)
+	}
+	if verification.Expired {
+		t.Fatal("expected issued certificate to be within its validity period")
+	}
+	if !verification.NotBefore.AsTime().Equal(leafCert.NotBefore) || !verification.NotAfter.AsTime().Equal(leafCert.NotAfter) {
+		t.Fatalf("unexpected validity period, got %v to %v", verification.NotBefore.AsTime(), verification.NotAfter.AsTime())
+	}
+	if verification.Extensions.GetIssuer() != emailIssuer {
+		t.Fatalf("unexpected issuer, expected %s, got %s", emailIssuer, verification.Extensions.GetIssuer())
+	}
+
+	// A certificate from a foreign root does not
+	rootCert, rootKey, err := test.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA() = %v", err)
+	}
+	foreignCert, _, err := test.GenerateLeafCert(emailSubject, "https://foreign.example.com", rootCert, rootKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCert() = %v", err)
+	}
+	foreignPEM, err := cryptoutils.MarshalCertificateToPEM(foreignCert)
+	if err != nil {
+		t.Fatalf("MarshalCertificateToPEM() = %v", err)
+	}
+	verification, err = client.VerifySigningCertificate(ctx, &protobuf.VerifySigningCertificateRequest{
+		Certificate: string(foreignPEM),
+	})
+	if err != nil {
+		t.Fatalf("VerifySigningCertificate() = %v", err)
+	}
+	if verification.Trusted {
+		t.Fatal("expected certificate from a foreign root to be untrusted")
+	}
+	if verification.Error == "" {
+		t.Fatal("expected an error describing why the certificate is untrusted")
+	}
+	if verification.Extensions.GetIssuer() != "https://foreign.example.com" {
+		t.Fatalf("unexpected issuer, got %s", verification.Extensions.GetIssuer())
+	}
+
+	// Including the foreign root as an intermediate must not make it trusted
+	verification, err = client.VerifySigningCertificate(ctx, &protobuf.VerifySigningCertificateRequest{
+		Certificate: string(foreignPEM) + string(cryptoutils.PEMEncode(cryptoutils.CertificatePEMType, rootCert.Raw)),
+	})
+	if err != nil {
+		t.Fatalf("VerifySigningCertificate() = %v", err)
+	}
+	if verification.Trusted {
+		t.Fatal("expected certificate chaining to a supplied root to be untrusted")
+	}
+
+	// Invalid PEM is rejected
+	_, err = client.VerifySigningCertificate(ctx, &protobuf.VerifySigningCertificateRequest{
+		Certificate: "invalid",
+	})
+	if err == nil || !strings.Contains(err.Error(), "The certificate supplied in the request could not be parsed") {
+		t.Fatalf("expected invalid certificate error, got %v", err)
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected invalid argument, got %v", status.Code(err))
+	}
+}
+
 // Stand up a very simple OIDC endpoint.
 func newOIDCIssuer(t *testing.T) (jose.Signer, string) {
 	t.Helper()
+	return newOIDCIssuerWithHostname(t, "")
+}
+
+// newOIDCIssuerWithHostname is like newOIDCIssuer, but the issuer URL has the
+// hostname hostname, which must resolve to the loopback address, if set.
+func newOIDCIssuerWithHostname(t *testing.T, hostname string) (jose.Signer, string) {
+	t.Helper()
 
 	pk, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
@@ -1382,7 +3853,11 @@ func newOIDCIssuer(t *testing.T) (jose.Signer, string) {
 	t.Cleanup(oidcServer.Close)
 
 	// Setup the testIssuer, so everything uses the right URL.
-	testIssuer = &oidcServer.URL
+	issuerURL := oidcServer.URL
+	if hostname != "" {
+		issuerURL = strings.Replace(issuerURL, "127.0.0.1", hostname, 1)
+	}
+	testIssuer = &issuerURL
 
 	return signer, *testIssuer
 }
@@ -16,17 +16,41 @@
 package server
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sigstore/fulcio/pkg/identity/username"
+	"google.golang.org/grpc/status"
 	"sigs.k8s.io/release-utils/version"
 )
 
 var (
-	metricNewEntries = promauto.NewCounter(prometheus.CounterOpts{
+	metricNewEntries = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "fulcio_new_certs",
 		Help: "The total number of certificates generated",
+	}, []string{"issuer"})
+
+	metricNewEntryFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fulcio_new_certs_failures",
+		Help: "The total number of failed certificate requests",
+	}, []string{"code"})
+
+	metricSerialCollisions = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "fulcio_serial_collisions",
+		Help: "The total number of certificate serial number collisions reported by the CA",
+	})
+
+	metricSigningQueueWait = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "fulcio_signing_queue_wait_seconds",
+		Help: "Time requests waited for a slot under the signing concurrency limit",
 	})
 
+	metricSANFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fulcio_san_failures",
+		Help: "The total number of failures to marshal or unmarshal username SANs, by reason",
+	}, []string{"operation", "reason"})
+
 	MetricLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Name: "fulcio_api_latency",
 		Help: "API Latency on calls",
@@ -52,3 +76,35 @@ var (
 		func() float64 { return 1 },
 	)
 )
+
+// recordIssued records a certificate issued for a token from issuer.
+func (g *grpcCAServer) recordIssued(issuer string) {
+	metricNewEntries.WithLabelValues(issuer).Inc()
+	if g.statsd != nil {
+		g.statsd.Count("new_certs", 1, map[string]string{"issuer": issuer})
+	}
+}
+
+// recordIssuance records the outcome and latency of a certificate request
+// that started at start.
+func (g *grpcCAServer) recordIssuance(start time.Time, err error) {
+	code := status.Code(err).String()
+	if err != nil {
+		metricNewEntryFailures.WithLabelValues(code).Inc()
+	}
+	if g.statsd == nil {
+		return
+	}
+	if err != nil {
+		g.statsd.Count("new_certs_failures", 1, map[string]string{"code": code})
+	}
+	g.statsd.Timing("new_cert_latency", time.Since(start), map[string]string{"code": code})
+}
+
+// SANFailureObserver counts the failures of the username package in
+// Prometheus, for username.SetObserver.
+type SANFailureObserver struct{}
+
+func (SANFailureObserver) ObserveFailure(op username.Operation, reason username.FailureReason) {
+	metricSANFailures.WithLabelValues(string(op), string(reason)).Inc()
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultNonceTTL is how long a nonce issued by CreateNonce may be used
+	// for.
+	DefaultNonceTTL = 10 * time.Minute
+
+	// DefaultMaxNonces is the default number of nonces that may be
+	// outstanding at once.
+	DefaultMaxNonces = 100000
+)
+
+// errTooManyNonces is returned when the maximum number of nonces are
+// outstanding.
+var errTooManyNonces = errors.New("too many outstanding nonces")
+
+// nonceStore tracks the nonces issued by this server until they are used or
+// expire. Nonces are single use. The store is in memory, so a nonce is only
+// accepted by the instance that issued it. Nonces are issued to
+// unauthenticated callers, so the number outstanding is limited, and expired
+// nonces are removed in the background rather than by callers.
+type nonceStore struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	max    int
+	nonces map[string]time.Time
+	now    func() time.Time
+
+	pruning sync.Once
+}
+
+func newNonceStore(ttl time.Duration, max int) *nonceStore {
+	return &nonceStore{
+		ttl:    ttl,
+		max:    max,
+		nonces: make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+// issue returns a new random nonce and the time it expires, or
+// errTooManyNonces if the maximum number of nonces are outstanding.
+func (s *nonceStore) issue() (string, time.Time, error) {
+	s.pruning.Do(func() {
+		go s.pruneEvery(s.ttl)
+	})
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.max > 0 && len(s.nonces) >= s.max {
+		return "", time.Time{}, errTooManyNonces
+	}
+	expires := s.now().Add(s.ttl)
+	s.nonces[nonce] = expires
+	return nonce, expires, nil
+}
+
+// consume reports whether nonce was issued by this store and has not expired,
+// and removes it so that it can't be used again.
+func (s *nonceStore) consume(nonce string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expires, ok := s.nonces[nonce]
+	if !ok {
+		return false
+	}
+	delete(s.nonces, nonce)
+	return s.now().Before(expires)
+}
+
+// pruneEvery removes expired nonces every interval, so that a nonce is held
+// for at most twice the TTL.
+func (s *nonceStore) pruneEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.prune()
+	}
+}
+
+func (s *nonceStore) prune() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for nonce, expires := range s.nonces {
+		if !now.Before(expires) {
+			delete(s.nonces, nonce)
+		}
+	}
+}
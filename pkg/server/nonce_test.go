@@ -0,0 +1,56 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNonceStoreLimit(t *testing.T) {
+	now := time.Now()
+	s := newNonceStore(time.Minute, 2)
+	s.now = func() time.Time { return now }
+
+	first, _, err := s.issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.issue(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.issue(); !errors.Is(err, errTooManyNonces) {
+		t.Fatalf("expected errTooManyNonces at the limit, got %v", err)
+	}
+
+	// Using a nonce frees its slot
+	if !s.consume(first) {
+		t.Fatal("expected nonce to be consumed")
+	}
+	if _, _, err := s.issue(); err != nil {
+		t.Fatalf("expected a nonce after one was used, got %v", err)
+	}
+
+	// Expired nonces keep their slots until they are pruned
+	now = now.Add(time.Minute)
+	if _, _, err := s.issue(); !errors.Is(err, errTooManyNonces) {
+		t.Fatalf("expected errTooManyNonces before pruning, got %v", err)
+	}
+	s.prune()
+	if _, _, err := s.issue(); err != nil {
+		t.Fatalf("expected a nonce after pruning, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	certauth "github.com/sigstore/fulcio/pkg/ca"
+	"github.com/sigstore/fulcio/pkg/log"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// OCSPPath is the path of the OCSP responder. Requests are POSTed to it,
+	// or sent with GET to OCSPPath/<base64 request>, as in RFC 6960.
+	OCSPPath = "/api/v2/ocsp"
+
+	// ocspResponseValidity is how long OCSP responses may be cached. Issued
+	// certificates are short lived, so revocations must propagate quickly.
+	ocspResponseValidity = 5 * time.Minute
+
+	maxOCSPRequestSize = 10 * 1024
+)
+
+// NewOCSPHandler returns a minimal OCSP responder for the certificates issued
+// by ca, signed directly by the CA key. Certificates in any of revocations
+// are reported as revoked, and all other serial numbers of the CA as good,
+// since issued certificates aren't recorded.
+func NewOCSPHandler(ca certauth.SignerWithChain, revocations ...RevocationSource) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var der []byte
+		switch r.Method {
+		case http.MethodGet:
+			encoded := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, OCSPPath), "/")
+			// The request may or may not have been URL escaped
+			if unescaped, err := url.PathUnescape(encoded); err == nil {
+				encoded = unescaped
+			}
+			var err error
+			if der, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+				writeOCSPResponse(w, ocsp.MalformedRequestErrorResponse)
+				return
+			}
+		case http.MethodPost:
+			var err error
+			if der, err = io.ReadAll(io.LimitReader(r.Body, maxOCSPRequestSize)); err != nil {
+				writeOCSPResponse(w, ocsp.MalformedRequestErrorResponse)
+				return
+			}
+		default:
+			w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		resp, err := ocspResponse(ca, revocations, der, time.Now())
+		if err != nil {
+			log.ContextLogger(r.Context()).Errorw("creating OCSP response", "error", err)
+			writeOCSPResponse(w, ocsp.InternalErrorErrorResponse)
+			return
+		}
+		writeOCSPResponse(w, resp)
+	})
+}
+
+func ocspResponse(ca certauth.SignerWithChain, revocations []RevocationSource, der []byte, now time.Time) ([]byte, error) {
+	req, err := ocsp.ParseRequest(der)
+	if err != nil {
+		return ocsp.MalformedRequestErrorResponse, nil
+	}
+
+	chain, signer := ca.GetSignerWithChain()
+	issuer := chain[0]
+	if ok, err := issuedBy(req, issuer); err != nil || !ok {
+		// We can only answer for our own certificates
+		return ocsp.UnauthorizedErrorResponse, nil
+	}
+
+	template := ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: req.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(ocspResponseValidity),
+	}
+	rc, revoked, err := revokedIn(revocations, req.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		template.Status = ocsp.Revoked
+		template.RevokedAt = rc.RevokedAt
+		template.RevocationReason = rc.Reason
+	}
+	return ocsp.CreateResponse(issuer, issuer, template, signer)
+}
+
+// issuedBy checks that the issuer name and key hashes of req identify cert.
+func issuedBy(req *ocsp.Request, cert *x509.Certificate) (bool, error) {
+	if !req.HashAlgorithm.Available() {
+		return false, fmt.Errorf("unsupported hash algorithm %v", req.HashAlgorithm)
+	}
+	var spki struct {
+		Algorithm asn1.RawValue
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return false, err
+	}
+	return bytes.Equal(hash(req.HashAlgorithm, cert.RawSubject), req.IssuerNameHash) &&
+		bytes.Equal(hash(req.HashAlgorithm, spki.PublicKey.RightAlign()), req.IssuerKeyHash), nil
+}
+
+func hash(h crypto.Hash, data []byte) []byte {
+	hh := h.New()
+	hh.Write(data)
+	return hh.Sum(nil)
+}
+
+func writeOCSPResponse(w http.ResponseWriter, resp []byte) {
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	_, _ = w.Write(resp)
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/ca/ephemeralca"
+	"github.com/sigstore/fulcio/pkg/config"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestOCSPHandler(t *testing.T) {
+	eca, err := ephemeralca.NewEphemeralCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, _ := eca.GetSignerWithChain()
+	issuer := chain[0]
+
+	revokedAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	cfg, err := config.Read([]byte(`{
+		"Revocations": [
+			{"SerialNumber": "2a", "RevokedAt": "2022-10-01T12:00:00Z", "Reason": 1}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	srv := httptest.NewServer(NewOCSPHandler(eca, cfg))
+	t.Cleanup(srv.Close)
+
+	request := func(serial int64, issuer *x509.Certificate) []byte {
+		t.Helper()
+		req, err := ocsp.CreateRequest(&x509.Certificate{SerialNumber: big.NewInt(serial)}, issuer, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+	post := func(req []byte) []byte {
+		t.Helper()
+		resp, err := http.Post(srv.URL+OCSPPath, "application/ocsp-request", bytes.NewReader(req))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if ct := resp.Header.Get("Content-Type"); ct != "application/ocsp-response" {
+			t.Fatalf("unexpected content type %q", ct)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+	get := func(req []byte) []byte {
+		t.Helper()
+		resp, err := http.Get(srv.URL + OCSPPath + "/" + url.PathEscape(base64.StdEncoding.EncodeToString(req)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	for name, send := range map[string]func([]byte) []byte{"POST": post, "GET": get} {
+		t.Run(name, func(t *testing.T) {
+			// An unrevoked serial is good
+			resp, err := ocsp.ParseResponseForCert(send(request(1, issuer)), &x509.Certificate{SerialNumber: big.NewInt(1)}, issuer)
+			if err != nil {
+				t.Fatalf("ParseResponse() = %v", err)
+			}
+			if resp.Status != ocsp.Good {
+				t.Fatalf("expected good status, got %d", resp.Status)
+			}
+			if !resp.NextUpdate.After(resp.ThisUpdate) {
+				t.Fatalf("expected next update after this update, got %v and %v", resp.NextUpdate, resp.ThisUpdate)
+			}
+
+			// A revoked serial is revoked, with the configured time and reason
+			resp, err = ocsp.ParseResponseForCert(send(request(42, issuer)), &x509.Certificate{SerialNumber: big.NewInt(42)}, issuer)
+			if err != nil {
+				t.Fatalf("ParseResponse() = %v", err)
+			}
+			if resp.Status != ocsp.Revoked {
+				t.Fatalf("expected revoked status, got %d", resp.Status)
+			}
+			if !resp.RevokedAt.Equal(revokedAt) || resp.RevocationReason != ocsp.KeyCompromise {
+				t.Fatalf("unexpected revocation at %v for reason %d", resp.RevokedAt, resp.RevocationReason)
+			}
+		})
+	}
+
+	// Requests about other CAs' certificates are unauthorized
+	other, err := ephemeralca.NewEphemeralCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherChain, _ := other.GetSignerWithChain()
+	if _, err := ocsp.ParseResponse(post(request(1, otherChain[0])), nil); err != (ocsp.ResponseError{Status: ocsp.Unauthorized}) {
+		t.Fatalf("expected unauthorized, got %v", err)
+	}
+
+	// Garbage is malformed
+	if _, err := ocsp.ParseResponse(post([]byte("garbage")), nil); err != (ocsp.ResponseError{Status: ocsp.Malformed}) {
+		t.Fatalf("expected malformed, got %v", err)
+	}
+}
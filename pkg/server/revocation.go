@@ -0,0 +1,160 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/config"
+)
+
+// RevocationSource lists revoked certificates. Both the Revocations of a
+// FulcioConfig and a RevocationList are sources.
+type RevocationSource interface {
+	RevokedCertificates() ([]config.RevokedCertificate, error)
+}
+
+// revokedIn returns the revocation of serial in any of sources.
+func revokedIn(sources []RevocationSource, serial *big.Int) (config.RevokedCertificate, bool, error) {
+	for _, src := range sources {
+		revoked, err := src.RevokedCertificates()
+		if err != nil {
+			return config.RevokedCertificate{}, false, err
+		}
+		for _, rc := range revoked {
+			if rc.SerialNumber.Cmp(serial) == 0 {
+				return rc, true, nil
+			}
+		}
+	}
+	return config.RevokedCertificate{}, false, nil
+}
+
+// allRevokedIn returns the revocations of all sources, without duplicates.
+func allRevokedIn(sources []RevocationSource) ([]config.RevokedCertificate, error) {
+	var all []config.RevokedCertificate
+	seen := make(map[string]bool)
+	for _, src := range sources {
+		revoked, err := src.RevokedCertificates()
+		if err != nil {
+			return nil, err
+		}
+		for _, rc := range revoked {
+			if key := rc.SerialNumber.String(); !seen[key] {
+				seen[key] = true
+				all = append(all, rc)
+			}
+		}
+	}
+	return all, nil
+}
+
+// RevocationList is a revocation list persisted to a local JSON file, which
+// operators add to with the admin revoke endpoint. The file holds a list of
+// revocations in the format of the Revocations configuration.
+type RevocationList struct {
+	path string
+
+	mu          sync.RWMutex
+	revocations []config.Revocation
+}
+
+// LoadRevocationList reads the revocation list at path. The file is created
+// when the first certificate is revoked if it doesn't exist.
+func LoadRevocationList(path string) (*RevocationList, error) {
+	l := &RevocationList{path: path}
+	b, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &l.revocations); err != nil {
+		return nil, fmt.Errorf("parsing revocation list %s: %w", path, err)
+	}
+	if _, err := l.RevokedCertificates(); err != nil {
+		return nil, fmt.Errorf("revocation list %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// RevokedCertificates returns the parsed revocations in the list.
+func (l *RevocationList) RevokedCertificates() ([]config.RevokedCertificate, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	cfg := config.FulcioConfig{Revocations: l.revocations}
+	return cfg.RevokedCertificates()
+}
+
+// Revoke adds the certificate with the given serial number to the list and
+// persists it. Revoking a certificate again is a no-op.
+func (l *RevocationList) Revoke(serial *big.Int, reason int, at time.Time) error {
+	r := config.Revocation{
+		SerialNumber: serial.Text(16),
+		RevokedAt:    at.UTC().Format(time.RFC3339),
+		Reason:       reason,
+	}
+	if _, err := (&config.FulcioConfig{Revocations: []config.Revocation{r}}).RevokedCertificates(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cfg := config.FulcioConfig{Revocations: l.revocations}
+	if _, revoked, err := cfg.Revoked(serial); err != nil || revoked {
+		return err
+	}
+	revocations := append(append([]config.Revocation{}, l.revocations...), r)
+	if err := writeFileAtomic(l.path, revocations); err != nil {
+		return fmt.Errorf("persisting revocation list: %w", err)
+	}
+	l.revocations = revocations
+	return nil
+}
+
+// writeFileAtomic writes v as JSON to a temporary file beside path and renames
+// it into place, so that readers never see a partially written list.
+func writeFileAtomic(path string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,145 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"crypto/x509"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/ca/ephemeralca"
+	"github.com/sigstore/fulcio/pkg/config"
+)
+
+func TestRevocationList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "revocations.json")
+
+	// A missing list is empty
+	l, err := LoadRevocationList(path)
+	if err != nil {
+		t.Fatalf("LoadRevocationList() = %v", err)
+	}
+	if revoked, err := l.RevokedCertificates(); err != nil || len(revoked) != 0 {
+		t.Fatalf("expected no revocations, got %v, %v", revoked, err)
+	}
+
+	at := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	if err := l.Revoke(big.NewInt(42), 1, at); err != nil {
+		t.Fatalf("Revoke() = %v", err)
+	}
+	// Revoking again keeps the original revocation
+	if err := l.Revoke(big.NewInt(42), 4, at.Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke() = %v", err)
+	}
+
+	// Revocations are persisted
+	l, err = LoadRevocationList(path)
+	if err != nil {
+		t.Fatalf("LoadRevocationList() = %v", err)
+	}
+	revoked, err := l.RevokedCertificates()
+	if err != nil {
+		t.Fatalf("RevokedCertificates() = %v", err)
+	}
+	if len(revoked) != 1 {
+		t.Fatalf("expected one revocation, got %v", revoked)
+	}
+	if rc := revoked[0]; rc.SerialNumber.Int64() != 42 || !rc.RevokedAt.Equal(at) || rc.Reason != 1 {
+		t.Fatalf("unexpected revocation %v", rc)
+	}
+
+	// Invalid reasons are rejected
+	if err := l.Revoke(big.NewInt(43), 7, at); err == nil {
+		t.Fatal("expected error for unused reason code")
+	}
+}
+
+func TestCRLHandler(t *testing.T) {
+	eca, err := ephemeralca.NewEphemeralCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, _ := eca.GetSignerWithChain()
+
+	cfg, err := config.Read([]byte(`{
+		"Revocations": [
+			{"SerialNumber": "2a", "RevokedAt": "2022-10-01T12:00:00Z", "Reason": 1}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+	l, err := LoadRevocationList(filepath.Join(t.TempDir(), "revocations.json"))
+	if err != nil {
+		t.Fatalf("LoadRevocationList() = %v", err)
+	}
+	// Duplicates between sources are listed once
+	if err := l.Revoke(big.NewInt(42), 0, time.Now()); err != nil {
+		t.Fatalf("Revoke() = %v", err)
+	}
+	if err := l.Revoke(big.NewInt(43), 0, time.Now()); err != nil {
+		t.Fatalf("Revoke() = %v", err)
+	}
+
+	srv := httptest.NewServer(NewCRLHandler(eca, cfg, l))
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + CRLPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/pkix-crl" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crl, err := x509.ParseCRL(body)
+	if err != nil {
+		t.Fatalf("ParseCRL() = %v", err)
+	}
+	if err := chain[0].CheckCRLSignature(crl); err != nil {
+		t.Fatalf("CheckCRLSignature() = %v", err)
+	}
+
+	entries := crl.TBSCertList.RevokedCertificates
+	if len(entries) != 2 {
+		t.Fatalf("expected two revoked certificates, got %d", len(entries))
+	}
+	if entries[0].SerialNumber.Int64() != 42 || len(entries[0].Extensions) != 1 || !entries[0].Extensions[0].Id.Equal(oidCRLReason) {
+		t.Fatalf("expected serial 42 with a reason code, got %v", entries[0])
+	}
+	if entries[1].SerialNumber.Int64() != 43 || len(entries[1].Extensions) != 0 {
+		t.Fatalf("expected serial 43 without a reason code, got %v", entries[1])
+	}
+
+	// Only GET is allowed
+	post, err := http.Post(srv.URL+CRLPath, "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post.Body.Close()
+	if post.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected method not allowed, got %d", post.StatusCode)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"context"
+	"time"
+)
+
+// signingQueue limits the number of certificates signed concurrently, so
+// that a burst of requests waits its turn instead of overloading a CA
+// backend with limited throughput, such as an HSM. A limit of 0 disables it.
+type signingQueue struct {
+	slots chan struct{}
+}
+
+func newSigningQueue(limit int) *signingQueue {
+	if limit <= 0 {
+		return nil
+	}
+	return &signingQueue{slots: make(chan struct{}, limit)}
+}
+
+// acquire waits for a slot in the queue, until ctx is done. The time spent
+// waiting is recorded in metricSigningQueueWait. release must be called once
+// the certificate is signed.
+func (q *signingQueue) acquire(ctx context.Context) (release func(), err error) {
+	if q == nil {
+		return func() {}, nil
+	}
+
+	start := time.Now()
+	select {
+	case q.slots <- struct{}{}:
+		metricSigningQueueWait.Observe(time.Since(start).Seconds())
+		return func() { <-q.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
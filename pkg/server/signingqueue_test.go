@@ -0,0 +1,217 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sigstore/fulcio/pkg/ca"
+	"github.com/sigstore/fulcio/pkg/ca/ephemeralca"
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/generated/protobuf"
+	"github.com/sigstore/fulcio/pkg/identity"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// blockingCA signs certificates only once unblocked
+type blockingCA struct {
+	*ephemeralca.EphemeralCA
+	signing chan struct{}
+	unblock chan struct{}
+}
+
+func (b *blockingCA) CreateCertificate(ctx context.Context, principal identity.Principal, publicKey crypto.PublicKey) (*ca.CodeSigningCertificate, error) {
+	b.signing <- struct{}{}
+	<-b.unblock
+	return b.EphemeralCA.CreateCertificate(ctx, principal, publicKey)
+}
+
+func signingQueueWait(t *testing.T) (count uint64, sum float64) {
+	t.Helper()
+	var m dto.Metric
+	if err := metricSigningQueueWait.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
+
+// Tests that requests queued behind the signing concurrency limit record
+// how long they waited
+func TestAPIWithSigningConcurrencyLimit(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	eca, err := ephemeralca.NewEphemeralCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bca := &blockingCA{EphemeralCA: eca, signing: make(chan struct{}, 2), unblock: make(chan struct{})}
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, nil, bca, WithSigningConcurrencyLimit(1))
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	beforeCount, beforeSum := signingQueueWait(t)
+
+	var wg sync.WaitGroup
+	sign := func() {
+		defer wg.Done()
+		pubBytes, proof := generateKeyAndProof(emailSubject, t)
+		_, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+			Credentials: &protobuf.Credentials{
+				Credentials: &protobuf.Credentials_OidcIdentityToken{
+					OidcIdentityToken: tok,
+				},
+			},
+			Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+				PublicKeyRequest: &protobuf.PublicKeyRequest{
+					PublicKey: &protobuf.PublicKey{
+						Content: pubBytes,
+					},
+					ProofOfPossession: proof,
+				},
+			},
+		})
+		if err != nil {
+			t.Errorf("SigningCert() = %v", err)
+		}
+	}
+
+	// The first request holds the only slot, and the second queues behind it
+	wg.Add(2)
+	go sign()
+	<-bca.signing
+	go sign()
+	const queued = 100 * time.Millisecond
+	time.Sleep(queued)
+	select {
+	case <-bca.signing:
+		t.Fatal("expected the second request to wait for a slot")
+	default:
+	}
+	close(bca.unblock)
+	wg.Wait()
+
+	count, sum := signingQueueWait(t)
+	if count-beforeCount != 2 {
+		t.Fatalf("expected 2 queue wait observations, got %d", count-beforeCount)
+	}
+	if waited := time.Duration((sum - beforeSum) * float64(time.Second)); waited < queued/2 {
+		t.Fatalf("expected queue wait of at least %v, got %v", queued/2, waited)
+	}
+}
+
+// Tests that a request for a certificate with an embedded SCT only queues
+// once, for the precertificate and final certificate together
+func TestAPIWithSigningConcurrencyLimitEmbeddedSCT(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	ctClient, eca := createCA(cfg, t)
+	ctx := context.Background()
+	server, conn := setupGRPCForTest(ctx, t, cfg, ctClient, eca, WithSigningConcurrencyLimit(1))
+	defer func() {
+		server.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	beforeCount, _ := signingQueueWait(t)
+
+	pubBytes, proof := generateKeyAndProof(emailSubject, t)
+	resp, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+		Credentials: &protobuf.Credentials{
+			Credentials: &protobuf.Credentials_OidcIdentityToken{
+				OidcIdentityToken: tok,
+			},
+		},
+		Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+			PublicKeyRequest: &protobuf.PublicKeyRequest{
+				PublicKey: &protobuf.PublicKey{
+					Content: pubBytes,
+				},
+				ProofOfPossession: proof,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SigningCert() = %v", err)
+	}
+	if resp.GetSignedCertificateEmbeddedSct() == nil {
+		t.Fatal("expected a certificate with an embedded SCT")
+	}
+
+	if count, _ := signingQueueWait(t); count-beforeCount != 1 {
+		t.Fatalf("expected 1 queue wait observation, got %d", count-beforeCount)
+	}
+}
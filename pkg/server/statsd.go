@@ -0,0 +1,77 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/log"
+)
+
+// StatsDClient sends metrics to a StatsD server over UDP. Tags are sent in
+// the DogStatsD format, which most StatsD servers accept. Sending is best
+// effort, errors are logged and otherwise ignored.
+type StatsDClient struct {
+	conn   net.Conn
+	prefix string
+}
+
+// NewStatsDClient returns a client that sends metrics to the StatsD server
+// at addr, a host:port. Metric names are prefixed with prefix and a dot, if
+// prefix is not empty.
+func NewStatsDClient(addr, prefix string) (*StatsDClient, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: %w", err)
+	}
+	if prefix != "" {
+		prefix += "."
+	}
+	return &StatsDClient{conn: conn, prefix: prefix}, nil
+}
+
+// Count adds value to the counter name.
+func (c *StatsDClient) Count(name string, value int64, tags map[string]string) {
+	c.send(fmt.Sprintf("%s%s:%d|c", c.prefix, name, value), tags)
+}
+
+// Timing records a duration for the timer name, in milliseconds.
+func (c *StatsDClient) Timing(name string, d time.Duration, tags map[string]string) {
+	c.send(fmt.Sprintf("%s%s:%d|ms", c.prefix, name, d.Milliseconds()), tags)
+}
+
+// Close closes the connection to the StatsD server.
+func (c *StatsDClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *StatsDClient) send(metric string, tags map[string]string) {
+	if len(tags) > 0 {
+		pairs := make([]string, 0, len(tags))
+		for k, v := range tags {
+			pairs = append(pairs, k+":"+v)
+		}
+		sort.Strings(pairs)
+		metric += "|#" + strings.Join(pairs, ",")
+	}
+	if _, err := c.conn.Write([]byte(metric)); err != nil {
+		log.Logger.Debugf("statsd: failed to send metric: %v", err)
+	}
+}
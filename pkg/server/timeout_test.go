@@ -0,0 +1,160 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/ca"
+	"github.com/sigstore/fulcio/pkg/ca/ephemeralca"
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/generated/protobuf"
+	"github.com/sigstore/fulcio/pkg/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// slowCA never answers before the request context is done
+type slowCA struct {
+	*ephemeralca.EphemeralCA
+}
+
+func (s *slowCA) TrustBundle(ctx context.Context) ([][]*x509.Certificate, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *slowCA) CreateCertificate(ctx context.Context, _ identity.Principal, _ crypto.PublicKey) (*ca.CodeSigningCertificate, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// Tests slow calls fail once their method's timeout passes
+func TestUnaryTimeoutInterceptor(t *testing.T) {
+	emailSigner, emailIssuer := newOIDCIssuer(t)
+
+	cfg, err := config.Read([]byte(fmt.Sprintf(`{
+		"OIDCIssuers": {
+			%q: {
+				"IssuerURL": %q,
+				"ClientID": "sigstore",
+				"Type": "email"
+			}
+		}
+	}`, emailIssuer, emailIssuer)))
+	if err != nil {
+		t.Fatalf("config.Read() = %v", err)
+	}
+
+	emailSubject := "foo@example.com"
+	tok, err := jwt.Signed(emailSigner).Claims(jwt.Claims{
+		Issuer:   emailIssuer,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(time.Now().Add(30 * time.Minute)),
+		Subject:  emailSubject,
+		Audience: jwt.Audience{"sigstore"},
+	}).Claims(customClaims{Email: emailSubject, EmailVerified: true}).CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = %v", err)
+	}
+
+	const (
+		defaultTimeout     = 10 * time.Second
+		trustBundleTimeout = 100 * time.Millisecond
+		signingTimeout     = time.Second
+	)
+	_, eca := createCA(cfg, t)
+	l := bufconn.Listen(bufSize)
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		passFulcioConfigThruContext(cfg),
+		UnaryTimeoutInterceptor(defaultTimeout, map[string]time.Duration{
+			"GetTrustBundle":           trustBundleTimeout,
+			"CreateSigningCertificate": signingTimeout,
+		}),
+	))
+	protobuf.RegisterCAServer(s, NewGRPCCAServer(nil, &slowCA{EphemeralCA: eca}))
+	go func() {
+		if err := s.Serve(l); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			t.Errorf("Server exited with error: %v", err)
+		}
+	}()
+	ctx := context.Background()
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal("could not create grpc connection", err)
+	}
+	defer func() {
+		s.Stop()
+		conn.Close()
+	}()
+	client := protobuf.NewCAClient(conn)
+
+	wantTimeout := func(t *testing.T, call func() error, timeout time.Duration) {
+		t.Helper()
+		start := time.Now()
+		err := call()
+		elapsed := time.Since(start)
+		if status.Code(err) != codes.DeadlineExceeded {
+			t.Fatalf("expected DeadlineExceeded, got %v", err)
+		}
+		// The next shortest timeout is at least twice as long
+		if elapsed < timeout || elapsed >= 2*timeout {
+			t.Fatalf("expected call to time out after %v, took %v", timeout, elapsed)
+		}
+	}
+
+	t.Run("GetTrustBundle", func(t *testing.T) {
+		wantTimeout(t, func() error {
+			_, err := client.GetTrustBundle(ctx, &protobuf.GetTrustBundleRequest{})
+			return err
+		}, trustBundleTimeout)
+	})
+
+	t.Run("CreateSigningCertificate", func(t *testing.T) {
+		pubBytes, proof := generateKeyAndProof(emailSubject, t)
+		wantTimeout(t, func() error {
+			_, err := client.CreateSigningCertificate(ctx, &protobuf.CreateSigningCertificateRequest{
+				Credentials: &protobuf.Credentials{
+					Credentials: &protobuf.Credentials_OidcIdentityToken{
+						OidcIdentityToken: tok,
+					},
+				},
+				Key: &protobuf.CreateSigningCertificateRequest_PublicKeyRequest{
+					PublicKeyRequest: &protobuf.PublicKeyRequest{
+						PublicKey: &protobuf.PublicKey{
+							Content: pubBytes,
+						},
+						ProofOfPossession: proof,
+					},
+				},
+			})
+			return err
+		}, signingTimeout)
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/identity/username"
+)
+
+const (
+	// ValidateSANPath is the path of the endpoint that validates a username
+	// OtherName without issuing a certificate.
+	ValidateSANPath = "/api/v2/validateSAN"
+
+	// maxValidateSANRequestBytes limits the size of a request body, which
+	// only holds one name
+	maxValidateSANRequestBytes = 64 * 1024
+)
+
+// ValidateSANRequest is the JSON body POSTed to ValidateSANPath.
+type ValidateSANRequest struct {
+	// OtherName is the requested identity, <username>!<hostname>
+	OtherName string `json:"otherName"`
+}
+
+// ValidateSANResponse is the JSON response from ValidateSANPath.
+type ValidateSANResponse struct {
+	// Valid is true if the OtherName has no errors
+	Valid bool `json:"valid"`
+	// Extension is the hex encoded value of the Subject Alternative Name
+	// extension that would be issued, if the OtherName can be encoded
+	Extension string `json:"extension,omitempty"`
+	// Critical is whether the extension would be marked critical, under
+	// the SANCriticality of the configuration
+	Critical bool               `json:"critical"`
+	Errors   []ValidateSANError `json:"errors,omitempty"`
+}
+
+// ValidateSANError is a reason the OtherName would be rejected.
+type ValidateSANError struct {
+	Reason  username.FailureReason `json:"reason"`
+	Message string                 `json:"message"`
+}
+
+// NewValidateSANHandler returns a handler that reports whether a username
+// OtherName would be accepted, and the extension MarshalSANS would encode it
+// as, so clients can debug a requested identity without a certificate being
+// signed or logged. Requests are POSTed as a JSON ValidateSANRequest, and
+// answered with a ValidateSANResponse.
+func NewValidateSANHandler(cfg *config.FulcioConfig) http.Handler {
+	return WithMaxBytes(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req ValidateSANRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "request must be a JSON object with an otherName", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(validateSAN(cfg, req.OtherName))
+	}), maxValidateSANRequestBytes)
+}
+
+func validateSAN(cfg *config.FulcioConfig, otherName string) ValidateSANResponse {
+	resp := ValidateSANResponse{
+		Critical: cfg.CriticalityPolicy().ShouldBeCritical(config.IdentityKindUsername),
+	}
+	if err := username.ValidateOtherName(otherName); err != nil {
+		resp.Errors = append(resp.Errors, ValidateSANError{Reason: username.ReasonInvalidOtherName, Message: err.Error()})
+	}
+	ext, err := username.MarshalSANS(otherName, resp.Critical)
+	if err != nil {
+		resp.Errors = append(resp.Errors, ValidateSANError{Reason: username.FailureReasonOf(err), Message: err.Error()})
+	} else {
+		resp.Extension = hex.EncodeToString(ext.Value)
+	}
+	resp.Valid = len(resp.Errors) == 0
+	return resp
+}
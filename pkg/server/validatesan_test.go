@@ -0,0 +1,101 @@
+// Copyright 2022 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sigstore/fulcio/pkg/config"
+	"github.com/sigstore/fulcio/pkg/identity/username"
+)
+
+func TestValidateSANHandler(t *testing.T) {
+	srv := httptest.NewServer(NewValidateSANHandler(nil))
+	t.Cleanup(srv.Close)
+
+	validate := func(body string) (int, ValidateSANResponse) {
+		t.Helper()
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var got ValidateSANResponse
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode, got
+	}
+
+	// The encoding MarshalSANS produces for alice!example.com
+	code, got := validate(`{"otherName": "alice!example.com"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	const want = "3023a021060a2b0601040183bf300107a0130c11616c696365216578616d706c652e636f6d"
+	if !got.Valid || got.Extension != want || !got.Critical || len(got.Errors) != 0 {
+		t.Fatalf("unexpected response for valid OtherName: %+v", got)
+	}
+
+	// A name without a hostname can be encoded, but isn't valid
+	code, got = validate(`{"otherName": "alice"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if got.Valid || got.Extension == "" || len(got.Errors) != 1 || got.Errors[0].Reason != username.ReasonInvalidOtherName ||
+		!strings.Contains(got.Errors[0].Message, "<username>!<hostname>") {
+		t.Fatalf("unexpected response for OtherName without hostname: %+v", got)
+	}
+
+	// A name that's too long can't be encoded at all
+	_, got = validate(`{"otherName": "` + strings.Repeat("a", 300) + `!example.com"}`)
+	if got.Valid || got.Extension != "" {
+		t.Fatalf("unexpected response for long OtherName: %+v", got)
+	}
+	found := false
+	for _, e := range got.Errors {
+		found = found || e.Reason == username.ReasonOtherNameTooLong
+	}
+	if !found {
+		t.Fatalf("expected %s error, got %+v", username.ReasonOtherNameTooLong, got.Errors)
+	}
+
+	if code, _ := validate(`not json`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for malformed request, got %d", code)
+	}
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for GET, got %d", resp.StatusCode)
+	}
+}
+
+func TestValidateSANHandlerCriticality(t *testing.T) {
+	cfg := &config.FulcioConfig{SANCriticality: config.CriticalityPolicy{config.IdentityKindUsername: false}}
+	got := validateSAN(cfg, "alice!example.com")
+	if !got.Valid || got.Critical {
+		t.Fatalf("expected a valid, non-critical extension, got %+v", got)
+	}
+}